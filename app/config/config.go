@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +15,9 @@ type Config struct {
 	AppEnv             string
 	JwtSecret          string
 	RefreshSecret      string
+	JwtKeys            []string
+	JwtSigningKeyID    string
+	RefreshTokenTTL    time.Duration
 	DBHost             string
 	DBPort             string
 	DBUser             string
@@ -22,7 +28,56 @@ type Config struct {
 	ResendKey          string
 	GithubClientID     string
 	GithubClientSecret string
+	GithubRedirectURL  string
+	OAuthProviders     []OAuthProvider
+	OAuthStateTTL      time.Duration
 	AllowOrigins       string
+	ProxyHeader        string
+	AutoMigrate        bool
+	ChartRefresh       time.Duration
+	StorageDriver      string
+	StorageLocalDir    string
+	MaxUploadSize      int
+	StreamListens      bool
+	StreamListenAt     int
+	StreamURLSecret    string
+	StreamURLTTL       time.Duration
+	FFmpegPath         string
+	HLSBitrates        []int
+	HLSSegmentDuration time.Duration
+	PackagingWorkers   int
+	ImageWidths        []int
+	ImagePublicURL     string
+	JobWorkers         int
+	JobPollInterval    time.Duration
+	JobShutdownTimeout time.Duration
+	MailDriver         string
+	MailFrom           string
+	MailLogDir         string
+	SMTPHost           string
+	SMTPPort           int
+	SMTPUsername       string
+	SMTPPassword       string
+	PasswordResetURL   string
+	PasswordResetTTL   time.Duration
+	RateLimitDriver    string
+	LoginMaxFailures   int
+	LoginLockout       time.Duration
+}
+
+// OAuthProvider configures a sign in provider named in OAUTH_PROVIDERS. Type is oidc, which
+// discovers its endpoints from Issuer, or github.
+type OAuthProvider struct {
+	Name         string
+	Type         string
+	ClientID     string
+	ClientSecret string
+	Issuer       string
+	RedirectURL  string
+	Scopes       []string
+	// Audiences are the client ids of other apps, such as the mobile app, whose ID tokens are
+	// accepted besides the ones issued to ClientID
+	Audiences []string
 }
 
 func NewConfig() *Config {
@@ -38,6 +93,9 @@ func NewConfig() *Config {
 		AppEnv:             getEnv("APP_ENV", "development"),
 		JwtSecret:          getEnv("JWT_SECRET", ""),
 		RefreshSecret:      getEnv("REFRESH_SECRET", ""),
+		JwtKeys:            getEnvList("JWT_KEYS"),
+		JwtSigningKeyID:    getEnv("JWT_SIGNING_KEY_ID", ""),
+		RefreshTokenTTL:    getEnvDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour),
 		DBHost:             getEnv("DB_HOST", "localhost"),
 		DBPort:             getEnv("DB_PORT", "5432"),
 		DBUser:             getEnv("DB_USER", "postgres"),
@@ -48,8 +106,62 @@ func NewConfig() *Config {
 		ResendKey:          getEnv("RESEND_KEY", ""),
 		GithubClientID:     getEnv("GITHUB_CLIENT_ID", ""),
 		GithubClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
+		GithubRedirectURL:  getEnv("GITHUB_REDIRECT_URL", ""),
+		OAuthProviders:     getOAuthProviders(),
+		OAuthStateTTL:      getEnvDuration("OAUTH_STATE_TTL", 10*time.Minute),
 		AllowOrigins:       getEnv("ALLOW_ORIGINS", ""),
+		ProxyHeader:        getEnv("PROXY_HEADER", ""),
+		AutoMigrate:        getEnvBool("AUTO_MIGRATE", false),
+		ChartRefresh:       getEnvDuration("CHART_REFRESH_INTERVAL", 10*time.Minute),
+		StorageDriver:      getEnv("STORAGE_DRIVER", "local"),
+		StorageLocalDir:    getEnv("STORAGE_LOCAL_DIR", "storage"),
+		MaxUploadSize:      getEnvInt("MAX_UPLOAD_SIZE_MB", 50) * 1024 * 1024,
+		StreamListens:      getEnvBool("STREAM_RECORD_LISTENS", true),
+		StreamListenAt:     min(getEnvInt("STREAM_LISTEN_PERCENT", 30), 100),
+		StreamURLSecret:    getEnv("STREAM_URL_SECRET", ""),
+		StreamURLTTL:       getEnvDuration("STREAM_URL_TTL", 10*time.Minute),
+		FFmpegPath:         getEnv("FFMPEG_PATH", "ffmpeg"),
+		HLSBitrates:        getEnvInts("HLS_BITRATES_KBPS", []int{64, 128, 256}),
+		HLSSegmentDuration: getEnvDuration("HLS_SEGMENT_DURATION", 6*time.Second),
+		PackagingWorkers:   getEnvInt("PACKAGING_WORKERS", 1),
+		ImageWidths:        getEnvInts("IMAGE_VARIANT_WIDTHS", []int{320, 640, 1280}),
+		ImagePublicURL:     strings.TrimSuffix(getEnv("IMAGE_PUBLIC_URL", "/v1/images"), "/"),
+		JobWorkers:         getEnvInt("JOB_WORKERS", 4),
+		JobPollInterval:    getEnvDuration("JOB_POLL_INTERVAL", time.Second),
+		JobShutdownTimeout: getEnvDuration("JOB_SHUTDOWN_TIMEOUT", 30*time.Second),
+		MailDriver:         getEnv("MAIL_DRIVER", "resend"),
+		MailFrom:           getEnv("MAIL_FROM", "Mulo <noreply@craftedfolio.my.id>"),
+		MailLogDir:         getEnv("MAIL_LOG_DIR", ""),
+		SMTPHost:           getEnv("SMTP_HOST", ""),
+		SMTPPort:           getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:       getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:       getEnv("SMTP_PASSWORD", ""),
+		PasswordResetURL:   getEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+		PasswordResetTTL:   getEnvDuration("PASSWORD_RESET_TTL", 30*time.Minute),
+		RateLimitDriver:    getEnv("RATE_LIMIT_DRIVER", "postgres"),
+		LoginMaxFailures:   getEnvInt("LOGIN_MAX_FAILURES", 5),
+		LoginLockout:       getEnvDuration("LOGIN_LOCKOUT", 15*time.Minute),
+	}
+}
+
+// getOAuthProviders reads the OAUTH_<NAME>_* settings of every provider in OAUTH_PROVIDERS.
+func getOAuthProviders() []OAuthProvider {
+	var providers []OAuthProvider
+	for _, name := range getEnvList("OAUTH_PROVIDERS") {
+		name = strings.ToLower(name)
+		prefix := "OAUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OAuthProvider{
+			Name:         name,
+			Type:         getEnv(prefix+"TYPE", "oidc"),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", ""),
+			Scopes:       getEnvList(prefix + "SCOPES"),
+			Audiences:    getEnvList(prefix + "AUDIENCES"),
+		})
 	}
+	return providers
 }
 
 func getEnv(key, fallback string) string {
@@ -58,3 +170,75 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvBool(key string, fallback bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid boolean for %s, using %v", key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("Warning: invalid integer for %s, using %v", key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvInts reads a comma separated list of positive integers.
+func getEnvInts(key string, fallback []int) []int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	var parsed []int
+	for _, field := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n <= 0 {
+			log.Printf("Warning: invalid integer list for %s, using %v", key, fallback)
+			return fallback
+		}
+		parsed = append(parsed, n)
+	}
+	return parsed
+}
+
+// getEnvList reads a comma separated list, skipping empty entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, field := range strings.Split(os.Getenv(key), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			list = append(list, field)
+		}
+	}
+	return list
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("Warning: invalid duration for %s, using %v", key, fallback)
+		return fallback
+	}
+	return parsed
+}
@@ -5,12 +5,15 @@ import (
 
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
 type AlbumRepository interface {
-	FindAll(ctx context.Context, pageSize, offset int) (albums []models.Album, err error)
+	FindAll(ctx context.Context, spec query.Spec, pageSize, offset int) (albums []models.Album, err error)
+	FindAllByCursor(ctx context.Context, spec query.Spec, cursor utils.Cursor, limit int) (albums []models.Album, err error)
 	FindAlbumById(ctx context.Context, id int) (album *models.AlbumWithArtist, err error)
-	FindCount(ctx context.Context) (total int, err error)
+	FindCount(ctx context.Context, spec query.Spec) (total int, err error)
 	FindExistsAlbumById(ctx context.Context, id int) (exists bool, err error)
 	FindExistsAlbumBySlug(ctx context.Context, slug string) (exists bool, err error)
 	Store(ctx context.Context, input models.CreateAlbumInput) (err error)
@@ -20,11 +23,19 @@ type AlbumRepository interface {
 }
 
 type AlbumService interface {
-	// GetAll Return list of albums and total.
+	// GetAll Return list of albums and total, sorted and filtered by the spec.
 	//  Returns:
 	//   200 OK: Success with lists and total.
+	//   400 Bad Request: On invalid sort or filter.
 	//   500 Internal Server Error: On Failure.
-	GetAll(ctx context.Context, pageSize, offset int) (albums []dto.AlbumWithArtist, total int, err error)
+	GetAll(ctx context.Context, spec query.Spec, pageSize, offset int) (albums []dto.AlbumWithArtist, total int, err error)
+
+	// GetAllByCursor Return a page of albums after the cursor, total only when asked.
+	//  Returns:
+	//   200 OK: Success with lists and next cursor.
+	//   400 Bad Request: On invalid cursor or sort given with a cursor.
+	//   500 Internal Server Error: On Failure.
+	GetAllByCursor(ctx context.Context, spec query.Spec, cursor string, pageSize int, withTotal bool) (albums []dto.AlbumWithArtist, pagination dto.CursorPagination, err error)
 
 	// GetAlbumById Retrieve a album by Id.
 	//  Returns:
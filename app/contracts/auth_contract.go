@@ -13,14 +13,48 @@ type AuthRepository interface {
 	StoreUserVerifyCode(ctx context.Context, userId int, code string) (err error)
 	UpdateUserVerifiedAt(ctx context.Context, userId int) (err error)
 
-	IsRefreshTokenValid(ctx context.Context, userID int, token string) (exists bool, err error)
-	StoreRefreshToken(ctx context.Context, userID int, token string, expiredAt time.Time) (err error)
-	UpdateRefreshToken(ctx context.Context, userID int, token string) (err error)
-	DeleteRefreshToken(ctx context.Context, token string) (err error)
+	// Refresh tokens are stored and looked up by their SHA-256, see utils.HashToken.
+	// FindRefreshTokenSession returns the session of the token, 0 when it is unknown, revoked or expired.
+	FindRefreshTokenSession(ctx context.Context, userID int, tokenHash string) (sessionID int, err error)
+	// FindRefreshToken returns the token revoked or not, nil when it is unknown.
+	FindRefreshToken(ctx context.Context, tokenHash string) (token *models.RefreshToken, err error)
+	StoreSession(ctx context.Context, input models.SessionInput) (sessionID int, err error)
+	// StoreRefreshToken adds the next token of the session and marks the session used.
+	StoreRefreshToken(ctx context.Context, userID, sessionID int, tokenHash string, expiredAt time.Time) (err error)
+	// RevokeRefreshToken reports false when the token was already revoked, such as by a concurrent refresh.
+	RevokeRefreshToken(ctx context.Context, tokenHash string) (revoked bool, err error)
+	DeleteSessionByRefreshToken(ctx context.Context, tokenHash string) (err error)
+	// FindSessionsByUserID returns the sessions with an unrevoked token, the last used first.
+	FindSessionsByUserID(ctx context.Context, userID int) (sessions []models.Session, err error)
+	DeleteSession(ctx context.Context, userID, sessionID int) (deleted bool, err error)
+	// DeleteUserSessions signs the user out of every session but the one of exceptTokenHash, empty signs out all.
+	DeleteUserSessions(ctx context.Context, userID int, exceptTokenHash string) (err error)
+
 	StoreUserWithOAuthAccount(ctx context.Context, input models.OAuthAccountInput) (userID int, err error)
 	StoreOAuthAccount(ctx context.Context, userID int, providerID, providerUserID string) (err error)
 	FindOAuthAccount(ctx context.Context, provider, providerUserID string) (*models.OAuthAccount, error)
 	FindExistsOauthAccount(ctx context.Context, userID int) (exists bool, err error)
+	// StoreOAuthState saves a started provider sign in under the hash of its state.
+	StoreOAuthState(ctx context.Context, stateHash string, state models.OAuthState) (err error)
+	// ConsumeOAuthState deletes the sign in and returns it, nil when the state is unknown or used.
+	ConsumeOAuthState(ctx context.Context, stateHash string) (state *models.OAuthState, err error)
+
+	// StorePasswordReset replaces the unused reset tokens of the user with the new one.
+	StorePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (err error)
+	// ConsumePasswordReset marks the token used and returns its user, 0 when the token is unknown,
+	// used or expired.
+	ConsumePasswordReset(ctx context.Context, tokenHash string) (userID int, err error)
+	UpdateUserPassword(ctx context.Context, userID int, password string) (err error)
+
+	// StoreEmailChange replaces the pending email change of the user.
+	StoreEmailChange(ctx context.Context, userID int, email, codeHash string) (err error)
+	// FindEmailChange returns the pending email change of the user, nil when there is none.
+	FindEmailChange(ctx context.Context, userID int) (change *models.EmailChange, err error)
+	// FailEmailChange counts a wrong code for the pending change, the change is dropped once
+	// maxAttempts codes were wrong.
+	FailEmailChange(ctx context.Context, userID, maxAttempts int) (err error)
+	// UpdateUserEmail applies the change, the address is verified and pending changes are dropped.
+	UpdateUserEmail(ctx context.Context, userID int, email string) (err error)
 }
 
 type AuthService interface {
@@ -33,14 +67,90 @@ type AuthService interface {
 	Refresh(ctx context.Context, token string) (accessToken, refreshToken string, err error)
 	Logout(ctx context.Context, token string) (err error)
 
-	// OAuthGithubCallback: Login or Register with github oAuth2
+	// GetSessions lists the signed in devices of the user, the one of refreshToken is marked current.
+	//  Returns:
+	//   200 OK: Sessions, the last used first
+	GetSessions(ctx context.Context, userID int, refreshToken string) (sessions []dto.Session, err error)
+	// RevokeSession signs the user out of one of their sessions.
+	//  Returns:
+	//   404 Not Found: No such session of the user
+	RevokeSession(ctx context.Context, userID, sessionID int) (err error)
+	// RevokeUserSessions signs the user out everywhere, used by the user and by admins.
+	//  Returns:
+	//   404 Not Found: User does not exist
+	RevokeUserSessions(ctx context.Context, userID int) (err error)
+
+	// ForgotPassword queues a reset link for the email, sent by SendPasswordReset when the email
+	// belongs to a user. It answers the same whether or not it does, so accounts cannot be
+	// enumerated.
+	//  Returns:
+	//   200 OK: Always, unless the request is invalid
+	//   429 Too Many Requests: Too many links asked for by the client or for the email
+	ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) (err error)
+	// SendPasswordReset emails a reset link to the user of the email, run by the job queue. An
+	// email without an account is not an error.
+	SendPasswordReset(ctx context.Context, email string) (err error)
+	// ResetPassword sets a new password with a reset token and signs the user out everywhere.
+	//  Returns:
+	//   200 OK: Password updated, every session signed out
+	//   400 Bad Request: Token unknown, used or expired
+	ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) (err error)
+
+	// ChangePassword sets a new password and signs out every other session. Users with a password
+	// confirm the current one, OAuth-only users add their first password without it.
+	//  Returns:
+	//   200 OK: Password updated
+	//   400 Bad Request: Invalid request or wrong current password
+	//   404 Not Found: User no longer exists
+	ChangePassword(ctx context.Context, userID int, refreshToken string, req dto.ChangePasswordRequest) (err error)
+	// ChangeEmail sends a verification code to the new address, the email only changes once the
+	// code is confirmed with ConfirmEmailChange.
+	//  Returns:
+	//   202 Accepted: Code sent to the new address
+	//   400 Bad Request: Invalid request
+	//   409 Conflict: Email already used by this or another account
+	ChangeEmail(ctx context.Context, userID int, req dto.ChangeEmailRequest) (err error)
+	// ConfirmEmailChange applies the pending email change the code was sent for.
+	//  Returns:
+	//   200 OK: Email changed and verified
+	//   404 Not Found: No pending change with this code
+	//   409 Conflict: Email taken by another account meanwhile
+	//   410 Gone: Code has expired
+	ConfirmEmailChange(ctx context.Context, userID int, req dto.ConfirmEmailChangeRequest) (err error)
+
+	// OAuthAuthorize starts a sign in with a configured OAuth2/OIDC provider. The state is stored
+	// with a PKCE code verifier and has to come back on the callback, from the cookie too.
+	//  Returns:
+	//   302 Found: Redirect to the authorization URL of the provider
+	//   404 Not Found: Provider is not configured
+	//   429 Too Many Requests: Too many sign ins started by the client
+	OAuthAuthorize(ctx context.Context, provider string) (authURL, state string, err error)
+	// OAuthProviderCallback: Login or Register with the code of a configured OAuth2/OIDC provider
+	//  Flows:
+	//   Check state against stateCookie and consume it
+	//   Exchange code with the PKCE code verifier -> read provider identity, it must have a verified email
+	//   Check oauth_account by provider user id:
+	//    if oauth_account exists -> generate tokens for its user
+	//    if oauth_account does not exist -> check user by email:
+	//     if user already exist -> create oauth_accounts -> generate tokens
+	//     if user does not exist -> create user and oauth_accounts -> generate tokens
+	//  Returns:
+	//   200 OK: Signed in
+	//   401 Unauthorized: Unknown, used or expired state, or code refused by the provider
+	//   403 Forbidden: Provider account has no verified email
+	//   404 Not Found: Provider is not configured
+	OAuthProviderCallback(ctx context.Context, provider string, req dto.OAuthCodeRequest, stateCookie string) (accessToken, refreshToken string, err error)
+	// OAuthCallback: Login or register with a token the client got from the provider
 	//  Flows:
-	//   Check user by email:
-	//    if user already exist -> check oauth_account by user_id
-	//     if oauth_account does not exists -> create oauth_accounts -> generate tokens
-	//     if oauth_account already exists -> generate tokens
-	//    if user does not exist -> create user and oauth_accounts -> generate tokens
-	OAuthGithubCallback(ctx context.Context, req dto.GithubReq) (accessToken, refreshToken string, err error)
-	// OAuthCallback: Login or register with OAuth
+	//   Verify the token with the provider: an ID token against its keys and trusted client ids,
+	//   a GitHub access token against the OAuth app -> read provider identity
+	//   Then the same as OAuthProviderCallback
+	//  Returns:
+	//   200 OK: Signed in
+	//   400 Bad Request: No id_token or access_token
+	//   401 Unauthorized: Token is forged, expired or issued to an untrusted client
+	//   403 Forbidden: Provider account has no verified email
+	//   404 Not Found: Provider is not configured
+	//   429 Too Many Requests: Too many sign in attempts from the client
 	OAuthCallback(ctx context.Context, req dto.OAuthRequest) (accessToken, refreshToken string, err error)
 }
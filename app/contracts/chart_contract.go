@@ -0,0 +1,47 @@
+package contracts
+
+import (
+	"context"
+
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+)
+
+type ChartRepository interface {
+	FindSongs(ctx context.Context, filter models.ChartFilter, pageSize, offset int) (songs []models.ChartSong, err error)
+	FindCountSongs(ctx context.Context, filter models.ChartFilter) (total int, err error)
+	FindArtists(ctx context.Context, filter models.ChartFilter, pageSize, offset int) (artists []models.ChartArtist, err error)
+	FindCountArtists(ctx context.Context, filter models.ChartFilter) (total int, err error)
+	FindAlbums(ctx context.Context, filter models.ChartFilter, pageSize, offset int) (albums []models.ChartAlbum, err error)
+	FindCountAlbums(ctx context.Context, filter models.ChartFilter) (total int, err error)
+	Refresh(ctx context.Context) (err error)
+}
+
+type ChartService interface {
+	// GetSongCharts returns songs ranked by play count within the window and total count.
+	//  Returns:
+	//   200 OK: with the lists and total.
+	//   400 Bad Request: on validation failure.
+	//   404 Not Found: if genre is missing.
+	//   500 Internal Server Error: on failure.
+	GetSongCharts(ctx context.Context, req dto.ChartRequest, pageSize, offset int) (songs []dto.ChartSong, total int, err error)
+
+	// GetArtistCharts returns artists ranked by play count of their songs within the window and total count.
+	//  Returns:
+	//   200 OK: with the lists and total.
+	//   400 Bad Request: on validation failure.
+	//   404 Not Found: if genre is missing.
+	//   500 Internal Server Error: on failure.
+	GetArtistCharts(ctx context.Context, req dto.ChartRequest, pageSize, offset int) (artists []dto.ChartArtist, total int, err error)
+
+	// GetAlbumCharts returns albums ranked by play count of their songs within the window and total count.
+	//  Returns:
+	//   200 OK: with the lists and total.
+	//   400 Bad Request: on validation failure.
+	//   404 Not Found: if genre is missing.
+	//   500 Internal Server Error: on failure.
+	GetAlbumCharts(ctx context.Context, req dto.ChartRequest, pageSize, offset int) (albums []dto.ChartAlbum, total int, err error)
+
+	// RefreshCharts recomputes the play count aggregate the charts are served from.
+	RefreshCharts(ctx context.Context) (err error)
+}
@@ -5,10 +5,12 @@ import (
 
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
 type FavoriteRepository interface {
 	FindFavoriteSongsByUserID(ctx context.Context, userID, pageSize, offset int) (songs []models.Song, err error)
+	FindFavoriteSongsByCursor(ctx context.Context, userID int, cursor utils.Cursor, limit int) (favorites []models.FavoriteSong, err error)
 	FindCountFavoriteSongsByUserID(ctx context.Context, userID int) (total int, err error)
 	FindExistsFavoriteSongBySongID(ctx context.Context, userID, songID int) (exists bool, err error)
 	StoreFavoriteSong(ctx context.Context, userID, songID int) (err error)
@@ -19,6 +21,9 @@ type FavoriteService interface {
 	// Get list of favorite songs
 	GetFavoriteSongsByUserID(ctx context.Context, userID, pageSize, offset int) (songs []dto.Song, total int, err error)
 
+	// Get page of favorite songs after the cursor
+	GetFavoriteSongsByCursor(ctx context.Context, userID int, cursor string, pageSize int, withTotal bool) (songs []dto.Song, pagination dto.CursorPagination, err error)
+
 	// Add song to favorite
 	AddFavoriteSong(ctx context.Context, userID, songID int) (err error)
 
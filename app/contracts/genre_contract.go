@@ -5,10 +5,12 @@ import (
 
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
 type GenreRepository interface {
 	FindAll(ctx context.Context, pageSize, offset int) (genres []models.Genre, err error)
+	FindAllByCursor(ctx context.Context, cursor utils.Cursor, limit int) (genres []models.Genre, err error)
 	FindCount(ctx context.Context) (total int, err error)
 	FindExistsGenreById(ctx context.Context, id int) (exists bool, err error)
 	FindGenreById(ctx context.Context, id int) (genre *models.Genre, err error)
@@ -36,6 +38,13 @@ type GenreService interface {
 	//   500 Internal Server Error:: On failure.
 	GetAll(ctx context.Context, pageSize, offset int) (genres []dto.Genre, total int, err error)
 
+	// GetAllByCursor returns a page of genres after the cursor, total count only when asked.
+	//  Returns:
+	//   200 OK: Success with list and next cursor.
+	//   400 Bad Request: On invalid cursor.
+	//   500 Internal Server Error: On failure.
+	GetAllByCursor(ctx context.Context, cursor string, pageSize int, withTotal bool) (genres []dto.Genre, pagination dto.CursorPagination, err error)
+
 	// GetGenreById retrieves a genre by its ID.
 	//  Returns:
 	//   200 OK: on success.
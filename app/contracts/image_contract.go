@@ -0,0 +1,33 @@
+package contracts
+
+import (
+	"context"
+	"io"
+
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+)
+
+type ImageService interface {
+	// UploadImage store resized variants of a jpeg, png or webp image with its metadata stripped.
+	// The returned image, with its server computed blur hash, is what artists, albums, songs and genres take.
+	//  Returns:
+	//   200 OK: on success with the image and its variants.
+	//   400 Bad Request: on unsupported image format, a corrupt or a too large image.
+	//   500 Internal Server Error: on failure.
+	UploadImage(ctx context.Context, file io.Reader) (image dto.Image, err error)
+
+	// RemoteImage download an image hosted elsewhere, such as an OAuth provider avatar, for its
+	// size and blur hash. The image keeps its src and is not stored.
+	//  Returns:
+	//   200 OK: on success with the image.
+	//   500 Internal Server Error: on a failed download or an image that does not decode.
+	RemoteImage(ctx context.Context, src string) (image dto.Image, err error)
+
+	// OpenImage open a stored variant of an uploaded image.
+	//  Returns:
+	//   200 OK: on success with the opened file, the caller closes it.
+	//   404 Not Found: image or variant does not exists.
+	//   500 Internal Server Error: on failure.
+	OpenImage(ctx context.Context, id, file string) (image models.StoredFile, err error)
+}
@@ -0,0 +1,49 @@
+package contracts
+
+import (
+	"context"
+	"time"
+
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+)
+
+type ListenRepository interface {
+	// Store inserts a listen unless the same user already listened to the same song within dedupWindow.
+	Store(ctx context.Context, input models.CreateListenInput, dedupWindow time.Duration) (recorded bool, err error)
+	FindHistoryByUserID(ctx context.Context, userID, pageSize, offset int) (listens []models.Listen, err error)
+	FindCountHistoryByUserID(ctx context.Context, userID int) (total int, err error)
+	FindRecentlyPlayedByUserID(ctx context.Context, userID, pageSize, offset int) (listens []models.Listen, err error)
+	FindCountRecentlyPlayedByUserID(ctx context.Context, userID int) (total int, err error)
+	DeleteHistory(ctx context.Context, userID int, input models.ClearHistoryInput) (deleted int, err error)
+}
+
+type ListenService interface {
+	// RecordListen record a song play by the authenticated user.
+	//  Returns:
+	//   201 Created: recorded is true.
+	//   200 OK: recorded is false, a repeat event within the dedup window was dropped.
+	//   400 Bad Request: on validation failure.
+	//   404 Not Found: song does not exists.
+	//   500 Internal Server Error: on failure.
+	RecordListen(ctx context.Context, req dto.CreateListenRequest, userID, songID int) (recorded bool, err error)
+
+	// GetHistory returns the user listens newest first and total.
+	//  Returns:
+	//   200 OK: with lists and total.
+	//   500 Internal Server Error: on failure.
+	GetHistory(ctx context.Context, userID, pageSize, offset int) (history []dto.ListenHistory, total int, err error)
+
+	// GetRecentlyPlayed returns the distinct songs the user played, most recent first, and total.
+	//  Returns:
+	//   200 OK: with lists and total.
+	//   500 Internal Server Error: on failure.
+	GetRecentlyPlayed(ctx context.Context, userID, pageSize, offset int) (songs []dto.RecentlyPlayed, total int, err error)
+
+	// ClearHistory remove the user listens matching the optional song and time range filters.
+	//  Returns:
+	//   200 OK: with the number of removed listens.
+	//   400 Bad Request: on validation failure.
+	//   500 Internal Server Error: on failure.
+	ClearHistory(ctx context.Context, userID int, req dto.ClearHistoryRequest) (deleted int, err error)
+}
@@ -0,0 +1,12 @@
+package contracts
+
+import "context"
+
+type PackagingService interface {
+	// Enqueue queues the song for HLS packaging, inside a transaction the job is queued once it commits.
+	Enqueue(ctx context.Context, songID int) (err error)
+
+	// Package segments the stored audio of the song and marks it ready, or failed when packaging fails.
+	// Songs without stored audio are skipped.
+	Package(ctx context.Context, songID int) (err error)
+}
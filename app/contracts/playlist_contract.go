@@ -5,10 +5,12 @@ import (
 
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
 type PlaylistRepository interface {
 	FindAll(ctx context.Context, userRole string, userId, pageSize, offset int) (playlists []models.Playlist, err error)
+	FindAllByCursor(ctx context.Context, userRole string, userId int, cursor utils.Cursor, limit int) (playlists []models.Playlist, err error)
 	FindById(ctx context.Context, userRole string, userId, id int) (playlist *models.Playlist, err error)
 	FindCount(ctx context.Context, userRole string, userId int) (total int, err error)
 	FindExistsPlaylistById(ctx context.Context, userRole string, userId, id int) (exists bool, err error)
@@ -23,6 +25,7 @@ type PlaylistRepository interface {
 
 type PlaylistService interface {
 	GetAll(ctx context.Context, userRole string, userId, pageSize, offset int) (playlists []dto.Playlist, total int, err error)
+	GetAllByCursor(ctx context.Context, userRole string, userId int, cursor string, pageSize int, withTotal bool) (playlists []dto.Playlist, pagination dto.CursorPagination, err error)
 	GetPlaylistById(ctx context.Context, userRole string, userId, id int) (playlist dto.Playlist, err error)
 	CreatePlaylist(ctx context.Context, req dto.CreatePlaylistRequest) (err error)
 	UpdatePlaylist(ctx context.Context, req dto.CreatePlaylistRequest, userRole string, userId, id int) (err error)
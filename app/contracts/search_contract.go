@@ -0,0 +1,24 @@
+package contracts
+
+import (
+	"context"
+
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+)
+
+type SearchRepository interface {
+	SearchSongs(ctx context.Context, tsQuery string, pageSize, offset int) (songs []models.Song, err error)
+	SearchAlbums(ctx context.Context, tsQuery string, pageSize, offset int) (albums []models.AlbumWithArtist, err error)
+	SearchArtists(ctx context.Context, tsQuery string, pageSize, offset int) (artists []models.Artist, err error)
+	SearchGenres(ctx context.Context, tsQuery string, pageSize, offset int) (genres []models.Genre, err error)
+}
+
+type SearchService interface {
+	// Search returns songs, albums, artists and genres matching the query grouped by type and ranked by relevance.
+	//  Returns:
+	//   200 OK: with the grouped results.
+	//   400 Bad Request: on validation failure.
+	//   500 Internal Server Error: on failure.
+	Search(ctx context.Context, req dto.SearchRequest, pageSize, offset int) (result dto.SearchResult, err error)
+}
@@ -2,29 +2,43 @@ package contracts
 
 import (
 	"context"
+	"io"
 
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
 type SongRepository interface {
-	FindAll(ctx context.Context, pageSize, offset int) (songs []models.Song, err error)
-	FindCount(ctx context.Context) (total int, err error)
+	FindAll(ctx context.Context, spec query.Spec, pageSize, offset int) (songs []models.Song, err error)
+	FindAllByCursor(ctx context.Context, spec query.Spec, cursor utils.Cursor, limit int) (songs []models.Song, err error)
+	FindCount(ctx context.Context, spec query.Spec) (total int, err error)
 	FindSongById(ctx context.Context, id int) (song *models.Song, err error)
 	FindExistsSongById(ctx context.Context, id int) (exists bool, err error)
 	Store(ctx context.Context, input models.CreateSongInput) (err error)
 	Update(ctx context.Context, input models.CreateSongInput, id int) (err error)
+	UpdateAudio(ctx context.Context, id int, input models.SongAudioInput) (err error)
+	UpdateProcessing(ctx context.Context, id int, input models.SongProcessingInput) (updated bool, err error)
 	Delete(ctx context.Context, id int) (err error)
 	FindSongsByAlbumId(ctx context.Context, albumId, pageSize, offset int) (songs []models.Song, err error)
 	FindCountSongsByAlbumId(ctx context.Context, albumId int) (total int, err error)
 }
 
 type SongService interface {
-	// GetAll Return list of songs and total.
+	// GetAll Return list of songs and total, sorted and filtered by the spec.
 	//  Returns:
 	//   200 OK: Success with lists and total.
+	//   400 Bad Request: On invalid sort or filter.
 	//   500 Internal Server Error: On Failure.
-	GetAll(ctx context.Context, pageSize, offset int) (songs []dto.Song, total int, err error)
+	GetAll(ctx context.Context, spec query.Spec, pageSize, offset int) (songs []dto.Song, total int, err error)
+
+	// GetAllByCursor Return a page of songs after the cursor, total only when asked.
+	//  Returns:
+	//   200 OK: Success with lists and next cursor.
+	//   400 Bad Request: On invalid cursor or sort given with a cursor.
+	//   500 Internal Server Error: On Failure.
+	GetAllByCursor(ctx context.Context, spec query.Spec, cursor string, pageSize int, withTotal bool) (songs []dto.Song, pagination dto.CursorPagination, err error)
 
 	// GetSongById Retrieve a song by Id.
 	//  Returns:
@@ -49,6 +63,31 @@ type SongService interface {
 	//   500 Internal Server Error: on failure.
 	UpdateSong(ctx context.Context, req dto.CreateSongRequest, id int) (err error)
 
+	// UploadAudio store the audio file of a song and point the song to the stored key.
+	// The format is sniffed from the file content, mp3, flac, ogg, wav and m4a are accepted.
+	// Duration, stream properties and tags are read from mp3, flac and ogg files and saved on the song,
+	// values differing from the song are reported as mismatches.
+	//  Returns:
+	//   200 OK: on success with the read metadata.
+	//   400 Bad Request: on unsupported audio format or a corrupt file.
+	//   404 Not Found: song does not exists.
+	//   500 Internal Server Error: on failure.
+	UploadAudio(ctx context.Context, id int, file io.Reader) (upload dto.SongAudioUpload, err error)
+
+	// OpenAudio open the stored audio file of a song for streaming.
+	//  Returns:
+	//   200 OK: on success with the opened file, the caller closes it.
+	//   404 Not Found: song does not exists or has no uploaded audio.
+	//   500 Internal Server Error: on failure.
+	OpenAudio(ctx context.Context, id int) (audio models.SongAudio, err error)
+
+	// OpenHLS open a file of the HLS package of a song, the master playlist, a media playlist or a segment.
+	//  Returns:
+	//   200 OK: on success with the opened file, the caller closes it.
+	//   404 Not Found: song does not exists, is not packaged yet or the file does not exists.
+	//   500 Internal Server Error: on failure.
+	OpenHLS(ctx context.Context, id int, name string) (file models.StoredFile, err error)
+
 	// DeleteSong remove a song by ID.
 	//  Returns:
 	//   200 OK: on success.
@@ -0,0 +1,22 @@
+package contracts
+
+import (
+	"context"
+
+	"github.com/wahyusahajaa/mulo-api-go/pkg/signedurl"
+)
+
+type StreamService interface {
+	// SignStream grants the user short lived access to stream the song audio.
+	//  Returns:
+	//   200 OK: with the signed query params.
+	//   404 Not Found: song does not exists or has no uploaded audio.
+	//   500 Internal Server Error: on failure.
+	SignStream(ctx context.Context, songID, userID int) (params signedurl.Params, err error)
+
+	// AuthorizeStream checks a signed stream URL and returns the user it was issued to.
+	//  Returns:
+	//   200 OK: with the user id.
+	//   403 Forbidden: signature is invalid, expired or issued for another song.
+	AuthorizeStream(ctx context.Context, songID int, params signedurl.Params) (userID int, err error)
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+type txKey struct{}
+
+// Querier is what *sql.DB and *sql.Tx have in common.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Transactor lets services group repository writes and enqueued jobs in one transaction.
+type Transactor interface {
+	// WithinTx runs fn in a transaction that commits when fn returns nil. Repositories and the
+	// job queue called with the ctx passed to fn join it, nested calls join the outer transaction.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error)
+}
+
+func NewTransactor(db *DB) Transactor {
+	return db
+}
+
+func (db *DB) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	return WithinTx(ctx, db.DB, fn)
+}
+
+// WithinTx is Transactor.WithinTx for repositories holding the *sql.DB.
+func WithinTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	return fn(context.WithValue(ctx, txKey{}, tx))
+}
+
+// Conn returns the transaction WithinTx started on ctx, or db outside of one.
+func Conn(ctx context.Context, db *sql.DB) Querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
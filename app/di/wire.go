@@ -13,23 +13,41 @@ import (
 	"github.com/wahyusahajaa/mulo-api-go/app/repositories"
 	"github.com/wahyusahajaa/mulo-api-go/app/routers"
 	"github.com/wahyusahajaa/mulo-api-go/app/services"
+	"github.com/wahyusahajaa/mulo-api-go/app/workers"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/hls"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/jobs"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/jwt"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/logger"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/mailer"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/oauth"
-	"github.com/wahyusahajaa/mulo-api-go/pkg/resend"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/ratelimit"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/signedurl"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/storage"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/verification"
 )
 
 type AppContainer struct {
-	App    *fiber.App
-	Config *config.Config
+	App     *fiber.App
+	Config  *config.Config
+	DB      *database.DB
+	Workers *workers.Workers
 }
 
 var commonSet = wire.NewSet(
 	jwt.NewJWTService,
-	resend.NewResendService,
 	verification.NewVerificationService,
-	oauth.NewOauthService,
+	oauth.NewRegistry,
+	ratelimit.NewLimiter,
+	storage.NewStorage,
+)
+
+var jobSet = wire.NewSet(
+	database.NewTransactor,
+	jobs.NewQueue,
+	wire.Bind(new(jobs.Enqueuer), new(*jobs.Queue)),
+	mailer.NewMailer,
+	mailer.NewOutbox,
+	workers.NewWorkers,
 )
 
 var authSet = wire.NewSet(
@@ -58,6 +76,8 @@ var albumSet = wire.NewSet(
 
 var songSet = wire.NewSet(
 	repositories.NewSongRepository,
+	hls.NewPackager,
+	services.NewPackagingService,
 	services.NewSongService,
 	handlers.NewSongHandler,
 )
@@ -80,6 +100,35 @@ var favoriteSet = wire.NewSet(
 	handlers.NewFavoriteHandler,
 )
 
+var listenSet = wire.NewSet(
+	repositories.NewListenRepository,
+	services.NewListenService,
+	handlers.NewListenHandler,
+)
+
+var chartSet = wire.NewSet(
+	repositories.NewChartRepository,
+	services.NewChartService,
+	handlers.NewChartHandler,
+)
+
+var searchSet = wire.NewSet(
+	repositories.NewSearchRepository,
+	services.NewSearchService,
+	handlers.NewSearchHandler,
+)
+
+var streamSet = wire.NewSet(
+	signedurl.NewSignedURLService,
+	services.NewStreamService,
+	handlers.NewStreamHandler,
+)
+
+var imageSet = wire.NewSet(
+	services.NewImageService,
+	handlers.NewImageHandler,
+)
+
 func InitializedApp() (*AppContainer, error) {
 	wire.Build(
 		logger.NewLogger,
@@ -87,6 +136,7 @@ func InitializedApp() (*AppContainer, error) {
 		config.NewConfig,
 		database.NewDB,
 		commonSet,
+		jobSet,
 		authSet,
 		userSet,
 		artistSet,
@@ -95,6 +145,11 @@ func InitializedApp() (*AppContainer, error) {
 		genreSet,
 		playlistSet,
 		favoriteSet,
+		listenSet,
+		chartSet,
+		searchSet,
+		streamSet,
+		imageSet,
 		middlewares.NewAuthMiddleware,
 		handlers.NewHandlers,
 		routers.ProviderFiberApp,
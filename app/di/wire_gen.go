@@ -16,10 +16,16 @@ import (
 	"github.com/wahyusahajaa/mulo-api-go/app/repositories"
 	"github.com/wahyusahajaa/mulo-api-go/app/routers"
 	"github.com/wahyusahajaa/mulo-api-go/app/services"
+	"github.com/wahyusahajaa/mulo-api-go/app/workers"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/hls"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/jobs"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/jwt"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/logger"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/mailer"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/oauth"
-	"github.com/wahyusahajaa/mulo-api-go/pkg/resend"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/ratelimit"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/signedurl"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/storage"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/verification"
 )
 
@@ -34,13 +40,34 @@ func InitializedApp() (*AppContainer, error) {
 	logrusLogger := logger.NewLogger()
 	authRepository := repositories.NewAuthRepository(db, logrusLogger)
 	userRepository := repositories.NewUserRepository(db, logrusLogger)
-	jwtService := jwt.NewJWTService(configConfig)
+	jwtService, err := jwt.NewJWTService(configConfig)
+	if err != nil {
+		return nil, err
+	}
 	verificationService := verification.NewVerificationService(userRepository)
-	resendService := resend.NewResendService(configConfig, logrusLogger)
-	oAuthService := oauth.NewOauthService(configConfig, logrusLogger)
-	authService := services.NewAuthService(authRepository, userRepository, jwtService, verificationService, resendService, oAuthService, logrusLogger, configConfig)
+	queue := jobs.NewQueue(db, configConfig, logrusLogger)
+	transactor := database.NewTransactor(db)
+	mailerMailer, err := mailer.NewMailer(configConfig, logrusLogger)
+	if err != nil {
+		return nil, err
+	}
+	outbox := mailer.NewOutbox(db, queue, mailerMailer, logrusLogger)
+	registry, err := oauth.NewRegistry(configConfig, logrusLogger)
+	if err != nil {
+		return nil, err
+	}
+	limiter, err := ratelimit.NewLimiter(db, configConfig, logrusLogger)
+	if err != nil {
+		return nil, err
+	}
+	storageStorage, err := storage.NewStorage(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	imageService := services.NewImageService(configConfig, storageStorage, logrusLogger)
+	authService := services.NewAuthService(authRepository, userRepository, jwtService, verificationService, outbox, queue, transactor, registry, limiter, imageService, logrusLogger, configConfig)
 	authHandler := handlers.NewAuthHandler(authService, logrusLogger, jwtService)
-	authMiddleware := middlewares.NewAuthMiddleware(jwtService)
+	authMiddleware := middlewares.NewAuthMiddleware(jwtService, logrusLogger)
 	userService := services.NewUserService(userRepository, logrusLogger)
 	userHandler := handlers.NewUserHandler(userService, logrusLogger)
 	artistRepository := repositories.NewArtistRepository(db, logrusLogger)
@@ -50,7 +77,9 @@ func InitializedApp() (*AppContainer, error) {
 	albumService := services.NewAlbumService(albumRepository, artistRepository, logrusLogger)
 	albumHandler := handlers.NewAlbumHandler(albumService, logrusLogger)
 	songRepository := repositories.NewSongRepository(db, logrusLogger)
-	songService := services.NewSongService(songRepository, albumRepository, logrusLogger)
+	packager := hls.NewPackager(configConfig)
+	packagingService := services.NewPackagingService(songRepository, storageStorage, packager, queue, logrusLogger)
+	songService := services.NewSongService(songRepository, albumRepository, storageStorage, packagingService, transactor, logrusLogger)
 	songHandler := handlers.NewSongHandler(songService, logrusLogger)
 	genreRepository := repositories.NewGenreRepository(db, logrusLogger)
 	genreService := services.NewGenreService(genreRepository, artistRepository, songRepository, logrusLogger)
@@ -61,12 +90,31 @@ func InitializedApp() (*AppContainer, error) {
 	favoriteRepository := repositories.NewFavoriteRepository(db, logrusLogger)
 	favoriteService := services.NewFavoriteService(favoriteRepository, songRepository, logrusLogger)
 	favoriteHandler := handlers.NewFavoriteHandler(favoriteService, logrusLogger)
-	handlersHandlers := handlers.NewHandlers(authHandler, authMiddleware, userHandler, artistHandler, albumHandler, songHandler, genreHandler, playlistHandler, favoriteHandler)
+	listenRepository := repositories.NewListenRepository(db, logrusLogger)
+	listenService := services.NewListenService(listenRepository, songRepository, logrusLogger)
+	listenHandler := handlers.NewListenHandler(listenService, logrusLogger)
+	chartRepository := repositories.NewChartRepository(db, logrusLogger)
+	chartService := services.NewChartService(chartRepository, genreRepository, logrusLogger)
+	chartHandler := handlers.NewChartHandler(chartService, logrusLogger)
+	searchRepository := repositories.NewSearchRepository(db, logrusLogger)
+	searchService := services.NewSearchService(searchRepository, logrusLogger)
+	searchHandler := handlers.NewSearchHandler(searchService, logrusLogger)
+	signedURLService, err := signedurl.NewSignedURLService(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	streamService := services.NewStreamService(songRepository, signedURLService, logrusLogger)
+	streamHandler := handlers.NewStreamHandler(streamService, songService, listenService, configConfig, logrusLogger)
+	imageHandler := handlers.NewImageHandler(imageService, logrusLogger)
+	handlersHandlers := handlers.NewHandlers(authHandler, authMiddleware, userHandler, artistHandler, albumHandler, songHandler, genreHandler, playlistHandler, favoriteHandler, listenHandler, chartHandler, searchHandler, streamHandler, imageHandler)
 	v := middlewares.FiberLogger(logrusLogger)
 	app := routers.ProviderFiberApp(handlersHandlers, v, configConfig)
+	workersWorkers := workers.NewWorkers(queue, outbox, authService, packagingService, chartService, configConfig, logrusLogger)
 	appContainer := &AppContainer{
-		App:    app,
-		Config: configConfig,
+		App:     app,
+		Config:  configConfig,
+		DB:      db,
+		Workers: workersWorkers,
 	}
 	return appContainer, nil
 }
@@ -74,11 +122,15 @@ func InitializedApp() (*AppContainer, error) {
 // wire.go:
 
 type AppContainer struct {
-	App    *fiber.App
-	Config *config.Config
+	App     *fiber.App
+	Config  *config.Config
+	DB      *database.DB
+	Workers *workers.Workers
 }
 
-var commonSet = wire.NewSet(jwt.NewJWTService, resend.NewResendService, verification.NewVerificationService, oauth.NewOauthService)
+var commonSet = wire.NewSet(jwt.NewJWTService, verification.NewVerificationService, oauth.NewRegistry, ratelimit.NewLimiter, storage.NewStorage)
+
+var jobSet = wire.NewSet(database.NewTransactor, jobs.NewQueue, wire.Bind(new(jobs.Enqueuer), new(*jobs.Queue)), mailer.NewMailer, mailer.NewOutbox, workers.NewWorkers)
 
 var authSet = wire.NewSet(repositories.NewAuthRepository, services.NewAuthService, handlers.NewAuthHandler)
 
@@ -88,10 +140,20 @@ var artistSet = wire.NewSet(repositories.NewArtistRepository, services.NewArtist
 
 var albumSet = wire.NewSet(repositories.NewAlbumRepository, services.NewAlbumService, handlers.NewAlbumHandler)
 
-var songSet = wire.NewSet(repositories.NewSongRepository, services.NewSongService, handlers.NewSongHandler)
+var songSet = wire.NewSet(repositories.NewSongRepository, hls.NewPackager, services.NewPackagingService, services.NewSongService, handlers.NewSongHandler)
 
 var genreSet = wire.NewSet(repositories.NewGenreRepository, services.NewGenreService, handlers.NewGenreHandler)
 
 var playlistSet = wire.NewSet(repositories.NewPlaylistRepository, services.NewPlaylistService, handlers.NewPlaylistHandler)
 
 var favoriteSet = wire.NewSet(repositories.NewFavoriteRepository, services.NewFavoriteService, handlers.NewFavoriteHandler)
+
+var listenSet = wire.NewSet(repositories.NewListenRepository, services.NewListenService, handlers.NewListenHandler)
+
+var chartSet = wire.NewSet(repositories.NewChartRepository, services.NewChartService, handlers.NewChartHandler)
+
+var searchSet = wire.NewSet(repositories.NewSearchRepository, services.NewSearchService, handlers.NewSearchHandler)
+
+var streamSet = wire.NewSet(signedurl.NewSignedURLService, services.NewStreamService, handlers.NewStreamHandler)
+
+var imageSet = wire.NewSet(services.NewImageService, handlers.NewImageHandler)
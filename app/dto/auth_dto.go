@@ -1,28 +1,54 @@
 package dto
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 type RegisterRequest struct {
 	Fullname string `json:"full_name" validate:"required"`
 	Username string `json:"username" validate:"required"`
-	Email    string `json:"email" validate:"required,email"`
+	Email    string `json:"email" validate:"required,email,max=254"`
 	Password string `json:"password" validate:"required,min=6"`
 } //@name RegisterRequest
 
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
+	Email    string `json:"email" validate:"required,email,max=254"`
 	Password string `json:"password" validate:"required"`
 } //@name LoginRequest
 
 type VerifyRequest struct {
-	Email string `json:"email" validate:"required,email"`
+	Email string `json:"email" validate:"required,email,max=254"`
 	Code  string `json:"code" validate:"required"`
 } //@name VerifyRequest
 
 type ResendVerificationRequest struct {
-	Email string `json:"email" validate:"required,email"`
+	Email string `json:"email" validate:"required,email,max=254"`
 } //@name ResendVerificationRequest
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+} //@name ForgotPasswordRequest
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+} //@name ResetPasswordRequest
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+} //@name ChangePasswordRequest
+
+type ChangeEmailRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+} //@name ChangeEmailRequest
+
+type ConfirmEmailChangeRequest struct {
+	Code string `json:"code" validate:"required"`
+} //@name ConfirmEmailChangeRequest
+
 type JWTCustomClaims struct {
 	ID        int    `json:"id"`
 	Username  string `json:"username"`
@@ -31,29 +57,27 @@ type JWTCustomClaims struct {
 	jwt.RegisteredClaims
 } //@name JWTCustomClaims
 
-type GithubUser struct {
-	ID        int    `json:"id"`
-	Login     string `json:"login"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	AvatarURL string `json:"avatar_url"`
-} //@name GithubUser
-type GithubEmail struct {
-	Email      string `json:"email"`
-	Primary    bool   `json:"primary"`
-	Verified   bool   `json:"verified"`
-	Visibility string `json:"visibility"`
-} //@name GithubEmail
-
-type GithubReq struct {
-	Code string `json:"code" validate:"required"`
-} //@name GithubReq
+type OAuthCodeRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+} //@name OAuthCodeRequest
 
+// OAuthRequest
+// @Description A token the client got from the provider, an ID token for OpenID providers and an access token for GitHub
 type OAuthRequest struct {
-	ProviderID string `json:"provider_id" validate:"required"`
-	Provider   string `json:"provider" validate:"required"`
-	Fullname   string `json:"full_name" validate:"required"`
-	Username   string `json:"username" validate:"required"`
-	Email      string `json:"email" validate:"required,email"`
-	Avatar     string `json:"avatar" validate:"required"`
-} // @name OAuthRequest
\ No newline at end of file
+	Provider    string `json:"provider" validate:"required"`
+	IDToken     string `json:"id_token"`
+	AccessToken string `json:"access_token"`
+} // @name OAuthRequest
+
+// Session
+// @Description A signed in device of the user, current is the device of the request
+type Session struct {
+	Id         int       `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+} //@name Session
@@ -0,0 +1,24 @@
+package dto
+
+type ChartRequest struct {
+	Window  string `json:"window" query:"window" validate:"omitempty,oneof=day week month all"`
+	GenreId int    `json:"genre_id" query:"genre_id" validate:"gte=0"`
+} // @name ChartRequest
+
+type ChartSong struct {
+	Rank      int  `json:"rank"`
+	PlayCount int  `json:"play_count"`
+	Song      Song `json:"song"`
+} // @name ChartSong
+
+type ChartArtist struct {
+	Rank      int    `json:"rank"`
+	PlayCount int    `json:"play_count"`
+	Artist    Artist `json:"artist"`
+} // @name ChartArtist
+
+type ChartAlbum struct {
+	Rank      int             `json:"rank"`
+	PlayCount int             `json:"play_count"`
+	Album     AlbumWithArtist `json:"album"`
+} // @name ChartAlbum
@@ -1,10 +1,21 @@
 package dto
 
 type Image struct {
-	Src      string `json:"src"`
-	BlurHash string `json:"blur_hash"`
+	Src      string         `json:"src"`
+	BlurHash string         `json:"blur_hash"`
+	Width    int            `json:"width,omitempty"`
+	Height   int            `json:"height,omitempty"`
+	Variants []ImageVariant `json:"variants,omitempty"`
 } //@name Image
 
+// ImageVariant
+// @Description A resized copy of an uploaded image, for srcset
+type ImageVariant struct {
+	Src    string `json:"src"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+} //@name ImageVariant
+
 type Pagination struct {
 	Total    int `json:"total"`
 	PageSize int `json:"page_size"`
@@ -58,3 +69,11 @@ type InternalErrorResponse struct {
 	Message   string `json:"message" example:"Internal server error"`
 	RequestId string `json:"request_id" example:"abcd-1234"`
 } //@name InternalErrorResponse
+
+// CursorPagination
+// @Description Keyset pagination, pass next_cursor as the cursor query param to get the next page. Empty next_cursor means last page.
+type CursorPagination struct {
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor"`
+	Total      *int   `json:"total,omitempty"`
+} //@name CursorPagination
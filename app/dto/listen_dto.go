@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+type CreateListenRequest struct {
+	Duration int    `json:"duration" validate:"gte=0"`
+	Client   string `json:"client" validate:"max=50"`
+} // @name CreateListenRequest
+
+type ClearHistoryRequest struct {
+	SongId int    `query:"song_id" json:"song_id" validate:"gte=0"`
+	From   string `query:"from" json:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To     string `query:"to" json:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+} // @name ClearHistoryRequest
+
+type ListenHistory struct {
+	Id         int       `json:"id"`
+	Duration   int       `json:"duration"`
+	Client     string    `json:"client"`
+	ListenedAt time.Time `json:"listened_at"`
+	Song       Song      `json:"song"`
+} // @name ListenHistory
+
+type RecentlyPlayed struct {
+	LastPlayedAt time.Time `json:"last_played_at"`
+	Song         Song      `json:"song"`
+} // @name RecentlyPlayed
@@ -0,0 +1,15 @@
+package dto
+
+type SearchRequest struct {
+	Query string `json:"q" query:"q" validate:"required,max=100"`
+	Type  string `json:"type" query:"type" validate:"omitempty,oneof=song album artist genre"`
+} // @name SearchRequest
+
+// SearchResult groups the matches by type, each ordered by relevance.
+// Groups without matches or excluded by the type filter are left out.
+type SearchResult struct {
+	Songs   []Song            `json:"songs,omitempty"`
+	Albums  []AlbumWithArtist `json:"albums,omitempty"`
+	Artists []Artist          `json:"artists,omitempty"`
+	Genres  []Genre           `json:"genres,omitempty"`
+} // @name SearchResult
@@ -3,16 +3,37 @@ package dto
 type CreateSongRequest struct {
 	AlbumId  int    `json:"album_id" validate:"required"`
 	Title    string `json:"title" validate:"required,min=1"`
-	Audio    string `json:"audio" validate:"required"`
-	Duration int    `json:"duration" validate:"required"`
+	Duration int    `json:"duration" validate:"min=0"`
 	Image    *Image `json:"image" validate:"required"`
 } // @name CreateSongRequest
 
 type Song struct {
-	Id       int             `json:"id"`
-	Title    string          `json:"title"`
-	Audio    string          `json:"audio"`
-	Duration int             `json:"duration"`
-	Image    Image           `json:"image"`
-	Album    AlbumWithArtist `json:"album"`
+	Id              int             `json:"id"`
+	Title           string          `json:"title"`
+	Audio           string          `json:"audio"`
+	Duration        int             `json:"duration"`
+	Image           Image           `json:"image"`
+	Album           AlbumWithArtist `json:"album"`
+	ProcessingState string          `json:"processing_state" enums:"pending,ready,failed"`
 } // @name Song
+
+// SongAudioUpload is what was read from an uploaded audio file, zero values could not be read.
+type SongAudioUpload struct {
+	Audio      string          `json:"audio"`
+	Format     string          `json:"format"`
+	Duration   int             `json:"duration"`
+	Bitrate    int             `json:"bitrate"`
+	SampleRate int             `json:"sample_rate"`
+	Channels   int             `json:"channels"`
+	Title      string          `json:"title"`
+	Artist     string          `json:"artist"`
+	Album      string          `json:"album"`
+	Mismatches []AudioMismatch `json:"mismatches"`
+} // @name SongAudioUpload
+
+// AudioMismatch a song value that differs from what the uploaded file says.
+type AudioMismatch struct {
+	Field  string `json:"field"`
+	Song   string `json:"song"`
+	Parsed string `json:"parsed"`
+} // @name AudioMismatch
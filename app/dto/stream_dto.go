@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+type StreamURL struct {
+	Url string `json:"url"`
+	// HlsUrl the master playlist, served once the song processing_state is ready
+	HlsUrl    string    `json:"hls_url"`
+	ExpiresAt time.Time `json:"expires_at"`
+} // @name StreamURL
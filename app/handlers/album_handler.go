@@ -7,7 +7,9 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/repositories"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
@@ -30,13 +32,37 @@ func NewAlbumHandler(svc contracts.AlbumService, log *logrus.Logger) *AlbumHandl
 // @Produce      	json
 // @Param        	page     	query    	int  false  "Page number" default(1)
 // @Param        	pageSize 	query    	int  false  "Page size" default(10)
+// @Param        	sort     	query    	string  false  "Comma separated sort fields, prefix with - for descending" example(-created_at) Enums(name, created_at)
+// @Param        	artist_id	query    	int  false  "Only albums of this artist"
+// @Param        	genre_id	query    	int  false  "Only albums by artists of this genre"
+// @Param        	cursor   	query    	string  false  "Opt into keyset pagination, empty for the first page then next_cursor"
+// @Param        	withTotal 	query    	bool  false  "Include total count with cursor pagination"
 // @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.Album, dto.Pagination]
+// @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.AlbumWithArtist, dto.CursorPagination] "With cursor"
+// @Failure 		400			{object} 	dto.ValidationErrorResponse "Invalid sort, filter or cursor"
 // @Failure 		500			{object}	dto.InternalErrorResponse "Internal server error"
 // @Router      	/albums [get]
 func (h *AlbumHandler) GetAlbums(c *fiber.Ctx) error {
 	page, pageSize, offset := utils.GetPaginationParam(c)
 
-	albums, total, err := h.svc.GetAll(c.Context(), pageSize, offset)
+	spec, err := query.Parse(c.Queries(), repositories.AlbumQueryColumns)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "album_handler", "GetAlbums", err)
+	}
+
+	if cursor, pageSize, withTotal, ok := utils.GetCursorParam(c); ok {
+		albums, pagination, err := h.svc.GetAllByCursor(c.Context(), spec, cursor, pageSize, withTotal)
+		if err != nil {
+			return errs.HandleHTTPError(c, h.log, "album_handler", "GetAlbums", err)
+		}
+
+		return c.JSON(dto.ResponseWithPagination[[]dto.AlbumWithArtist, dto.CursorPagination]{
+			Data:       albums,
+			Pagination: pagination,
+		})
+	}
+
+	albums, total, err := h.svc.GetAll(c.Context(), spec, pageSize, offset)
 	if err != nil {
 		return errs.HandleHTTPError(c, h.log, "album_handler", "GetAlbums", err)
 	}
@@ -8,7 +8,9 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/repositories"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
@@ -32,13 +34,36 @@ func NewArtistHandler(svc contracts.ArtistService, log *logrus.Logger) *ArtistHa
 // @Produce      	json
 // @Param        	page     	query    	int  false  "Page number" default(1)
 // @Param        	pageSize 	query    	int  false  "Page size" default(10)
+// @Param        	sort     	query    	string  false  "Comma separated sort fields, prefix with - for descending" example(name) Enums(name, created_at)
+// @Param        	genre_id	query    	int  false  "Only artists of this genre"
+// @Param        	cursor   	query    	string  false  "Opt into keyset pagination, empty for the first page then next_cursor"
+// @Param        	withTotal 	query    	bool  false  "Include total count with cursor pagination"
 // @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.Artist, dto.Pagination]
+// @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.Artist, dto.CursorPagination] "With cursor"
+// @Failure 		400			{object} 	dto.ValidationErrorResponse "Invalid sort, filter or cursor"
 // @Failure 		500			{object}	dto.InternalErrorResponse "Internal server error"
 // @Router      	/artists [get]
 func (h *ArtistHandler) GetArtists(c *fiber.Ctx) error {
 	page, pageSize, offset := utils.GetPaginationParam(c)
 
-	artists, total, err := h.svc.GetAll(c.Context(), pageSize, offset)
+	spec, err := query.Parse(c.Queries(), repositories.ArtistQueryColumns)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "artist_handler", "GetArtists", err)
+	}
+
+	if cursor, pageSize, withTotal, ok := utils.GetCursorParam(c); ok {
+		artists, pagination, err := h.svc.GetAllByCursor(c.Context(), spec, cursor, pageSize, withTotal)
+		if err != nil {
+			return errs.HandleHTTPError(c, h.log, "artist_handler", "GetArtists", err)
+		}
+
+		return c.JSON(dto.ResponseWithPagination[[]dto.Artist, dto.CursorPagination]{
+			Data:       artists,
+			Pagination: pagination,
+		})
+	}
+
+	artists, total, err := h.svc.GetAll(c.Context(), spec, pageSize, offset)
 	if err != nil {
 		return errs.HandleHTTPError(c, h.log, "artist_handler", "GetArtists", err)
 	}
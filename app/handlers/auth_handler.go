@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"net/mail"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
@@ -12,6 +14,9 @@ import (
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
+// oauthStateCookie binds a started provider sign in to the browser that started it.
+const oauthStateCookie = "oauth_state"
+
 type AuthHandler struct {
 	svc    contracts.AuthService
 	jwtSvc jwt.JWTService
@@ -67,6 +72,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 // @Failure 		400			{object} 	dto.ValidationErrorResponse "Invalid request"
 // @Failure 		403			{object} 	dto.ErrorResponse "Account not activated"
 // @Failure 		404			{object} 	dto.ErrorResponse "Invalid email or password"
+// @Failure 		429			{object} 	dto.ErrorResponse "Too many attempts or account locked, see the Retry-After header"
 // @Failure 		500 		{object} 	dto.InternalErrorResponse "Internal server error"
 // @Router 			/auth/login [post]
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
@@ -103,6 +109,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 // @Failure 		404			{object} 	dto.ErrorResponse "Email or Code does not exists."
 // @Failure 		409			{object} 	dto.ErrorResponse "Email is already verified."
 // @Failure 		410			{object} 	dto.ErrorResponse "Code has expired."
+// @Failure 		429			{object} 	dto.ErrorResponse "Too many attempts, see the Retry-After header"
 // @Failure 		500 		{object} 	dto.InternalErrorResponse "Internal server error"
 // @Router 			/auth/verify [post]
 func (h *AuthHandler) Verify(c *fiber.Ctx) error {
@@ -134,6 +141,7 @@ func (h *AuthHandler) Verify(c *fiber.Ctx) error {
 // @Failure 			400			{object} 	dto.ValidationErrorResponse "Invalid request"
 // @Failure 			404			{object} 	dto.ErrorResponse "Email does not exists."
 // @Failure 			409			{object} 	dto.ErrorResponse "Email is already verified."
+// @Failure 			429			{object} 	dto.ErrorResponse "Too many attempts, see the Retry-After header"
 // @Failure 			500 		{object} 	dto.InternalErrorResponse "Internal server error"
 // @Router 				/auth/resend-verification [post]
 func (h *AuthHandler) ResendVerification(c *fiber.Ctx) error {
@@ -209,6 +217,198 @@ func (h *AuthHandler) AuthMe(c *fiber.Ctx) error {
 	})
 }
 
+// ChangePassword		Change password
+// @Summary				Change password
+// @Description 		Sets a new password for the current user and signs out their other sessions. The current password is required unless the account has none yet, like accounts created with OAuth.
+// @Tags        		auth
+// @Security     		BearerAuth
+// @Accept 				json
+// @Produce 			json
+// @Param 				password 	body		dto.ChangePasswordRequest true "current and new password"
+// @Success 			200 		{object} 	dto.ResponseMessage
+// @Failure 			400			{object} 	dto.ValidationErrorResponse "Invalid request or wrong current password"
+// @Failure 			429			{object} 	dto.ErrorResponse "Too many attempts, see the Retry-After header"
+// @Failure 			500 		{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 				/me/password [put]
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	var req dto.ChangePasswordRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Invalid body request.",
+		})
+	}
+
+	userID := utils.GetUserId(c.Context())
+	if err := h.svc.ChangePassword(c.Context(), userID, c.Cookies("refresh_token"), req); err != nil {
+		return errs.HandleHTTPError(c, h.log, "auth_handler", "ChangePassword", err)
+	}
+
+	return c.JSON(dto.ResponseMessage{
+		Message: "Your password has been changed.",
+	})
+}
+
+// ChangeEmail			Change email
+// @Summary				Change email
+// @Description 		Sends a verification code to the new email address. The email changes once the code is confirmed.
+// @Tags        		auth
+// @Security     		BearerAuth
+// @Accept 				json
+// @Produce 			json
+// @Param 				email	 	body		dto.ChangeEmailRequest true "new email address"
+// @Success 			202 		{object} 	dto.ResponseMessage
+// @Failure 			400			{object} 	dto.ValidationErrorResponse "Invalid request"
+// @Failure 			409			{object} 	dto.ErrorResponse "Email already used"
+// @Failure 			429			{object} 	dto.ErrorResponse "Too many attempts, see the Retry-After header"
+// @Failure 			500 		{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 				/me/email [put]
+func (h *AuthHandler) ChangeEmail(c *fiber.Ctx) error {
+	var req dto.ChangeEmailRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Invalid body request.",
+		})
+	}
+
+	userID := utils.GetUserId(c.Context())
+	if err := h.svc.ChangeEmail(c.Context(), userID, req); err != nil {
+		return errs.HandleHTTPError(c, h.log, "auth_handler", "ChangeEmail", err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(dto.ResponseMessage{
+		Message: "A verification code has been sent to your new email.",
+	})
+}
+
+// ConfirmEmailChange	Confirm email change
+// @Summary				Confirm email change
+// @Description 		Applies the pending email change with the code sent to the new address. After five wrong codes the change is dropped and a new code has to be requested.
+// @Tags        		auth
+// @Security     		BearerAuth
+// @Accept 				json
+// @Produce 			json
+// @Param 				verify	 	body		dto.ConfirmEmailChangeRequest true "verification code"
+// @Success 			200 		{object} 	dto.ResponseMessage
+// @Failure 			400			{object} 	dto.ValidationErrorResponse "Invalid request"
+// @Failure 			404			{object} 	dto.ErrorResponse "Code does not exists."
+// @Failure 			409			{object} 	dto.ErrorResponse "Email already used"
+// @Failure 			410			{object} 	dto.ErrorResponse "Code has expired."
+// @Failure 			429			{object} 	dto.ErrorResponse "Too many attempts, see the Retry-After header"
+// @Failure 			500 		{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 				/me/email/verify [post]
+func (h *AuthHandler) ConfirmEmailChange(c *fiber.Ctx) error {
+	var req dto.ConfirmEmailChangeRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Invalid body request.",
+		})
+	}
+
+	userID := utils.GetUserId(c.Context())
+	if err := h.svc.ConfirmEmailChange(c.Context(), userID, req); err != nil {
+		return errs.HandleHTTPError(c, h.log, "auth_handler", "ConfirmEmailChange", err)
+	}
+
+	return c.JSON(dto.ResponseMessage{
+		Message: "Your email has been changed.",
+	})
+}
+
+// GetSessions			List sessions
+// @Summary				List sessions
+// @Description 		Lists the devices signed in to the current user's account, the last used first. The session of the request is marked current.
+// @Tags        		auth
+// @Security     		BearerAuth
+// @Produce 			json
+// @Success 			200 		{object} 	dto.ResponseWithData[[]dto.Session]
+// @Failure 			500 		{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 				/me/sessions [get]
+func (h *AuthHandler) GetSessions(c *fiber.Ctx) error {
+	userID := utils.GetUserId(c.Context())
+
+	sessions, err := h.svc.GetSessions(c.Context(), userID, c.Cookies("refresh_token"))
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "auth_handler", "GetSessions", err)
+	}
+
+	return c.JSON(dto.ResponseWithData[[]dto.Session]{
+		Data: sessions,
+	})
+}
+
+// RevokeSession		Revoke a session
+// @Summary				Revoke a session
+// @Description 		Signs the current user out of one of their sessions.
+// @Tags        		auth
+// @Security     		BearerAuth
+// @Produce 			json
+// @Param 				id 			path		int true "Session ID"
+// @Success 			200 		{object} 	dto.ResponseMessage
+// @Failure 			404			{object} 	dto.ErrorResponse "Session not found"
+// @Failure 			500 		{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 				/me/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *fiber.Ctx) error {
+	sessionID, _ := strconv.Atoi(c.Params("id"))
+	userID := utils.GetUserId(c.Context())
+
+	if err := h.svc.RevokeSession(c.Context(), userID, sessionID); err != nil {
+		return errs.HandleHTTPError(c, h.log, "auth_handler", "RevokeSession", err)
+	}
+
+	return c.JSON(dto.ResponseMessage{
+		Message: "Session has been revoked.",
+	})
+}
+
+// LogoutEverywhere		Log out everywhere
+// @Summary				Log out everywhere
+// @Description 		Signs the current user out of every session, this one included, and removes the token cookies.
+// @Tags        		auth
+// @Security     		BearerAuth
+// @Produce 			json
+// @Success 			200 		{object} 	dto.ResponseMessage
+// @Failure 			500 		{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 				/me/sessions [delete]
+func (h *AuthHandler) LogoutEverywhere(c *fiber.Ctx) error {
+	userID := utils.GetUserId(c.Context())
+
+	if err := h.svc.RevokeUserSessions(c.Context(), userID); err != nil {
+		return errs.HandleHTTPError(c, h.log, "auth_handler", "LogoutEverywhere", err)
+	}
+
+	h.jwtSvc.ClearTokenCookies(c)
+
+	return c.JSON(dto.ResponseMessage{
+		Message: "Successfully logged out everywhere.",
+	})
+}
+
+// RevokeUserSessions	Revoke the sessions of a user
+// @Summary				Revoke the sessions of a user
+// @Description 		Signs the user with the specified ID out of every session. Access tokens already issued stay valid until they expire.
+// @Tags        		users
+// @Security     		BearerAuth
+// @Produce 			json
+// @Param 				id 			path		int true "User ID"
+// @Success 			200 		{object} 	dto.ResponseMessage
+// @Failure 			404			{object} 	dto.ErrorResponse "User not found"
+// @Failure 			500 		{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 				/users/{id}/sessions [delete]
+func (h *AuthHandler) RevokeUserSessions(c *fiber.Ctx) error {
+	userID, _ := strconv.Atoi(c.Params("id"))
+
+	if err := h.svc.RevokeUserSessions(c.Context(), userID); err != nil {
+		return errs.HandleHTTPError(c, h.log, "auth_handler", "RevokeUserSessions", err)
+	}
+
+	return c.JSON(dto.ResponseMessage{
+		Message: "User sessions have been revoked.",
+	})
+}
+
 // @Summary      Refresh access token
 // @Description  Get a new access token using a valid refresh token from cookies
 // @Tags         auth
@@ -239,6 +439,19 @@ func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
 	})
 }
 
+// @Summary      JSON Web Key Set
+// @Description  Public keys access and refresh tokens are signed with, looked up by the kid header of a token
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  jwt.JWKS	"Success"
+// @Router       /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c *fiber.Ctx) error {
+	// Verifiers may cache the set, a rotation publishes the new key well before it signs
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return c.JSON(h.jwtSvc.JWKS())
+}
+
 // @Summary      Logout user
 // @Description  Remove access and refresh token from cookies and revoke session
 // @Tags         auth
@@ -268,22 +481,114 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 	})
 }
 
-// GithubCallback handles GitHub OAuth callback.
-// @Summary		OAuth GitHub Callback
-// @Description	Handles the redirect from GitHub after OAuth login/signup and set cookies JWT token and refresh if successful.
+// ForgotPassword		Request a password reset
+// @Summary 			Request a password reset
+// @Description 		Emails a password reset link when the email belongs to an account. The response is the same either way.
+// @Tags        		auth
+// @Accept 				json
+// @Produce 			json
+// @Param 				forgot	 	body		dto.ForgotPasswordRequest true "email of the account"
+// @Success 			200 		{object} 	dto.ResponseMessage
+// @Failure 			400			{object} 	dto.ValidationErrorResponse "Invalid request"
+// @Failure 			429			{object} 	dto.ErrorResponse "Too many attempts, see the Retry-After header"
+// @Failure 			500 		{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 				/auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req dto.ForgotPasswordRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Invalid body request.",
+		})
+	}
+
+	if err := h.svc.ForgotPassword(c.Context(), req); err != nil {
+		return errs.HandleHTTPError(c, h.log, "auth_handler", "ForgotPassword", err)
+	}
+
+	return c.JSON(dto.ResponseMessage{
+		Message: "If an account exists for this email, a password reset link has been sent.",
+	})
+}
+
+// ResetPassword		Reset password
+// @Summary 			Reset password
+// @Description 		Sets a new password with the token from the reset email and signs the account out of every session.
+// @Tags        		auth
+// @Accept 				json
+// @Produce 			json
+// @Param 				reset	 	body		dto.ResetPasswordRequest true "reset token and new password"
+// @Success 			200 		{object} 	dto.ResponseMessage
+// @Failure 			400			{object} 	dto.ValidationErrorResponse "Invalid request or expired token"
+// @Failure 			500 		{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 				/auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req dto.ResetPasswordRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Invalid body request.",
+		})
+	}
+
+	if err := h.svc.ResetPassword(c.Context(), req); err != nil {
+		return errs.HandleHTTPError(c, h.log, "auth_handler", "ResetPassword", err)
+	}
+
+	// Cookies of this browser belong to a revoked session too
+	h.jwtSvc.ClearTokenCookies(c)
+
+	return c.JSON(dto.ResponseMessage{
+		Message: "Your password has been reset. Please log in again.",
+	})
+}
+
+// OAuthAuthorize	starts a sign in with a configured provider.
+// @Summary		OAuth provider authorize
+// @Description	Creates the state and PKCE verifier of a sign in with the provider, sets the state cookie and redirects to the consent page of the provider. The provider redirects back with the code and state for the callback.
+// @Tags		auth
+// @Param		provider	path		string	true "Provider name, like github or google"
+// @Success 	302			"Redirect to the provider"
+// @Failure		404			{object}	dto.ErrorResponse "Not Found: Provider is not configured"
+// @Failure		429			{object}	dto.ErrorResponse "Too Many Requests"
+// @Failure		500			{object}	dto.ErrorResponse "Internal server error"
+// @Router		/auth/oauth/{provider}/authorize [GET]
+func (h *AuthHandler) OAuthAuthorize(c *fiber.Ctx) error {
+	authURL, state, err := h.svc.OAuthAuthorize(c.Context(), c.Params("provider"))
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "auth_handler", "OAuthAuthorize", err)
+	}
+
+	// The callback is called cross-site by the frontend like the token cookies, the stored state expires it
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthStateCookie,
+		Path:     "/",
+		Value:    state,
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "none",
+	})
+
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// OAuthProviderCallback	handles the OAuth callback of a configured provider.
+// @Summary		OAuth provider callback
+// @Description	Exchanges the code of the provider redirect, such as GitHub, Google or Keycloak, for the user of the provider to login/signup and set cookies JWT token and refresh if successful. The state must match the one of the authorize redirect and its cookie.
 // @Tags		auth
 // @Accept		json
 // @Produce 	json
-// @Param		oauth		body		dto.GithubReq true "Authorization code received from GitHub redirect."
-// @Success 	200			{object} 	dto.ResponseMessage "Authenticated successfully with GitHub"
+// @Param		provider	path		string					true "Provider name, like github or google"
+// @Param		oauth		body		dto.OAuthCodeRequest	true "Authorization code and state received from the provider redirect."
+// @Success 	200			{object} 	dto.ResponseMessage "Authenticated successfully with the provider"
 // @Failure		400			{object}	dto.ErrorResponse "Invalid request or missing code"
-// @Failure		401			{object}	dto.ErrorResponse "Unauthorized: Bad credentials"
-// @Failure		404			{object}	dto.ErrorResponse "Not Found: Github user does not exists"
-// @Failure		408			{object}	dto.ErrorResponse "Time Out: Fetching GitHub email timed out"
+// @Failure		401			{object}	dto.ErrorResponse "Unauthorized: Invalid state or bad credentials"
+// @Failure		403			{object}	dto.ErrorResponse "Forbidden: Provider account has no verified email"
+// @Failure		404			{object}	dto.ErrorResponse "Not Found: Provider is not configured"
 // @Failure		500			{object}	dto.ErrorResponse "Internal server error"
-// @Router		/auth/oauth/github/callback [POST]
-func (h *AuthHandler) OAuthGithubCallback(c *fiber.Ctx) error {
-	var req dto.GithubReq
+// @Router		/auth/oauth/{provider}/callback [POST]
+func (h *AuthHandler) OAuthProviderCallback(c *fiber.Ctx) error {
+	var req dto.OAuthCodeRequest
 
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
@@ -291,28 +596,42 @@ func (h *AuthHandler) OAuthGithubCallback(c *fiber.Ctx) error {
 		})
 	}
 
-	accessToken, refreshToken, err := h.svc.OAuthGithubCallback(c.Context(), req)
+	accessToken, refreshToken, err := h.svc.OAuthProviderCallback(c.Context(), c.Params("provider"), req, c.Cookies(oauthStateCookie))
+	// The state is single use whether the sign in succeeds or not. Expired with the attributes
+	// it was set with, browsers ignore a cross-site cookie without SameSite=None and Secure
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthStateCookie,
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "none",
+	})
 	if err != nil {
-		return errs.HandleHTTPError(c, h.log, "auth_handler", "GithubCallback", err)
+		return errs.HandleHTTPError(c, h.log, "auth_handler", "OAuthProviderCallback", err)
 	}
 
 	// Set cookie access and refresh token
 	h.jwtSvc.AddTokenCookies(c, accessToken, refreshToken)
 
 	return c.JSON(dto.ResponseMessage{
-		Message: "Successfully registered with github.",
+		Message: "Successfully logged in.",
 	})
 }
 
-// OAuthCallback	handles OAuth callback from login/register.
+// OAuthCallback	handles OAuth login/register with a token the client got from the provider.
 // @Summary			handles OAuth callback from login/register.
-// @Description		Handles the redirect from OAuth login/signup and set cookies JWT token and refresh if successful.
+// @Description		Verifies a token the frontend got from the provider, an ID token for OpenID providers or an access token for GitHub, to login/signup with the user of the provider and set cookies JWT token and refresh if successful.
 // @Tags			auth
 // @Accept			json
 // @Produce 		json
-// @Param			oauth		body		dto.OAuthRequest true "oauth object that needs to be login/register"
+// @Param			oauth		body		dto.OAuthRequest true "Provider name and the id_token or access_token issued by it"
 // @Success 		200			{object} 	dto.ResponseMessage "Authenticated successfully with OAuth"
-// @Failure			400			{object}	dto.ErrorResponse "Invalid body request"
+// @Failure			400			{object}	dto.ErrorResponse "Invalid body request or missing token"
+// @Failure			401			{object}	dto.ErrorResponse "Unauthorized: Token is invalid or not issued to a trusted client"
+// @Failure			403			{object}	dto.ErrorResponse "Forbidden: Provider account has no verified email"
+// @Failure			404			{object}	dto.ErrorResponse "Not Found: Provider is not configured"
+// @Failure			429			{object}	dto.ErrorResponse "Too many requests"
 // @Failure			500			{object}	dto.ErrorResponse "Internal server error"
 // @Router			/auth/oauth/callback [POST]
 func (h *AuthHandler) OAuthCallback(c *fiber.Ctx) error {
@@ -326,7 +645,7 @@ func (h *AuthHandler) OAuthCallback(c *fiber.Ctx) error {
 
 	accessToken, refreshToken, err := h.svc.OAuthCallback(c.Context(), req)
 	if err != nil {
-		return errs.HandleHTTPError(c, h.log, "auth_handler", "GithubCallback", err)
+		return errs.HandleHTTPError(c, h.log, "auth_handler", "OAuthCallback", err)
 	}
 
 	// Set cookes for access & refresh token
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type ChartHandler struct {
+	svc contracts.ChartService
+	log *logrus.Logger
+}
+
+func NewChartHandler(svc contracts.ChartService, log *logrus.Logger) *ChartHandler {
+	return &ChartHandler{
+		svc: svc,
+		log: log,
+	}
+}
+
+// @Summary      	Top songs chart
+// @Description  	Get paginated list of songs ranked by play count within the window
+// @Tags         	charts
+// @Security     	BearerAuth
+// @Produce      	json
+// @Param        	window		query    	string  false  "Chart window" Enums(day, week, month, all) default(week)
+// @Param        	genre_id	query    	int  	false  "Only songs of this genre"
+// @Param        	page     	query    	int  	false  "Page number" default(1)
+// @Param        	pageSize 	query    	int  	false  "Page size" default(10)
+// @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.ChartSong, dto.Pagination]
+// @Failure 		400			{object} 	dto.ValidationErrorResponse "Invalid request"
+// @Failure 		404 		{object} 	dto.ErrorResponse "Genre not found"
+// @Failure 		500			{object}	dto.InternalErrorResponse "Internal server error"
+// @Router      	/charts/songs [get]
+func (h *ChartHandler) GetSongCharts(c *fiber.Ctx) error {
+	var req dto.ChartRequest
+	page, pageSize, offset := utils.GetPaginationParam(c)
+
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Invalid query parameters.",
+		})
+	}
+
+	songs, total, err := h.svc.GetSongCharts(c.Context(), req, pageSize, offset)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "chart_handler", "GetSongCharts", err)
+	}
+
+	return c.JSON(dto.ResponseWithPagination[[]dto.ChartSong, dto.Pagination]{
+		Data: songs,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	})
+}
+
+// @Summary      	Top artists chart
+// @Description  	Get paginated list of artists ranked by play count of their songs within the window
+// @Tags         	charts
+// @Security     	BearerAuth
+// @Produce      	json
+// @Param        	window		query    	string  false  "Chart window" Enums(day, week, month, all) default(week)
+// @Param        	genre_id	query    	int  	false  "Only artists of this genre"
+// @Param        	page     	query    	int  	false  "Page number" default(1)
+// @Param        	pageSize 	query    	int  	false  "Page size" default(10)
+// @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.ChartArtist, dto.Pagination]
+// @Failure 		400			{object} 	dto.ValidationErrorResponse "Invalid request"
+// @Failure 		404 		{object} 	dto.ErrorResponse "Genre not found"
+// @Failure 		500			{object}	dto.InternalErrorResponse "Internal server error"
+// @Router      	/charts/artists [get]
+func (h *ChartHandler) GetArtistCharts(c *fiber.Ctx) error {
+	var req dto.ChartRequest
+	page, pageSize, offset := utils.GetPaginationParam(c)
+
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Invalid query parameters.",
+		})
+	}
+
+	artists, total, err := h.svc.GetArtistCharts(c.Context(), req, pageSize, offset)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "chart_handler", "GetArtistCharts", err)
+	}
+
+	return c.JSON(dto.ResponseWithPagination[[]dto.ChartArtist, dto.Pagination]{
+		Data: artists,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	})
+}
+
+// @Summary      	Top albums chart
+// @Description  	Get paginated list of albums ranked by play count of their songs within the window
+// @Tags         	charts
+// @Security     	BearerAuth
+// @Produce      	json
+// @Param        	window		query    	string  false  "Chart window" Enums(day, week, month, all) default(week)
+// @Param        	genre_id	query    	int  	false  "Only albums by artists of this genre"
+// @Param        	page     	query    	int  	false  "Page number" default(1)
+// @Param        	pageSize 	query    	int  	false  "Page size" default(10)
+// @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.ChartAlbum, dto.Pagination]
+// @Failure 		400			{object} 	dto.ValidationErrorResponse "Invalid request"
+// @Failure 		404 		{object} 	dto.ErrorResponse "Genre not found"
+// @Failure 		500			{object}	dto.InternalErrorResponse "Internal server error"
+// @Router      	/charts/albums [get]
+func (h *ChartHandler) GetAlbumCharts(c *fiber.Ctx) error {
+	var req dto.ChartRequest
+	page, pageSize, offset := utils.GetPaginationParam(c)
+
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Invalid query parameters.",
+		})
+	}
+
+	albums, total, err := h.svc.GetAlbumCharts(c.Context(), req, pageSize, offset)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "chart_handler", "GetAlbumCharts", err)
+	}
+
+	return c.JSON(dto.ResponseWithPagination[[]dto.ChartAlbum, dto.Pagination]{
+		Data: albums,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	})
+}
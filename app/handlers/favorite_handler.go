@@ -30,13 +30,29 @@ func NewFavoriteHandler(svc contracts.FavoriteService, log *logrus.Logger) *Favo
 // @Produce      	json
 // @Param        	page     	query    	int  false  "Page number" default(1)
 // @Param        	pageSize 	query    	int  false  "Page size" default(10)
+// @Param        	cursor   	query    	string  false  "Opt into keyset pagination, empty for the first page then next_cursor"
+// @Param        	withTotal 	query    	bool  false  "Include total count with cursor pagination"
 // @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.Song, dto.Pagination]
+// @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.Song, dto.CursorPagination] "With cursor"
+// @Failure 		400			{object} 	dto.ValidationErrorResponse "Invalid cursor"
 // @Failure 		500			{object}	dto.InternalErrorResponse "Internal server error"
 // @Router      	/favorites/songs [get]
 func (h *FavoriteHandler) GetFavoriteSongsByUserID(c *fiber.Ctx) error {
 	page, pageSize, offset := utils.GetPaginationParam(c)
 	userID := utils.GetUserId(c.Context())
 
+	if cursor, pageSize, withTotal, ok := utils.GetCursorParam(c); ok {
+		songs, pagination, err := h.svc.GetFavoriteSongsByCursor(c.Context(), userID, cursor, pageSize, withTotal)
+		if err != nil {
+			return errs.HandleHTTPError(c, h.log, "favorite_handler", "GetFavoriteSongsByUserID", err)
+		}
+
+		return c.JSON(dto.ResponseWithPagination[[]dto.Song, dto.CursorPagination]{
+			Data:       songs,
+			Pagination: pagination,
+		})
+	}
+
 	songs, total, err := h.svc.GetFavoriteSongsByUserID(c.Context(), userID, pageSize, offset)
 	if err != nil {
 		return errs.HandleHTTPError(c, h.log, "favorite_handler", "GetFavoriteSongsByUserID", err)
@@ -31,12 +31,28 @@ func NewGenreHandler(svc contracts.GenreService, log *logrus.Logger) *GenreHandl
 // @Produce      	json
 // @Param        	page     	query    	int  false  "Page number" default(1)
 // @Param        	pageSize 	query    	int  false  "Page size" default(10)
+// @Param        	cursor   	query    	string  false  "Opt into keyset pagination, empty for the first page then next_cursor"
+// @Param        	withTotal 	query    	bool  false  "Include total count with cursor pagination"
 // @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.Genre, dto.Pagination]
+// @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.Genre, dto.CursorPagination] "With cursor"
+// @Failure 		400			{object} 	dto.ValidationErrorResponse "Invalid cursor"
 // @Failure 		500			{object}	dto.InternalErrorResponse "Internal server error"
 // @Router      	/genres [get]
 func (h *GenreHandler) GetGenres(c *fiber.Ctx) error {
 	page, pageSize, offset := utils.GetPaginationParam(c)
 
+	if cursor, pageSize, withTotal, ok := utils.GetCursorParam(c); ok {
+		genres, pagination, err := h.svc.GetAllByCursor(c.Context(), cursor, pageSize, withTotal)
+		if err != nil {
+			return errs.HandleHTTPError(c, h.log, "genre_handler", "GetGenres", err)
+		}
+
+		return c.JSON(dto.ResponseWithPagination[[]dto.Genre, dto.CursorPagination]{
+			Data:       genres,
+			Pagination: pagination,
+		})
+	}
+
 	genres, total, err := h.svc.GetAll(c.Context(), pageSize, offset)
 	if err != nil {
 		return errs.HandleHTTPError(c, h.log, "genre_handler", "GetGenres", err)
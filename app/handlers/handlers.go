@@ -12,6 +12,11 @@ type Handlers struct {
 	Genre      *GenreHandler
 	Playlist   *PlaylistHandler
 	Favorite   *FavoriteHandler
+	Listen     *ListenHandler
+	Chart      *ChartHandler
+	Search     *SearchHandler
+	Stream     *StreamHandler
+	Image      *ImageHandler
 }
 
 func NewHandlers(
@@ -24,6 +29,11 @@ func NewHandlers(
 	genre *GenreHandler,
 	playlist *PlaylistHandler,
 	favorite *FavoriteHandler,
+	listen *ListenHandler,
+	chart *ChartHandler,
+	search *SearchHandler,
+	stream *StreamHandler,
+	image *ImageHandler,
 ) *Handlers {
 	return &Handlers{
 		Auth:       auth,
@@ -35,5 +45,10 @@ func NewHandlers(
 		Genre:      genre,
 		Playlist:   playlist,
 		Favorite:   favorite,
+		Listen:     listen,
+		Chart:      chart,
+		Search:     search,
+		Stream:     stream,
+		Image:      image,
 	}
 }
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+)
+
+type ImageHandler struct {
+	svc contracts.ImageService
+	log *logrus.Logger
+}
+
+func NewImageHandler(svc contracts.ImageService, log *logrus.Logger) *ImageHandler {
+	return &ImageHandler{
+		svc: svc,
+		log: log,
+	}
+}
+
+// @Summary 		Upload image
+// @Description 	Upload a jpeg, png or webp image. Resized variants are stored with the metadata stripped and the blur hash is computed, pass the returned image as the image of an artist, album, song or genre.
+// @Tags        	images
+// @Security     	BearerAuth
+// @Accept 			multipart/form-data
+// @Produce 		json
+// @Param 			image	formData	file true "Image file, jpeg, png or webp"
+// @Success 		200 	{object} 	dto.ResponseWithData[dto.Image]
+// @Failure 		400		{object} 	dto.ValidationErrorResponse "Missing file, unsupported image format, corrupt or too large image"
+// @Failure 		413 	{object} 	dto.ErrorResponse "File too large"
+// @Failure 		500 	{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 			/images [post]
+func (h *ImageHandler) UploadImage(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Image file is required.",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "image_handler", "UploadImage", err)
+	}
+	defer file.Close()
+
+	image, err := h.svc.UploadImage(c.Context(), file)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "image_handler", "UploadImage", err)
+	}
+
+	return c.JSON(dto.ResponseWithData[dto.Image]{
+		Data: image,
+	})
+}
+
+// @Summary 		Get image
+// @Description 	Get a stored variant of an uploaded image, variants never change and are cached for a year.
+// @Tags        	images
+// @Produce 		image/jpeg,image/png
+// @Param 			id 		path 		string true "Image ID"
+// @Param 			file 	path 		string true "Variant file, like 640w.jpg"
+// @Success 		200
+// @Failure 		404 	{object} 	dto.ErrorResponse "Image not found"
+// @Failure 		500 	{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 			/images/{id}/{file} [get]
+func (h *ImageHandler) GetImage(c *fiber.Ctx) error {
+	image, err := h.svc.OpenImage(c.Context(), c.Params("id"), c.Params("file"))
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "image_handler", "GetImage", err)
+	}
+
+	c.Set(fiber.HeaderContentType, image.ContentType)
+	c.Set(fiber.HeaderLastModified, image.ModTime.UTC().Format(http.TimeFormat))
+	c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	// fasthttp closes the body stream once it is sent
+	c.Context().SetBodyStream(image.Body, int(image.Size))
+	return nil
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type ListenHandler struct {
+	svc contracts.ListenService
+	log *logrus.Logger
+}
+
+func NewListenHandler(svc contracts.ListenService, log *logrus.Logger) *ListenHandler {
+	return &ListenHandler{
+		svc: svc,
+		log: log,
+	}
+}
+
+// @Summary 		Record song listen
+// @Description 	Record a play of the song by the authenticated user. Repeat events of the same song within a short window are dropped.
+// @Tags        	listens
+// @Security     	BearerAuth
+// @Accept 			json
+// @Produce 		json
+// @Param 			id 		path 		int true "Song ID"
+// @Param 			listen	body		dto.CreateListenRequest true "Listen object that needs to be recorded"
+// @Success 		201 	{object} 	dto.ResponseMessage "Listen recorded"
+// @Success 		200 	{object} 	dto.ResponseMessage "Repeat listen ignored"
+// @Failure 		400		{object} 	dto.ValidationErrorResponse "Invalid request"
+// @Failure 		404 	{object} 	dto.ErrorResponse "Song not found"
+// @Failure 		500 	{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 			/songs/{id}/listens [post]
+func (h *ListenHandler) CreateListen(c *fiber.Ctx) error {
+	var req dto.CreateListenRequest
+	songID, _ := strconv.Atoi(c.Params("id"))
+	userID := utils.GetUserId(c.Context())
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Invalid body request.",
+		})
+	}
+
+	recorded, err := h.svc.RecordListen(c.Context(), req, userID, songID)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "listen_handler", "CreateListen", err)
+	}
+
+	if !recorded {
+		return c.JSON(dto.ResponseMessage{
+			Message: "Listen already recorded recently.",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.ResponseMessage{
+		Message: "Successfully recorded listen.",
+	})
+}
+
+// @Summary      	Listening history
+// @Description  	Get paginated list of the authenticated user listens, newest first
+// @Tags         	listens
+// @Security     	BearerAuth
+// @Produce      	json
+// @Param        	page     	query    	int  false  "Page number" default(1)
+// @Param        	pageSize 	query    	int  false  "Page size" default(10)
+// @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.ListenHistory, dto.Pagination]
+// @Failure 		500			{object}	dto.InternalErrorResponse "Internal server error"
+// @Router      	/me/history [get]
+func (h *ListenHandler) GetHistory(c *fiber.Ctx) error {
+	page, pageSize, offset := utils.GetPaginationParam(c)
+	userID := utils.GetUserId(c.Context())
+
+	history, total, err := h.svc.GetHistory(c.Context(), userID, pageSize, offset)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "listen_handler", "GetHistory", err)
+	}
+
+	return c.JSON(dto.ResponseWithPagination[[]dto.ListenHistory, dto.Pagination]{
+		Data: history,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	})
+}
+
+// @Summary      	Recently played songs
+// @Description  	Get paginated list of distinct songs the authenticated user played, most recent first
+// @Tags         	listens
+// @Security     	BearerAuth
+// @Produce      	json
+// @Param        	page     	query    	int  false  "Page number" default(1)
+// @Param        	pageSize 	query    	int  false  "Page size" default(10)
+// @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.RecentlyPlayed, dto.Pagination]
+// @Failure 		500			{object}	dto.InternalErrorResponse "Internal server error"
+// @Router      	/me/recently-played [get]
+func (h *ListenHandler) GetRecentlyPlayed(c *fiber.Ctx) error {
+	page, pageSize, offset := utils.GetPaginationParam(c)
+	userID := utils.GetUserId(c.Context())
+
+	songs, total, err := h.svc.GetRecentlyPlayed(c.Context(), userID, pageSize, offset)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "listen_handler", "GetRecentlyPlayed", err)
+	}
+
+	return c.JSON(dto.ResponseWithPagination[[]dto.RecentlyPlayed, dto.Pagination]{
+		Data: songs,
+		Pagination: dto.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    total,
+		},
+	})
+}
+
+// @Summary 		Clear listening history
+// @Description 	Remove the authenticated user listens. Without filters the whole history is cleared.
+// @Tags        	listens
+// @Security     	BearerAuth
+// @Produce 		json
+// @Param        	song_id	query    	int  	false  "Only listens of this song"
+// @Param        	from	query    	string  false  "Only listens at or after this RFC3339 time"
+// @Param        	to		query    	string  false  "Only listens before this RFC3339 time"
+// @Success 		200 	{object} 	dto.ResponseMessage
+// @Failure 		400		{object} 	dto.ValidationErrorResponse "Invalid request"
+// @Failure 		500 	{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 			/me/history [delete]
+func (h *ListenHandler) ClearHistory(c *fiber.Ctx) error {
+	var req dto.ClearHistoryRequest
+	userID := utils.GetUserId(c.Context())
+
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Invalid query parameters.",
+		})
+	}
+
+	deleted, err := h.svc.ClearHistory(c.Context(), userID, req)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "listen_handler", "ClearHistory", err)
+	}
+
+	return c.JSON(dto.ResponseMessage{
+		Message: fmt.Sprintf("Successfully removed %d listen(s) from history.", deleted),
+	})
+}
@@ -30,7 +30,11 @@ func NewPlaylistHandler(svc contracts.PlaylistService, log *logrus.Logger) *Play
 // @Produce      	json
 // @Param        	page     	query    	int  false  "Page number" default(1)
 // @Param        	pageSize 	query    	int  false  "Page size" default(10)
+// @Param        	cursor   	query    	string  false  "Opt into keyset pagination, empty for the first page then next_cursor"
+// @Param        	withTotal 	query    	bool  false  "Include total count with cursor pagination"
 // @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.Playlist, dto.Pagination]
+// @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.Playlist, dto.CursorPagination] "With cursor"
+// @Failure 		400			{object} 	dto.ValidationErrorResponse "Invalid cursor"
 // @Failure 		500			{object}	dto.InternalErrorResponse "Internal server error"
 // @Router      	/playlists [get]
 func (h *PlaylistHandler) GetPlaylists(c *fiber.Ctx) error {
@@ -38,6 +42,18 @@ func (h *PlaylistHandler) GetPlaylists(c *fiber.Ctx) error {
 	role := utils.GetRole(c.Context())
 	userId := utils.GetUserId(c.Context())
 
+	if cursor, pageSize, withTotal, ok := utils.GetCursorParam(c); ok {
+		playlists, pagination, err := h.svc.GetAllByCursor(c.Context(), role, userId, cursor, pageSize, withTotal)
+		if err != nil {
+			return errs.HandleHTTPError(c, h.log, "playlist_handler", "GetPlaylists", err)
+		}
+
+		return c.JSON(dto.ResponseWithPagination[[]dto.Playlist, dto.CursorPagination]{
+			Data:       playlists,
+			Pagination: pagination,
+		})
+	}
+
 	playlists, total, err := h.svc.GetAll(c.Context(), role, userId, pageSize, offset)
 	if err != nil {
 		return errs.HandleHTTPError(c, h.log, "playlist_handler", "GetPlaylists", err)
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type SearchHandler struct {
+	svc contracts.SearchService
+	log *logrus.Logger
+}
+
+func NewSearchHandler(svc contracts.SearchService, log *logrus.Logger) *SearchHandler {
+	return &SearchHandler{
+		svc: svc,
+		log: log,
+	}
+}
+
+// @Summary      	Search catalog
+// @Description  	Full-text search over song titles, album, artist and genre names. Accent-insensitive, every word matches as a prefix.
+// @Tags         	search
+// @Security     	BearerAuth
+// @Produce      	json
+// @Param        	q			query    	string  true   "Search text"
+// @Param        	type		query    	string  false  "Only search this type" Enums(song, album, artist, genre)
+// @Param        	page     	query    	int  	false  "Page number, per type" default(1)
+// @Param        	pageSize 	query    	int  	false  "Page size, per type" default(10)
+// @Success 		200 		{object}	dto.ResponseWithData[dto.SearchResult]
+// @Failure 		400			{object} 	dto.ValidationErrorResponse "Invalid request"
+// @Failure 		500			{object}	dto.InternalErrorResponse "Internal server error"
+// @Router      	/search [get]
+func (h *SearchHandler) Search(c *fiber.Ctx) error {
+	var req dto.SearchRequest
+	_, pageSize, offset := utils.GetPaginationParam(c)
+
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Invalid query parameters.",
+		})
+	}
+
+	result, err := h.svc.Search(c.Context(), req, pageSize, offset)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "search_handler", "Search", err)
+	}
+
+	return c.JSON(dto.ResponseWithData[dto.SearchResult]{
+		Data: result,
+	})
+}
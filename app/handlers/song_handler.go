@@ -7,7 +7,9 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/repositories"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
@@ -30,13 +32,40 @@ func NewSongHandler(svc contracts.SongService, log *logrus.Logger) *SongHandler
 // @Produce      	json
 // @Param        	page     	query    	int  false  "Page number" default(1)
 // @Param        	pageSize 	query    	int  false  "Page size" default(10)
+// @Param        	sort     	query    	string  false  "Comma separated sort fields, prefix with - for descending" example(-created_at,title) Enums(title, duration, created_at)
+// @Param        	artist_id	query    	int  false  "Only songs of this artist"
+// @Param        	album_id	query    	int  false  "Only songs of this album"
+// @Param        	genre_id	query    	int  false  "Only songs of this genre"
+// @Param        	duration_min	query    	int  false  "Minimum duration in seconds"
+// @Param        	duration_max	query    	int  false  "Maximum duration in seconds"
+// @Param        	cursor   	query    	string  false  "Opt into keyset pagination, empty for the first page then next_cursor"
+// @Param        	withTotal 	query    	bool  false  "Include total count with cursor pagination"
 // @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.Song, dto.Pagination]
+// @Success 		200 		{object}	dto.ResponseWithPagination[[]dto.Song, dto.CursorPagination] "With cursor"
+// @Failure 		400			{object} 	dto.ValidationErrorResponse "Invalid sort, filter or cursor"
 // @Failure 		500			{object}	dto.InternalErrorResponse "Internal server error"
 // @Router      	/songs [get]
 func (h *SongHandler) GetSongs(c *fiber.Ctx) error {
 	page, pageSize, offset := utils.GetPaginationParam(c)
 
-	songs, total, err := h.svc.GetAll(c.Context(), pageSize, offset)
+	spec, err := query.Parse(c.Queries(), repositories.SongQueryColumns)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "song_handler", "GetSongs", err)
+	}
+
+	if cursor, pageSize, withTotal, ok := utils.GetCursorParam(c); ok {
+		songs, pagination, err := h.svc.GetAllByCursor(c.Context(), spec, cursor, pageSize, withTotal)
+		if err != nil {
+			return errs.HandleHTTPError(c, h.log, "song_handler", "GetSongs", err)
+		}
+
+		return c.JSON(dto.ResponseWithPagination[[]dto.Song, dto.CursorPagination]{
+			Data:       songs,
+			Pagination: pagination,
+		})
+	}
+
+	songs, total, err := h.svc.GetAll(c.Context(), spec, pageSize, offset)
 	if err != nil {
 		return errs.HandleHTTPError(c, h.log, "song_handler", "GetSongs", err)
 	}
@@ -135,6 +164,46 @@ func (h *SongHandler) UpdateSong(c *fiber.Ctx) error {
 	})
 }
 
+// @Summary 		Upload song audio
+// @Description 	Upload the audio file of the song with the specified ID, replacing the previous one. The format is detected from the file content.
+// @Tags        	songs
+// @Security     	BearerAuth
+// @Accept 			multipart/form-data
+// @Produce 		json
+// @Param 			id 		path 		int true "Song ID"
+// @Param 			audio	formData	file true "Audio file, mp3, flac, ogg, wav or m4a"
+// @Success 		200 	{object} 	dto.ResponseWithData[dto.SongAudioUpload]
+// @Failure 		400		{object} 	dto.ValidationErrorResponse "Missing file, unsupported audio format or corrupt file"
+// @Failure 		404 	{object} 	dto.ErrorResponse "Song not found"
+// @Failure 		413 	{object} 	dto.ErrorResponse "File too large"
+// @Failure 		500 	{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 			/songs/{id}/audio [post]
+func (h *SongHandler) UploadAudio(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+
+	fileHeader, err := c.FormFile("audio")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Message: "Audio file is required.",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "song_handler", "UploadAudio", err)
+	}
+	defer file.Close()
+
+	upload, err := h.svc.UploadAudio(c.Context(), id, file)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "song_handler", "UploadAudio", err)
+	}
+
+	return c.JSON(dto.ResponseWithData[dto.SongAudioUpload]{
+		Data: upload,
+	})
+}
+
 // @Summary 		Delete song
 // @Description 	Delete the song with the specified ID
 // @Tags        	songs
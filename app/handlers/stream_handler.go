@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/hls"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/signedurl"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/stream"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+// streamListenClient is the client recorded on listens counted by the stream endpoint.
+const streamListenClient = "stream"
+
+// StreamRouteName names the stream route so signed URLs are built from the router.
+const StreamRouteName = "songs.stream"
+
+// HLSRouteName names the HLS master playlist route.
+const HLSRouteName = "songs.hls"
+
+type StreamHandler struct {
+	svc       contracts.StreamService
+	songSvc   contracts.SongService
+	listenSvc contracts.ListenService
+	listens   bool
+	listenAt  int
+	log       *logrus.Logger
+}
+
+func NewStreamHandler(svc contracts.StreamService, songSvc contracts.SongService, listenSvc contracts.ListenService, cfg *config.Config, log *logrus.Logger) *StreamHandler {
+	return &StreamHandler{
+		svc:       svc,
+		songSvc:   songSvc,
+		listenSvc: listenSvc,
+		listens:   cfg.StreamListens,
+		listenAt:  cfg.StreamListenAt,
+		log:       log,
+	}
+}
+
+// @Summary 		Get song stream URL
+// @Description 	Issue a short lived signed URL streaming the song audio for the authenticated user. Native players and CDNs can fetch it without the auth cookie, request a new one once it expires.
+// @Tags        	songs
+// @Security     	BearerAuth
+// @Produce 		json
+// @Param 			id 		path 		int true "Song ID"
+// @Success 		200 	{object} 	dto.ResponseWithData[dto.StreamURL]
+// @Failure 		404 	{object} 	dto.ErrorResponse "Song or audio not found"
+// @Failure 		500 	{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 			/songs/{id}/stream-url [get]
+func (h *StreamHandler) GetStreamURL(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	userID := utils.GetUserId(c.Context())
+
+	params, err := h.svc.SignStream(c.Context(), id, userID)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "stream_handler", "GetStreamURL", err)
+	}
+
+	path, err := c.GetRouteURL(StreamRouteName, fiber.Map{"id": id})
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "stream_handler", "GetStreamURL", err)
+	}
+
+	hlsPath, err := c.GetRouteURL(HLSRouteName, fiber.Map{"id": id})
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "stream_handler", "GetStreamURL", err)
+	}
+
+	return c.JSON(dto.ResponseWithData[dto.StreamURL]{
+		Data: dto.StreamURL{
+			Url:       c.BaseURL() + path + "?" + params.Query(),
+			HlsUrl:    c.BaseURL() + hlsPath + "?" + params.Query(),
+			ExpiresAt: params.ExpiresAt(),
+		},
+	})
+}
+
+// @Summary 		Stream song audio
+// @Description 	Stream the uploaded audio of the song through a signed URL from `/songs/{id}/stream-url`, no auth cookie is read. Supports single byte `Range` requests with `206 Partial Content` so players can seek, and `ETag`/`Last-Modified` validators for conditional requests. A listen is recorded for the user the URL was issued to once the served range reaches the configured share of the file.
+// @Tags        	songs
+// @Produce 		audio/mpeg,audio/flac,audio/ogg,audio/wav,audio/mp4
+// @Param 			id 					path 		int 	true 	"Song ID"
+// @Param 			uid 				query 		int 	true 	"User the URL was issued to"
+// @Param 			exp 				query 		int 	true 	"Expiry as unix seconds"
+// @Param 			sig 				query 		string 	true 	"URL signature"
+// @Param 			Range 				header 		string 	false 	"Byte range, like bytes=0-"
+// @Param 			If-Range 			header 		string 	false 	"Only apply Range when the content still matches this ETag or date"
+// @Param 			If-None-Match 		header 		string 	false 	"ETag of a cached copy"
+// @Param 			If-Modified-Since 	header 		string 	false 	"Date of a cached copy"
+// @Success 		200 	{file} 		binary "Whole audio file"
+// @Success 		206 	{file} 		binary "Requested byte range"
+// @Success 		304 	"Not modified"
+// @Failure 		403 	{object} 	dto.ErrorResponse "Invalid or expired signature"
+// @Failure 		404 	{object} 	dto.ErrorResponse "Song or audio not found"
+// @Failure 		416 	{object} 	dto.ErrorResponse "Range not satisfiable"
+// @Failure 		500 	{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 			/songs/{id}/stream [get]
+func (h *StreamHandler) StreamSong(c *fiber.Ctx) error {
+	var params signedurl.Params
+	id, _ := strconv.Atoi(c.Params("id"))
+
+	if err := c.QueryParser(&params); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{
+			Message: "Invalid signed URL.",
+		})
+	}
+
+	userID, err := h.svc.AuthorizeStream(c.Context(), id, params)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "stream_handler", "StreamSong", err)
+	}
+
+	audio, err := h.songSvc.OpenAudio(c.Context(), id)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "stream_handler", "StreamSong", err)
+	}
+
+	etag := stream.ETag(audio.Size, audio.ModTime)
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, audio.ModTime.UTC().Format(http.TimeFormat))
+	c.Set(fiber.HeaderCacheControl, "private, no-cache")
+
+	if stream.NotModified(c.Get(fiber.HeaderIfNoneMatch), c.Get(fiber.HeaderIfModifiedSince), etag, audio.ModTime) {
+		audio.Body.Close()
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	served := stream.Range{Start: 0, Length: audio.Size}
+	status := fiber.StatusOK
+
+	if stream.RangeApplies(c.Get(fiber.HeaderIfRange), etag, audio.ModTime) {
+		r, ok, err := stream.ParseRange(c.Get(fiber.HeaderRange), audio.Size)
+		if errors.Is(err, stream.ErrUnsatisfiable) {
+			audio.Body.Close()
+			c.Set(fiber.HeaderContentRange, "bytes */"+strconv.FormatInt(audio.Size, 10))
+			return c.Status(fiber.StatusRequestedRangeNotSatisfiable).JSON(dto.ErrorResponse{
+				Message: "Requested range not satisfiable.",
+			})
+		}
+		if ok {
+			served = r
+			status = fiber.StatusPartialContent
+			c.Set(fiber.HeaderContentRange, r.ContentRange(audio.Size))
+		}
+	}
+
+	if _, err := audio.Body.Seek(served.Start, io.SeekStart); err != nil {
+		audio.Body.Close()
+		return errs.HandleHTTPError(c, h.log, "stream_handler", "StreamSong", err)
+	}
+
+	if c.Method() == fiber.MethodGet {
+		h.recordListen(c, userID, audio, served)
+	}
+
+	c.Set(fiber.HeaderContentType, audio.ContentType)
+	c.Status(status)
+	// fasthttp closes the body stream once it is sent
+	c.Context().SetBodyStream(&rangeBody{Reader: io.LimitReader(audio.Body, served.Length), Closer: audio.Body}, int(served.Length))
+
+	return nil
+}
+
+// @Summary 		Stream song over HLS
+// @Description 	Serve the HLS package of the song through the signed query from `/songs/{id}/stream-url`, no auth cookie is read. Start from `master.m3u8`, every URI in the served playlists carries the same signature so players follow them as is. Available once the song `processing_state` is `ready`.
+// @Tags        	songs
+// @Produce 		application/vnd.apple.mpegurl,video/mp2t
+// @Param 			id 		path 		int 	true 	"Song ID"
+// @Param 			file 	path 		string 	true 	"master.m3u8, a media playlist like 128k/index.m3u8 or a segment"
+// @Param 			uid 	query 		int 	true 	"User the URL was issued to"
+// @Param 			exp 	query 		int 	true 	"Expiry as unix seconds"
+// @Param 			sig 	query 		string 	true 	"URL signature"
+// @Success 		200 	{file} 		binary "Playlist or segment"
+// @Failure 		403 	{object} 	dto.ErrorResponse "Invalid or expired signature"
+// @Failure 		404 	{object} 	dto.ErrorResponse "Song not found or not packaged yet"
+// @Failure 		500 	{object} 	dto.InternalErrorResponse "Internal server error"
+// @Router 			/songs/{id}/hls/{file} [get]
+func (h *StreamHandler) StreamHLS(c *fiber.Ctx) error {
+	var params signedurl.Params
+	id, _ := strconv.Atoi(c.Params("id"))
+	name := c.Params("*", hls.MasterPlaylist)
+
+	if err := c.QueryParser(&params); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{
+			Message: "Invalid signed URL.",
+		})
+	}
+
+	if _, err := h.svc.AuthorizeStream(c.Context(), id, params); err != nil {
+		return errs.HandleHTTPError(c, h.log, "stream_handler", "StreamHLS", err)
+	}
+
+	file, err := h.songSvc.OpenHLS(c.Context(), id, name)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "stream_handler", "StreamHLS", err)
+	}
+
+	c.Set(fiber.HeaderContentType, file.ContentType)
+	c.Set(fiber.HeaderLastModified, file.ModTime.UTC().Format(http.TimeFormat))
+
+	if !hls.IsPlaylist(name) {
+		// A package is never rewritten, a new upload is packaged under a new prefix
+		c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+		// fasthttp closes the body stream once it is sent
+		c.Context().SetBodyStream(file.Body, int(file.Size))
+		return nil
+	}
+
+	defer file.Body.Close()
+	playlist, err := io.ReadAll(file.Body)
+	if err != nil {
+		return errs.HandleHTTPError(c, h.log, "stream_handler", "StreamHLS", err)
+	}
+
+	// Playlists carry the signature of this request, they must not outlive it in a shared cache
+	c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	return c.Send(hls.SignPlaylist(playlist, params.Query()))
+}
+
+// recordListen counts a listen when the served range covers the byte at the listen threshold,
+// players fetch that chunk once per playthrough and the listen service drops repeats.
+// Failures are logged only, they must not break playback.
+func (h *StreamHandler) recordListen(c *fiber.Ctx, userID int, audio models.SongAudio, served stream.Range) {
+	if !h.listens || audio.Size == 0 {
+		return
+	}
+
+	threshold := audio.Size * int64(h.listenAt) / 100
+	if !served.Contains(min(threshold, audio.Size-1)) {
+		return
+	}
+
+	req := dto.CreateListenRequest{
+		Duration: audio.Duration * h.listenAt / 100,
+		Client:   streamListenClient,
+	}
+
+	if _, err := h.listenSvc.RecordListen(c.Context(), req, userID, audio.SongId); err != nil {
+		utils.LogWarn(h.log, c.Context(), "stream_handler", "StreamSong", err)
+	}
+}
+
+type rangeBody struct {
+	io.Reader
+	io.Closer
+}
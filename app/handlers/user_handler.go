@@ -81,7 +81,7 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 
 // UpdateUser		Update an existing user.
 // @Summary 		Update user
-// @Description 	Updates the user with the specified ID
+// @Description 	Updates the user with the specified ID, members can only update themselves
 // @Tags        	users
 // @Security     	BearerAuth
 // @Accept 			json
@@ -90,6 +90,7 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 // @Param 			user	 body		dto.CreateUserInput true "User object that needs to be updated"
 // @Success 		200 	{object} 	dto.ResponseMessage
 // @Failure 		400		{object} 	dto.ValidationErrorResponse "Invalid request"
+// @Failure 		403 	{object} 	dto.ErrorResponse "Forbidden: Not the user and not an admin"
 // @Failure 		404 	{object} 	dto.ErrorResponse "User not found"
 // @Failure 		500 	{object} 	dto.InternalErrorResponse "Internal server error"
 // @Router 			/users/{id} [put]
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+)
+
+// BodyLimit refuses request bodies over limit bytes with 413. The server streams request bodies,
+// so the limit of each route is checked here: a larger Content-Length is refused before the body
+// is read and a chunked body is read up to the limit.
+func BodyLimit(limit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := c.Request()
+		contentLength := req.Header.ContentLength()
+		if contentLength > limit {
+			return bodyTooLarge(c)
+		}
+
+		if contentLength == -1 && req.IsBodyStream() {
+			body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+					Message: "Invalid body request.",
+				})
+			}
+			if len(body) > limit {
+				return bodyTooLarge(c)
+			}
+			req.SetBody(body)
+		}
+
+		return c.Next()
+	}
+}
+
+func bodyTooLarge(c *fiber.Ctx) error {
+	// The rest of the body is never read, the connection can't serve another request
+	c.Context().SetConnectionClose()
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(dto.ErrorResponse{
+		Message: "Request body too large.",
+	})
+}
@@ -0,0 +1,115 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+)
+
+type BodyLimitTestSuite struct {
+	suite.Suite
+	app *fiber.App
+}
+
+func (s *BodyLimitTestSuite) SetupTest() {
+	s.app = fiber.New(fiber.Config{StreamRequestBody: true, DisablePreParseMultipartForm: true})
+
+	echoSize := func(c *fiber.Ctx) error {
+		return c.JSON(dto.ResponseMessage{Message: strconv.Itoa(len(c.Body()))})
+	}
+	// Same layout as the router, the upload route ahead of the default limit
+	s.app.Post("/upload", BodyLimit(8*1024), echoSize)
+	s.app.Use(BodyLimit(4 * 1024))
+	s.app.Post("/json", echoSize)
+}
+
+func (s *BodyLimitTestSuite) TestBodyLimit() {
+	testCases := []struct {
+		name          string
+		path          string
+		body          io.Reader
+		expectStatus  int
+		expectMessage string
+	}{
+		{
+			name:          "body under the default limit",
+			path:          "/json",
+			body:          bytes.NewReader(make([]byte, 2*1024)),
+			expectStatus:  fiber.StatusOK,
+			expectMessage: "2048",
+		},
+		{
+			name:          "body over the default limit",
+			path:          "/json",
+			body:          bytes.NewReader(make([]byte, 6*1024)),
+			expectStatus:  fiber.StatusRequestEntityTooLarge,
+			expectMessage: "Request body too large.",
+		},
+		{
+			name:          "chunked body over the default limit",
+			path:          "/json",
+			body:          io.LimitReader(zeroReader{}, 6*1024),
+			expectStatus:  fiber.StatusRequestEntityTooLarge,
+			expectMessage: "Request body too large.",
+		},
+		{
+			name:          "chunked body under the default limit",
+			path:          "/json",
+			body:          io.LimitReader(zeroReader{}, 3*1024),
+			expectStatus:  fiber.StatusOK,
+			expectMessage: "3072",
+		},
+		{
+			name:          "upload over the default limit",
+			path:          "/upload",
+			body:          bytes.NewReader(make([]byte, 6*1024)),
+			expectStatus:  fiber.StatusOK,
+			expectMessage: "6144",
+		},
+		{
+			name:          "upload over the upload limit",
+			path:          "/upload",
+			body:          bytes.NewReader(make([]byte, 9*1024)),
+			expectStatus:  fiber.StatusRequestEntityTooLarge,
+			expectMessage: "Request body too large.",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			req := httptest.NewRequest(fiber.MethodPost, tc.path, tc.body)
+			if req.ContentLength == -1 {
+				req.TransferEncoding = []string{"chunked"}
+			}
+
+			res, err := s.app.Test(req)
+			s.Require().NoError(err)
+			defer res.Body.Close()
+
+			var body dto.ErrorResponse
+			s.Require().NoError(json.NewDecoder(res.Body).Decode(&body))
+
+			s.Equal(tc.expectStatus, res.StatusCode)
+			s.Equal(tc.expectMessage, body.Message)
+		})
+	}
+}
+
+// zeroReader is a body of unknown length, the request is sent chunked.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestBodyLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(BodyLimitTestSuite))
+}
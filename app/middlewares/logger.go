@@ -10,6 +10,8 @@ func FiberLogger(logger *logrus.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		requestId := uuid.New().String()
 		c.Locals("requestId", requestId)
+		c.Locals("ip", c.IP())
+		c.Locals("user_agent", c.Get("User-Agent"))
 		err := c.Next()
 
 		logger.WithFields(logrus.Fields{
@@ -18,6 +20,7 @@ func FiberLogger(logger *logrus.Logger) fiber.Handler {
 			"url":        c.OriginalURL(),
 			"status":     c.Response().StatusCode(),
 			"user_agent": c.Get("User-Agent"),
+			"ip":         c.IP(),
 			"requestId":  requestId,
 		}).Info("Request processed")
 
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/jwt"
@@ -11,11 +12,13 @@ import (
 
 type AuthMiddleware struct {
 	jwtService jwt.JWTService
+	log        *logrus.Logger
 }
 
-func NewAuthMiddleware(jwtService jwt.JWTService) *AuthMiddleware {
+func NewAuthMiddleware(jwtService jwt.JWTService, log *logrus.Logger) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtService: jwtService,
+		log:        log,
 	}
 }
 
@@ -56,6 +59,7 @@ func (m *AuthMiddleware) AuthRequired() fiber.Handler {
 		c.Locals("username", claims.Username)
 		c.Locals("role", claims.UserRole)
 		c.Locals("token_type", claims.TokenType)
+		c.Locals("claims", claims)
 
 		return c.Next()
 	}
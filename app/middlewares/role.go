@@ -1 +1,54 @@
-package middlewares
\ No newline at end of file
+package middlewares
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+)
+
+// RequireRole only lets the request through when the role claim of the
+// authenticated user is one of the allowed roles. It must be registered after AuthRequired.
+func (m *AuthMiddleware) RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals("claims").(*dto.JWTCustomClaims)
+		if !ok || claims == nil || claims.UserRole == "" {
+			forbiddenErr := errs.NewForbiddenError("Access denied. Missing role claim.")
+			return errs.HandleHTTPError(c, m.log, "auth_middleware", "RequireRole", forbiddenErr)
+		}
+
+		if !slices.Contains(roles, claims.UserRole) {
+			forbiddenErr := errs.NewForbiddenError("Access denied. You do not have permission to access this resource.",
+				fmt.Errorf("role %q is not allowed, expected one of %v", claims.UserRole, roles))
+			return errs.HandleHTTPError(c, m.log, "auth_middleware", "RequireRole", forbiddenErr)
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireSelfOrRole lets the request through when the id route param is the authenticated
+// user, or when their role claim is one of the allowed roles. It must be registered after AuthRequired.
+func (m *AuthMiddleware) RequireSelfOrRole(param string, roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals("claims").(*dto.JWTCustomClaims)
+		if !ok || claims == nil || claims.UserRole == "" {
+			forbiddenErr := errs.NewForbiddenError("Access denied. Missing role claim.")
+			return errs.HandleHTTPError(c, m.log, "auth_middleware", "RequireSelfOrRole", forbiddenErr)
+		}
+
+		if id, err := c.ParamsInt(param); err == nil && id == claims.ID {
+			return c.Next()
+		}
+
+		if !slices.Contains(roles, claims.UserRole) {
+			forbiddenErr := errs.NewForbiddenError("Access denied. You do not have permission to access this resource.",
+				fmt.Errorf("user %d with role %q is not %s %q, expected one of %v", claims.ID, claims.UserRole, param, c.Params(param), roles))
+			return errs.HandleHTTPError(c, m.log, "auth_middleware", "RequireSelfOrRole", forbiddenErr)
+		}
+
+		return c.Next()
+	}
+}
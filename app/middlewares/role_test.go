@@ -0,0 +1,177 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+)
+
+type RoleMiddlewareTestSuite struct {
+	suite.Suite
+	Middleware *AuthMiddleware
+}
+
+func (s *RoleMiddlewareTestSuite) SetupTest() {
+	s.Middleware = NewAuthMiddleware(nil, nil)
+}
+
+func (s *RoleMiddlewareTestSuite) newApp(claims *dto.JWTCustomClaims, roles ...string) *fiber.App {
+	app := fiber.New()
+	app.Get("/resource",
+		func(c *fiber.Ctx) error {
+			if claims != nil {
+				c.Locals("claims", claims)
+			}
+			return c.Next()
+		},
+		s.Middleware.RequireRole(roles...),
+		func(c *fiber.Ctx) error {
+			return c.JSON(dto.ResponseMessage{Message: "ok"})
+		},
+	)
+
+	return app
+}
+
+func (s *RoleMiddlewareTestSuite) TestRequireRole() {
+	testCases := []struct {
+		name          string
+		claims        *dto.JWTCustomClaims
+		roles         []string
+		expectStatus  int
+		expectMessage string
+	}{
+		{
+			name:          "admin allowed",
+			claims:        &dto.JWTCustomClaims{ID: 1, UserRole: "admin"},
+			roles:         []string{"admin"},
+			expectStatus:  fiber.StatusOK,
+			expectMessage: "ok",
+		},
+		{
+			name:          "member forbidden",
+			claims:        &dto.JWTCustomClaims{ID: 2, UserRole: "member"},
+			roles:         []string{"admin"},
+			expectStatus:  fiber.StatusForbidden,
+			expectMessage: "Access denied. You do not have permission to access this resource.",
+		},
+		{
+			name:          "member allowed on multiple roles",
+			claims:        &dto.JWTCustomClaims{ID: 2, UserRole: "member"},
+			roles:         []string{"admin", "member"},
+			expectStatus:  fiber.StatusOK,
+			expectMessage: "ok",
+		},
+		{
+			name:          "missing claims",
+			claims:        nil,
+			roles:         []string{"admin"},
+			expectStatus:  fiber.StatusForbidden,
+			expectMessage: "Access denied. Missing role claim.",
+		},
+		{
+			name:          "empty role claim",
+			claims:        &dto.JWTCustomClaims{ID: 3},
+			roles:         []string{"admin"},
+			expectStatus:  fiber.StatusForbidden,
+			expectMessage: "Access denied. Missing role claim.",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			app := s.newApp(tc.claims, tc.roles...)
+
+			res, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/resource", nil))
+			s.Require().NoError(err)
+			defer res.Body.Close()
+
+			var body dto.ErrorResponse
+			s.Require().NoError(json.NewDecoder(res.Body).Decode(&body))
+
+			s.Equal(tc.expectStatus, res.StatusCode)
+			s.Equal(tc.expectMessage, body.Message)
+		})
+	}
+}
+
+func (s *RoleMiddlewareTestSuite) TestRequireSelfOrRole() {
+	testCases := []struct {
+		name          string
+		claims        *dto.JWTCustomClaims
+		path          string
+		expectStatus  int
+		expectMessage string
+	}{
+		{
+			name:          "member updates themselves",
+			claims:        &dto.JWTCustomClaims{ID: 2, UserRole: "member"},
+			path:          "/users/2",
+			expectStatus:  fiber.StatusOK,
+			expectMessage: "ok",
+		},
+		{
+			name:          "member updates another user",
+			claims:        &dto.JWTCustomClaims{ID: 2, UserRole: "member"},
+			path:          "/users/3",
+			expectStatus:  fiber.StatusForbidden,
+			expectMessage: "Access denied. You do not have permission to access this resource.",
+		},
+		{
+			name:          "member with a non numeric id",
+			claims:        &dto.JWTCustomClaims{ID: 2, UserRole: "member"},
+			path:          "/users/me",
+			expectStatus:  fiber.StatusForbidden,
+			expectMessage: "Access denied. You do not have permission to access this resource.",
+		},
+		{
+			name:          "admin updates another user",
+			claims:        &dto.JWTCustomClaims{ID: 1, UserRole: "admin"},
+			path:          "/users/3",
+			expectStatus:  fiber.StatusOK,
+			expectMessage: "ok",
+		},
+		{
+			name:          "missing claims",
+			path:          "/users/2",
+			expectStatus:  fiber.StatusForbidden,
+			expectMessage: "Access denied. Missing role claim.",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			app := fiber.New()
+			app.Put("/users/:id",
+				func(c *fiber.Ctx) error {
+					if tc.claims != nil {
+						c.Locals("claims", tc.claims)
+					}
+					return c.Next()
+				},
+				s.Middleware.RequireSelfOrRole("id", "admin"),
+				func(c *fiber.Ctx) error {
+					return c.JSON(dto.ResponseMessage{Message: "ok"})
+				},
+			)
+
+			res, err := app.Test(httptest.NewRequest(fiber.MethodPut, tc.path, nil))
+			s.Require().NoError(err)
+			defer res.Body.Close()
+
+			var body dto.ErrorResponse
+			s.Require().NoError(json.NewDecoder(res.Body).Decode(&body))
+
+			s.Equal(tc.expectStatus, res.StatusCode)
+			s.Equal(tc.expectMessage, body.Message)
+		})
+	}
+}
+
+func TestRoleMiddlewareTestSuite(t *testing.T) {
+	suite.Run(t, new(RoleMiddlewareTestSuite))
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/stretchr/testify/mock"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
 type MockAlbumRepository struct {
@@ -37,8 +39,8 @@ func (m *MockAlbumRepository) FindAlbumsByArtistId(ctx context.Context, artistId
 	return albums, args.Error(1)
 }
 
-func (m *MockAlbumRepository) FindAll(ctx context.Context, pageSize int, offset int) (albums []models.Album, err error) {
-	args := m.Called(ctx, pageSize, offset)
+func (m *MockAlbumRepository) FindAll(ctx context.Context, spec query.Spec, pageSize int, offset int) (albums []models.Album, err error) {
+	args := m.Called(ctx, spec, pageSize, offset)
 
 	if args.Get(0) != nil {
 		albums = args.Get(0).([]models.Album)
@@ -47,8 +49,8 @@ func (m *MockAlbumRepository) FindAll(ctx context.Context, pageSize int, offset
 	return albums, args.Error(1)
 }
 
-func (m *MockAlbumRepository) FindCount(ctx context.Context) (total int, err error) {
-	args := m.Called(ctx)
+func (m *MockAlbumRepository) FindCount(ctx context.Context, spec query.Spec) (total int, err error) {
+	args := m.Called(ctx, spec)
 
 	if args.Get(0) != nil {
 		total = args.Get(0).(int)
@@ -88,3 +90,13 @@ func (m *MockAlbumRepository) Update(ctx context.Context, input models.CreateAlb
 
 	return args.Error(0)
 }
+
+func (m *MockAlbumRepository) FindAllByCursor(ctx context.Context, spec query.Spec, cursor utils.Cursor, limit int) (albums []models.Album, err error) {
+	args := m.Called(ctx, spec, cursor, limit)
+
+	if args.Get(0) != nil {
+		albums = args.Get(0).([]models.Album)
+	}
+
+	return albums, args.Error(1)
+}
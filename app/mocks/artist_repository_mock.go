@@ -5,6 +5,8 @@ import (
 
 	"github.com/stretchr/testify/mock"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
 type MockArtistRepository struct {
@@ -17,8 +19,8 @@ func (m *MockArtistRepository) Delete(ctx context.Context, id int) (err error) {
 	return args.Error(0)
 }
 
-func (m *MockArtistRepository) FindAll(ctx context.Context, pageSize int, offset int) (artists []models.Artist, err error) {
-	args := m.Called(ctx, pageSize, offset)
+func (m *MockArtistRepository) FindAll(ctx context.Context, spec query.Spec, pageSize int, offset int) (artists []models.Artist, err error) {
+	args := m.Called(ctx, spec, pageSize, offset)
 
 	if args.Get(0) != nil {
 		artists = args.Get(0).([]models.Artist)
@@ -47,8 +49,8 @@ func (m *MockArtistRepository) FindByArtistIds(ctx context.Context, inClause str
 	return artists, args.Error(1)
 }
 
-func (m *MockArtistRepository) FindCount(ctx context.Context) (total int, err error) {
-	args := m.Called(ctx)
+func (m *MockArtistRepository) FindCount(ctx context.Context, spec query.Spec) (total int, err error) {
+	args := m.Called(ctx, spec)
 
 	if args.Get(0) != nil {
 		total = args.Get(0).(int)
@@ -84,3 +86,13 @@ func (m *MockArtistRepository) FindExistsArtistById(ctx context.Context, id int)
 
 	return args.Get(0).(bool), args.Error(1)
 }
+
+func (m *MockArtistRepository) FindAllByCursor(ctx context.Context, spec query.Spec, cursor utils.Cursor, limit int) (artists []models.Artist, err error) {
+	args := m.Called(ctx, spec, cursor, limit)
+
+	if args.Get(0) != nil {
+		artists = args.Get(0).([]models.Artist)
+	}
+
+	return artists, args.Error(1)
+}
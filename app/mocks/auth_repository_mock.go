@@ -0,0 +1,195 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+)
+
+type MockAuthRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuthRepository) Store(ctx context.Context, input models.RegisterInput) (err error) {
+	args := m.Called(ctx, input)
+
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) StoreUserVerifyCode(ctx context.Context, userId int, code string) (err error) {
+	args := m.Called(ctx, userId, code)
+
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) UpdateUserVerifiedAt(ctx context.Context, userId int) (err error) {
+	args := m.Called(ctx, userId)
+
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) FindRefreshTokenSession(ctx context.Context, userID int, tokenHash string) (sessionID int, err error) {
+	args := m.Called(ctx, userID, tokenHash)
+
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAuthRepository) FindRefreshToken(ctx context.Context, tokenHash string) (token *models.RefreshToken, err error) {
+	args := m.Called(ctx, tokenHash)
+
+	if args.Get(0) != nil {
+		token = args.Get(0).(*models.RefreshToken)
+	}
+
+	return token, args.Error(1)
+}
+
+func (m *MockAuthRepository) StoreSession(ctx context.Context, input models.SessionInput) (sessionID int, err error) {
+	args := m.Called(ctx, input)
+
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAuthRepository) StoreRefreshToken(ctx context.Context, userID, sessionID int, tokenHash string, expiredAt time.Time) (err error) {
+	args := m.Called(ctx, userID, sessionID, tokenHash, expiredAt)
+
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) RevokeRefreshToken(ctx context.Context, tokenHash string) (revoked bool, err error) {
+	args := m.Called(ctx, tokenHash)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthRepository) DeleteSessionByRefreshToken(ctx context.Context, tokenHash string) (err error) {
+	args := m.Called(ctx, tokenHash)
+
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) FindSessionsByUserID(ctx context.Context, userID int) (sessions []models.Session, err error) {
+	args := m.Called(ctx, userID)
+
+	if args.Get(0) != nil {
+		sessions = args.Get(0).([]models.Session)
+	}
+
+	return sessions, args.Error(1)
+}
+
+func (m *MockAuthRepository) DeleteSession(ctx context.Context, userID, sessionID int) (deleted bool, err error) {
+	args := m.Called(ctx, userID, sessionID)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthRepository) DeleteUserSessions(ctx context.Context, userID int, exceptTokenHash string) (err error) {
+	args := m.Called(ctx, userID, exceptTokenHash)
+
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) StoreUserWithOAuthAccount(ctx context.Context, input models.OAuthAccountInput) (userID int, err error) {
+	args := m.Called(ctx, input)
+
+	if args.Get(0) != nil {
+		userID = args.Get(0).(int)
+	}
+
+	return userID, args.Error(1)
+}
+
+func (m *MockAuthRepository) StoreOAuthAccount(ctx context.Context, userID int, providerID, providerUserID string) (err error) {
+	args := m.Called(ctx, userID, providerID, providerUserID)
+
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) FindOAuthAccount(ctx context.Context, provider, providerUserID string) (account *models.OAuthAccount, err error) {
+	args := m.Called(ctx, provider, providerUserID)
+
+	if args.Get(0) != nil {
+		account = args.Get(0).(*models.OAuthAccount)
+	}
+
+	return account, args.Error(1)
+}
+
+func (m *MockAuthRepository) FindExistsOauthAccount(ctx context.Context, userID int) (exists bool, err error) {
+	args := m.Called(ctx, userID)
+
+	if args.Get(0) != nil {
+		exists = args.Get(0).(bool)
+	}
+
+	return exists, args.Error(1)
+}
+
+func (m *MockAuthRepository) StoreOAuthState(ctx context.Context, stateHash string, state models.OAuthState) (err error) {
+	args := m.Called(ctx, stateHash, state)
+
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) ConsumeOAuthState(ctx context.Context, stateHash string) (state *models.OAuthState, err error) {
+	args := m.Called(ctx, stateHash)
+
+	if args.Get(0) != nil {
+		state = args.Get(0).(*models.OAuthState)
+	}
+
+	return state, args.Error(1)
+}
+
+func (m *MockAuthRepository) StorePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (err error) {
+	args := m.Called(ctx, userID, tokenHash, expiresAt)
+
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) ConsumePasswordReset(ctx context.Context, tokenHash string) (userID int, err error) {
+	args := m.Called(ctx, tokenHash)
+
+	if args.Get(0) != nil {
+		userID = args.Get(0).(int)
+	}
+
+	return userID, args.Error(1)
+}
+
+func (m *MockAuthRepository) UpdateUserPassword(ctx context.Context, userID int, password string) (err error) {
+	args := m.Called(ctx, userID, password)
+
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) StoreEmailChange(ctx context.Context, userID int, email, codeHash string) (err error) {
+	args := m.Called(ctx, userID, email, codeHash)
+
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) FindEmailChange(ctx context.Context, userID int) (change *models.EmailChange, err error) {
+	args := m.Called(ctx, userID)
+
+	if args.Get(0) != nil {
+		change = args.Get(0).(*models.EmailChange)
+	}
+
+	return change, args.Error(1)
+}
+
+func (m *MockAuthRepository) FailEmailChange(ctx context.Context, userID, maxAttempts int) (err error) {
+	args := m.Called(ctx, userID, maxAttempts)
+
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) UpdateUserEmail(ctx context.Context, userID int, email string) (err error) {
+	args := m.Called(ctx, userID, email)
+
+	return args.Error(0)
+}
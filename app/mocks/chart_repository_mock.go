@@ -0,0 +1,77 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+)
+
+type MockChartRepository struct {
+	mock.Mock
+}
+
+func (m *MockChartRepository) FindSongs(ctx context.Context, filter models.ChartFilter, pageSize, offset int) (songs []models.ChartSong, err error) {
+	args := m.Called(ctx, filter, pageSize, offset)
+
+	if args.Get(0) != nil {
+		songs = args.Get(0).([]models.ChartSong)
+	}
+
+	return songs, args.Error(1)
+}
+
+func (m *MockChartRepository) FindCountSongs(ctx context.Context, filter models.ChartFilter) (total int, err error) {
+	args := m.Called(ctx, filter)
+
+	if args.Get(0) != nil {
+		total = args.Get(0).(int)
+	}
+
+	return total, args.Error(1)
+}
+
+func (m *MockChartRepository) FindArtists(ctx context.Context, filter models.ChartFilter, pageSize, offset int) (artists []models.ChartArtist, err error) {
+	args := m.Called(ctx, filter, pageSize, offset)
+
+	if args.Get(0) != nil {
+		artists = args.Get(0).([]models.ChartArtist)
+	}
+
+	return artists, args.Error(1)
+}
+
+func (m *MockChartRepository) FindCountArtists(ctx context.Context, filter models.ChartFilter) (total int, err error) {
+	args := m.Called(ctx, filter)
+
+	if args.Get(0) != nil {
+		total = args.Get(0).(int)
+	}
+
+	return total, args.Error(1)
+}
+
+func (m *MockChartRepository) FindAlbums(ctx context.Context, filter models.ChartFilter, pageSize, offset int) (albums []models.ChartAlbum, err error) {
+	args := m.Called(ctx, filter, pageSize, offset)
+
+	if args.Get(0) != nil {
+		albums = args.Get(0).([]models.ChartAlbum)
+	}
+
+	return albums, args.Error(1)
+}
+
+func (m *MockChartRepository) FindCountAlbums(ctx context.Context, filter models.ChartFilter) (total int, err error) {
+	args := m.Called(ctx, filter)
+
+	if args.Get(0) != nil {
+		total = args.Get(0).(int)
+	}
+
+	return total, args.Error(1)
+}
+
+func (m *MockChartRepository) Refresh(ctx context.Context) (err error) {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/mock"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
 type MockFavoriteRepository struct {
@@ -52,3 +53,13 @@ func (m *MockFavoriteRepository) StoreFavoriteSong(ctx context.Context, userID i
 
 	return args.Error(0)
 }
+
+func (m *MockFavoriteRepository) FindFavoriteSongsByCursor(ctx context.Context, userID int, cursor utils.Cursor, limit int) (favorites []models.FavoriteSong, err error) {
+	args := m.Called(ctx, userID, cursor, limit)
+
+	if args.Get(0) != nil {
+		favorites = args.Get(0).([]models.FavoriteSong)
+	}
+
+	return favorites, args.Error(1)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/mock"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
 type MockGenreRepository struct {
@@ -171,3 +172,13 @@ func (m *MockGenreRepository) FindCount(ctx context.Context) (total int, err err
 
 	return count, args.Error(1)
 }
+
+func (m *MockGenreRepository) FindAllByCursor(ctx context.Context, cursor utils.Cursor, limit int) (genres []models.Genre, err error) {
+	args := m.Called(ctx, cursor, limit)
+
+	if args.Get(0) != nil {
+		genres = args.Get(0).([]models.Genre)
+	}
+
+	return genres, args.Error(1)
+}
@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"context"
+	"io"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+)
+
+type MockImageService struct {
+	mock.Mock
+}
+
+func (m *MockImageService) UploadImage(ctx context.Context, file io.Reader) (image dto.Image, err error) {
+	args := m.Called(ctx, file)
+
+	return args.Get(0).(dto.Image), args.Error(1)
+}
+
+func (m *MockImageService) RemoteImage(ctx context.Context, src string) (image dto.Image, err error) {
+	args := m.Called(ctx, src)
+
+	return args.Get(0).(dto.Image), args.Error(1)
+}
+
+func (m *MockImageService) OpenImage(ctx context.Context, id, file string) (image models.StoredFile, err error) {
+	args := m.Called(ctx, id, file)
+
+	return args.Get(0).(models.StoredFile), args.Error(1)
+}
@@ -0,0 +1,19 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/jobs"
+)
+
+type MockEnqueuer struct {
+	mock.Mock
+}
+
+// Enqueue expectations match on the args and the number of options, options are funcs and do not compare.
+func (m *MockEnqueuer) Enqueue(ctx context.Context, args jobs.Args, opts ...jobs.Option) (err error) {
+	called := m.Called(ctx, args, len(opts))
+
+	return called.Error(0)
+}
@@ -0,0 +1,68 @@
+package mocks
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/jwt"
+)
+
+type MockJWTService struct {
+	mock.Mock
+}
+
+func (m *MockJWTService) GenerateTokens(id int, username string, role string) (accessToken, refreshToken string, err error) {
+	args := m.Called(id, username, role)
+
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockJWTService) ParseToken(token, tokenType string) (claims *dto.JWTCustomClaims, err error) {
+	args := m.Called(token, tokenType)
+
+	if args.Get(0) != nil {
+		claims = args.Get(0).(*dto.JWTCustomClaims)
+	}
+
+	return claims, args.Error(1)
+}
+
+func (m *MockJWTService) ParseAccessToken(tokenString string) (claims *dto.JWTCustomClaims, err error) {
+	args := m.Called(tokenString)
+
+	if args.Get(0) != nil {
+		claims = args.Get(0).(*dto.JWTCustomClaims)
+	}
+
+	return claims, args.Error(1)
+}
+
+func (m *MockJWTService) ParseRefreshToken(tokenString string) (claims *dto.JWTCustomClaims, err error) {
+	args := m.Called(tokenString)
+
+	if args.Get(0) != nil {
+		claims = args.Get(0).(*dto.JWTCustomClaims)
+	}
+
+	return claims, args.Error(1)
+}
+
+func (m *MockJWTService) JWKS() jwt.JWKS {
+	args := m.Called()
+
+	return args.Get(0).(jwt.JWKS)
+}
+
+func (m *MockJWTService) ExtractTokenFromHeader(authHeader string) (string, error) {
+	args := m.Called(authHeader)
+
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockJWTService) AddTokenCookies(c *fiber.Ctx, accessToken, refreshToken string) {
+	m.Called(c, accessToken, refreshToken)
+}
+
+func (m *MockJWTService) ClearTokenCookies(c *fiber.Ctx) {
+	m.Called(c)
+}
@@ -0,0 +1,73 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+)
+
+type MockListenRepository struct {
+	mock.Mock
+}
+
+func (m *MockListenRepository) Store(ctx context.Context, input models.CreateListenInput, dedupWindow time.Duration) (recorded bool, err error) {
+	args := m.Called(ctx, input, dedupWindow)
+
+	if args.Get(0) != nil {
+		recorded = args.Get(0).(bool)
+	}
+
+	return recorded, args.Error(1)
+}
+
+func (m *MockListenRepository) FindHistoryByUserID(ctx context.Context, userID, pageSize, offset int) (listens []models.Listen, err error) {
+	args := m.Called(ctx, userID, pageSize, offset)
+
+	if args.Get(0) != nil {
+		listens = args.Get(0).([]models.Listen)
+	}
+
+	return listens, args.Error(1)
+}
+
+func (m *MockListenRepository) FindCountHistoryByUserID(ctx context.Context, userID int) (total int, err error) {
+	args := m.Called(ctx, userID)
+
+	if args.Get(0) != nil {
+		total = args.Get(0).(int)
+	}
+
+	return total, args.Error(1)
+}
+
+func (m *MockListenRepository) FindRecentlyPlayedByUserID(ctx context.Context, userID, pageSize, offset int) (listens []models.Listen, err error) {
+	args := m.Called(ctx, userID, pageSize, offset)
+
+	if args.Get(0) != nil {
+		listens = args.Get(0).([]models.Listen)
+	}
+
+	return listens, args.Error(1)
+}
+
+func (m *MockListenRepository) FindCountRecentlyPlayedByUserID(ctx context.Context, userID int) (total int, err error) {
+	args := m.Called(ctx, userID)
+
+	if args.Get(0) != nil {
+		total = args.Get(0).(int)
+	}
+
+	return total, args.Error(1)
+}
+
+func (m *MockListenRepository) DeleteHistory(ctx context.Context, userID int, input models.ClearHistoryInput) (deleted int, err error) {
+	args := m.Called(ctx, userID, input)
+
+	if args.Get(0) != nil {
+		deleted = args.Get(0).(int)
+	}
+
+	return deleted, args.Error(1)
+}
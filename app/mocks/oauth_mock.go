@@ -0,0 +1,74 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/oauth"
+)
+
+type MockOAuthRegistry struct {
+	mock.Mock
+}
+
+func (m *MockOAuthRegistry) Provider(name string) (provider oauth.Provider, err error) {
+	args := m.Called(name)
+
+	if args.Get(0) != nil {
+		provider = args.Get(0).(oauth.Provider)
+	}
+
+	return provider, args.Error(1)
+}
+
+func (m *MockOAuthRegistry) Names() []string {
+	args := m.Called()
+
+	return args.Get(0).([]string)
+}
+
+type MockOAuthProvider struct {
+	mock.Mock
+}
+
+func (m *MockOAuthProvider) Name() string {
+	args := m.Called()
+
+	return args.String(0)
+}
+
+func (m *MockOAuthProvider) AuthCodeURL(ctx context.Context, state, codeChallenge string) (authURL string, err error) {
+	args := m.Called(ctx, state, codeChallenge)
+
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOAuthProvider) Exchange(ctx context.Context, code, codeVerifier string) (token *oauth.Token, err error) {
+	args := m.Called(ctx, code, codeVerifier)
+
+	if args.Get(0) != nil {
+		token = args.Get(0).(*oauth.Token)
+	}
+
+	return token, args.Error(1)
+}
+
+func (m *MockOAuthProvider) Identity(ctx context.Context, token *oauth.Token) (identity *oauth.Identity, err error) {
+	args := m.Called(ctx, token)
+
+	if args.Get(0) != nil {
+		identity = args.Get(0).(*oauth.Identity)
+	}
+
+	return identity, args.Error(1)
+}
+
+func (m *MockOAuthProvider) VerifyToken(ctx context.Context, token *oauth.Token) (identity *oauth.Identity, err error) {
+	args := m.Called(ctx, token)
+
+	if args.Get(0) != nil {
+		identity = args.Get(0).(*oauth.Identity)
+	}
+
+	return identity, args.Error(1)
+}
@@ -0,0 +1,24 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/mailer"
+)
+
+type MockOutbox struct {
+	mock.Mock
+}
+
+func (m *MockOutbox) Queue(ctx context.Context, to string, email mailer.Email) (err error) {
+	args := m.Called(ctx, to, email)
+
+	return args.Error(0)
+}
+
+func (m *MockOutbox) Deliver(ctx context.Context, id int64, lastAttempt bool) (err error) {
+	args := m.Called(ctx, id, lastAttempt)
+
+	return args.Error(0)
+}
@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPackager struct {
+	mock.Mock
+}
+
+func (m *MockPackager) Package(ctx context.Context, input, outDir string) (err error) {
+	args := m.Called(ctx, input, outDir)
+
+	return args.Error(0)
+}
@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPackagingService struct {
+	mock.Mock
+}
+
+func (m *MockPackagingService) Enqueue(ctx context.Context, songID int) (err error) {
+	args := m.Called(ctx, songID)
+
+	return args.Error(0)
+}
+
+func (m *MockPackagingService) Package(ctx context.Context, songID int) (err error) {
+	args := m.Called(ctx, songID)
+
+	return args.Error(0)
+}
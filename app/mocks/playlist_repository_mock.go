@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/mock"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
 type MockPlaylistRepository struct {
@@ -100,3 +101,13 @@ func (m *MockPlaylistRepository) Update(ctx context.Context, input models.Create
 
 	return args.Error(0)
 }
+
+func (m *MockPlaylistRepository) FindAllByCursor(ctx context.Context, userRole string, userId int, cursor utils.Cursor, limit int) (playlists []models.Playlist, err error) {
+	args := m.Called(ctx, userRole, userId, cursor, limit)
+
+	if args.Get(0) != nil {
+		playlists = args.Get(0).([]models.Playlist)
+	}
+
+	return playlists, args.Error(1)
+}
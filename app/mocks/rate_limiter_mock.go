@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/ratelimit"
+)
+
+type MockLimiter struct {
+	mock.Mock
+}
+
+func (m *MockLimiter) Allow(ctx context.Context, rule ratelimit.Rule, key string) (res ratelimit.Result, err error) {
+	args := m.Called(ctx, rule, key)
+
+	return args.Get(0).(ratelimit.Result), args.Error(1)
+}
+
+func (m *MockLimiter) Peek(ctx context.Context, rule ratelimit.Rule, key string) (res ratelimit.Result, err error) {
+	args := m.Called(ctx, rule, key)
+
+	return args.Get(0).(ratelimit.Result), args.Error(1)
+}
+
+func (m *MockLimiter) Reset(ctx context.Context, rule ratelimit.Rule, key string) (err error) {
+	args := m.Called(ctx, rule, key)
+
+	return args.Error(0)
+}
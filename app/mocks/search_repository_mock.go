@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+)
+
+type MockSearchRepository struct {
+	mock.Mock
+}
+
+func (m *MockSearchRepository) SearchSongs(ctx context.Context, tsQuery string, pageSize, offset int) (songs []models.Song, err error) {
+	args := m.Called(ctx, tsQuery, pageSize, offset)
+
+	if args.Get(0) != nil {
+		songs = args.Get(0).([]models.Song)
+	}
+
+	return songs, args.Error(1)
+}
+
+func (m *MockSearchRepository) SearchAlbums(ctx context.Context, tsQuery string, pageSize, offset int) (albums []models.AlbumWithArtist, err error) {
+	args := m.Called(ctx, tsQuery, pageSize, offset)
+
+	if args.Get(0) != nil {
+		albums = args.Get(0).([]models.AlbumWithArtist)
+	}
+
+	return albums, args.Error(1)
+}
+
+func (m *MockSearchRepository) SearchArtists(ctx context.Context, tsQuery string, pageSize, offset int) (artists []models.Artist, err error) {
+	args := m.Called(ctx, tsQuery, pageSize, offset)
+
+	if args.Get(0) != nil {
+		artists = args.Get(0).([]models.Artist)
+	}
+
+	return artists, args.Error(1)
+}
+
+func (m *MockSearchRepository) SearchGenres(ctx context.Context, tsQuery string, pageSize, offset int) (genres []models.Genre, err error) {
+	args := m.Called(ctx, tsQuery, pageSize, offset)
+
+	if args.Get(0) != nil {
+		genres = args.Get(0).([]models.Genre)
+	}
+
+	return genres, args.Error(1)
+}
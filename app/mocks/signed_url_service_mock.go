@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/signedurl"
+)
+
+type MockSignedURLService struct {
+	mock.Mock
+}
+
+func (m *MockSignedURLService) Sign(resource string, userID int) (params signedurl.Params) {
+	args := m.Called(resource, userID)
+
+	return args.Get(0).(signedurl.Params)
+}
+
+func (m *MockSignedURLService) Verify(resource string, params signedurl.Params) (err error) {
+	args := m.Called(resource, params)
+
+	return args.Error(0)
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/stretchr/testify/mock"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
 type MockSongRepository struct {
@@ -17,8 +19,8 @@ func (m *MockSongRepository) Delete(ctx context.Context, id int) (err error) {
 	return args.Error(0)
 }
 
-func (m *MockSongRepository) FindAll(ctx context.Context, pageSize int, offset int) (songs []models.Song, err error) {
-	args := m.Called(ctx, pageSize, offset)
+func (m *MockSongRepository) FindAll(ctx context.Context, spec query.Spec, pageSize int, offset int) (songs []models.Song, err error) {
+	args := m.Called(ctx, spec, pageSize, offset)
 
 	if args.Get(0) != nil {
 		songs = args.Get(0).([]models.Song)
@@ -27,8 +29,8 @@ func (m *MockSongRepository) FindAll(ctx context.Context, pageSize int, offset i
 	return songs, args.Error(1)
 }
 
-func (m *MockSongRepository) FindCount(ctx context.Context) (total int, err error) {
-	args := m.Called(ctx)
+func (m *MockSongRepository) FindCount(ctx context.Context, spec query.Spec) (total int, err error) {
+	args := m.Called(ctx, spec)
 
 	if args.Get(0) != nil {
 		total = args.Get(0).(int)
@@ -69,6 +71,18 @@ func (m *MockSongRepository) Update(ctx context.Context, input models.CreateSong
 	return args.Error(0)
 }
 
+func (m *MockSongRepository) UpdateAudio(ctx context.Context, id int, input models.SongAudioInput) (err error) {
+	args := m.Called(ctx, id, input)
+
+	return args.Error(0)
+}
+
+func (m *MockSongRepository) UpdateProcessing(ctx context.Context, id int, input models.SongProcessingInput) (updated bool, err error) {
+	args := m.Called(ctx, id, input)
+
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockSongRepository) FindCountSongsByAlbumId(ctx context.Context, albumId int) (total int, err error) {
 	args := m.Called(ctx, albumId)
 
@@ -88,3 +102,13 @@ func (m *MockSongRepository) FindSongsByAlbumId(ctx context.Context, albumId int
 
 	return songs, args.Error(1)
 }
+
+func (m *MockSongRepository) FindAllByCursor(ctx context.Context, spec query.Spec, cursor utils.Cursor, limit int) (songs []models.Song, err error) {
+	args := m.Called(ctx, spec, cursor, limit)
+
+	if args.Get(0) != nil {
+		songs = args.Get(0).([]models.Song)
+	}
+
+	return songs, args.Error(1)
+}
@@ -0,0 +1,47 @@
+package mocks
+
+import (
+	"context"
+	"io"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/storage"
+)
+
+type MockStorage struct {
+	mock.Mock
+}
+
+// Put reads the whole object, expectations match on its content as []byte.
+func (m *MockStorage) Put(ctx context.Context, key string, r io.Reader, contentType string) (err error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	args := m.Called(ctx, key, body, contentType)
+
+	return args.Error(0)
+}
+
+func (m *MockStorage) Open(ctx context.Context, key string) (rc io.ReadSeekCloser, obj storage.Object, err error) {
+	args := m.Called(ctx, key)
+
+	if args.Get(0) != nil {
+		rc = args.Get(0).(io.ReadSeekCloser)
+	}
+
+	return rc, args.Get(1).(storage.Object), args.Error(2)
+}
+
+func (m *MockStorage) Delete(ctx context.Context, key string) (err error) {
+	args := m.Called(ctx, key)
+
+	return args.Error(0)
+}
+
+func (m *MockStorage) DeletePrefix(ctx context.Context, prefix string) (err error) {
+	args := m.Called(ctx, prefix)
+
+	return args.Error(0)
+}
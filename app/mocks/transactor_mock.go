@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTransactor runs fn in place, expectations see whether a transaction was used.
+type MockTransactor struct {
+	mock.Mock
+}
+
+func (m *MockTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	m.Called(ctx)
+
+	return fn(ctx)
+}
@@ -0,0 +1,114 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+)
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) FindAll(ctx context.Context, pageSize, offset int) (users []models.User, err error) {
+	args := m.Called(ctx, pageSize, offset)
+
+	if args.Get(0) != nil {
+		users = args.Get(0).([]models.User)
+	}
+
+	return users, args.Error(1)
+}
+
+func (m *MockUserRepository) FindExistsUserByUserID(ctx context.Context, userID int) (exists bool, err error) {
+	args := m.Called(ctx, userID)
+
+	if args.Get(0) != nil {
+		exists = args.Get(0).(bool)
+	}
+
+	return exists, args.Error(1)
+}
+
+func (m *MockUserRepository) FindUserVerifiedByCode(ctx context.Context, code string) (exists bool, err error) {
+	args := m.Called(ctx, code)
+
+	if args.Get(0) != nil {
+		exists = args.Get(0).(bool)
+	}
+
+	return exists, args.Error(1)
+}
+
+func (m *MockUserRepository) FindUserExistsByEmail(ctx context.Context, email string) (exists bool, err error) {
+	args := m.Called(ctx, email)
+
+	if args.Get(0) != nil {
+		exists = args.Get(0).(bool)
+	}
+
+	return exists, args.Error(1)
+}
+
+func (m *MockUserRepository) FindUserExistsByUsername(ctx context.Context, username string) (exists bool, err error) {
+	args := m.Called(ctx, username)
+
+	if args.Get(0) != nil {
+		exists = args.Get(0).(bool)
+	}
+
+	return exists, args.Error(1)
+}
+
+func (m *MockUserRepository) FindUserByEmail(ctx context.Context, email string) (user *models.User, err error) {
+	args := m.Called(ctx, email)
+
+	if args.Get(0) != nil {
+		user = args.Get(0).(*models.User)
+	}
+
+	return user, args.Error(1)
+}
+
+func (m *MockUserRepository) FindUserVerifiedByUserIDAndCode(ctx context.Context, userId int, code string) (userVerified *models.UserVerified, err error) {
+	args := m.Called(ctx, userId, code)
+
+	if args.Get(0) != nil {
+		userVerified = args.Get(0).(*models.UserVerified)
+	}
+
+	return userVerified, args.Error(1)
+}
+
+func (m *MockUserRepository) FindUserByUserID(ctx context.Context, userID int) (user *models.User, err error) {
+	args := m.Called(ctx, userID)
+
+	if args.Get(0) != nil {
+		user = args.Get(0).(*models.User)
+	}
+
+	return user, args.Error(1)
+}
+
+func (m *MockUserRepository) Count(ctx context.Context) (total int, err error) {
+	args := m.Called(ctx)
+
+	if args.Get(0) != nil {
+		total = args.Get(0).(int)
+	}
+
+	return total, args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, input models.CreateUserInput, userID int) (err error) {
+	args := m.Called(ctx, input, userID)
+
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, userID int) (err error) {
+	args := m.Called(ctx, userID)
+
+	return args.Error(0)
+}
@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockVerificationService struct {
+	mock.Mock
+}
+
+func (m *MockVerificationService) GenerateVerificationCode(ctx context.Context) (code string, err error) {
+	args := m.Called(ctx)
+
+	return args.String(0), args.Error(1)
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type RegisterInput struct {
 	Fullname string
 	Username string
@@ -7,3 +9,30 @@ type RegisterInput struct {
 	Password string
 	Code     string
 }
+
+// Session is a signed in device, the user agent and ip are the ones it signed in with.
+type Session struct {
+	Id         int
+	UserID     int
+	UserAgent  string
+	Device     string
+	IP         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+// RefreshToken is one token of the session it was issued for, every refresh revokes the
+// presented token and issues the next one.
+type RefreshToken struct {
+	UserID    int
+	SessionID int
+	Revoked   bool
+	ExpiresAt time.Time
+}
+
+type SessionInput struct {
+	UserID    int
+	UserAgent string
+	Device    string
+	IP        string
+}
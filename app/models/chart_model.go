@@ -0,0 +1,31 @@
+package models
+
+const (
+	ChartWindowDay   = "day"
+	ChartWindowWeek  = "week"
+	ChartWindowMonth = "month"
+	ChartWindowAll   = "all"
+)
+
+type ChartFilter struct {
+	Window  string
+	GenreId int
+}
+
+type ChartSong struct {
+	Rank      int
+	PlayCount int
+	Song      Song
+}
+
+type ChartArtist struct {
+	Rank      int
+	PlayCount int
+	Artist    Artist
+}
+
+type ChartAlbum struct {
+	Rank      int
+	PlayCount int
+	Album     AlbumWithArtist
+}
@@ -0,0 +1,8 @@
+package models
+
+import "time"
+
+type FavoriteSong struct {
+	Song
+	FavoritedAt time.Time
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"io"
+	"time"
+)
+
+// StoredFile is an opened stored object, a file of a song HLS package or an image variant.
+// Body must be closed by the caller.
+type StoredFile struct {
+	Body        io.ReadSeekCloser
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
@@ -0,0 +1,22 @@
+package models
+
+// Jobs enqueued by services and worked by app/workers, the json tags name the stored payload fields.
+
+// PackageSongJob packages the stored audio of a song for HLS.
+type PackageSongJob struct {
+	SongID int `json:"song_id"`
+}
+
+func (PackageSongJob) Kind() string { return "songs.package" }
+
+// PasswordResetJob looks up the account of a forgotten password and emails it a reset link.
+type PasswordResetJob struct {
+	Email string `json:"email"`
+}
+
+func (PasswordResetJob) Kind() string { return "auth.password_reset" }
+
+// RefreshChartsJob recomputes the charts aggregate, every run schedules the next one.
+type RefreshChartsJob struct{}
+
+func (RefreshChartsJob) Kind() string { return "charts.refresh" }
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Listen struct {
+	Id        int
+	UserId    int
+	SongId    int
+	Duration  int
+	Client    sql.NullString
+	CreatedAt time.Time
+	Song      Song
+}
+
+type CreateListenInput struct {
+	UserId   int
+	SongId   int
+	Duration int
+	Client   string
+}
+
+// ClearHistoryInput zero values mean "any", so an empty input clears the whole history.
+type ClearHistoryInput struct {
+	SongId int
+	From   *time.Time
+	To     *time.Time
+}
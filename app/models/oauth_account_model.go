@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type OAuthAccount struct {
 	ID             int
 	UserID         int
@@ -15,3 +17,11 @@ type OAuthAccountInput struct {
 	Image    []byte
 	Provider string // github, google, facebook
 }
+
+// OAuthState is a started provider sign in, the callback exchanges the code with its PKCE
+// code verifier.
+type OAuthState struct {
+	Provider     string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
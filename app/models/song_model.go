@@ -1,19 +1,62 @@
 package models
 
+import (
+	"io"
+	"time"
+)
+
+// Processing states of the HLS package of a song.
+const (
+	SongProcessingPending = "pending"
+	SongProcessingReady   = "ready"
+	SongProcessingFailed  = "failed"
+)
+
 type Song struct {
-	Id       int
-	AlbumId  int
-	Title    string
-	Audio    string
-	Duration int
-	Image    []byte
-	Album    AlbumWithArtist
+	Id              int
+	AlbumId         int
+	Title           string
+	Audio           string
+	Duration        int
+	Image           []byte
+	ProcessingState string
+	HLS             string
+	Album           AlbumWithArtist
 }
 
+// SongAudio is the opened audio file of a song, Body must be closed by the caller.
+type SongAudio struct {
+	SongId      int
+	Duration    int
+	Body        io.ReadSeekCloser
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// SongProcessingInput is the outcome of packaging the audio stored under Audio.
+type SongProcessingInput struct {
+	Audio string
+	State string
+	HLS   string
+}
+
+// CreateSongInput a zero Duration keeps the stored one, it is filled in when the audio is uploaded.
 type CreateSongInput struct {
 	AlbumId  int
 	Title    string
-	Audio    string
 	Duration int
 	Image    []byte
 }
+
+// SongAudioInput is the stored audio key with what was read from the file, Duration is in seconds.
+type SongAudioInput struct {
+	Audio      string
+	Duration   int
+	Bitrate    int
+	SampleRate int
+	Channels   int
+	TagTitle   string
+	TagArtist  string
+	TagAlbum   string
+}
@@ -6,3 +6,11 @@ type UserVerified struct {
 	Code      string
 	ExpiredAt sql.NullTime
 }
+
+// EmailChange is a pending change of the user email, applied once the code sent to Email is confirmed.
+type EmailChange struct {
+	Email     string
+	CodeHash  string
+	Attempts  int
+	ExpiredAt sql.NullTime
+}
@@ -4,15 +4,29 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"github.com/sirupsen/logrus"
 	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
 	"github.com/wahyusahajaa/mulo-api-go/app/database"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
+// AlbumQueryColumns allow-lists sorting and filtering on the albums list, handlers parse list requests with it.
+var AlbumQueryColumns = query.Columns{
+	Sorts: map[string]string{
+		"name":       "al.name",
+		"created_at": "al.created_at",
+	},
+	Filters: map[string]string{
+		"artist_id": "al.artist_id = %s",
+		"genre_id":  "EXISTS (SELECT 1 FROM artist_genres ag WHERE ag.artist_id = al.artist_id AND ag.genre_id = %s)",
+	},
+}
+
 type albumRepository struct {
 	db  *sql.DB
 	log *logrus.Logger
@@ -25,9 +39,10 @@ func NewAlbumRepository(db *database.DB, log *logrus.Logger) contracts.AlbumRepo
 	}
 }
 
-func (repo *albumRepository) FindAll(ctx context.Context, pageSize int, offset int) (albums []models.Album, err error) {
-	query := `SELECT id, artist_id, name, slug, image FROM albums ORDER BY id DESC LIMIT $1 OFFSET $2`
-	args := []any{pageSize, offset}
+func (repo *albumRepository) FindAll(ctx context.Context, spec query.Spec, pageSize int, offset int) (albums []models.Album, err error) {
+	where, orderBy, specArgs := spec.Build(AlbumQueryColumns, 3, "al.id DESC")
+	query := fmt.Sprintf(`SELECT al.id, al.artist_id, al.name, al.slug, al.image FROM albums al %s ORDER BY %s LIMIT $1 OFFSET $2`, whereClause(where), orderBy)
+	args := append([]any{pageSize, offset}, specArgs...)
 
 	rows, err := repo.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -56,6 +71,38 @@ func (repo *albumRepository) FindAll(ctx context.Context, pageSize int, offset i
 	return albums, nil
 }
 
+func (repo *albumRepository) FindAllByCursor(ctx context.Context, spec query.Spec, cursor utils.Cursor, limit int) (albums []models.Album, err error) {
+	where, _, specArgs := spec.Build(AlbumQueryColumns, 3, "al.id DESC")
+	query := fmt.Sprintf(`SELECT al.id, al.artist_id, al.name, al.slug, al.image FROM albums al %s ORDER BY al.id DESC LIMIT $2`, whereClause("($1 = 0 OR al.id < $1)", where))
+	args := append([]any{cursor.Id, limit}, specArgs...)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "album_repo", "FindAllByCursor", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		album := models.Album{}
+		if err = rows.Scan(
+			&album.Id,
+			&album.ArtistId,
+			&album.Name,
+			&album.Slug,
+			&album.Image,
+		); err != nil {
+			utils.LogError(repo.log, ctx, "album_repo", "FindAllByCursor", err)
+			return nil, err
+		}
+
+		albums = append(albums, album)
+	}
+
+	return albums, nil
+}
+
 func (repo *albumRepository) FindAlbumById(ctx context.Context, id int) (album *models.AlbumWithArtist, err error) {
 	query := `
 		SELECT 
@@ -97,10 +144,11 @@ func (repo *albumRepository) FindAlbumById(ctx context.Context, id int) (album *
 	return
 }
 
-func (repo *albumRepository) FindCount(ctx context.Context) (total int, err error) {
-	query := `SELECT COUNT(*) FROM albums`
+func (repo *albumRepository) FindCount(ctx context.Context, spec query.Spec) (total int, err error) {
+	where, _, args := spec.Build(AlbumQueryColumns, 1, "al.id DESC")
+	query := `SELECT COUNT(*) FROM albums al ` + whereClause(where)
 
-	if err = repo.db.QueryRowContext(ctx, query).Scan(&total); err != nil {
+	if err = repo.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
 		utils.LogError(repo.log, ctx, "album_repo", "FindCount", err)
 		return
 	}
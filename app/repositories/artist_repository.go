@@ -11,9 +11,21 @@ import (
 	"github.com/wahyusahajaa/mulo-api-go/app/database"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
+// ArtistQueryColumns allow-lists sorting and filtering on the artists list, handlers parse list requests with it.
+var ArtistQueryColumns = query.Columns{
+	Sorts: map[string]string{
+		"name":       "ar.name",
+		"created_at": "ar.created_at",
+	},
+	Filters: map[string]string{
+		"genre_id": "EXISTS (SELECT 1 FROM artist_genres ag WHERE ag.artist_id = ar.id AND ag.genre_id = %s)",
+	},
+}
+
 type artistRepository struct {
 	db  *sql.DB
 	log *logrus.Logger
@@ -26,9 +38,10 @@ func NewArtistRepository(db *database.DB, log *logrus.Logger) contracts.ArtistRe
 	}
 }
 
-func (repo *artistRepository) FindAll(ctx context.Context, pageSize, offset int) (artists []models.Artist, err error) {
-	query := `SELECT id, name, slug, image FROM artists ORDER BY id DESC LIMIT $1 OFFSET $2`
-	args := []any{pageSize, offset}
+func (repo *artistRepository) FindAll(ctx context.Context, spec query.Spec, pageSize, offset int) (artists []models.Artist, err error) {
+	where, orderBy, specArgs := spec.Build(ArtistQueryColumns, 3, "ar.id DESC")
+	query := fmt.Sprintf(`SELECT ar.id, ar.name, ar.slug, ar.image FROM artists ar %s ORDER BY %s LIMIT $1 OFFSET $2`, whereClause(where), orderBy)
+	args := append([]any{pageSize, offset}, specArgs...)
 
 	rows, err := repo.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -56,6 +69,32 @@ func (repo *artistRepository) FindAll(ctx context.Context, pageSize, offset int)
 	return artists, nil
 }
 
+func (repo *artistRepository) FindAllByCursor(ctx context.Context, spec query.Spec, cursor utils.Cursor, limit int) (artists []models.Artist, err error) {
+	where, _, specArgs := spec.Build(ArtistQueryColumns, 3, "ar.id DESC")
+	query := fmt.Sprintf(`SELECT ar.id, ar.name, ar.slug, ar.image FROM artists ar %s ORDER BY ar.id DESC LIMIT $2`, whereClause("($1 = 0 OR ar.id < $1)", where))
+	args := append([]any{cursor.Id, limit}, specArgs...)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "artist_repo", "FindAllByCursor", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		artist := models.Artist{}
+		if err = rows.Scan(&artist.Id, &artist.Name, &artist.Slug, &artist.Image); err != nil {
+			utils.LogError(repo.log, ctx, "artist_repo", "FindAllByCursor", err)
+			return nil, err
+		}
+
+		artists = append(artists, artist)
+	}
+
+	return artists, nil
+}
+
 func (repo *artistRepository) FindByArtistIds(ctx context.Context, inClause string, artistIds []any) (artists []models.Artist, err error) {
 	query := fmt.Sprintf(`SELECT id, name, slug, image FROM artists WHERE id IN %s`, inClause)
 
@@ -107,10 +146,11 @@ func (repo *artistRepository) FindExistsArtistById(ctx context.Context, id int)
 	return
 }
 
-func (repo *artistRepository) FindCount(ctx context.Context) (total int, err error) {
-	query := `SELECT COUNT(*) FROM artists`
+func (repo *artistRepository) FindCount(ctx context.Context, spec query.Spec) (total int, err error) {
+	where, _, args := spec.Build(ArtistQueryColumns, 1, "ar.id DESC")
+	query := `SELECT COUNT(*) FROM artists ar ` + whereClause(where)
 
-	if err := repo.db.QueryRowContext(ctx, query).Scan(&total); err != nil {
+	if err := repo.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
 		utils.LogError(repo.log, ctx, "artist_repo", "Count", err)
 		return 0, err
 	}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
 	"github.com/wahyusahajaa/mulo-api-go/app/database"
@@ -15,6 +15,9 @@ import (
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
+// uniqueViolation is the Postgres error code of a violated unique constraint.
+const uniqueViolation = "23505"
+
 type authRepository struct {
 	db  *sql.DB
 	log *logrus.Logger
@@ -28,45 +31,32 @@ func NewAuthRepository(db *database.DB, log *logrus.Logger) contracts.AuthReposi
 }
 
 func (repo *authRepository) Store(ctx context.Context, input models.RegisterInput) (err error) {
-	tx, err := repo.db.Begin()
-	if err != nil {
-		utils.LogError(repo.log, ctx, "auth_repo", "Store", err)
-		return err
-	}
-
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			err = tx.Commit()
+	return database.WithinTx(ctx, repo.db, func(ctx context.Context) (err error) {
+		tx := database.Conn(ctx, repo.db)
+
+		var userId int
+		userQuery := `INSERT INTO users(full_name, username, email, password, role) VALUES($1, $2, $3, $4, $5) RETURNING id`
+		userArgs := []any{input.Fullname, input.Username, input.Email, input.Password, "member"}
+		if err = tx.QueryRowContext(ctx, userQuery, userArgs...).Scan(&userId); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "Store", err)
+			return err
 		}
-	}()
 
-	var userId int
-	userQuery := `INSERT INTO users(full_name, username, email, password, role) VALUES($1, $2, $3, $4, $5) RETURNING id`
-	userArgs := []any{input.Fullname, input.Username, input.Email, input.Password, "member"}
-	if err = tx.QueryRowContext(ctx, userQuery, userArgs...).Scan(&userId); err != nil {
-		utils.LogError(repo.log, ctx, "auth_repo", "Store", err)
-		return err
-	}
-
-	userVerifiedQuery := `INSERT INTO user_verified(user_id, code) VALUES($1, $2);`
-	verifyArgs := []any{userId, input.Code}
-	if _, err = tx.ExecContext(ctx, userVerifiedQuery, verifyArgs...); err != nil {
-		utils.LogError(repo.log, ctx, "auth_repo", "Store", err)
-		return err
-	}
+		userVerifiedQuery := `INSERT INTO user_verified(user_id, code) VALUES($1, $2);`
+		verifyArgs := []any{userId, input.Code}
+		if _, err = tx.ExecContext(ctx, userVerifiedQuery, verifyArgs...); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "Store", err)
+			return err
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (repo *authRepository) StoreUserVerifyCode(ctx context.Context, userId int, code string) (err error) {
 	query := `INSERT INTO user_verified(user_id, code) VALUES($1, $2);`
 
-	if _, err = repo.db.ExecContext(ctx, query, userId, code); err != nil {
+	if _, err = database.Conn(ctx, repo.db).ExecContext(ctx, query, userId, code); err != nil {
 		utils.LogError(repo.log, ctx, "auth_repo", "StoreUserVerifyCode", err)
 		return
 	}
@@ -85,61 +75,175 @@ func (repo *authRepository) UpdateUserVerifiedAt(ctx context.Context, userId int
 	return
 }
 
-func (repo *authRepository) IsRefreshTokenValid(ctx context.Context, userID int, token string) (valid bool, err error) {
-	var revoked bool
-	var expiredAt time.Time
-	query := `SELECT revoked, expires_at FROM refresh_tokens WHERE user_id = $1 AND token = $2`
+func (repo *authRepository) FindRefreshTokenSession(ctx context.Context, userID int, tokenHash string) (sessionID int, err error) {
+	query := `SELECT session_id FROM refresh_tokens WHERE user_id = $1 AND token_hash = $2 AND revoked = FALSE AND expires_at > NOW()`
 
-	if err = repo.db.QueryRowContext(ctx, query, userID, token).Scan(&revoked, &expiredAt); err != nil {
+	if err = repo.db.QueryRowContext(ctx, query, userID, tokenHash).Scan(&sessionID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			nfErr := errs.NewNotFoundErrorWithMsg(fmt.Sprintf(`refresh_tokens with user_id: %d and token: %s does not exists.`, userID, token))
-			utils.LogWarn(repo.log, ctx, "auth_repo", "FindExistsRefreshToken", nfErr)
-			return false, nil
+			return 0, nil
 		}
 
-		utils.LogWarn(repo.log, ctx, "auth_repo", "FindExistsRefreshToken", err)
-		return false, err
+		utils.LogError(repo.log, ctx, "auth_repo", "FindRefreshTokenSession", err)
+		return 0, err
 	}
 
-	// If has revoked or refresh token has expired set valid to false
-	if revoked || expiredAt.Before(time.Now()) {
-		return false, nil
+	return sessionID, nil
+}
+
+func (repo *authRepository) FindRefreshToken(ctx context.Context, tokenHash string) (token *models.RefreshToken, err error) {
+	query := `SELECT user_id, session_id, revoked, expires_at FROM refresh_tokens WHERE token_hash = $1`
+
+	token = &models.RefreshToken{}
+	if err = repo.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.UserID,
+		&token.SessionID,
+		&token.Revoked,
+		&token.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		utils.LogError(repo.log, ctx, "auth_repo", "FindRefreshToken", err)
+		return nil, err
 	}
 
-	return true, nil
+	return token, nil
 }
 
-func (repo *authRepository) StoreRefreshToken(ctx context.Context, userID int, token string, expiredAt time.Time) (err error) {
-	query := ` INSERT INTO refresh_tokens (user_id, token, expires_at) VALUES ($1, $2, $3)`
+func (repo *authRepository) StoreSession(ctx context.Context, input models.SessionInput) (sessionID int, err error) {
+	err = database.WithinTx(ctx, repo.db, func(ctx context.Context) (err error) {
+		tx := database.Conn(ctx, repo.db)
+
+		// Sessions whose tokens all expired are gone for good, drop them on the next sign in
+		deleteQuery := `
+			DELETE FROM sessions s
+			WHERE s.user_id = $1 AND NOT EXISTS (
+				SELECT 1 FROM refresh_tokens rt WHERE rt.session_id = s.id AND rt.revoked = FALSE AND rt.expires_at > NOW()
+			)`
+		if _, err = tx.ExecContext(ctx, deleteQuery, input.UserID); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "StoreSession", err)
+			return err
+		}
 
-	if _, err = repo.db.ExecContext(ctx, query, userID, token, expiredAt); err != nil {
-		utils.LogError(repo.log, ctx, "auth_repo", "FindExistsRefreshToken", err)
-		return
-	}
+		insertQuery := `INSERT INTO sessions(user_id, user_agent, device, ip) VALUES($1, $2, $3, $4) RETURNING id`
+		if err = tx.QueryRowContext(ctx, insertQuery, input.UserID, input.UserAgent, input.Device, input.IP).Scan(&sessionID); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "StoreSession", err)
+			return err
+		}
 
-	return
+		return nil
+	})
+
+	return sessionID, err
 }
 
-func (repo *authRepository) UpdateRefreshToken(ctx context.Context, userID int, token string) (err error) {
-	query := `UPDATE refresh_tokens SET revoked = TRUE, revoked_at = NOW() WHERE user_id = $1 AND token = $2 AND revoked = FALSE`
+func (repo *authRepository) StoreRefreshToken(ctx context.Context, userID, sessionID int, tokenHash string, expiredAt time.Time) (err error) {
+	return database.WithinTx(ctx, repo.db, func(ctx context.Context) (err error) {
+		tx := database.Conn(ctx, repo.db)
 
-	if _, err = repo.db.ExecContext(ctx, query, userID, token); err != nil {
-		utils.LogError(repo.log, ctx, "auth_repo", "UpdateRefreshToken", err)
-		return
+		insertQuery := `INSERT INTO refresh_tokens (user_id, session_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`
+		if _, err = tx.ExecContext(ctx, insertQuery, userID, sessionID, tokenHash, expiredAt); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "StoreRefreshToken", err)
+			return err
+		}
+
+		updateQuery := `UPDATE sessions SET last_used_at = NOW() WHERE id = $1`
+		if _, err = tx.ExecContext(ctx, updateQuery, sessionID); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "StoreRefreshToken", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (repo *authRepository) RevokeRefreshToken(ctx context.Context, tokenHash string) (revoked bool, err error) {
+	query := `UPDATE refresh_tokens SET revoked = TRUE, revoked_at = NOW() WHERE token_hash = $1 AND revoked = FALSE`
+
+	result, err := database.Conn(ctx, repo.db).ExecContext(ctx, query, tokenHash)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "auth_repo", "RevokeRefreshToken", err)
+		return false, err
 	}
 
-	return
+	affected, err := result.RowsAffected()
+	if err != nil {
+		utils.LogError(repo.log, ctx, "auth_repo", "RevokeRefreshToken", err)
+		return false, err
+	}
+
+	return affected > 0, nil
 }
 
-func (repo *authRepository) DeleteRefreshToken(ctx context.Context, token string) (err error) {
-	query := `DELETE FROM refresh_tokens WHERE token = $1`
-	if _, err = repo.db.ExecContext(ctx, query, token); err != nil {
-		utils.LogError(repo.log, ctx, "auth_repo", "DeleteRefreshToken", err)
+func (repo *authRepository) DeleteSessionByRefreshToken(ctx context.Context, tokenHash string) (err error) {
+	query := `DELETE FROM sessions WHERE id = (SELECT session_id FROM refresh_tokens WHERE token_hash = $1)`
+	if _, err = repo.db.ExecContext(ctx, query, tokenHash); err != nil {
+		utils.LogError(repo.log, ctx, "auth_repo", "DeleteSessionByRefreshToken", err)
 		return
 	}
 	return
 }
 
+func (repo *authRepository) FindSessionsByUserID(ctx context.Context, userID int) (sessions []models.Session, err error) {
+	query := `
+		SELECT s.id, s.user_agent, s.device, s.ip, s.created_at, s.last_used_at
+		FROM sessions s
+		WHERE s.user_id = $1 AND EXISTS (
+			SELECT 1 FROM refresh_tokens rt WHERE rt.session_id = s.id AND rt.revoked = FALSE AND rt.expires_at > NOW()
+		)
+		ORDER BY s.last_used_at DESC, s.id DESC`
+
+	rows, err := repo.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "auth_repo", "FindSessionsByUserID", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(
+			&session.Id,
+			&session.UserAgent,
+			&session.Device,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+		); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "FindSessionsByUserID", err)
+			return nil, err
+		}
+		session.UserID = userID
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		utils.LogError(repo.log, ctx, "auth_repo", "FindSessionsByUserID", err)
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (repo *authRepository) DeleteSession(ctx context.Context, userID, sessionID int) (deleted bool, err error) {
+	query := `DELETE FROM sessions WHERE id = $1 AND user_id = $2`
+
+	result, err := repo.db.ExecContext(ctx, query, sessionID, userID)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "auth_repo", "DeleteSession", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		utils.LogError(repo.log, ctx, "auth_repo", "DeleteSession", err)
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
 func (repo *authRepository) StoreUserWithOAuthAccount(ctx context.Context, input models.OAuthAccountInput) (userID int, err error) {
 	tx, err := repo.db.Begin()
 	if err != nil {
@@ -159,7 +263,9 @@ func (repo *authRepository) StoreUserWithOAuthAccount(ctx context.Context, input
 	}()
 
 	userQuery := `INSERT INTO users(full_name, username, email, image, role, email_verified_at) VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
-	userArgs := []any{input.Fullname, input.Username, input.Email, input.Image, "member", time.Now()}
+	// Without a username the column is NULL, empty strings would collide on its unique index
+	username := sql.NullString{String: input.Username, Valid: input.Username != ""}
+	userArgs := []any{input.Fullname, username, input.Email, input.Image, "member", time.Now()}
 	if err = tx.QueryRowContext(ctx, userQuery, userArgs...).Scan(&userID); err != nil {
 		utils.LogError(repo.log, ctx, "auth_repo", "StoreOAuthAccounts", err)
 		return 0, err
@@ -240,3 +346,195 @@ func (repo *authRepository) FindExistsOauthAccount(ctx context.Context, userID i
 
 	return
 }
+
+func (repo *authRepository) StoreOAuthState(ctx context.Context, stateHash string, state models.OAuthState) (err error) {
+	return database.WithinTx(ctx, repo.db, func(ctx context.Context) (err error) {
+		tx := database.Conn(ctx, repo.db)
+
+		// Abandoned sign ins are dropped when the next one starts
+		deleteQuery := `DELETE FROM oauth_states WHERE expires_at <= NOW()`
+		if _, err = tx.ExecContext(ctx, deleteQuery); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "StoreOAuthState", err)
+			return err
+		}
+
+		insertQuery := `INSERT INTO oauth_states(state_hash, provider, code_verifier, expires_at) VALUES($1, $2, $3, $4)`
+		if _, err = tx.ExecContext(ctx, insertQuery, stateHash, state.Provider, state.CodeVerifier, state.ExpiresAt); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "StoreOAuthState", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (repo *authRepository) ConsumeOAuthState(ctx context.Context, stateHash string) (state *models.OAuthState, err error) {
+	// Deleting in the same statement lets a state complete a single callback
+	query := `DELETE FROM oauth_states WHERE state_hash = $1 RETURNING provider, code_verifier, expires_at`
+
+	state = &models.OAuthState{}
+	if err = database.Conn(ctx, repo.db).QueryRowContext(ctx, query, stateHash).Scan(&state.Provider, &state.CodeVerifier, &state.ExpiresAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		utils.LogError(repo.log, ctx, "auth_repo", "ConsumeOAuthState", err)
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func (repo *authRepository) StorePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (err error) {
+	return database.WithinTx(ctx, repo.db, func(ctx context.Context) (err error) {
+		tx := database.Conn(ctx, repo.db)
+
+		// Only the latest link works, asking again invalidates the one sent before
+		deleteQuery := `DELETE FROM password_resets WHERE user_id = $1 AND used_at IS NULL`
+		if _, err = tx.ExecContext(ctx, deleteQuery, userID); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "StorePasswordReset", err)
+			return err
+		}
+
+		insertQuery := `INSERT INTO password_resets(user_id, token_hash, expires_at) VALUES($1, $2, $3)`
+		if _, err = tx.ExecContext(ctx, insertQuery, userID, tokenHash, expiresAt); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "StorePasswordReset", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (repo *authRepository) ConsumePasswordReset(ctx context.Context, tokenHash string) (userID int, err error) {
+	// A single statement so two requests racing with the same token cannot both use it
+	query := `UPDATE password_resets SET used_at = NOW() WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW() RETURNING user_id`
+
+	if err = database.Conn(ctx, repo.db).QueryRowContext(ctx, query, tokenHash).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+
+		utils.LogError(repo.log, ctx, "auth_repo", "ConsumePasswordReset", err)
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+func (repo *authRepository) UpdateUserPassword(ctx context.Context, userID int, password string) (err error) {
+	query := `UPDATE users SET password = $1 WHERE id = $2`
+
+	if _, err = database.Conn(ctx, repo.db).ExecContext(ctx, query, password, userID); err != nil {
+		utils.LogError(repo.log, ctx, "auth_repo", "UpdateUserPassword", err)
+		return
+	}
+
+	return
+}
+
+func (repo *authRepository) DeleteUserSessions(ctx context.Context, userID int, exceptTokenHash string) (err error) {
+	query := `
+		DELETE FROM sessions
+		WHERE user_id = $1 AND id NOT IN (SELECT session_id FROM refresh_tokens WHERE token_hash = $2)`
+
+	if _, err = database.Conn(ctx, repo.db).ExecContext(ctx, query, userID, exceptTokenHash); err != nil {
+		utils.LogError(repo.log, ctx, "auth_repo", "DeleteUserSessions", err)
+		return
+	}
+
+	return
+}
+
+func (repo *authRepository) StoreEmailChange(ctx context.Context, userID int, email, codeHash string) (err error) {
+	return database.WithinTx(ctx, repo.db, func(ctx context.Context) (err error) {
+		tx := database.Conn(ctx, repo.db)
+
+		deleteQuery := `DELETE FROM user_verified WHERE user_id = $1 AND email IS NOT NULL`
+		if _, err = tx.ExecContext(ctx, deleteQuery, userID); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "StoreEmailChange", err)
+			return err
+		}
+
+		insertQuery := `INSERT INTO user_verified(user_id, code, email) VALUES($1, $2, $3)`
+		if _, err = tx.ExecContext(ctx, insertQuery, userID, codeHash, email); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "StoreEmailChange", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (repo *authRepository) FindEmailChange(ctx context.Context, userID int) (change *models.EmailChange, err error) {
+	query := `SELECT email, code, attempts, expired_at FROM user_verified WHERE user_id = $1 AND email IS NOT NULL`
+
+	change = &models.EmailChange{}
+	if err = database.Conn(ctx, repo.db).QueryRowContext(ctx, query, userID).Scan(&change.Email, &change.CodeHash, &change.Attempts, &change.ExpiredAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		utils.LogError(repo.log, ctx, "auth_repo", "FindEmailChange", err)
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func (repo *authRepository) FailEmailChange(ctx context.Context, userID, maxAttempts int) (err error) {
+	return database.WithinTx(ctx, repo.db, func(ctx context.Context) (err error) {
+		tx := database.Conn(ctx, repo.db)
+
+		// The update locks the row, wrong codes sent at once are all counted
+		var attempts int
+		updateQuery := `UPDATE user_verified SET attempts = attempts + 1 WHERE user_id = $1 AND email IS NOT NULL RETURNING attempts`
+		if err = tx.QueryRowContext(ctx, updateQuery, userID).Scan(&attempts); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+
+			utils.LogError(repo.log, ctx, "auth_repo", "FailEmailChange", err)
+			return err
+		}
+		if attempts < maxAttempts {
+			return nil
+		}
+
+		deleteQuery := `DELETE FROM user_verified WHERE user_id = $1 AND email IS NOT NULL`
+		if _, err = tx.ExecContext(ctx, deleteQuery, userID); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "FailEmailChange", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (repo *authRepository) UpdateUserEmail(ctx context.Context, userID int, email string) (err error) {
+	return database.WithinTx(ctx, repo.db, func(ctx context.Context) (err error) {
+		tx := database.Conn(ctx, repo.db)
+
+		updateQuery := `UPDATE users SET email = $1, email_verified_at = NOW() WHERE id = $2`
+		if _, err = tx.ExecContext(ctx, updateQuery, email, userID); err != nil {
+			// Another account took the address after the service checked it
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+				conflictErr := errs.NewConflictError("User", "email", email, err)
+				utils.LogWarn(repo.log, ctx, "auth_repo", "UpdateUserEmail", conflictErr)
+				return conflictErr
+			}
+
+			utils.LogError(repo.log, ctx, "auth_repo", "UpdateUserEmail", err)
+			return err
+		}
+
+		deleteQuery := `DELETE FROM user_verified WHERE user_id = $1 AND email IS NOT NULL`
+		if _, err = tx.ExecContext(ctx, deleteQuery, userID); err != nil {
+			utils.LogError(repo.log, ctx, "auth_repo", "UpdateUserEmail", err)
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,288 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/database"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+// chartPlaysColumns maps a chart window to its play count column in chart_song_plays.
+var chartPlaysColumns = map[string]string{
+	models.ChartWindowDay:   "cp.plays_day",
+	models.ChartWindowWeek:  "cp.plays_week",
+	models.ChartWindowMonth: "cp.plays_month",
+	models.ChartWindowAll:   "cp.plays_all",
+}
+
+type chartRepository struct {
+	db  *sql.DB
+	log *logrus.Logger
+}
+
+func NewChartRepository(db *database.DB, log *logrus.Logger) contracts.ChartRepository {
+	return &chartRepository{
+		db:  db.DB,
+		log: log,
+	}
+}
+
+// chartConditions builds the play count column and where clause shared by the chart queries.
+// Genre filters songs through song_genres, artists and albums through artist_genres.
+func chartConditions(filter models.ChartFilter, genreCondition string, argIndex int) (plays, where string, args []any) {
+	plays, ok := chartPlaysColumns[filter.Window]
+	if !ok {
+		plays = chartPlaysColumns[models.ChartWindowWeek]
+	}
+
+	where = fmt.Sprintf("WHERE %s > 0", plays)
+	if filter.GenreId > 0 {
+		where += " AND " + fmt.Sprintf(genreCondition, argIndex)
+		args = append(args, filter.GenreId)
+	}
+
+	return plays, where, args
+}
+
+const (
+	chartSongGenreCondition   = "EXISTS (SELECT 1 FROM song_genres g WHERE g.song_id = s.id AND g.genre_id = $%d)"
+	chartArtistGenreCondition = "EXISTS (SELECT 1 FROM artist_genres g WHERE g.artist_id = al.artist_id AND g.genre_id = $%d)"
+)
+
+func (repo *chartRepository) FindSongs(ctx context.Context, filter models.ChartFilter, pageSize, offset int) (songs []models.ChartSong, err error) {
+	plays, where, genreArgs := chartConditions(filter, chartSongGenreCondition, 3)
+	query := fmt.Sprintf(`
+		SELECT
+			RANK() OVER (ORDER BY %[1]s DESC) AS rank,
+			%[1]s AS play_count,
+			s.id,
+			s.title,
+			s.audio,
+			s.duration,
+			s.processing_state,
+			s.image,
+			al.id AS album_id,
+			al.name AS album_name,
+			al.slug AS album_slug,
+			al.image AS album_image,
+			ar.id AS artist_id,
+			ar.name AS artist_name,
+			ar.slug AS artist_slug,
+			ar.image AS artist_image
+		FROM chart_song_plays cp
+		INNER JOIN songs s ON s.id = cp.song_id
+		INNER JOIN albums al ON al.id = s.album_id
+		INNER JOIN artists ar ON ar.id = al.artist_id
+		%[2]s
+		ORDER BY rank, s.id
+		LIMIT $1 OFFSET $2
+	`, plays, where)
+	args := append([]any{pageSize, offset}, genreArgs...)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "chart_repo", "FindSongs", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var song models.ChartSong
+		if err := rows.Scan(
+			&song.Rank,
+			&song.PlayCount,
+			&song.Song.Id,
+			&song.Song.Title,
+			&song.Song.Audio,
+			&song.Song.Duration,
+			&song.Song.ProcessingState,
+			&song.Song.Image,
+			&song.Song.Album.Id,
+			&song.Song.Album.Name,
+			&song.Song.Album.Slug,
+			&song.Song.Album.Image,
+			&song.Song.Album.Artist.Id,
+			&song.Song.Album.Artist.Name,
+			&song.Song.Album.Artist.Slug,
+			&song.Song.Album.Artist.Image,
+		); err != nil {
+			utils.LogError(repo.log, ctx, "chart_repo", "FindSongs", err)
+			return nil, err
+		}
+		song.Song.AlbumId = song.Song.Album.Id
+
+		songs = append(songs, song)
+	}
+
+	return songs, nil
+}
+
+func (repo *chartRepository) FindCountSongs(ctx context.Context, filter models.ChartFilter) (total int, err error) {
+	_, where, args := chartConditions(filter, chartSongGenreCondition, 1)
+	query := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM chart_song_plays cp
+		INNER JOIN songs s ON s.id = cp.song_id
+		%s
+	`, where)
+
+	if err = repo.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		utils.LogError(repo.log, ctx, "chart_repo", "FindCountSongs", err)
+		return
+	}
+	return
+}
+
+func (repo *chartRepository) FindArtists(ctx context.Context, filter models.ChartFilter, pageSize, offset int) (artists []models.ChartArtist, err error) {
+	plays, where, genreArgs := chartConditions(filter, chartArtistGenreCondition, 3)
+	query := fmt.Sprintf(`
+		SELECT
+			RANK() OVER (ORDER BY SUM(%[1]s) DESC) AS rank,
+			SUM(%[1]s) AS play_count,
+			ar.id,
+			ar.name,
+			ar.slug,
+			ar.image
+		FROM chart_song_plays cp
+		INNER JOIN songs s ON s.id = cp.song_id
+		INNER JOIN albums al ON al.id = s.album_id
+		INNER JOIN artists ar ON ar.id = al.artist_id
+		%[2]s
+		GROUP BY ar.id
+		ORDER BY rank, ar.id
+		LIMIT $1 OFFSET $2
+	`, plays, where)
+	args := append([]any{pageSize, offset}, genreArgs...)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "chart_repo", "FindArtists", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var artist models.ChartArtist
+		if err := rows.Scan(
+			&artist.Rank,
+			&artist.PlayCount,
+			&artist.Artist.Id,
+			&artist.Artist.Name,
+			&artist.Artist.Slug,
+			&artist.Artist.Image,
+		); err != nil {
+			utils.LogError(repo.log, ctx, "chart_repo", "FindArtists", err)
+			return nil, err
+		}
+
+		artists = append(artists, artist)
+	}
+
+	return artists, nil
+}
+
+func (repo *chartRepository) FindCountArtists(ctx context.Context, filter models.ChartFilter) (total int, err error) {
+	_, where, args := chartConditions(filter, chartArtistGenreCondition, 1)
+	query := fmt.Sprintf(`
+		SELECT COUNT(DISTINCT al.artist_id)
+		FROM chart_song_plays cp
+		INNER JOIN songs s ON s.id = cp.song_id
+		INNER JOIN albums al ON al.id = s.album_id
+		%s
+	`, where)
+
+	if err = repo.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		utils.LogError(repo.log, ctx, "chart_repo", "FindCountArtists", err)
+		return
+	}
+	return
+}
+
+func (repo *chartRepository) FindAlbums(ctx context.Context, filter models.ChartFilter, pageSize, offset int) (albums []models.ChartAlbum, err error) {
+	plays, where, genreArgs := chartConditions(filter, chartArtistGenreCondition, 3)
+	query := fmt.Sprintf(`
+		SELECT
+			RANK() OVER (ORDER BY SUM(%[1]s) DESC) AS rank,
+			SUM(%[1]s) AS play_count,
+			al.id,
+			al.name,
+			al.slug,
+			al.image,
+			ar.id AS artist_id,
+			ar.name AS artist_name,
+			ar.slug AS artist_slug,
+			ar.image AS artist_image
+		FROM chart_song_plays cp
+		INNER JOIN songs s ON s.id = cp.song_id
+		INNER JOIN albums al ON al.id = s.album_id
+		INNER JOIN artists ar ON ar.id = al.artist_id
+		%[2]s
+		GROUP BY al.id, ar.id
+		ORDER BY rank, al.id
+		LIMIT $1 OFFSET $2
+	`, plays, where)
+	args := append([]any{pageSize, offset}, genreArgs...)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "chart_repo", "FindAlbums", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var album models.ChartAlbum
+		if err := rows.Scan(
+			&album.Rank,
+			&album.PlayCount,
+			&album.Album.Id,
+			&album.Album.Name,
+			&album.Album.Slug,
+			&album.Album.Image,
+			&album.Album.Artist.Id,
+			&album.Album.Artist.Name,
+			&album.Album.Artist.Slug,
+			&album.Album.Artist.Image,
+		); err != nil {
+			utils.LogError(repo.log, ctx, "chart_repo", "FindAlbums", err)
+			return nil, err
+		}
+		album.Album.ArtistId = album.Album.Artist.Id
+
+		albums = append(albums, album)
+	}
+
+	return albums, nil
+}
+
+func (repo *chartRepository) FindCountAlbums(ctx context.Context, filter models.ChartFilter) (total int, err error) {
+	_, where, args := chartConditions(filter, chartArtistGenreCondition, 1)
+	query := fmt.Sprintf(`
+		SELECT COUNT(DISTINCT s.album_id)
+		FROM chart_song_plays cp
+		INNER JOIN songs s ON s.id = cp.song_id
+		INNER JOIN albums al ON al.id = s.album_id
+		%s
+	`, where)
+
+	if err = repo.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		utils.LogError(repo.log, ctx, "chart_repo", "FindCountAlbums", err)
+		return
+	}
+	return
+}
+
+func (repo *chartRepository) Refresh(ctx context.Context) (err error) {
+	// CONCURRENTLY keeps the view readable while it is recomputed, relies on the unique song_id index
+	query := `REFRESH MATERIALIZED VIEW CONCURRENTLY chart_song_plays`
+	if _, err = repo.db.ExecContext(ctx, query); err != nil {
+		utils.LogError(repo.log, ctx, "chart_repo", "Refresh", err)
+		return
+	}
+	return
+}
@@ -31,6 +31,7 @@ func (repo *favoriteRepository) FindFavoriteSongsByUserID(ctx context.Context, u
 			s.title AS song_title,
 			s.audio AS song_audio,
 			s.duration AS song_duration,
+			s.processing_state AS song_processing_state,
 			s.image AS song_image,
 			al.id AS album_id,
 			al.artist_id AS album_artist_id,
@@ -68,6 +69,7 @@ func (repo *favoriteRepository) FindFavoriteSongsByUserID(ctx context.Context, u
 			&song.Title,
 			&song.Audio,
 			&song.Duration,
+			&song.ProcessingState,
 			&song.Image,
 			&song.Album.Id,
 			&song.Album.ArtistId,
@@ -89,6 +91,80 @@ func (repo *favoriteRepository) FindFavoriteSongsByUserID(ctx context.Context, u
 	return songs, nil
 }
 
+func (repo *favoriteRepository) FindFavoriteSongsByCursor(ctx context.Context, userId int, cursor utils.Cursor, limit int) (favorites []models.FavoriteSong, err error) {
+	query := `
+		SELECT
+			sf.created_at,
+			s.id AS song_id,
+			s.album_id AS song_album_id,
+			s.title AS song_title,
+			s.audio AS song_audio,
+			s.duration AS song_duration,
+			s.processing_state AS song_processing_state,
+			s.image AS song_image,
+			al.id AS album_id,
+			al.artist_id AS album_artist_id,
+			al.name AS album_name,
+			al.slug AS album_slug,
+			al.image AS album_image,
+			ar.id AS artist_id,
+			ar.name AS artist_name,
+			ar.slug AS artist_slug,
+			ar.image AS artist_image
+		FROM song_favorites sf
+		INNER JOIN songs s on s.id = sf.song_id
+		INNER JOIN albums al on al.id = s.album_id
+		INNER JOIN artists ar on ar.id = al.artist_id
+		WHERE
+			sf.user_id = $1
+	`
+	args := []any{userId, limit}
+
+	if cursor.CreatedAt != nil {
+		query += ` AND (sf.created_at, sf.song_id) < ($3, $4)`
+		args = append(args, *cursor.CreatedAt, cursor.Id)
+	}
+	query += ` ORDER BY sf.created_at DESC, sf.song_id DESC LIMIT $2`
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "favorite_repo", "FindFavoriteSongsByCursor", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var favorite models.FavoriteSong
+		if err = rows.Scan(
+			&favorite.FavoritedAt,
+			&favorite.Id,
+			&favorite.AlbumId,
+			&favorite.Title,
+			&favorite.Audio,
+			&favorite.Duration,
+			&favorite.ProcessingState,
+			&favorite.Image,
+			&favorite.Album.Id,
+			&favorite.Album.ArtistId,
+			&favorite.Album.Name,
+			&favorite.Album.Slug,
+			&favorite.Album.Image,
+			&favorite.Album.Artist.Id,
+			&favorite.Album.Artist.Name,
+			&favorite.Album.Artist.Slug,
+			&favorite.Album.Artist.Image,
+		); err != nil {
+			utils.LogError(repo.log, ctx, "favorite_repo", "FindFavoriteSongsByCursor", err)
+			return nil, err
+		}
+
+		favorites = append(favorites, favorite)
+	}
+
+	return favorites, nil
+}
+
 func (repo *favoriteRepository) FindCountFavoriteSongsByUserID(ctx context.Context, userId int) (total int, err error) {
 	query := `SELECT COUNT(*) FROM song_favorites WHERE user_id = $1`
 	if err = repo.db.QueryRowContext(ctx, query, userId).Scan(&total); err != nil {
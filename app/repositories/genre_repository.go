@@ -50,6 +50,31 @@ func (repo *genreRepository) FindAll(ctx context.Context, pageSize, offset int)
 	return genres, nil
 }
 
+func (repo *genreRepository) FindAllByCursor(ctx context.Context, cursor utils.Cursor, limit int) (genres []models.Genre, err error) {
+	query := `SELECT id, name, image FROM genres WHERE ($1 = 0 OR id < $1) ORDER BY id DESC LIMIT $2`
+	args := []any{cursor.Id, limit}
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "genre_repo", "FindAllByCursor", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		genre := models.Genre{}
+		if err := rows.Scan(&genre.Id, &genre.Name, &genre.Image); err != nil {
+			utils.LogError(repo.log, ctx, "genre_repo", "FindAllByCursor", err)
+			return nil, err
+		}
+
+		genres = append(genres, genre)
+	}
+
+	return genres, nil
+}
+
 func (repo *genreRepository) FindCount(ctx context.Context) (total int, err error) {
 	query := `SELECT COUNT(*) FROM genres`
 
@@ -290,6 +315,7 @@ func (repo *genreRepository) FindAllSongs(ctx context.Context, genreId int, page
 			s.title,
 			s.audio,
 			s.duration,
+			s.processing_state,
 			s.image,
 			al.id as album_id ,
 			al."name" as album_name,
@@ -323,6 +349,7 @@ func (repo *genreRepository) FindAllSongs(ctx context.Context, genreId int, page
 			&song.Title,
 			&song.Audio,
 			&song.Duration,
+			&song.ProcessingState,
 			&song.Image,
 			&song.Album.Id,
 			&song.Album.Name,
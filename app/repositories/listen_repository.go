@@ -0,0 +1,268 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/database"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type listenRepository struct {
+	db  *sql.DB
+	log *logrus.Logger
+}
+
+func NewListenRepository(db *database.DB, log *logrus.Logger) contracts.ListenRepository {
+	return &listenRepository{
+		db:  db.DB,
+		log: log,
+	}
+}
+
+func (repo *listenRepository) Store(ctx context.Context, input models.CreateListenInput, dedupWindow time.Duration) (recorded bool, err error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "listen_repo", "Store", err)
+		return false, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	// Serialize concurrent events of the same user and song, so the window check below can't race
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, input.UserId, input.SongId); err != nil {
+		utils.LogError(repo.log, ctx, "listen_repo", "Store", err)
+		return false, err
+	}
+
+	query := `
+		INSERT INTO song_listens(user_id, song_id, duration, client)
+		SELECT $1, $2, $3, NULLIF($4, '')
+		WHERE NOT EXISTS (
+			SELECT 1 FROM song_listens
+			WHERE user_id = $1 AND song_id = $2 AND created_at > NOW() - make_interval(secs => $5)
+		)
+	`
+	args := []any{input.UserId, input.SongId, input.Duration, input.Client, dedupWindow.Seconds()}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "listen_repo", "Store", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		utils.LogError(repo.log, ctx, "listen_repo", "Store", err)
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (repo *listenRepository) FindHistoryByUserID(ctx context.Context, userID, pageSize, offset int) (listens []models.Listen, err error) {
+	query := `
+		SELECT
+			sl.id,
+			sl.duration,
+			sl.client,
+			sl.created_at,
+			s.id,
+			s.title,
+			s.audio,
+			s.duration,
+			s.processing_state,
+			s.image,
+			al.id AS album_id,
+			al.name AS album_name,
+			al.slug AS album_slug,
+			al.image AS album_image,
+			ar.id AS artist_id,
+			ar.name AS artist_name,
+			ar.slug AS artist_slug,
+			ar.image AS artist_image
+		FROM song_listens sl
+		INNER JOIN songs s ON s.id = sl.song_id
+		INNER JOIN albums al ON al.id = s.album_id
+		INNER JOIN artists ar ON ar.id = al.artist_id
+		WHERE sl.user_id = $1
+		ORDER BY sl.created_at DESC, sl.id DESC
+		LIMIT $2 OFFSET $3
+	`
+	args := []any{userID, pageSize, offset}
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "listen_repo", "FindHistoryByUserID", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		listen := models.Listen{UserId: userID}
+		if err := rows.Scan(
+			&listen.Id,
+			&listen.Duration,
+			&listen.Client,
+			&listen.CreatedAt,
+			&listen.Song.Id,
+			&listen.Song.Title,
+			&listen.Song.Audio,
+			&listen.Song.Duration,
+			&listen.Song.ProcessingState,
+			&listen.Song.Image,
+			&listen.Song.Album.Id,
+			&listen.Song.Album.Name,
+			&listen.Song.Album.Slug,
+			&listen.Song.Album.Image,
+			&listen.Song.Album.Artist.Id,
+			&listen.Song.Album.Artist.Name,
+			&listen.Song.Album.Artist.Slug,
+			&listen.Song.Album.Artist.Image,
+		); err != nil {
+			utils.LogError(repo.log, ctx, "listen_repo", "FindHistoryByUserID", err)
+			return nil, err
+		}
+		listen.SongId = listen.Song.Id
+
+		listens = append(listens, listen)
+	}
+
+	return listens, nil
+}
+
+func (repo *listenRepository) FindCountHistoryByUserID(ctx context.Context, userID int) (total int, err error) {
+	query := `SELECT COUNT(*) FROM song_listens WHERE user_id = $1`
+	if err = repo.db.QueryRowContext(ctx, query, userID).Scan(&total); err != nil {
+		utils.LogError(repo.log, ctx, "listen_repo", "FindCountHistoryByUserID", err)
+		return
+	}
+	return
+}
+
+func (repo *listenRepository) FindRecentlyPlayedByUserID(ctx context.Context, userID, pageSize, offset int) (listens []models.Listen, err error) {
+	query := `
+		SELECT
+			sl.created_at,
+			s.id,
+			s.title,
+			s.audio,
+			s.duration,
+			s.processing_state,
+			s.image,
+			al.id AS album_id,
+			al.name AS album_name,
+			al.slug AS album_slug,
+			al.image AS album_image,
+			ar.id AS artist_id,
+			ar.name AS artist_name,
+			ar.slug AS artist_slug,
+			ar.image AS artist_image
+		FROM (
+			SELECT song_id, MAX(created_at) AS created_at
+			FROM song_listens
+			WHERE user_id = $1
+			GROUP BY song_id
+		) sl
+		INNER JOIN songs s ON s.id = sl.song_id
+		INNER JOIN albums al ON al.id = s.album_id
+		INNER JOIN artists ar ON ar.id = al.artist_id
+		ORDER BY sl.created_at DESC, s.id DESC
+		LIMIT $2 OFFSET $3
+	`
+	args := []any{userID, pageSize, offset}
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "listen_repo", "FindRecentlyPlayedByUserID", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		listen := models.Listen{UserId: userID}
+		if err := rows.Scan(
+			&listen.CreatedAt,
+			&listen.Song.Id,
+			&listen.Song.Title,
+			&listen.Song.Audio,
+			&listen.Song.Duration,
+			&listen.Song.ProcessingState,
+			&listen.Song.Image,
+			&listen.Song.Album.Id,
+			&listen.Song.Album.Name,
+			&listen.Song.Album.Slug,
+			&listen.Song.Album.Image,
+			&listen.Song.Album.Artist.Id,
+			&listen.Song.Album.Artist.Name,
+			&listen.Song.Album.Artist.Slug,
+			&listen.Song.Album.Artist.Image,
+		); err != nil {
+			utils.LogError(repo.log, ctx, "listen_repo", "FindRecentlyPlayedByUserID", err)
+			return nil, err
+		}
+		listen.SongId = listen.Song.Id
+
+		listens = append(listens, listen)
+	}
+
+	return listens, nil
+}
+
+func (repo *listenRepository) FindCountRecentlyPlayedByUserID(ctx context.Context, userID int) (total int, err error) {
+	query := `SELECT COUNT(DISTINCT song_id) FROM song_listens WHERE user_id = $1`
+	if err = repo.db.QueryRowContext(ctx, query, userID).Scan(&total); err != nil {
+		utils.LogError(repo.log, ctx, "listen_repo", "FindCountRecentlyPlayedByUserID", err)
+		return
+	}
+	return
+}
+
+func (repo *listenRepository) DeleteHistory(ctx context.Context, userID int, input models.ClearHistoryInput) (deleted int, err error) {
+	conditions := []string{"user_id = $1"}
+	args := []any{userID}
+
+	if input.SongId > 0 {
+		args = append(args, input.SongId)
+		conditions = append(conditions, fmt.Sprintf("song_id = $%d", len(args)))
+	}
+	if input.From != nil {
+		args = append(args, *input.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if input.To != nil {
+		args = append(args, *input.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	query := `DELETE FROM song_listens WHERE ` + strings.Join(conditions, " AND ")
+
+	result, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "listen_repo", "DeleteHistory", err)
+		return 0, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		utils.LogError(repo.log, ctx, "listen_repo", "DeleteHistory", err)
+		return 0, err
+	}
+
+	return int(affected), nil
+}
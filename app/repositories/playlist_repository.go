@@ -59,6 +59,37 @@ func (repo *playlistRepository) FindAll(ctx context.Context, role string, userId
 	return playlists, nil
 }
 
+func (repo *playlistRepository) FindAllByCursor(ctx context.Context, role string, userId int, cursor utils.Cursor, limit int) (playlists []models.Playlist, err error) {
+	query := `SELECT id, name FROM playlists WHERE ($1 = 0 OR id < $1)`
+	args := []any{cursor.Id, limit}
+
+	if role == "member" {
+		query += ` AND user_id = $3`
+		args = append(args, userId)
+	}
+	query += ` ORDER BY id DESC LIMIT $2`
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "playlist_repo", "FindAllByCursor", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		playlist := models.Playlist{}
+		if err := rows.Scan(&playlist.Id, &playlist.Name); err != nil {
+			utils.LogError(repo.log, ctx, "playlist_repo", "FindAllByCursor", err)
+			return nil, err
+		}
+
+		playlists = append(playlists, playlist)
+	}
+
+	return playlists, nil
+}
+
 func (repo *playlistRepository) FindById(ctx context.Context, role string, userId, id int) (playlist *models.Playlist, err error) {
 	query := `SELECT id, name FROM playlists WHERE id = $1`
 	var args []any
@@ -172,6 +203,7 @@ func (repo *playlistRepository) FindPlaylistSongs(ctx context.Context, playlistI
 			s.title,
 			s.audio,
 			s.duration,
+			s.processing_state,
 			s.image,
 			al.id as album_id ,
 			al.name as album_name,
@@ -205,6 +237,7 @@ func (repo *playlistRepository) FindPlaylistSongs(ctx context.Context, playlistI
 			&song.Title,
 			&song.Audio,
 			&song.Duration,
+			&song.ProcessingState,
 			&song.Image,
 			&song.Album.Id,
 			&song.Album.Name,
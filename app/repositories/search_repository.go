@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/database"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type searchRepository struct {
+	db  *sql.DB
+	log *logrus.Logger
+}
+
+func NewSearchRepository(db *database.DB, log *logrus.Logger) contracts.SearchRepository {
+	return &searchRepository{
+		db:  db.DB,
+		log: log,
+	}
+}
+
+// The tsquery is unaccented the same way as the search_vector columns, so accents don't matter on either side.
+
+func (repo *searchRepository) SearchSongs(ctx context.Context, tsQuery string, pageSize, offset int) (songs []models.Song, err error) {
+	query := `
+		SELECT
+			s.id,
+			s.title,
+			s.audio,
+			s.duration,
+			s.processing_state,
+			s.image,
+			al.id AS album_id,
+			al.name AS album_name,
+			al.slug AS album_slug,
+			al.image AS album_image,
+			ar.id AS artist_id,
+			ar.name AS artist_name,
+			ar.slug AS artist_slug,
+			ar.image AS artist_image
+		FROM songs s
+		INNER JOIN albums al ON al.id = s.album_id
+		INNER JOIN artists ar ON ar.id = al.artist_id,
+		to_tsquery('simple', f_unaccent($1)) q
+		WHERE s.search_vector @@ q
+		ORDER BY ts_rank(s.search_vector, q) DESC, s.id DESC
+		LIMIT $2 OFFSET $3
+	`
+	args := []any{tsQuery, pageSize, offset}
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "search_repo", "SearchSongs", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		song := models.Song{}
+		if err := rows.Scan(
+			&song.Id,
+			&song.Title,
+			&song.Audio,
+			&song.Duration,
+			&song.ProcessingState,
+			&song.Image,
+			&song.Album.Id,
+			&song.Album.Name,
+			&song.Album.Slug,
+			&song.Album.Image,
+			&song.Album.Artist.Id,
+			&song.Album.Artist.Name,
+			&song.Album.Artist.Slug,
+			&song.Album.Artist.Image,
+		); err != nil {
+			utils.LogError(repo.log, ctx, "search_repo", "SearchSongs", err)
+			return nil, err
+		}
+		song.AlbumId = song.Album.Id
+
+		songs = append(songs, song)
+	}
+
+	return songs, nil
+}
+
+func (repo *searchRepository) SearchAlbums(ctx context.Context, tsQuery string, pageSize, offset int) (albums []models.AlbumWithArtist, err error) {
+	query := `
+		SELECT
+			al.id,
+			al.name,
+			al.slug,
+			al.image,
+			ar.id AS artist_id,
+			ar.name AS artist_name,
+			ar.slug AS artist_slug,
+			ar.image AS artist_image
+		FROM albums al
+		INNER JOIN artists ar ON ar.id = al.artist_id,
+		to_tsquery('simple', f_unaccent($1)) q
+		WHERE al.search_vector @@ q
+		ORDER BY ts_rank(al.search_vector, q) DESC, al.id DESC
+		LIMIT $2 OFFSET $3
+	`
+	args := []any{tsQuery, pageSize, offset}
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "search_repo", "SearchAlbums", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		album := models.AlbumWithArtist{}
+		if err := rows.Scan(
+			&album.Id,
+			&album.Name,
+			&album.Slug,
+			&album.Image,
+			&album.Artist.Id,
+			&album.Artist.Name,
+			&album.Artist.Slug,
+			&album.Artist.Image,
+		); err != nil {
+			utils.LogError(repo.log, ctx, "search_repo", "SearchAlbums", err)
+			return nil, err
+		}
+		album.ArtistId = album.Artist.Id
+
+		albums = append(albums, album)
+	}
+
+	return albums, nil
+}
+
+func (repo *searchRepository) SearchArtists(ctx context.Context, tsQuery string, pageSize, offset int) (artists []models.Artist, err error) {
+	query := `
+		SELECT ar.id, ar.name, ar.slug, ar.image
+		FROM artists ar, to_tsquery('simple', f_unaccent($1)) q
+		WHERE ar.search_vector @@ q
+		ORDER BY ts_rank(ar.search_vector, q) DESC, ar.id DESC
+		LIMIT $2 OFFSET $3
+	`
+	args := []any{tsQuery, pageSize, offset}
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "search_repo", "SearchArtists", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		artist := models.Artist{}
+		if err := rows.Scan(&artist.Id, &artist.Name, &artist.Slug, &artist.Image); err != nil {
+			utils.LogError(repo.log, ctx, "search_repo", "SearchArtists", err)
+			return nil, err
+		}
+
+		artists = append(artists, artist)
+	}
+
+	return artists, nil
+}
+
+func (repo *searchRepository) SearchGenres(ctx context.Context, tsQuery string, pageSize, offset int) (genres []models.Genre, err error) {
+	query := `
+		SELECT g.id, g.name, g.image
+		FROM genres g, to_tsquery('simple', f_unaccent($1)) q
+		WHERE g.search_vector @@ q
+		ORDER BY ts_rank(g.search_vector, q) DESC, g.id DESC
+		LIMIT $2 OFFSET $3
+	`
+	args := []any{tsQuery, pageSize, offset}
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "search_repo", "SearchGenres", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		genre := models.Genre{}
+		if err := rows.Scan(&genre.Id, &genre.Name, &genre.Image); err != nil {
+			utils.LogError(repo.log, ctx, "search_repo", "SearchGenres", err)
+			return nil, err
+		}
+
+		genres = append(genres, genre)
+	}
+
+	return genres, nil
+}
@@ -4,15 +4,33 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"github.com/sirupsen/logrus"
 	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
 	"github.com/wahyusahajaa/mulo-api-go/app/database"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
+// SongQueryColumns allow-lists sorting and filtering on the songs list, handlers parse list requests with it.
+var SongQueryColumns = query.Columns{
+	Sorts: map[string]string{
+		"title":      "s.title",
+		"duration":   "s.duration",
+		"created_at": "s.created_at",
+	},
+	Filters: map[string]string{
+		"artist_id":    "al.artist_id = %s",
+		"album_id":     "s.album_id = %s",
+		"genre_id":     "EXISTS (SELECT 1 FROM song_genres sg WHERE sg.song_id = s.id AND sg.genre_id = %s)",
+		"duration_min": "s.duration >= %s",
+		"duration_max": "s.duration <= %s",
+	},
+}
+
 type songRepository struct {
 	db  *sql.DB
 	log *logrus.Logger
@@ -25,13 +43,15 @@ func NewSongRepository(db *database.DB, log *logrus.Logger) contracts.SongReposi
 	}
 }
 
-func (repo *songRepository) FindAll(ctx context.Context, pageSize int, offset int) (songs []models.Song, err error) {
-	query := `
+func (repo *songRepository) FindAll(ctx context.Context, spec query.Spec, pageSize int, offset int) (songs []models.Song, err error) {
+	where, orderBy, specArgs := spec.Build(SongQueryColumns, 3, "s.id DESC")
+	query := fmt.Sprintf(`
 		SELECT 
 			s.id,
 			s.title,
 			s.audio,
 			s.duration,
+			s.processing_state,
 			s.image,
 			al.id as album_id ,
 			al."name" as album_name,
@@ -44,10 +64,11 @@ func (repo *songRepository) FindAll(ctx context.Context, pageSize int, offset in
 		FROM songs s  
 		INNER JOIN albums al ON al.id = s.album_id
 		INNER JOIN artists ar ON ar.id = al.artist_id
-		ORDER BY s.id desc 
+		%s
+		ORDER BY %s
 		LIMIT $1 OFFSET $2
-	`
-	args := []any{pageSize, offset}
+	`, whereClause(where), orderBy)
+	args := append([]any{pageSize, offset}, specArgs...)
 
 	rows, err := repo.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -63,6 +84,7 @@ func (repo *songRepository) FindAll(ctx context.Context, pageSize int, offset in
 			&song.Title,
 			&song.Audio,
 			&song.Duration,
+			&song.ProcessingState,
 			&song.Image,
 			&song.Album.Id,
 			&song.Album.Name,
@@ -83,9 +105,72 @@ func (repo *songRepository) FindAll(ctx context.Context, pageSize int, offset in
 	return songs, nil
 }
 
-func (repo *songRepository) FindCount(ctx context.Context) (total int, err error) {
-	query := `SELECT COUNT(*) FROM songs`
-	if err = repo.db.QueryRowContext(ctx, query).Scan(&total); err != nil {
+func (repo *songRepository) FindAllByCursor(ctx context.Context, spec query.Spec, cursor utils.Cursor, limit int) (songs []models.Song, err error) {
+	where, _, specArgs := spec.Build(SongQueryColumns, 3, "s.id DESC")
+	query := fmt.Sprintf(`
+		SELECT
+			s.id,
+			s.title,
+			s.audio,
+			s.duration,
+			s.processing_state,
+			s.image,
+			al.id as album_id,
+			al.name as album_name,
+			al.slug as album_slug,
+			al.image as album_image,
+			ar.id as artist_id,
+			ar.name as artist_name,
+			ar.slug as artist_slug,
+			ar.image as artist_image
+		FROM songs s
+		INNER JOIN albums al ON al.id = s.album_id
+		INNER JOIN artists ar ON ar.id = al.artist_id
+		%s
+		ORDER BY s.id DESC
+		LIMIT $2
+	`, whereClause("($1 = 0 OR s.id < $1)", where))
+	args := append([]any{cursor.Id, limit}, specArgs...)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "song_repo", "FindAllByCursor", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		song := models.Song{}
+		if err := rows.Scan(
+			&song.Id,
+			&song.Title,
+			&song.Audio,
+			&song.Duration,
+			&song.ProcessingState,
+			&song.Image,
+			&song.Album.Id,
+			&song.Album.Name,
+			&song.Album.Slug,
+			&song.Album.Image,
+			&song.Album.Artist.Id,
+			&song.Album.Artist.Name,
+			&song.Album.Artist.Slug,
+			&song.Album.Artist.Image,
+		); err != nil {
+			utils.LogError(repo.log, ctx, "song_repo", "FindAllByCursor", err)
+			return nil, err
+		}
+
+		songs = append(songs, song)
+	}
+
+	return songs, nil
+}
+
+func (repo *songRepository) FindCount(ctx context.Context, spec query.Spec) (total int, err error) {
+	where, _, args := spec.Build(SongQueryColumns, 1, "s.id DESC")
+	query := `SELECT COUNT(*) FROM songs s INNER JOIN albums al ON al.id = s.album_id ` + whereClause(where)
+	if err = repo.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
 		utils.LogError(repo.log, ctx, "song_repo", "FindCount", err)
 		return
 	}
@@ -99,6 +184,8 @@ func (repo *songRepository) FindSongById(ctx context.Context, id int) (song *mod
 			s.title,
 			s.audio,
 			s.duration,
+			s.processing_state,
+			s.hls,
 			s.image,
 			al.id as album_id ,
 			al."name" as album_name,
@@ -120,6 +207,8 @@ func (repo *songRepository) FindSongById(ctx context.Context, id int) (song *mod
 		&song.Title,
 		&song.Audio,
 		&song.Duration,
+		&song.ProcessingState,
+		&song.HLS,
 		&song.Image,
 		&song.Album.Id,
 		&song.Album.Name,
@@ -153,8 +242,8 @@ func (repo *songRepository) FindExistsSongById(ctx context.Context, id int) (exi
 }
 
 func (repo *songRepository) Store(ctx context.Context, input models.CreateSongInput) (err error) {
-	query := `INSERT INTO songs(album_id, title, audio, duration, image) VALUES($1, $2, $3, $4, $5)`
-	args := []any{input.AlbumId, input.Title, input.Audio, input.Duration, input.Image}
+	query := `INSERT INTO songs(album_id, title, duration, image) VALUES($1, $2, $3, $4)`
+	args := []any{input.AlbumId, input.Title, input.Duration, input.Image}
 
 	if _, err = repo.db.ExecContext(ctx, query, args...); err != nil {
 		utils.LogError(repo.log, ctx, "song_repo", "Store", err)
@@ -165,8 +254,8 @@ func (repo *songRepository) Store(ctx context.Context, input models.CreateSongIn
 }
 
 func (repo *songRepository) Update(ctx context.Context, input models.CreateSongInput, id int) (err error) {
-	query := `UPDATE songs SET album_id = $1, audio = $2, title= $3, duration = $4, image = $5 WHERE id = $6`
-	args := []any{input.AlbumId, input.Audio, input.Title, input.Duration, input.Image, id}
+	query := `UPDATE songs SET album_id = $1, title= $2, duration = COALESCE(NULLIF($3, 0), duration), image = $4 WHERE id = $5`
+	args := []any{input.AlbumId, input.Title, input.Duration, input.Image, id}
 
 	if _, err = repo.db.ExecContext(ctx, query, args...); err != nil {
 		utils.LogError(repo.log, ctx, "song_repo", "Update", err)
@@ -176,6 +265,50 @@ func (repo *songRepository) Update(ctx context.Context, input models.CreateSongI
 	return
 }
 
+func (repo *songRepository) UpdateAudio(ctx context.Context, id int, input models.SongAudioInput) (err error) {
+	query := `
+		UPDATE songs SET 
+			audio = $1,
+			duration = $2,
+			bitrate = $3,
+			sample_rate = $4,
+			channels = $5,
+			tag_title = $6,
+			tag_artist = $7,
+			tag_album = $8,
+			processing_state = 'pending'
+		WHERE id = $9
+	`
+	args := []any{input.Audio, input.Duration, input.Bitrate, input.SampleRate, input.Channels, input.TagTitle, input.TagArtist, input.TagAlbum, id}
+
+	if _, err = database.Conn(ctx, repo.db).ExecContext(ctx, query, args...); err != nil {
+		utils.LogError(repo.log, ctx, "song_repo", "UpdateAudio", err)
+		return
+	}
+
+	return
+}
+
+// UpdateProcessing only applies while the song still points to the packaged audio, a newer
+// upload during packaging leaves updated false.
+func (repo *songRepository) UpdateProcessing(ctx context.Context, id int, input models.SongProcessingInput) (updated bool, err error) {
+	query := `UPDATE songs SET processing_state = $1, hls = $2 WHERE id = $3 AND audio = $4`
+
+	result, err := repo.db.ExecContext(ctx, query, input.State, input.HLS, id, input.Audio)
+	if err != nil {
+		utils.LogError(repo.log, ctx, "song_repo", "UpdateProcessing", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		utils.LogError(repo.log, ctx, "song_repo", "UpdateProcessing", err)
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
 func (repo *songRepository) Delete(ctx context.Context, id int) (err error) {
 	query := `DELETE FROM songs WHERE id = $1`
 
@@ -194,6 +327,7 @@ func (repo *songRepository) FindSongsByAlbumId(ctx context.Context, albumId int,
 			s.title,
 			s.audio,
 			s.duration,
+			s.processing_state,
 			s.image,
 			al.id as album_id ,
 			al."name" as album_name,
@@ -227,6 +361,7 @@ func (repo *songRepository) FindSongsByAlbumId(ctx context.Context, albumId int,
 			&song.Title,
 			&song.Audio,
 			&song.Duration,
+			&song.ProcessingState,
 			&song.Image,
 			&song.Album.Id,
 			&song.Album.Name,
@@ -0,0 +1,18 @@
+package repositories
+
+import "strings"
+
+// whereClause joins the non-empty conditions into a WHERE clause, empty when there is none.
+func whereClause(conditions ...string) string {
+	parts := make([]string, 0, len(conditions))
+	for _, condition := range conditions {
+		if condition != "" {
+			parts = append(parts, condition)
+		}
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(parts, " AND ")
+}
@@ -70,9 +70,10 @@ func (repo *userRepository) FindExistsUserByUserID(ctx context.Context, userID i
 }
 
 func (repo *userRepository) FindUserVerifiedByCode(ctx context.Context, code string) (exists bool, err error) {
-	query := `SELECT EXISTS (SELECT 1 FROM user_verified WHERE code = $1)`
+	// Email change codes are stored hashed, a code is taken in either form
+	query := `SELECT EXISTS (SELECT 1 FROM user_verified WHERE code = $1 OR code = $2)`
 
-	if err := repo.db.QueryRowContext(ctx, query, code).Scan(&exists); err != nil {
+	if err := repo.db.QueryRowContext(ctx, query, code, utils.HashToken(code)).Scan(&exists); err != nil {
 		utils.LogError(repo.log, ctx, "user_repo", "FindUserVerifiedByCode", err)
 		return false, err
 	}
@@ -155,7 +156,7 @@ func (repo *userRepository) FindUserByUserID(ctx context.Context, userID int) (u
 }
 
 func (repo *userRepository) FindUserVerifiedByUserIDAndCode(ctx context.Context, userId int, code string) (userVerified *models.UserVerified, err error) {
-	query := `SELECT uv.code, uv.expired_at FROM user_verified uv INNER JOIN users u ON u.id = uv.user_id WHERE uv.user_id = $1 AND uv.code = $2 AND u.email_verified_at IS NULL`
+	query := `SELECT uv.code, uv.expired_at FROM user_verified uv INNER JOIN users u ON u.id = uv.user_id WHERE uv.user_id = $1 AND uv.code = $2 AND uv.email IS NULL AND u.email_verified_at IS NULL`
 
 	userVerified = &models.UserVerified{}
 	if err = repo.db.QueryRowContext(ctx, query, userId, code).Scan(&userVerified.Code, &userVerified.ExpiredAt); err != nil {
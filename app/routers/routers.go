@@ -6,18 +6,26 @@ import (
 	"github.com/gofiber/swagger"
 	"github.com/wahyusahajaa/mulo-api-go/app/config"
 	"github.com/wahyusahajaa/mulo-api-go/app/handlers"
+	"github.com/wahyusahajaa/mulo-api-go/app/middlewares"
 	_ "github.com/wahyusahajaa/mulo-api-go/docs"
 )
 
 func ProviderFiberApp(h *handlers.Handlers, fiberLogger fiber.Handler, cfg *config.Config) *fiber.App {
 	app := fiber.New(fiber.Config{
 		AppName: "Mulo Music Streaming",
+		// Bodies are streamed so uploads can pass the default limit, middlewares.BodyLimit checks
+		// the limit of each route. Multipart forms are parsed by the upload handlers after the check.
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+		// Behind a reverse proxy the client ip, which the rate limits key on, comes from its header
+		ProxyHeader: cfg.ProxyHeader,
 	})
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     cfg.AllowOrigins,
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
-		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, Range, If-Range, If-None-Match, If-Modified-Since",
+		AllowMethods:     "GET,HEAD,POST,PUT,DELETE,OPTIONS",
+		ExposeHeaders:    "Accept-Ranges, Content-Range, Content-Length, ETag, Last-Modified",
 		AllowCredentials: true,
 	}))
 
@@ -25,11 +33,26 @@ func ProviderFiberApp(h *handlers.Handlers, fiberLogger fiber.Handler, cfg *conf
 
 	v1 := app.Group("/v1")
 
+	// Allowed roles per route group
+	userManagers := h.Middleware.RequireRole("admin")
+	// Members update their own profile, admins any profile
+	profileEditors := h.Middleware.RequireSelfOrRole("id", "admin")
+	catalogEditors := h.Middleware.RequireRole("admin")
+
+	// Uploads are registered ahead of the default body limit, they take up to MaxUploadSize.
+	// Being ahead of v1Protected too, they require auth themselves.
+	uploadLimit := middlewares.BodyLimit(cfg.MaxUploadSize)
+	v1.Post("/songs/:id/audio", uploadLimit, h.Middleware.AuthRequired(), catalogEditors, h.Song.UploadAudio)
+	v1.Post("/images", uploadLimit, h.Middleware.AuthRequired(), catalogEditors, h.Image.UploadImage)
+
+	app.Use(middlewares.BodyLimit(fiber.DefaultBodyLimit))
+
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("Welcome to Mulo!")
 	})
 
 	app.Get("/docs/*", swagger.HandlerDefault)
+	app.Get("/.well-known/jwks.json", h.Auth.JWKS)
 	v1.Get("/ping", Ping)
 
 	authGroup := v1.Group("auth")
@@ -40,59 +63,80 @@ func ProviderFiberApp(h *handlers.Handlers, fiberLogger fiber.Handler, cfg *conf
 	authGroup.Post("/verify", h.Auth.Verify)
 	authGroup.Post("/resend-verification", h.Auth.ResendVerification)
 	authGroup.Get("/verification-status", h.Auth.VerificationStatus)
+	authGroup.Post("/forgot-password", h.Auth.ForgotPassword)
+	authGroup.Post("/reset-password", h.Auth.ResetPassword)
 	authGroup.Post("/refresh", h.Auth.Refresh)
 	authGroup.Post("/logout", h.Auth.Logout)
-	authGroup.Post("/oauth/github/callback", h.Auth.OAuthGithubCallback)
+	authGroup.Get("/oauth/:provider/authorize", h.Auth.OAuthAuthorize)
+	authGroup.Post("/oauth/:provider/callback", h.Auth.OAuthProviderCallback)
 	authGroup.Post("/oauth/callback", h.Auth.OAuthCallback)
 
+	// Signed URL routes, authorized by the URL signature instead of the access token cookie
+	v1.Get("/songs/:id/stream", h.Stream.StreamSong).Name(handlers.StreamRouteName)
+	v1.Get("/songs/:id/hls/master.m3u8", h.Stream.StreamHLS).Name(handlers.HLSRouteName)
+	v1.Get("/songs/:id/hls/*", h.Stream.StreamHLS)
+
+	// Uploaded images are public, like the external image URLs they replace
+	v1.Get("/images/:id/:file", h.Image.GetImage)
+
 	v1Protected := v1.Use(h.Middleware.AuthRequired())
 	v1Protected.Get("auth/me", h.Auth.AuthMe)
+	v1Protected.Put("/me/password", h.Auth.ChangePassword)
+	v1Protected.Put("/me/email", h.Auth.ChangeEmail)
+	v1Protected.Post("/me/email/verify", h.Auth.ConfirmEmailChange)
+	v1Protected.Get("/me/sessions", h.Auth.GetSessions)
+	v1Protected.Delete("/me/sessions", h.Auth.LogoutEverywhere)
+	v1Protected.Delete("/me/sessions/:id", h.Auth.RevokeSession)
 
 	// Users endpoint
-	v1Protected.Get("/users", h.User.GetUsers)
-	v1Protected.Get("/users/:id", h.User.GetUser)
-	v1Protected.Put("/users/:id", h.User.Update)
-	v1Protected.Delete("/users/:id", h.User.Delete)
+	v1Protected.Get("/users", userManagers, h.User.GetUsers)
+	v1Protected.Get("/users/:id", userManagers, h.User.GetUser)
+	v1Protected.Put("/users/:id", profileEditors, h.User.Update)
+	v1Protected.Delete("/users/:id", userManagers, h.User.Delete)
+	v1Protected.Delete("/users/:id/sessions", userManagers, h.Auth.RevokeUserSessions)
 
 	// Artists endpoint
 	v1Protected.Get("/artists", h.Artist.GetArtists)
 	v1Protected.Get("/artists/:id", h.Artist.GetArtist)
-	v1Protected.Post("/artists", h.Artist.CreateArtist)
-	v1Protected.Put("/artists/:id", h.Artist.UpdateArtist)
-	v1Protected.Delete("/artists/:id", h.Artist.DeleteArtist)
+	v1Protected.Post("/artists", catalogEditors, h.Artist.CreateArtist)
+	v1Protected.Put("/artists/:id", catalogEditors, h.Artist.UpdateArtist)
+	v1Protected.Delete("/artists/:id", catalogEditors, h.Artist.DeleteArtist)
 	// Artists genres endpoint
 	v1Protected.Get("/artists/:id/genres", h.Genre.GetArtistGenres)
-	v1Protected.Post("/artists/:id/genres/:genreId", h.Genre.CreateArtistGenre)
-	v1Protected.Delete("/artists/:id/genres/:genreId", h.Genre.DeleteArtistGenre)
+	v1Protected.Post("/artists/:id/genres/:genreId", catalogEditors, h.Genre.CreateArtistGenre)
+	v1Protected.Delete("/artists/:id/genres/:genreId", catalogEditors, h.Genre.DeleteArtistGenre)
 	// Artist albums endpoint
 	v1Protected.Get("/artists/:id/albums", h.Album.GetAlbumsByArtistId)
 
 	// Albums endpoint
 	v1Protected.Get("/albums", h.Album.GetAlbums)
 	v1Protected.Get("/albums/:id", h.Album.GetAlbum)
-	v1Protected.Post("/albums", h.Album.CreateAlbum)
-	v1Protected.Put("/albums/:id", h.Album.UpdateAlbum)
-	v1Protected.Delete("/albums/:id", h.Album.DeleteAlbum)
+	v1Protected.Post("/albums", catalogEditors, h.Album.CreateAlbum)
+	v1Protected.Put("/albums/:id", catalogEditors, h.Album.UpdateAlbum)
+	v1Protected.Delete("/albums/:id", catalogEditors, h.Album.DeleteAlbum)
 	// List of Songs by album
 	v1Protected.Get("/albums/:id/songs", h.Song.GetSongsByAlbumId)
 
 	// Songs Endpoint
 	v1Protected.Get("/songs", h.Song.GetSongs)
 	v1Protected.Get("/songs/:id", h.Song.GetSong)
-	v1Protected.Post("/songs", h.Song.CreateSong)
-	v1Protected.Put("/songs/:id", h.Song.UpdateSong)
-	v1Protected.Delete("/songs/:id", h.Song.DeleteSong)
+	v1Protected.Post("/songs", catalogEditors, h.Song.CreateSong)
+	v1Protected.Put("/songs/:id", catalogEditors, h.Song.UpdateSong)
+	v1Protected.Delete("/songs/:id", catalogEditors, h.Song.DeleteSong)
+	v1Protected.Get("/songs/:id/stream-url", h.Stream.GetStreamURL)
 	// Songs genres endpoint
 	v1Protected.Get("/songs/:id/genres", h.Genre.GetSongGenres)
-	v1Protected.Post("/songs/:id/genres/:genreId", h.Genre.CreateSongGenre)
-	v1Protected.Delete("/songs/:id/genres/:genreId", h.Genre.DeleteSongGenre)
+	v1Protected.Post("/songs/:id/genres/:genreId", catalogEditors, h.Genre.CreateSongGenre)
+	v1Protected.Delete("/songs/:id/genres/:genreId", catalogEditors, h.Genre.DeleteSongGenre)
+	// Songs listens endpoint
+	v1Protected.Post("/songs/:id/listens", h.Listen.CreateListen)
 
 	// Genres Endpoint
 	v1Protected.Get("/genres", h.Genre.GetGenres)
 	v1Protected.Get("/genres/:id", h.Genre.GetGenre)
-	v1Protected.Post("/genres", h.Genre.CreateGenre)
-	v1Protected.Put("/genres/:id", h.Genre.UpdateGenre)
-	v1Protected.Delete("/genres/:id", h.Genre.DeleteGenre)
+	v1Protected.Post("/genres", catalogEditors, h.Genre.CreateGenre)
+	v1Protected.Put("/genres/:id", catalogEditors, h.Genre.UpdateGenre)
+	v1Protected.Delete("/genres/:id", catalogEditors, h.Genre.DeleteGenre)
 	v1Protected.Get("/genres/:id/artists", h.Genre.GetArtists)
 	v1Protected.Get("/genres/:id/songs", h.Genre.GetSongs)
 
@@ -112,6 +156,19 @@ func ProviderFiberApp(h *handlers.Handlers, fiberLogger fiber.Handler, cfg *conf
 	v1Protected.Post("/favorites/songs/:songId", h.Favorite.AddFavoriteSong)
 	v1Protected.Delete("/favorites/songs/:songId", h.Favorite.RemoveFavoriteSong)
 
+	// Search endpoint
+	v1Protected.Get("/search", h.Search.Search)
+
+	// Charts endpoints
+	v1Protected.Get("/charts/songs", h.Chart.GetSongCharts)
+	v1Protected.Get("/charts/artists", h.Chart.GetArtistCharts)
+	v1Protected.Get("/charts/albums", h.Chart.GetAlbumCharts)
+
+	// Listening history endpoints
+	v1Protected.Get("/me/history", h.Listen.GetHistory)
+	v1Protected.Delete("/me/history", h.Listen.ClearHistory)
+	v1Protected.Get("/me/recently-played", h.Listen.GetRecentlyPlayed)
+
 	return app
 }
 
@@ -9,6 +9,7 @@ import (
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
@@ -26,19 +27,67 @@ func NewAlbumService(repo contracts.AlbumRepository, artistRepo contracts.Artist
 	}
 }
 
-func (svc *albumService) GetAll(ctx context.Context, pageSize int, offset int) (albums []dto.AlbumWithArtist, total int, err error) {
-	total, err = svc.repo.FindCount(ctx)
+func (svc *albumService) GetAll(ctx context.Context, spec query.Spec, pageSize int, offset int) (albums []dto.AlbumWithArtist, total int, err error) {
+	total, err = svc.repo.FindCount(ctx, spec)
 	if err != nil {
 		utils.LogError(svc.log, ctx, "album_service", "GetCount", err)
 		return nil, 0, err
 	}
 
-	albumResults, err := svc.repo.FindAll(ctx, pageSize, offset)
+	albumResults, err := svc.repo.FindAll(ctx, spec, pageSize, offset)
 	if err != nil {
 		utils.LogError(svc.log, ctx, "album_service", "GetAll", err)
 		return nil, 0, err
 	}
 
+	albums, err = svc.withArtists(ctx, albumResults)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "album_service", "GetAll", err)
+		return nil, 0, err
+	}
+
+	return albums, total, nil
+}
+
+func (svc *albumService) GetAllByCursor(ctx context.Context, spec query.Spec, cursor string, pageSize int, withTotal bool) (albums []dto.AlbumWithArtist, pagination dto.CursorPagination, err error) {
+	if err := checkCursorSpec(spec); err != nil {
+		return nil, pagination, err
+	}
+
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, pagination, err
+	}
+
+	albumResults, err := svc.repo.FindAllByCursor(ctx, spec, after, pageSize+1)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "album_service", "GetAllByCursor", err)
+		return nil, pagination, err
+	}
+	albumResults, pagination = cursorPage(albumResults, pageSize, func(album models.Album) utils.Cursor {
+		return utils.Cursor{Id: album.Id}
+	})
+
+	if withTotal {
+		total, err := svc.repo.FindCount(ctx, spec)
+		if err != nil {
+			utils.LogError(svc.log, ctx, "album_service", "GetAllByCursor", err)
+			return nil, pagination, err
+		}
+		pagination.Total = &total
+	}
+
+	albums, err = svc.withArtists(ctx, albumResults)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "album_service", "GetAllByCursor", err)
+		return nil, pagination, err
+	}
+
+	return albums, pagination, nil
+}
+
+// withArtists maps albums to the dto shape, looking up their artists in one query.
+func (svc *albumService) withArtists(ctx context.Context, albumResults []models.Album) (albums []dto.AlbumWithArtist, err error) {
 	// Make unique artist ids
 	artistIdMap := make(map[int]struct{})
 	for _, albumResult := range albumResults {
@@ -53,8 +102,7 @@ func (svc *albumService) GetAll(ctx context.Context, pageSize int, offset int) (
 	// Get artists by artist ids
 	artists, err := svc.artistRepo.FindByArtistIds(ctx, inClause, args)
 	if err != nil {
-		utils.LogError(svc.log, ctx, "album_service", "GetAll", err)
-		return nil, 0, err
+		return nil, err
 	}
 
 	//  Build artist lookup map
@@ -84,7 +132,7 @@ func (svc *albumService) GetAll(ctx context.Context, pageSize int, offset int) (
 		albums = append(albums, album)
 	}
 
-	return albums, total, nil
+	return albums, nil
 }
 
 func (svc *albumService) GetAlbumById(ctx context.Context, id int) (album dto.AlbumWithArtist, err error) {
@@ -11,6 +11,7 @@ import (
 	"github.com/wahyusahajaa/mulo-api-go/app/mocks"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
@@ -47,8 +48,8 @@ func (s *AlbumServiceTestSuite) TestGetAll() {
 		{
 			name: "success",
 			prepareMock: func() {
-				s.AlbumRepo.On("FindCount", mock.Anything).Return(1, nil)
-				s.AlbumRepo.On("FindAll", mock.Anything, 10, 0).Return([]models.Album{
+				s.AlbumRepo.On("FindCount", mock.Anything, query.Spec{}).Return(1, nil)
+				s.AlbumRepo.On("FindAll", mock.Anything, query.Spec{}, 10, 0).Return([]models.Album{
 					{
 						Id:       1,
 						ArtistId: 1,
@@ -91,23 +92,23 @@ func (s *AlbumServiceTestSuite) TestGetAll() {
 		{
 			name: "FindCount_Error",
 			prepareMock: func() {
-				s.AlbumRepo.On("FindCount", mock.Anything).Return(0, errors.New("database failure"))
+				s.AlbumRepo.On("FindCount", mock.Anything, query.Spec{}).Return(0, errors.New("database failure"))
 			},
 			expectErr: errors.New("database failure"),
 		},
 		{
 			name: "FindAll_Error",
 			prepareMock: func() {
-				s.AlbumRepo.On("FindCount", mock.Anything).Return(1, nil)
-				s.AlbumRepo.On("FindAll", mock.Anything, 10, 0).Return(nil, errors.New("database failure"))
+				s.AlbumRepo.On("FindCount", mock.Anything, query.Spec{}).Return(1, nil)
+				s.AlbumRepo.On("FindAll", mock.Anything, query.Spec{}, 10, 0).Return(nil, errors.New("database failure"))
 			},
 			expectErr: errors.New("database failure"),
 		},
 		{
 			name: "FindByArtistIds_Error",
 			prepareMock: func() {
-				s.AlbumRepo.On("FindCount", mock.Anything).Return(1, nil)
-				s.AlbumRepo.On("FindAll", mock.Anything, 10, 0).Return([]models.Album{
+				s.AlbumRepo.On("FindCount", mock.Anything, query.Spec{}).Return(1, nil)
+				s.AlbumRepo.On("FindAll", mock.Anything, query.Spec{}, 10, 0).Return([]models.Album{
 					{
 						Id:       1,
 						ArtistId: 1,
@@ -134,7 +135,7 @@ func (s *AlbumServiceTestSuite) TestGetAll() {
 			}
 
 			// Actual
-			results, total, err := s.Svc.GetAll(s.T().Context(), 10, 0)
+			results, total, err := s.Svc.GetAll(s.T().Context(), query.Spec{}, 10, 0)
 
 			// Assertion
 			if tc.expectErr == nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
@@ -23,14 +24,14 @@ func NewArtistService(repo contracts.ArtistRepository, log *logrus.Logger) contr
 	}
 }
 
-func (svc *artistService) GetAll(ctx context.Context, pageSize, offset int) (artists []dto.Artist, total int, err error) {
-	total, err = svc.repo.FindCount(ctx)
+func (svc *artistService) GetAll(ctx context.Context, spec query.Spec, pageSize, offset int) (artists []dto.Artist, total int, err error) {
+	total, err = svc.repo.FindCount(ctx, spec)
 	if err != nil {
 		utils.LogError(svc.log, ctx, "artist_service", "GetAll", err)
 		return nil, 0, err
 	}
 
-	results, err := svc.repo.FindAll(ctx, pageSize, offset)
+	results, err := svc.repo.FindAll(ctx, spec, pageSize, offset)
 	if err != nil {
 		utils.LogError(svc.log, ctx, "artist_service", "GetAll", err)
 		return nil, 0, err
@@ -50,6 +51,47 @@ func (svc *artistService) GetAll(ctx context.Context, pageSize, offset int) (art
 	return artists, total, nil
 }
 
+func (svc *artistService) GetAllByCursor(ctx context.Context, spec query.Spec, cursor string, pageSize int, withTotal bool) (artists []dto.Artist, pagination dto.CursorPagination, err error) {
+	if err := checkCursorSpec(spec); err != nil {
+		return nil, pagination, err
+	}
+
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, pagination, err
+	}
+
+	results, err := svc.repo.FindAllByCursor(ctx, spec, after, pageSize+1)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "artist_service", "GetAllByCursor", err)
+		return nil, pagination, err
+	}
+	results, pagination = cursorPage(results, pageSize, func(artist models.Artist) utils.Cursor {
+		return utils.Cursor{Id: artist.Id}
+	})
+
+	if withTotal {
+		total, err := svc.repo.FindCount(ctx, spec)
+		if err != nil {
+			utils.LogError(svc.log, ctx, "artist_service", "GetAllByCursor", err)
+			return nil, pagination, err
+		}
+		pagination.Total = &total
+	}
+
+	artists = make([]dto.Artist, 0, len(results))
+	for _, result := range results {
+		artists = append(artists, dto.Artist{
+			Id:    result.Id,
+			Name:  result.Name,
+			Slug:  result.Slug,
+			Image: utils.ParseImageToJSON(result.Image),
+		})
+	}
+
+	return artists, pagination, nil
+}
+
 func (svc *artistService) CreateArtist(ctx context.Context, req dto.CreateArtistRequest) (err error) {
 	if errorsMap, err := utils.RequestValidate(&req); err != nil {
 		return errs.NewBadRequestError("validation failed", errorsMap)
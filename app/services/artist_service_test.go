@@ -11,6 +11,7 @@ import (
 	"github.com/wahyusahajaa/mulo-api-go/app/mocks"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
@@ -42,8 +43,8 @@ func (s *ArtistServiceTestSuite) TestGetAll() {
 		{
 			name: "success",
 			prepareMock: func() {
-				s.ArtistRepo.On("FindCount", mock.Anything).Return(1, nil)
-				s.ArtistRepo.On("FindAll", mock.Anything, 10, 0).Return([]models.Artist{
+				s.ArtistRepo.On("FindCount", mock.Anything, query.Spec{}).Return(1, nil)
+				s.ArtistRepo.On("FindAll", mock.Anything, query.Spec{}, 10, 0).Return([]models.Artist{
 					{
 						Id:    1,
 						Name:  "Noah",
@@ -65,15 +66,15 @@ func (s *ArtistServiceTestSuite) TestGetAll() {
 		{
 			name: "FindCount_Error",
 			prepareMock: func() {
-				s.ArtistRepo.On("FindCount", mock.Anything).Return(0, errors.New("database failure"))
+				s.ArtistRepo.On("FindCount", mock.Anything, query.Spec{}).Return(0, errors.New("database failure"))
 			},
 			expectErr: errors.New("database failure"),
 		},
 		{
 			name: "FindCount_Error",
 			prepareMock: func() {
-				s.ArtistRepo.On("FindCount", mock.Anything).Return(1, nil)
-				s.ArtistRepo.On("FindAll", mock.Anything, 10, 0).Return(nil, errors.New("database failure"))
+				s.ArtistRepo.On("FindCount", mock.Anything, query.Spec{}).Return(1, nil)
+				s.ArtistRepo.On("FindAll", mock.Anything, query.Spec{}, 10, 0).Return(nil, errors.New("database failure"))
 			},
 			expectErr: errors.New("database failure"),
 		},
@@ -87,7 +88,7 @@ func (s *ArtistServiceTestSuite) TestGetAll() {
 			}
 
 			// Actual
-			results, total, err := s.Svc.GetAll(s.T().Context(), 10, 0)
+			results, total, err := s.Svc.GetAll(s.T().Context(), query.Spec{}, 10, 0)
 
 			// Assert
 			if tc.expectErr == nil {
@@ -2,18 +2,27 @@ package services
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/wahyusahajaa/mulo-api-go/app/config"
 	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/database"
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/jobs"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/jwt"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/mailer"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/oauth"
-	"github.com/wahyusahajaa/mulo-api-go/pkg/resend"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/ratelimit"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/verification"
 )
@@ -23,19 +32,59 @@ type authService struct {
 	userRepo        contracts.UserRepository
 	jwtSvc          jwt.JWTService
 	verificationSvc verification.VerificationService
-	resendSvc       resend.ResendService
-	oauth           oauth.OAuthService
+	outbox          mailer.Outbox
+	jobs            jobs.Enqueuer
+	tx              database.Transactor
+	oauth           oauth.Registry
+	limiter         ratelimit.Limiter
+	images          contracts.ImageService
 	log             *logrus.Logger
 	config          *config.Config
 }
 
+// Rate limits of the auth endpoints, every attempt counts. The failed logins of an account are
+// limited by the lockout rule.
+var (
+	loginIPRule     = ratelimit.Rule{Name: "login:ip", Limit: 30, Window: 15 * time.Minute}
+	verifyIPRule    = ratelimit.Rule{Name: "verify:ip", Limit: 30, Window: 15 * time.Minute}
+	verifyEmailRule = ratelimit.Rule{Name: "verify:email", Limit: 5, Window: 15 * time.Minute}
+	resendIPRule    = ratelimit.Rule{Name: "resend:ip", Limit: 10, Window: time.Hour}
+	resendEmailRule = ratelimit.Rule{Name: "resend:email", Limit: 3, Window: time.Hour}
+	oauthIPRule     = ratelimit.Rule{Name: "oauth:ip", Limit: 30, Window: 15 * time.Minute}
+
+	forgotIPRule           = ratelimit.Rule{Name: "forgot:ip", Limit: 10, Window: time.Hour}
+	forgotEmailRule        = ratelimit.Rule{Name: "forgot:email", Limit: 3, Window: time.Hour}
+	changePasswordUserRule = ratelimit.Rule{Name: "change_password:user", Limit: 5, Window: 15 * time.Minute}
+	changePasswordIPRule   = ratelimit.Rule{Name: "change_password:ip", Limit: 30, Window: 15 * time.Minute}
+	changeEmailUserRule    = ratelimit.Rule{Name: "change_email:user", Limit: 5, Window: time.Hour}
+	changeEmailIPRule      = ratelimit.Rule{Name: "change_email:ip", Limit: 10, Window: time.Hour}
+	changeEmailTargetRule  = ratelimit.Rule{Name: "change_email:email", Limit: 3, Window: time.Hour}
+	confirmEmailUserRule   = ratelimit.Rule{Name: "confirm_email:user", Limit: 5, Window: 15 * time.Minute}
+	confirmEmailIPRule     = ratelimit.Rule{Name: "confirm_email:ip", Limit: 30, Window: 15 * time.Minute}
+)
+
+// maxEmailChangeAttempts is the number of wrong codes that drop a pending email change.
+const maxEmailChangeAttempts = 5
+
+const (
+	// maxUsernameLength is the length of the username column.
+	maxUsernameLength = 100
+	// usernameCandidates is how many suffixed usernames are tried for a new OAuth user before
+	// the user is registered without one.
+	usernameCandidates = 10
+)
+
 func NewAuthService(
 	authRepo contracts.AuthRepository,
 	userRepo contracts.UserRepository,
 	jwtSvc jwt.JWTService,
 	verificationSvc verification.VerificationService,
-	resendSvc resend.ResendService,
-	oauth oauth.OAuthService,
+	outbox mailer.Outbox,
+	jobs jobs.Enqueuer,
+	tx database.Transactor,
+	oauth oauth.Registry,
+	limiter ratelimit.Limiter,
+	images contracts.ImageService,
 	log *logrus.Logger,
 	config *config.Config,
 ) contracts.AuthService {
@@ -44,8 +93,12 @@ func NewAuthService(
 		userRepo:        userRepo,
 		jwtSvc:          jwtSvc,
 		verificationSvc: verificationSvc,
-		resendSvc:       resendSvc,
+		outbox:          outbox,
+		jobs:            jobs,
+		tx:              tx,
 		oauth:           oauth,
+		limiter:         limiter,
+		images:          images,
 		log:             log,
 		config:          config,
 	}
@@ -97,14 +150,18 @@ func (svc *authService) Register(ctx context.Context, req dto.RegisterRequest) (
 		Code:     code,
 	}
 
-	if err = svc.authRepo.Store(ctx, input); err != nil {
+	// The email is queued with the user, a registration rolled back sends nothing
+	err = svc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := svc.authRepo.Store(ctx, input); err != nil {
+			return err
+		}
+		return svc.outbox.Queue(ctx, req.Email, mailer.Verification{Code: code})
+	})
+	if err != nil {
 		utils.LogError(svc.log, ctx, "auth_service", "Register", err)
 		return err
 	}
 
-	// Send email code verification
-	go svc.resendSvc.SendEmailVerificationCode(req.Email, code)
-
 	return
 }
 
@@ -114,6 +171,23 @@ func (svc *authService) Login(ctx context.Context, req dto.LoginRequest) (access
 		return "", "", errs.NewBadRequestError("validation failed", errorsMap)
 	}
 
+	if err := svc.throttle(ctx, "Login", loginIPRule, utils.GetClientIP(ctx)); err != nil {
+		return "", "", err
+	}
+
+	// A locked account is refused before its password is checked
+	email := strings.ToLower(req.Email)
+	lock, err := svc.limiter.Peek(ctx, svc.lockoutRule(), email)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "Login", err)
+		return "", "", err
+	}
+	if !lock.Allowed {
+		tooManyErr := errs.NewTooManyRequestsError("Too many failed logins. The account is temporarily locked.", lock.RetryAfter)
+		utils.LogSecurity(svc.log, ctx, "locked_account_login", logrus.Fields{"email": email})
+		return "", "", tooManyErr
+	}
+
 	// Get existing user by email
 	user, err := svc.userRepo.FindUserByEmail(ctx, req.Email)
 	if err != nil {
@@ -121,6 +195,7 @@ func (svc *authService) Login(ctx context.Context, req dto.LoginRequest) (access
 		return "", "", err
 	}
 	if user == nil {
+		svc.loginFailed(ctx, email)
 		notFoundErr := errs.NewNotFoundError("User", "email", req.Email)
 		utils.LogWarn(svc.log, ctx, "auth_service", "Login", notFoundErr)
 		return "", "", notFoundErr
@@ -138,30 +213,29 @@ func (svc *authService) Login(ctx context.Context, req dto.LoginRequest) (access
 
 	// Check password
 	if !utils.CheckPasswordHash(req.Password, user.Password.String) {
+		svc.loginFailed(ctx, email)
 		notFoundErr := errs.NewNotFoundErrorWithMsg("Password mismatch. Try again.")
 		utils.LogWarn(svc.log, ctx, "auth_service", "Login", notFoundErr)
 		return "", "", notFoundErr
 	}
 
+	if err := svc.limiter.Reset(ctx, svc.lockoutRule(), email); err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "Login", err)
+		return "", "", err
+	}
+
 	if !user.EmailVerifiedAt.Valid {
 		forbiddenErr := errs.NewForbiddenError("Access denied. Please verify your email to continue.")
 		utils.LogWarn(svc.log, ctx, "auth_service", "login", forbiddenErr)
 		return "", "", forbiddenErr
 	}
 
-	accessToken, refreshToken, err = svc.jwtSvc.GenerateTokens(user.Id, user.Username.String, user.Role)
+	accessToken, refreshToken, err = svc.startSession(ctx, user.Id, user.Username.String, user.Role, user.Email)
 	if err != nil {
 		utils.LogError(svc.log, ctx, "auth_service", "Login", err)
 		return "", "", err
 	}
 
-	// Store Refresh Token
-	refreshTokenExpires := time.Now().Add(7 * 24 * time.Hour)
-	if err := svc.authRepo.StoreRefreshToken(ctx, user.Id, refreshToken, refreshTokenExpires); err != nil {
-		utils.LogError(svc.log, ctx, "auth_service", "Login", err)
-		return "", "", err
-	}
-
 	return
 }
 
@@ -171,6 +245,14 @@ func (svc *authService) Verify(ctx context.Context, req dto.VerifyRequest) (err
 		return errs.NewBadRequestError("validation failed", errorsMap)
 	}
 
+	// Codes are short, guesses are limited per client and per account
+	if err := svc.throttle(ctx, "Verify", verifyIPRule, utils.GetClientIP(ctx)); err != nil {
+		return err
+	}
+	if err := svc.throttle(ctx, "Verify", verifyEmailRule, strings.ToLower(req.Email)); err != nil {
+		return err
+	}
+
 	// Check existing user by email
 	user, err := svc.userRepo.FindUserByEmail(ctx, req.Email)
 	if err != nil {
@@ -223,6 +305,13 @@ func (svc *authService) ResendVerification(ctx context.Context, req dto.ResendVe
 		return errs.NewBadRequestError("validation failed", errorsMap)
 	}
 
+	if err := svc.throttle(ctx, "ResendVerification", resendIPRule, utils.GetClientIP(ctx)); err != nil {
+		return err
+	}
+	if err := svc.throttle(ctx, "ResendVerification", resendEmailRule, strings.ToLower(req.Email)); err != nil {
+		return err
+	}
+
 	user, err := svc.userRepo.FindUserByEmail(ctx, req.Email)
 	if err != nil {
 		utils.LogError(svc.log, ctx, "auth_service", "ResendVerification", err)
@@ -247,14 +336,17 @@ func (svc *authService) ResendVerification(ctx context.Context, req dto.ResendVe
 		return err
 	}
 
-	if err := svc.authRepo.StoreUserVerifyCode(ctx, user.Id, code); err != nil {
+	err = svc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := svc.authRepo.StoreUserVerifyCode(ctx, user.Id, code); err != nil {
+			return err
+		}
+		return svc.outbox.Queue(ctx, req.Email, mailer.Verification{Code: code})
+	})
+	if err != nil {
 		utils.LogError(svc.log, ctx, "auth_service", "ResendVerification", err)
 		return err
 	}
 
-	// Send email verification
-	go svc.resendSvc.SendEmailVerificationCode(req.Email, code)
-
 	return
 }
 
@@ -306,22 +398,19 @@ func (svc *authService) Refresh(ctx context.Context, token string) (accessToken
 		return "", "", err
 	}
 
-	// Check if refresh token is valid or not revoked
-	valid, err := svc.authRepo.IsRefreshTokenValid(ctx, claims.ID, token)
+	tokenHash := utils.HashToken(token)
+	current, err := svc.authRepo.FindRefreshToken(ctx, tokenHash)
 	if err != nil {
 		utils.LogError(svc.log, ctx, "auth_service", "Refresh", err)
 		return "", "", err
 	}
-	if !valid {
+	if current == nil || current.UserID != claims.ID || current.ExpiresAt.Before(time.Now()) {
 		unauthErr := errs.NewUnauthorizedError("Invalid refresh token or revoked.")
 		utils.LogWarn(svc.log, ctx, "auth_service", "refresh", unauthErr)
 		return "", "", unauthErr
 	}
-
-	// Update revoke status to TRUE or is revoked
-	if err = svc.authRepo.UpdateRefreshToken(ctx, claims.ID, token); err != nil {
-		utils.LogError(svc.log, ctx, "auth_service", "Refresh", err)
-		return "", "", err
+	if current.Revoked {
+		return "", "", svc.revokeReusedSession(ctx, current)
 	}
 
 	// Generate access and refresh token
@@ -331,18 +420,30 @@ func (svc *authService) Refresh(ctx context.Context, token string) (accessToken
 		return "", "", err
 	}
 
-	// Store refresh token for next request refresh token while acces token has expired
-	refreshTokenExpires := time.Now().Add(7 * 24 * time.Hour)
-	if err := svc.authRepo.StoreRefreshToken(ctx, claims.ID, refreshToken, refreshTokenExpires); err != nil {
+	// The new token replaces the old one in the same session. Losing the race to revoke it means
+	// the token was presented twice, the second is treated like any reused token.
+	var revoked bool
+	err = svc.tx.WithinTx(ctx, func(ctx context.Context) (err error) {
+		revoked, err = svc.authRepo.RevokeRefreshToken(ctx, tokenHash)
+		if err != nil || !revoked {
+			return err
+		}
+		refreshTokenExpires := time.Now().Add(svc.config.RefreshTokenTTL)
+		return svc.authRepo.StoreRefreshToken(ctx, claims.ID, current.SessionID, utils.HashToken(refreshToken), refreshTokenExpires)
+	})
+	if err != nil {
 		utils.LogError(svc.log, ctx, "auth_service", "Refresh", err)
 		return "", "", err
 	}
+	if !revoked {
+		return "", "", svc.revokeReusedSession(ctx, current)
+	}
 
 	return accessToken, refreshToken, nil
 }
 
 func (svc *authService) Logout(ctx context.Context, token string) (err error) {
-	if err = svc.authRepo.DeleteRefreshToken(ctx, token); err != nil {
+	if err = svc.authRepo.DeleteSessionByRefreshToken(ctx, utils.HashToken(token)); err != nil {
 		utils.LogError(svc.log, ctx, "auth_service", "Logout", err)
 		return
 	}
@@ -350,185 +451,689 @@ func (svc *authService) Logout(ctx context.Context, token string) (err error) {
 	return
 }
 
-func (svc *authService) OAuthGithubCallback(ctx context.Context, req dto.GithubReq) (accessToken, refreshToken string, err error) {
-	if errorMaps, err := utils.RequestValidate(&req); err != nil {
-		return "", "", errs.NewBadRequestError("validation failed", errorMaps)
+func (svc *authService) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) (err error) {
+	if errorsMap, err := utils.RequestValidate(&req); err != nil {
+		return errs.NewBadRequestError("validation failed", errorsMap)
 	}
 
-	// Get access token from github by auth code
-	githubToken, err := svc.oauth.GithubAccessToken(ctx, req.Code)
-	if err != nil {
-		utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
-		return "", "", err
+	if err := svc.throttle(ctx, "ForgotPassword", forgotIPRule, utils.GetClientIP(ctx)); err != nil {
+		return err
 	}
-	if githubToken == "" {
-		unauthErr := errs.NewUnauthorizedError("Bad credentials.")
-		utils.LogWarn(svc.log, ctx, "auth_service", "OAuthGithubCallback", unauthErr)
-		return "", "", unauthErr
+	if err := svc.throttle(ctx, "ForgotPassword", forgotEmailRule, strings.ToLower(req.Email)); err != nil {
+		return err
+	}
+
+	// The lookup runs on the queue, the answer takes as long for unknown emails as for accounts
+	if err := svc.jobs.Enqueue(ctx, models.PasswordResetJob{Email: req.Email}, jobs.MaxAttempts(3)); err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "ForgotPassword", err)
+		return err
 	}
 
-	// Get user info from github by access token
-	githubUser, err := svc.oauth.GithubUserInfo(ctx, githubToken)
+	return nil
+}
+
+func (svc *authService) SendPasswordReset(ctx context.Context, email string) (err error) {
+	user, err := svc.userRepo.FindUserByEmail(ctx, email)
 	if err != nil {
-		utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
-		return "", "", err
+		utils.LogError(svc.log, ctx, "auth_service", "SendPasswordReset", err)
+		return err
 	}
-	// Check githubUser while empty
-	if githubUser == nil {
-		nfError := errs.NewNotFoundError("GithubUser", "code", req.Code)
-		utils.LogWarn(svc.log, ctx, "auth_service", "OAuthGithubCallback", nfError)
-		return "", "", nfError
+	// The request was answered already, an email without an account is only logged
+	if user == nil {
+		utils.LogWarn(svc.log, ctx, "auth_service", "SendPasswordReset", errs.NewNotFoundError("User", "email", email))
+		return nil
 	}
 
-	// Fill missing email if not provided in user info
-	if githubUser.Email == "" {
-		emailCh := make(chan string)
-		errCh := make(chan error)
+	token, err := utils.GenerateToken()
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "SendPasswordReset", err)
+		return err
+	}
 
-		go func() {
-			email, err := svc.oauth.GithubUserEmail(ctx, githubToken)
-			if err != nil {
-				errCh <- err
-				return
-			}
-			emailCh <- email
-		}()
-
-		select {
-		case email := <-emailCh:
-			githubUser.Email = email
-		case err := <-errCh:
-			utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
-			return "", "", err
-		case <-ctx.Done():
-			timeoutErr := errs.NewTimeOut("Fetching GitHub email timed out")
-			utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", timeoutErr)
-			return "", "", timeoutErr
+	resetURL, err := url.Parse(svc.config.PasswordResetURL)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "SendPasswordReset", err)
+		return err
+	}
+	query := resetURL.Query()
+	query.Set("token", token)
+	resetURL.RawQuery = query.Encode()
+
+	resetEmail := mailer.PasswordReset{
+		URL:              resetURL.String(),
+		ExpiresInMinutes: int(svc.config.PasswordResetTTL.Minutes()),
+	}
+	err = svc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := svc.authRepo.StorePasswordReset(ctx, user.Id, utils.HashToken(token), time.Now().Add(svc.config.PasswordResetTTL)); err != nil {
+			return err
 		}
+		return svc.outbox.Queue(ctx, user.Email, resetEmail)
+	})
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "SendPasswordReset", err)
+		return err
 	}
 
-	// Use login as fallback name
-	if githubUser.Name == "" {
-		githubUser.Name = githubUser.Login
+	return nil
+}
+
+func (svc *authService) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) (err error) {
+	if errorsMap, err := utils.RequestValidate(&req); err != nil {
+		return errs.NewBadRequestError("validation failed", errorsMap)
 	}
 
-	// Check user by github email
-	user, err := svc.userRepo.FindUserByEmail(ctx, githubUser.Email)
+	// Consumed in the same transaction as the update, a failed update leaves the token usable
+	var userID int
+	err = svc.tx.WithinTx(ctx, func(ctx context.Context) (err error) {
+		userID, err = svc.authRepo.ConsumePasswordReset(ctx, utils.HashToken(req.Token))
+		if err != nil || userID == 0 {
+			return err
+		}
+
+		// Hashed once the token checks out, bcrypt is too slow to spend on guessed tokens
+		if err := svc.authRepo.UpdateUserPassword(ctx, userID, utils.HashPassword(req.Password)); err != nil {
+			return err
+		}
+		// Sessions started with the old password end with it
+		return svc.authRepo.DeleteUserSessions(ctx, userID, "")
+	})
 	if err != nil {
-		utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
-		return "", "", err
+		utils.LogError(svc.log, ctx, "auth_service", "ResetPassword", err)
+		return err
+	}
+	if userID == 0 {
+		badRequestErr := errs.NewBadRequestError("Reset token is invalid or has expired.", nil)
+		utils.LogWarn(svc.log, ctx, "auth_service", "ResetPassword", badRequestErr)
+		return badRequestErr
 	}
 
-	var userID int
-	var provider = "github"
-	var providerUserID = strconv.Itoa(githubUser.ID)
+	return nil
+}
+
+func (svc *authService) ChangePassword(ctx context.Context, userID int, refreshToken string, req dto.ChangePasswordRequest) (err error) {
+	if errorsMap, err := utils.RequestValidate(&req); err != nil {
+		return errs.NewBadRequestError("validation failed", errorsMap)
+	}
+
+	// Limited per account as well, the current password is guessable from any number of addresses
+	if err := svc.throttle(ctx, "ChangePassword", changePasswordIPRule, utils.GetClientIP(ctx)); err != nil {
+		return err
+	}
+	if err := svc.throttle(ctx, "ChangePassword", changePasswordUserRule, strconv.Itoa(userID)); err != nil {
+		return err
+	}
 
+	user, err := svc.userRepo.FindUserByUserID(ctx, userID)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "ChangePassword", err)
+		return err
+	}
 	if user == nil {
-		input := models.OAuthAccountInput{
-			ID:       providerUserID,
-			Fullname: githubUser.Name,
-			Username: githubUser.Login,
-			Email:    githubUser.Email,
-			Image:    utils.ParseImageToByte(&dto.Image{Src: githubUser.AvatarURL, BlurHash: ""}),
-			Provider: provider,
+		notFoundErr := errs.NewNotFoundError("User", "id", userID)
+		utils.LogWarn(svc.log, ctx, "auth_service", "ChangePassword", notFoundErr)
+		return notFoundErr
+	}
+
+	// Users who signed up with OAuth have no password to confirm and add their first one
+	if user.Password.Valid && !utils.CheckPasswordHash(req.CurrentPassword, user.Password.String) {
+		badRequestErr := errs.NewBadRequestError("validation failed", map[string]string{
+			"current_password": "Current password is incorrect.",
+		})
+		utils.LogWarn(svc.log, ctx, "auth_service", "ChangePassword", badRequestErr)
+		return badRequestErr
+	}
+
+	// The session changing the password stays signed in, every other one is signed out
+	err = svc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := svc.authRepo.UpdateUserPassword(ctx, userID, utils.HashPassword(req.NewPassword)); err != nil {
+			return err
 		}
-		// Store user with oauth_accounts
-		userID, err = svc.authRepo.StoreUserWithOAuthAccount(ctx, input)
-		if err != nil {
-			utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
-			return "", "", err
+		return svc.authRepo.DeleteUserSessions(ctx, userID, utils.HashToken(refreshToken))
+	})
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "ChangePassword", err)
+		return err
+	}
+
+	return nil
+}
+
+func (svc *authService) ChangeEmail(ctx context.Context, userID int, req dto.ChangeEmailRequest) (err error) {
+	if errorsMap, err := utils.RequestValidate(&req); err != nil {
+		return errs.NewBadRequestError("validation failed", errorsMap)
+	}
+
+	// Limited per new address as well, every attempt mails it a code
+	if err := svc.throttle(ctx, "ChangeEmail", changeEmailIPRule, utils.GetClientIP(ctx)); err != nil {
+		return err
+	}
+	if err := svc.throttle(ctx, "ChangeEmail", changeEmailUserRule, strconv.Itoa(userID)); err != nil {
+		return err
+	}
+	if err := svc.throttle(ctx, "ChangeEmail", changeEmailTargetRule, strings.ToLower(req.Email)); err != nil {
+		return err
+	}
+
+	user, err := svc.userRepo.FindUserByUserID(ctx, userID)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "ChangeEmail", err)
+		return err
+	}
+	if user == nil {
+		notFoundErr := errs.NewNotFoundError("User", "id", userID)
+		utils.LogWarn(svc.log, ctx, "auth_service", "ChangeEmail", notFoundErr)
+		return notFoundErr
+	}
+	if strings.EqualFold(user.Email, req.Email) {
+		conflictErr := errs.NewConflictErrorWithMsg("This is already your email.")
+		utils.LogWarn(svc.log, ctx, "auth_service", "ChangeEmail", conflictErr)
+		return conflictErr
+	}
+
+	exists, err := svc.userRepo.FindUserExistsByEmail(ctx, req.Email)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "ChangeEmail", err)
+		return err
+	}
+	if exists {
+		conflictErr := errs.NewConflictError("User", "email", req.Email)
+		utils.LogWarn(svc.log, ctx, "auth_service", "ChangeEmail", conflictErr)
+		return conflictErr
+	}
+
+	code, err := svc.verificationSvc.GenerateVerificationCode(ctx)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "ChangeEmail", err)
+		return err
+	}
+
+	// The code goes to the new address, only its owner can confirm the change
+	err = svc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := svc.authRepo.StoreEmailChange(ctx, userID, req.Email, utils.HashToken(code)); err != nil {
+			return err
 		}
-	} else {
-		userID = user.Id
-		oAuthAccount, err := svc.authRepo.FindOAuthAccount(ctx, provider, providerUserID)
-		if err != nil {
-			utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
-			return "", "", err
+		return svc.outbox.Queue(ctx, req.Email, mailer.EmailChange{Code: code})
+	})
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "ChangeEmail", err)
+		return err
+	}
+
+	return nil
+}
+
+func (svc *authService) ConfirmEmailChange(ctx context.Context, userID int, req dto.ConfirmEmailChangeRequest) (err error) {
+	if errorsMap, err := utils.RequestValidate(&req); err != nil {
+		return errs.NewBadRequestError("validation failed", errorsMap)
+	}
+
+	if err := svc.throttle(ctx, "ConfirmEmailChange", confirmEmailIPRule, utils.GetClientIP(ctx)); err != nil {
+		return err
+	}
+	if err := svc.throttle(ctx, "ConfirmEmailChange", confirmEmailUserRule, strconv.Itoa(userID)); err != nil {
+		return err
+	}
+
+	change, err := svc.authRepo.FindEmailChange(ctx, userID)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "ConfirmEmailChange", err)
+		return err
+	}
+	if change == nil {
+		notFoundErr := errs.NewNotFoundError("Verification", "code", req.Code)
+		utils.LogWarn(svc.log, ctx, "auth_service", "ConfirmEmailChange", notFoundErr)
+		return notFoundErr
+	}
+	// A wrong code counts against the change, too many and a new code has to be requested
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(req.Code)), []byte(change.CodeHash)) != 1 {
+		if err := svc.authRepo.FailEmailChange(ctx, userID, maxEmailChangeAttempts); err != nil {
+			utils.LogError(svc.log, ctx, "auth_service", "ConfirmEmailChange", err)
+			return err
 		}
-		if oAuthAccount == nil {
-			// Create new oauth_accounts
-			if err := svc.authRepo.StoreOAuthAccount(ctx, user.Id, provider, providerUserID); err != nil {
-				utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
-				return "", "", err
-			}
+
+		notFoundErr := errs.NewNotFoundError("Verification", "code", req.Code)
+		utils.LogWarn(svc.log, ctx, "auth_service", "ConfirmEmailChange", notFoundErr)
+		return notFoundErr
+	}
+	if change.ExpiredAt.Valid && change.ExpiredAt.Time.Before(time.Now()) {
+		goneErr := errs.NewGoneError("Verification Code", "code", req.Code)
+		utils.LogWarn(svc.log, ctx, "auth_service", "ConfirmEmailChange", goneErr)
+		return goneErr
+	}
+
+	// Another account may have registered the address since the code was sent
+	exists, err := svc.userRepo.FindUserExistsByEmail(ctx, change.Email)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "ConfirmEmailChange", err)
+		return err
+	}
+	if exists {
+		conflictErr := errs.NewConflictError("User", "email", change.Email)
+		utils.LogWarn(svc.log, ctx, "auth_service", "ConfirmEmailChange", conflictErr)
+		return conflictErr
+	}
+
+	if err := svc.authRepo.UpdateUserEmail(ctx, userID, change.Email); err != nil {
+		// Raced by a registration with the address, answered like the check above
+		var conflictErr *errs.ConflictError
+		if errors.As(err, &conflictErr) {
+			utils.LogWarn(svc.log, ctx, "auth_service", "ConfirmEmailChange", conflictErr)
+			return conflictErr
 		}
+
+		utils.LogError(svc.log, ctx, "auth_service", "ConfirmEmailChange", err)
+		return err
 	}
 
-	// Generate access & refresh token
-	accessToken, refreshToken, err = svc.jwtSvc.GenerateTokens(userID, githubUser.Login, "member")
+	return nil
+}
+
+func (svc *authService) OAuthAuthorize(ctx context.Context, providerName string) (authURL, state string, err error) {
+	provider, err := svc.oauthProvider(ctx, "OAuthAuthorize", providerName)
 	if err != nil {
-		utils.LogWarn(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
 		return "", "", err
 	}
 
-	// Store refresh token
-	refreshTokenExpires := time.Now().Add(7 * 24 * time.Hour)
-	if err := svc.authRepo.StoreRefreshToken(ctx, userID, refreshToken, refreshTokenExpires); err != nil {
-		utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
+	// Every start stores a state, a client can only start so many
+	if err := svc.throttle(ctx, "OAuthAuthorize", oauthIPRule, utils.GetClientIP(ctx)); err != nil {
 		return "", "", err
 	}
 
-	return
+	state, err = utils.GenerateToken()
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "OAuthAuthorize", err)
+		return "", "", err
+	}
+	codeVerifier, err := oauth.NewCodeVerifier()
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "OAuthAuthorize", err)
+		return "", "", err
+	}
+
+	oauthState := models.OAuthState{
+		Provider:     providerName,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(svc.config.OAuthStateTTL),
+	}
+	if err := svc.authRepo.StoreOAuthState(ctx, utils.HashToken(state), oauthState); err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "OAuthAuthorize", err)
+		return "", "", err
+	}
+
+	authURL, err = provider.AuthCodeURL(ctx, state, oauth.CodeChallenge(codeVerifier))
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "OAuthAuthorize", err)
+		return "", "", err
+	}
+
+	return authURL, state, nil
+}
+
+func (svc *authService) OAuthProviderCallback(ctx context.Context, providerName string, req dto.OAuthCodeRequest, stateCookie string) (accessToken, refreshToken string, err error) {
+	if errorMaps, err := utils.RequestValidate(&req); err != nil {
+		return "", "", errs.NewBadRequestError("validation failed", errorMaps)
+	}
+
+	provider, err := svc.oauthProvider(ctx, "OAuthProviderCallback", providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The state must come back to the browser that started the sign in, or a code of the
+	// attacker's account could sign the victim in to it
+	invalidState := errs.NewUnauthorizedError("Invalid or expired sign in, please start again.")
+	if stateCookie == "" || subtle.ConstantTimeCompare([]byte(stateCookie), []byte(req.State)) != 1 {
+		utils.LogSecurity(svc.log, ctx, "oauth_state_mismatch", logrus.Fields{"provider": providerName})
+		return "", "", invalidState
+	}
+
+	oauthState, err := svc.authRepo.ConsumeOAuthState(ctx, utils.HashToken(req.State))
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "OAuthProviderCallback", err)
+		return "", "", err
+	}
+	if oauthState == nil || oauthState.Provider != providerName || time.Now().After(oauthState.ExpiresAt) {
+		utils.LogWarn(svc.log, ctx, "auth_service", "OAuthProviderCallback", invalidState)
+		return "", "", invalidState
+	}
+
+	// Get the provider token by auth code
+	token, err := provider.Exchange(ctx, req.Code, oauthState.CodeVerifier)
+	if err != nil {
+		if errors.Is(err, oauth.ErrInvalidGrant) {
+			unauthErr := errs.NewUnauthorizedError("Bad credentials.")
+			utils.LogWarn(svc.log, ctx, "auth_service", "OAuthProviderCallback", unauthErr)
+			return "", "", unauthErr
+		}
+		utils.LogError(svc.log, ctx, "auth_service", "OAuthProviderCallback", err)
+		return "", "", err
+	}
+
+	identity, err := provider.Identity(ctx, token)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "OAuthProviderCallback", err)
+		return "", "", err
+	}
+
+	return svc.oauthSignIn(ctx, identity)
 }
 
 func (svc *authService) OAuthCallback(ctx context.Context, req dto.OAuthRequest) (accessToken string, refreshToken string, err error) {
 	if errorMaps, err := utils.RequestValidate(&req); err != nil {
 		return "", "", errs.NewBadRequestError("validation failed", errorMaps)
 	}
+	if req.IDToken == "" && req.AccessToken == "" {
+		return "", "", errs.NewBadRequestError("validation failed", map[string]string{
+			"id_token":     "id_token or access_token is required",
+			"access_token": "id_token or access_token is required",
+		})
+	}
 
-	// Check user by github email
-	user, err := svc.userRepo.FindUserByEmail(ctx, req.Email)
+	provider, err := svc.oauthProvider(ctx, "OAuthCallback", req.Provider)
 	if err != nil {
-		utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
 		return "", "", err
 	}
 
-	var userID int
+	// Every token is verified with the provider, a client can only send so many
+	if err := svc.throttle(ctx, "OAuthCallback", oauthIPRule, utils.GetClientIP(ctx)); err != nil {
+		return "", "", err
+	}
 
-	if user == nil {
-		input := models.OAuthAccountInput{
-			ID:       req.ProviderID,
-			Fullname: req.Fullname,
-			Username: req.Username,
-			Email:    req.Email,
-			Image:    utils.ParseImageToByte(&dto.Image{Src: req.Avatar, BlurHash: ""}),
-			Provider: req.Provider,
+	// The identity comes from the provider, never from the request, or anyone could sign in as anyone
+	identity, err := provider.VerifyToken(ctx, &oauth.Token{IDToken: req.IDToken, AccessToken: req.AccessToken})
+	if err != nil {
+		if errors.Is(err, oauth.ErrInvalidToken) {
+			utils.LogSecurity(svc.log, ctx, "oauth_token_rejected", logrus.Fields{"provider": req.Provider, "reason": err.Error()})
+			return "", "", errs.NewUnauthorizedError("Invalid provider token.")
 		}
-		// Store user with oauth_accounts
-		userID, err = svc.authRepo.StoreUserWithOAuthAccount(ctx, input)
-		if err != nil {
-			utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
+		utils.LogError(svc.log, ctx, "auth_service", "OAuthCallback", err)
+		return "", "", err
+	}
+
+	return svc.oauthSignIn(ctx, identity)
+}
+
+// oauthProvider looks up a configured provider, Not Found for any other name.
+func (svc *authService) oauthProvider(ctx context.Context, operation, name string) (oauth.Provider, error) {
+	provider, err := svc.oauth.Provider(name)
+	if err != nil {
+		nfError := errs.NewNotFoundErrorWithMsg(fmt.Sprintf("OAuth provider '%s' is not configured.", name))
+		utils.LogWarn(svc.log, ctx, "auth_service", operation, nfError)
+		return nil, nfError
+	}
+
+	return provider, nil
+}
+
+// oauthSignIn signs in the user linked to the provider account. An unlinked provider account
+// is linked to the user with its email, or registers a new user.
+func (svc *authService) oauthSignIn(ctx context.Context, identity *oauth.Identity) (accessToken, refreshToken string, err error) {
+	// Linking by an email the provider didn't verify would hand the account to whoever typed it in
+	if identity.Email == "" || !identity.EmailVerified {
+		forbiddenErr := errs.NewForbiddenError(fmt.Sprintf("Your %s account has no verified email.", identity.Provider))
+		utils.LogWarn(svc.log, ctx, "auth_service", "oauthSignIn", forbiddenErr)
+		return "", "", forbiddenErr
+	}
+
+	var user *models.User
+	oAuthAccount, err := svc.authRepo.FindOAuthAccount(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "oauthSignIn", err)
+		return "", "", err
+	}
+	if oAuthAccount != nil {
+		if user, err = svc.userRepo.FindUserByUserID(ctx, oAuthAccount.UserID); err != nil {
+			utils.LogError(svc.log, ctx, "auth_service", "oauthSignIn", err)
 			return "", "", err
 		}
-	} else {
-		userID = user.Id
-		oAuthAccount, err := svc.authRepo.FindOAuthAccount(ctx, req.Provider, req.ProviderID)
-		if err != nil {
-			utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
+	}
+
+	if user == nil {
+		// Check user by provider email
+		if user, err = svc.userRepo.FindUserByEmail(ctx, identity.Email); err != nil {
+			utils.LogError(svc.log, ctx, "auth_service", "oauthSignIn", err)
 			return "", "", err
 		}
-		if oAuthAccount == nil {
-			// Create new oauth_accounts
-			if err := svc.authRepo.StoreOAuthAccount(ctx, user.Id, req.Provider, req.ProviderID); err != nil {
-				utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
+
+		if user == nil {
+			username, err := svc.availableUsername(ctx, identity.Username)
+			if err != nil {
+				utils.LogError(svc.log, ctx, "auth_service", "oauthSignIn", err)
+				return "", "", err
+			}
+
+			input := models.OAuthAccountInput{
+				ID:       identity.Subject,
+				Fullname: identity.Name,
+				Username: username,
+				Email:    identity.Email,
+				Provider: identity.Provider,
+			}
+			// The avatar is stored with its blur hash like an uploaded image, or not at all
+			if identity.AvatarURL != "" {
+				if avatar, err := svc.images.RemoteImage(ctx, identity.AvatarURL); err == nil {
+					input.Image = utils.ParseImageToByte(&avatar)
+				}
+			}
+			// Store user with oauth_accounts
+			userID, err := svc.authRepo.StoreUserWithOAuthAccount(ctx, input)
+			if err != nil {
+				utils.LogError(svc.log, ctx, "auth_service", "oauthSignIn", err)
 				return "", "", err
 			}
+			// The account was just created, there is no sign in to alert of
+			return svc.startSession(ctx, userID, username, "member", "")
+		}
+
+		// Create new oauth_accounts
+		if err := svc.authRepo.StoreOAuthAccount(ctx, user.Id, identity.Provider, identity.Subject); err != nil {
+			utils.LogError(svc.log, ctx, "auth_service", "oauthSignIn", err)
+			return "", "", err
 		}
 	}
 
 	// Generate access & refresh token
-	accessToken, refreshToken, err = svc.jwtSvc.GenerateTokens(userID, req.Username, "member")
+	accessToken, refreshToken, err = svc.startSession(ctx, user.Id, user.Username.String, user.Role, user.Email)
 	if err != nil {
-		utils.LogWarn(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
+		utils.LogError(svc.log, ctx, "auth_service", "oauthSignIn", err)
 		return "", "", err
 	}
 
-	// Store refresh token
-	refreshTokenExpires := time.Now().Add(7 * 24 * time.Hour)
-	if err := svc.authRepo.StoreRefreshToken(ctx, userID, refreshToken, refreshTokenExpires); err != nil {
-		utils.LogError(svc.log, ctx, "auth_service", "OAuthGithubCallback", err)
+	return
+}
+
+func (svc *authService) GetSessions(ctx context.Context, userID int, refreshToken string) (sessions []dto.Session, err error) {
+	// No current session when the request comes without its refresh token
+	var currentID int
+	if refreshToken != "" {
+		currentID, err = svc.authRepo.FindRefreshTokenSession(ctx, userID, utils.HashToken(refreshToken))
+		if err != nil {
+			utils.LogError(svc.log, ctx, "auth_service", "GetSessions", err)
+			return nil, err
+		}
+	}
+
+	results, err := svc.authRepo.FindSessionsByUserID(ctx, userID)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "GetSessions", err)
+		return nil, err
+	}
+
+	sessions = make([]dto.Session, 0, len(results))
+	for _, session := range results {
+		sessions = append(sessions, dto.Session{
+			Id:         session.Id,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.Id == currentID,
+		})
+	}
+
+	return sessions, nil
+}
+
+func (svc *authService) RevokeSession(ctx context.Context, userID, sessionID int) (err error) {
+	deleted, err := svc.authRepo.DeleteSession(ctx, userID, sessionID)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "RevokeSession", err)
+		return err
+	}
+	if !deleted {
+		notFoundErr := errs.NewNotFoundError("Session", "id", sessionID)
+		utils.LogWarn(svc.log, ctx, "auth_service", "RevokeSession", notFoundErr)
+		return notFoundErr
+	}
+
+	return nil
+}
+
+func (svc *authService) RevokeUserSessions(ctx context.Context, userID int) (err error) {
+	user, err := svc.userRepo.FindUserByUserID(ctx, userID)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "RevokeUserSessions", err)
+		return err
+	}
+	if user == nil {
+		notFoundErr := errs.NewNotFoundError("User", "id", userID)
+		utils.LogWarn(svc.log, ctx, "auth_service", "RevokeUserSessions", notFoundErr)
+		return notFoundErr
+	}
+
+	if err := svc.authRepo.DeleteUserSessions(ctx, userID, ""); err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "RevokeUserSessions", err)
+		return err
+	}
+
+	utils.LogSecurity(svc.log, ctx, "sessions_revoked", logrus.Fields{"user_id": userID, "by": utils.GetUserId(ctx)})
+	return nil
+}
+
+// startSession issues the tokens of a new session. A sign in from a device the user has no
+// session on is mailed to email, so a stolen password or provider account shows. Empty email
+// sends no alert, neither does the first session of a user.
+func (svc *authService) startSession(ctx context.Context, userID int, username, role, email string) (accessToken, refreshToken string, err error) {
+	accessToken, refreshToken, err = svc.jwtSvc.GenerateTokens(userID, username, role)
+	if err != nil {
 		return "", "", err
 	}
 
-	return
+	userAgent := utils.GetUserAgent(ctx)
+	input := models.SessionInput{
+		UserID:    userID,
+		UserAgent: userAgent,
+		Device:    utils.DeviceName(userAgent),
+		IP:        utils.GetClientIP(ctx),
+	}
+	err = svc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if email != "" {
+			sessions, err := svc.authRepo.FindSessionsByUserID(ctx, userID)
+			if err != nil {
+				return err
+			}
+			knownDevice := slices.ContainsFunc(sessions, func(session models.Session) bool {
+				return session.UserAgent == userAgent
+			})
+			// Without any session there is no device to compare with, like right after registering
+			if len(sessions) > 0 && !knownDevice {
+				alert := mailer.NewLogin{Time: time.Now(), IP: input.IP, UserAgent: userAgent}
+				if err := svc.outbox.Queue(ctx, email, alert); err != nil {
+					return err
+				}
+			}
+		}
+
+		sessionID, err := svc.authRepo.StoreSession(ctx, input)
+		if err != nil {
+			return err
+		}
+		refreshTokenExpires := time.Now().Add(svc.config.RefreshTokenTTL)
+		return svc.authRepo.StoreRefreshToken(ctx, userID, sessionID, utils.HashToken(refreshToken), refreshTokenExpires)
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// revokeReusedSession ends the session a revoked refresh token was presented for. The token was
+// rotated already, so either it was stolen or the thief holds the current one, both lose the session.
+func (svc *authService) revokeReusedSession(ctx context.Context, token *models.RefreshToken) error {
+	utils.LogSecurity(svc.log, ctx, "refresh_token_reused", logrus.Fields{"user_id": token.UserID, "session_id": token.SessionID})
+
+	if _, err := svc.authRepo.DeleteSession(ctx, token.UserID, token.SessionID); err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "Refresh", err)
+		return err
+	}
+
+	return errs.NewUnauthorizedError("Refresh token was already used, the session has been signed out.")
+}
+
+// availableUsername returns the username of a new OAuth user, suffixed with a number when taken.
+// Provider usernames are only unique per provider, and OIDC falls back to the local part of the
+// email. The user is registered without a username, empty, when every candidate is taken.
+func (svc *authService) availableUsername(ctx context.Context, username string) (string, error) {
+	base := []rune(strings.TrimSpace(username))
+	if len(base) == 0 {
+		return "", nil
+	}
+
+	for i := 1; i <= usernameCandidates; i++ {
+		suffix := ""
+		if i > 1 {
+			suffix = strconv.Itoa(i)
+		}
+		candidate := base
+		if limit := maxUsernameLength - len(suffix); len(candidate) > limit {
+			candidate = candidate[:limit]
+		}
+
+		exists, err := svc.userRepo.FindUserExistsByUsername(ctx, string(candidate)+suffix)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return string(candidate) + suffix, nil
+		}
+	}
+
+	return "", nil
+}
+
+// throttle counts a hit of rule against key, a hit past the limit fails with a 429 error.
+func (svc *authService) throttle(ctx context.Context, operation string, rule ratelimit.Rule, key string) error {
+	res, err := svc.limiter.Allow(ctx, rule, key)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", operation, err)
+		return err
+	}
+	if !res.Allowed {
+		utils.LogSecurity(svc.log, ctx, "rate_limited", logrus.Fields{"operation": operation, "rule": rule.Name, "key": key})
+		return errs.NewTooManyRequestsError("Too many attempts. Please try again later.", res.RetryAfter)
+	}
+
+	return nil
+}
+
+// lockoutRule locks an account once its failed logins reach the configured maximum.
+func (svc *authService) lockoutRule() ratelimit.Rule {
+	return ratelimit.Rule{Name: "login:failures", Limit: svc.config.LoginMaxFailures, Window: svc.config.LoginLockout}
+}
+
+// loginFailed counts a failed login of email. The login fails either way, a limiter error is
+// only logged.
+func (svc *authService) loginFailed(ctx context.Context, email string) {
+	res, err := svc.limiter.Allow(ctx, svc.lockoutRule(), email)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "auth_service", "Login", err)
+		return
+	}
+
+	utils.LogSecurity(svc.log, ctx, "login_failed", logrus.Fields{"email": email, "remaining": res.Remaining})
+	if res.Remaining == 0 {
+		utils.LogSecurity(svc.log, ctx, "account_locked", logrus.Fields{"email": email, "lockout": svc.config.LoginLockout.String()})
+	}
 }
@@ -0,0 +1,1300 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/mocks"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/mailer"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/oauth"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/ratelimit"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type AuthServiceTestSuite struct {
+	suite.Suite
+	Svc          contracts.AuthService
+	authRepo     *mocks.MockAuthRepository
+	userRepo     *mocks.MockUserRepository
+	verification *mocks.MockVerificationService
+	outbox       *mocks.MockOutbox
+	jobs         *mocks.MockEnqueuer
+	tx           *mocks.MockTransactor
+	limiter      *mocks.MockLimiter
+	jwt          *mocks.MockJWTService
+	oauth        *mocks.MockOAuthRegistry
+	provider     *mocks.MockOAuthProvider
+	images       *mocks.MockImageService
+}
+
+func (s *AuthServiceTestSuite) SetupTest() {
+	s.authRepo = new(mocks.MockAuthRepository)
+	s.userRepo = new(mocks.MockUserRepository)
+	s.verification = new(mocks.MockVerificationService)
+	s.outbox = new(mocks.MockOutbox)
+	s.jobs = new(mocks.MockEnqueuer)
+	s.tx = new(mocks.MockTransactor)
+	s.limiter = new(mocks.MockLimiter)
+	s.jwt = new(mocks.MockJWTService)
+	s.oauth = new(mocks.MockOAuthRegistry)
+	s.provider = new(mocks.MockOAuthProvider)
+	s.images = new(mocks.MockImageService)
+	cfg := &config.Config{
+		PasswordResetURL: "https://mulo.example.com/reset-password?lang=en",
+		PasswordResetTTL: 30 * time.Minute,
+		RefreshTokenTTL:  7 * 24 * time.Hour,
+		LoginMaxFailures: 5,
+		LoginLockout:     15 * time.Minute,
+		OAuthStateTTL:    10 * time.Minute,
+	}
+	s.Svc = NewAuthService(s.authRepo, s.userRepo, s.jwt, s.verification, s.outbox, s.jobs, s.tx, s.oauth, s.limiter, s.images, nil, cfg)
+}
+
+func (s *AuthServiceTestSuite) ResetMocks() {
+	s.authRepo.ExpectedCalls = nil
+	s.authRepo.Calls = nil
+	s.userRepo.ExpectedCalls = nil
+	s.userRepo.Calls = nil
+	s.verification.ExpectedCalls = nil
+	s.verification.Calls = nil
+	s.outbox.ExpectedCalls = nil
+	s.outbox.Calls = nil
+	s.jobs.ExpectedCalls = nil
+	s.jobs.Calls = nil
+	s.tx.ExpectedCalls = nil
+	s.tx.Calls = nil
+	s.limiter.ExpectedCalls = nil
+	s.limiter.Calls = nil
+	s.jwt.ExpectedCalls = nil
+	s.jwt.Calls = nil
+	s.oauth.ExpectedCalls = nil
+	s.oauth.Calls = nil
+	s.provider.ExpectedCalls = nil
+	s.provider.Calls = nil
+	s.images.ExpectedCalls = nil
+	s.images.Calls = nil
+}
+
+func (s *AuthServiceTestSuite) TestLogin() {
+	lockout := ratelimit.Rule{Name: "login:failures", Limit: 5, Window: 15 * time.Minute}
+	allowed := ratelimit.Result{Allowed: true, Remaining: 3}
+	req := dto.LoginRequest{Email: "User@example.com", Password: "guess"}
+
+	testCases := []struct {
+		name        string
+		prepareMock func()
+		expectedErr error
+	}{
+		{
+			name: "client over the ip limit",
+			prepareMock: func() {
+				s.limiter.On("Allow", mock.Anything, loginIPRule, mock.Anything).Return(ratelimit.Result{RetryAfter: time.Minute}, nil)
+			},
+			expectedErr: errs.NewTooManyRequestsError("Too many attempts. Please try again later.", time.Minute),
+		},
+		{
+			name: "locked account is refused before the password check",
+			prepareMock: func() {
+				s.limiter.On("Allow", mock.Anything, loginIPRule, mock.Anything).Return(allowed, nil)
+				s.limiter.On("Peek", mock.Anything, lockout, "user@example.com").Return(ratelimit.Result{RetryAfter: time.Minute}, nil)
+			},
+			expectedErr: errs.NewTooManyRequestsError("Too many failed logins. The account is temporarily locked.", time.Minute),
+		},
+		{
+			name: "unknown email counts as a failure",
+			prepareMock: func() {
+				s.limiter.On("Allow", mock.Anything, loginIPRule, mock.Anything).Return(allowed, nil)
+				s.limiter.On("Peek", mock.Anything, lockout, "user@example.com").Return(allowed, nil)
+				s.userRepo.On("FindUserByEmail", mock.Anything, "User@example.com").Return(nil, nil)
+				s.limiter.On("Allow", mock.Anything, lockout, "user@example.com").Return(allowed, nil)
+			},
+			expectedErr: errs.NewNotFoundError("User", "email", "User@example.com"),
+		},
+		{
+			name: "wrong password counts as a failure",
+			prepareMock: func() {
+				user := &models.User{Id: 1, Password: sql.NullString{String: utils.HashPassword("password"), Valid: true}}
+				s.limiter.On("Allow", mock.Anything, loginIPRule, mock.Anything).Return(allowed, nil)
+				s.limiter.On("Peek", mock.Anything, lockout, "user@example.com").Return(allowed, nil)
+				s.userRepo.On("FindUserByEmail", mock.Anything, "User@example.com").Return(user, nil)
+				s.limiter.On("Allow", mock.Anything, lockout, "user@example.com").Return(ratelimit.Result{Allowed: true}, nil)
+			},
+			expectedErr: errs.NewNotFoundErrorWithMsg("Password mismatch. Try again."),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			tc.prepareMock()
+
+			// Actual
+			_, _, err := s.Svc.Login(s.T().Context(), req)
+
+			// Assert
+			s.Error(err)
+			s.EqualError(err, tc.expectedErr.Error())
+
+			var tooManyErr *errs.TooManyRequestsError
+			if errors.As(tc.expectedErr, &tooManyErr) {
+				s.ErrorAs(err, &tooManyErr)
+				s.Equal(time.Minute, tooManyErr.RetryAfter)
+			}
+
+			s.userRepo.AssertExpectations(s.T())
+			s.limiter.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *AuthServiceTestSuite) TestVerifyAndResendRateLimits() {
+	limited := ratelimit.Result{RetryAfter: time.Minute}
+	allowed := ratelimit.Result{Allowed: true}
+	expectedErr := errs.NewTooManyRequestsError("Too many attempts. Please try again later.", time.Minute)
+
+	s.Run("verify over the account limit", func() {
+		s.ResetMocks()
+		s.limiter.On("Allow", mock.Anything, verifyIPRule, mock.Anything).Return(allowed, nil)
+		s.limiter.On("Allow", mock.Anything, verifyEmailRule, "user@example.com").Return(limited, nil)
+
+		err := s.Svc.Verify(s.T().Context(), dto.VerifyRequest{Email: "User@example.com", Code: "12345"})
+
+		s.EqualError(err, expectedErr.Error())
+		s.limiter.AssertExpectations(s.T())
+		s.userRepo.AssertNotCalled(s.T(), "FindUserByEmail", mock.Anything, mock.Anything)
+	})
+
+	s.Run("resend over the ip limit", func() {
+		s.ResetMocks()
+		s.limiter.On("Allow", mock.Anything, resendIPRule, mock.Anything).Return(limited, nil)
+
+		err := s.Svc.ResendVerification(s.T().Context(), dto.ResendVerificationRequest{Email: "user@example.com"})
+
+		s.EqualError(err, expectedErr.Error())
+		s.limiter.AssertExpectations(s.T())
+		s.userRepo.AssertNotCalled(s.T(), "FindUserByEmail", mock.Anything, mock.Anything)
+	})
+
+	s.Run("limiter error", func() {
+		s.ResetMocks()
+		s.limiter.On("Allow", mock.Anything, resendIPRule, mock.Anything).Return(ratelimit.Result{}, errors.New("database failure"))
+
+		err := s.Svc.ResendVerification(s.T().Context(), dto.ResendVerificationRequest{Email: "user@example.com"})
+
+		s.EqualError(err, "database failure")
+	})
+}
+
+func (s *AuthServiceTestSuite) TestAccountRateLimits() {
+	limited := ratelimit.Result{RetryAfter: time.Minute}
+	allowed := ratelimit.Result{Allowed: true}
+	expectedErr := errs.NewTooManyRequestsError("Too many attempts. Please try again later.", time.Minute)
+
+	s.Run("forgot password over the email limit", func() {
+		s.ResetMocks()
+		s.limiter.On("Allow", mock.Anything, forgotIPRule, mock.Anything).Return(allowed, nil)
+		s.limiter.On("Allow", mock.Anything, forgotEmailRule, "user@example.com").Return(limited, nil)
+
+		err := s.Svc.ForgotPassword(s.T().Context(), dto.ForgotPasswordRequest{Email: "User@example.com"})
+
+		s.EqualError(err, expectedErr.Error())
+		s.limiter.AssertExpectations(s.T())
+		s.userRepo.AssertNotCalled(s.T(), "FindUserByEmail", mock.Anything, mock.Anything)
+	})
+
+	s.Run("change password over the account limit", func() {
+		s.ResetMocks()
+		s.limiter.On("Allow", mock.Anything, changePasswordIPRule, mock.Anything).Return(allowed, nil)
+		s.limiter.On("Allow", mock.Anything, changePasswordUserRule, "1").Return(limited, nil)
+
+		err := s.Svc.ChangePassword(s.T().Context(), 1, "refresh-token", dto.ChangePasswordRequest{CurrentPassword: "guess", NewPassword: "new-password"})
+
+		s.EqualError(err, expectedErr.Error())
+		s.limiter.AssertExpectations(s.T())
+		s.userRepo.AssertNotCalled(s.T(), "FindUserByUserID", mock.Anything, mock.Anything)
+	})
+
+	s.Run("change email over the new address limit", func() {
+		s.ResetMocks()
+		s.limiter.On("Allow", mock.Anything, changeEmailIPRule, mock.Anything).Return(allowed, nil)
+		s.limiter.On("Allow", mock.Anything, changeEmailUserRule, "1").Return(allowed, nil)
+		s.limiter.On("Allow", mock.Anything, changeEmailTargetRule, "new@example.com").Return(limited, nil)
+
+		err := s.Svc.ChangeEmail(s.T().Context(), 1, dto.ChangeEmailRequest{Email: "New@example.com"})
+
+		s.EqualError(err, expectedErr.Error())
+		s.limiter.AssertExpectations(s.T())
+		s.userRepo.AssertNotCalled(s.T(), "FindUserByUserID", mock.Anything, mock.Anything)
+	})
+
+	s.Run("confirm email change over the ip limit", func() {
+		s.ResetMocks()
+		s.limiter.On("Allow", mock.Anything, confirmEmailIPRule, mock.Anything).Return(limited, nil)
+
+		err := s.Svc.ConfirmEmailChange(s.T().Context(), 1, dto.ConfirmEmailChangeRequest{Code: "12345"})
+
+		s.EqualError(err, expectedErr.Error())
+		s.limiter.AssertExpectations(s.T())
+		s.authRepo.AssertNotCalled(s.T(), "FindEmailChange", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func (s *AuthServiceTestSuite) TestRefresh() {
+	claims := &dto.JWTCustomClaims{ID: 1, Username: "naff", UserRole: "member"}
+	oldHash, newHash := utils.HashToken("old-token"), utils.HashToken("new-token")
+	active := &models.RefreshToken{UserID: 1, SessionID: 7, ExpiresAt: time.Now().Add(time.Hour)}
+	isExpiry := mock.MatchedBy(func(expiresAt time.Time) bool {
+		return time.Until(expiresAt) > 7*24*time.Hour-time.Minute
+	})
+	reusedErr := errs.NewUnauthorizedError("Refresh token was already used, the session has been signed out.")
+	invalidErr := errs.NewUnauthorizedError("Invalid refresh token or revoked.")
+
+	testCases := []struct {
+		name        string
+		prepareMock func()
+		expectedErr error
+	}{
+		{
+			name: "success rotates the token within the session",
+			prepareMock: func() {
+				s.jwt.On("ParseRefreshToken", "old-token").Return(claims, nil)
+				s.authRepo.On("FindRefreshToken", mock.Anything, oldHash).Return(active, nil)
+				s.jwt.On("GenerateTokens", 1, "naff", "member").Return("access-token", "new-token", nil)
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.authRepo.On("RevokeRefreshToken", mock.Anything, oldHash).Return(true, nil)
+				s.authRepo.On("StoreRefreshToken", mock.Anything, 1, 7, newHash, isExpiry).Return(nil)
+			},
+		},
+		{
+			name: "reused token signs the session out",
+			prepareMock: func() {
+				s.jwt.On("ParseRefreshToken", "old-token").Return(claims, nil)
+				s.authRepo.On("FindRefreshToken", mock.Anything, oldHash).Return(&models.RefreshToken{UserID: 1, SessionID: 7, Revoked: true, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+				s.authRepo.On("DeleteSession", mock.Anything, 1, 7).Return(true, nil)
+			},
+			expectedErr: reusedErr,
+		},
+		{
+			name: "token rotated by a concurrent refresh signs the session out",
+			prepareMock: func() {
+				s.jwt.On("ParseRefreshToken", "old-token").Return(claims, nil)
+				s.authRepo.On("FindRefreshToken", mock.Anything, oldHash).Return(active, nil)
+				s.jwt.On("GenerateTokens", 1, "naff", "member").Return("access-token", "new-token", nil)
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.authRepo.On("RevokeRefreshToken", mock.Anything, oldHash).Return(false, nil)
+				s.authRepo.On("DeleteSession", mock.Anything, 1, 7).Return(true, nil)
+			},
+			expectedErr: reusedErr,
+		},
+		{
+			name: "unknown token",
+			prepareMock: func() {
+				s.jwt.On("ParseRefreshToken", "old-token").Return(claims, nil)
+				s.authRepo.On("FindRefreshToken", mock.Anything, oldHash).Return(nil, nil)
+			},
+			expectedErr: invalidErr,
+		},
+		{
+			name: "token of another user",
+			prepareMock: func() {
+				s.jwt.On("ParseRefreshToken", "old-token").Return(claims, nil)
+				s.authRepo.On("FindRefreshToken", mock.Anything, oldHash).Return(&models.RefreshToken{UserID: 2, SessionID: 7, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+			},
+			expectedErr: invalidErr,
+		},
+		{
+			name: "expired token",
+			prepareMock: func() {
+				s.jwt.On("ParseRefreshToken", "old-token").Return(claims, nil)
+				s.authRepo.On("FindRefreshToken", mock.Anything, oldHash).Return(&models.RefreshToken{UserID: 1, SessionID: 7, ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+			},
+			expectedErr: invalidErr,
+		},
+		{
+			name: "store error",
+			prepareMock: func() {
+				s.jwt.On("ParseRefreshToken", "old-token").Return(claims, nil)
+				s.authRepo.On("FindRefreshToken", mock.Anything, oldHash).Return(active, nil)
+				s.jwt.On("GenerateTokens", 1, "naff", "member").Return("access-token", "new-token", nil)
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.authRepo.On("RevokeRefreshToken", mock.Anything, oldHash).Return(true, nil)
+				s.authRepo.On("StoreRefreshToken", mock.Anything, 1, 7, newHash, isExpiry).Return(errors.New("database failure"))
+			},
+			expectedErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			tc.prepareMock()
+
+			// Actual
+			accessToken, refreshToken, err := s.Svc.Refresh(s.T().Context(), "old-token")
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+				s.Equal("access-token", accessToken)
+				s.Equal("new-token", refreshToken)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+				s.Empty(refreshToken)
+			}
+
+			s.authRepo.AssertExpectations(s.T())
+			s.jwt.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *AuthServiceTestSuite) TestGetSessions() {
+	lastUsed := time.Now()
+	sessions := []models.Session{
+		{Id: 7, UserID: 1, Device: "Firefox on Linux", IP: "10.0.0.1", LastUsedAt: lastUsed},
+		{Id: 3, UserID: 1, Device: "Safari on iOS", IP: "10.0.0.2", LastUsedAt: lastUsed.Add(-time.Hour)},
+	}
+
+	s.Run("marks the session of the request current", func() {
+		s.ResetMocks()
+		s.authRepo.On("FindRefreshTokenSession", mock.Anything, 1, utils.HashToken("refresh-token")).Return(3, nil)
+		s.authRepo.On("FindSessionsByUserID", mock.Anything, 1).Return(sessions, nil)
+
+		result, err := s.Svc.GetSessions(s.T().Context(), 1, "refresh-token")
+
+		s.NoError(err)
+		s.Require().Len(result, 2)
+		s.Equal(dto.Session{Id: 7, Device: "Firefox on Linux", IP: "10.0.0.1", LastUsedAt: lastUsed}, result[0])
+		s.True(result[1].Current)
+	})
+
+	s.Run("without refresh token no session is current", func() {
+		s.ResetMocks()
+		s.authRepo.On("FindSessionsByUserID", mock.Anything, 1).Return(nil, nil)
+
+		result, err := s.Svc.GetSessions(s.T().Context(), 1, "")
+
+		s.NoError(err)
+		s.NotNil(result)
+		s.Empty(result)
+		s.authRepo.AssertNotCalled(s.T(), "FindRefreshTokenSession", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func (s *AuthServiceTestSuite) TestRevokeSession() {
+	s.Run("success", func() {
+		s.ResetMocks()
+		s.authRepo.On("DeleteSession", mock.Anything, 1, 7).Return(true, nil)
+
+		s.NoError(s.Svc.RevokeSession(s.T().Context(), 1, 7))
+	})
+
+	s.Run("session of another user", func() {
+		s.ResetMocks()
+		s.authRepo.On("DeleteSession", mock.Anything, 1, 8).Return(false, nil)
+
+		s.EqualError(s.Svc.RevokeSession(s.T().Context(), 1, 8), errs.NewNotFoundError("Session", "id", 8).Error())
+	})
+}
+
+func (s *AuthServiceTestSuite) TestRevokeUserSessions() {
+	s.Run("success", func() {
+		s.ResetMocks()
+		s.userRepo.On("FindUserByUserID", mock.Anything, 2).Return(&models.User{Id: 2}, nil)
+		s.authRepo.On("DeleteUserSessions", mock.Anything, 2, "").Return(nil)
+
+		s.NoError(s.Svc.RevokeUserSessions(s.T().Context(), 2))
+		s.authRepo.AssertExpectations(s.T())
+	})
+
+	s.Run("user not found", func() {
+		s.ResetMocks()
+		s.userRepo.On("FindUserByUserID", mock.Anything, 2).Return(nil, nil)
+
+		s.EqualError(s.Svc.RevokeUserSessions(s.T().Context(), 2), errs.NewNotFoundError("User", "id", 2).Error())
+		s.authRepo.AssertNotCalled(s.T(), "DeleteUserSessions", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func (s *AuthServiceTestSuite) TestForgotPassword() {
+	testCases := []struct {
+		name        string
+		email       string
+		prepareMock func()
+		expectedErr error
+	}{
+		{
+			name:  "success queues the lookup",
+			email: "nobody@example.com",
+			prepareMock: func() {
+				s.jobs.On("Enqueue", mock.Anything, models.PasswordResetJob{Email: "nobody@example.com"}, 1).Return(nil)
+			},
+		},
+		{
+			name:        "invalid email",
+			email:       "not-an-email",
+			expectedErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name:        "email longer than an address can be",
+			email:       strings.Repeat("a", 247) + "@example.com",
+			expectedErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name:  "Enqueue error",
+			email: "user@example.com",
+			prepareMock: func() {
+				s.jobs.On("Enqueue", mock.Anything, models.PasswordResetJob{Email: "user@example.com"}, 1).Return(errors.New("database failure"))
+			},
+			expectedErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			s.limiter.On("Allow", mock.Anything, mock.Anything, mock.Anything).Return(ratelimit.Result{Allowed: true}, nil)
+			if tc.prepareMock != nil {
+				tc.prepareMock()
+			}
+
+			// Actual
+			err := s.Svc.ForgotPassword(s.T().Context(), dto.ForgotPasswordRequest{Email: tc.email})
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.jobs.AssertExpectations(s.T())
+			s.userRepo.AssertNotCalled(s.T(), "FindUserByEmail", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func (s *AuthServiceTestSuite) TestSendPasswordReset() {
+	user := &models.User{Id: 1, Email: "user@example.com"}
+	var storedHash string
+	storeReset := func(args mock.Arguments) {
+		storedHash = args.String(2)
+	}
+	// The emailed link carries the token whose hash was stored
+	isResetEmail := mock.MatchedBy(func(email mailer.Email) bool {
+		reset, ok := email.(mailer.PasswordReset)
+		if !ok || reset.ExpiresInMinutes != 30 {
+			return false
+		}
+		link, err := url.Parse(reset.URL)
+		return err == nil &&
+			strings.HasPrefix(reset.URL, "https://mulo.example.com/reset-password?") &&
+			link.Query().Get("lang") == "en" &&
+			utils.HashToken(link.Query().Get("token")) == storedHash
+	})
+	isExpiry := mock.MatchedBy(func(expiresAt time.Time) bool {
+		return time.Until(expiresAt) > 29*time.Minute && time.Until(expiresAt) <= 30*time.Minute
+	})
+
+	testCases := []struct {
+		name        string
+		email       string
+		prepareMock func()
+		expectedErr error
+	}{
+		{
+			name:  "success",
+			email: "user@example.com",
+			prepareMock: func() {
+				s.userRepo.On("FindUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.authRepo.On("StorePasswordReset", mock.Anything, 1, mock.Anything, isExpiry).Run(storeReset).Return(nil)
+				s.outbox.On("Queue", mock.Anything, "user@example.com", isResetEmail).Return(nil)
+			},
+		},
+		{
+			name:  "unknown email answers the same",
+			email: "nobody@example.com",
+			prepareMock: func() {
+				s.userRepo.On("FindUserByEmail", mock.Anything, "nobody@example.com").Return(nil, nil)
+			},
+		},
+		{
+			name:  "FindUserByEmail error",
+			email: "user@example.com",
+			prepareMock: func() {
+				s.userRepo.On("FindUserByEmail", mock.Anything, "user@example.com").Return(nil, errors.New("database failure"))
+			},
+			expectedErr: errors.New("database failure"),
+		},
+		{
+			name:  "Queue error",
+			email: "user@example.com",
+			prepareMock: func() {
+				s.userRepo.On("FindUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.authRepo.On("StorePasswordReset", mock.Anything, 1, mock.Anything, isExpiry).Return(nil)
+				s.outbox.On("Queue", mock.Anything, "user@example.com", mock.Anything).Return(errors.New("database failure"))
+			},
+			expectedErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			if tc.prepareMock != nil {
+				tc.prepareMock()
+			}
+
+			// Actual
+			err := s.Svc.SendPasswordReset(s.T().Context(), tc.email)
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.userRepo.AssertExpectations(s.T())
+			s.authRepo.AssertExpectations(s.T())
+			s.outbox.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *AuthServiceTestSuite) TestResetPassword() {
+	tokenHash := utils.HashToken("reset-token")
+	// Matchers run more than once, a bcrypt comparison each time would slow the suite down
+	isNewPassword := mock.MatchedBy(func(hash string) bool {
+		return strings.HasPrefix(hash, "$2a$")
+	})
+
+	testCases := []struct {
+		name        string
+		req         dto.ResetPasswordRequest
+		prepareMock func()
+		expectedErr error
+	}{
+		{
+			name: "success revokes refresh tokens",
+			req:  dto.ResetPasswordRequest{Token: "reset-token", Password: "new-password"},
+			prepareMock: func() {
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.authRepo.On("ConsumePasswordReset", mock.Anything, tokenHash).Return(1, nil)
+				s.authRepo.On("UpdateUserPassword", mock.Anything, 1, isNewPassword).Return(nil)
+				s.authRepo.On("DeleteUserSessions", mock.Anything, 1, "").Return(nil)
+			},
+		},
+		{
+			name: "unknown, used or expired token",
+			req:  dto.ResetPasswordRequest{Token: "reset-token", Password: "new-password"},
+			prepareMock: func() {
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.authRepo.On("ConsumePasswordReset", mock.Anything, tokenHash).Return(0, nil)
+			},
+			expectedErr: errs.NewBadRequestError("Reset token is invalid or has expired.", nil),
+		},
+		{
+			name:        "password too short",
+			req:         dto.ResetPasswordRequest{Token: "reset-token", Password: "short"},
+			expectedErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name: "RevokeUserRefreshTokens error",
+			req:  dto.ResetPasswordRequest{Token: "reset-token", Password: "new-password"},
+			prepareMock: func() {
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.authRepo.On("ConsumePasswordReset", mock.Anything, tokenHash).Return(1, nil)
+				s.authRepo.On("UpdateUserPassword", mock.Anything, 1, mock.Anything).Return(nil)
+				s.authRepo.On("DeleteUserSessions", mock.Anything, 1, "").Return(errors.New("database failure"))
+			},
+			expectedErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			if tc.prepareMock != nil {
+				tc.prepareMock()
+			}
+
+			// Actual
+			err := s.Svc.ResetPassword(s.T().Context(), tc.req)
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.authRepo.AssertExpectations(s.T())
+			s.tx.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *AuthServiceTestSuite) TestChangePassword() {
+	// bcrypt at the cost used by the service is slow, one hash serves every case
+	current := utils.HashPassword("current-password")
+	withPassword := &models.User{Id: 1, Password: sql.NullString{String: current, Valid: true}}
+	isNewPassword := mock.MatchedBy(func(hash string) bool {
+		return strings.HasPrefix(hash, "$2a$") && hash != current
+	})
+
+	testCases := []struct {
+		name        string
+		req         dto.ChangePasswordRequest
+		prepareMock func()
+		expectedErr error
+	}{
+		{
+			name: "success keeps the current session",
+			req:  dto.ChangePasswordRequest{CurrentPassword: "current-password", NewPassword: "new-password"},
+			prepareMock: func() {
+				s.userRepo.On("FindUserByUserID", mock.Anything, 1).Return(withPassword, nil)
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.authRepo.On("UpdateUserPassword", mock.Anything, 1, isNewPassword).Return(nil)
+				s.authRepo.On("DeleteUserSessions", mock.Anything, 1, utils.HashToken("refresh-token")).Return(nil)
+			},
+		},
+		{
+			name: "oauth user adds a first password",
+			req:  dto.ChangePasswordRequest{NewPassword: "new-password"},
+			prepareMock: func() {
+				s.userRepo.On("FindUserByUserID", mock.Anything, 1).Return(&models.User{Id: 1}, nil)
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.authRepo.On("UpdateUserPassword", mock.Anything, 1, isNewPassword).Return(nil)
+				s.authRepo.On("DeleteUserSessions", mock.Anything, 1, utils.HashToken("refresh-token")).Return(nil)
+			},
+		},
+		{
+			name: "wrong current password",
+			req:  dto.ChangePasswordRequest{CurrentPassword: "guess", NewPassword: "new-password"},
+			prepareMock: func() {
+				s.userRepo.On("FindUserByUserID", mock.Anything, 1).Return(withPassword, nil)
+			},
+			expectedErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name: "missing current password",
+			req:  dto.ChangePasswordRequest{NewPassword: "new-password"},
+			prepareMock: func() {
+				s.userRepo.On("FindUserByUserID", mock.Anything, 1).Return(withPassword, nil)
+			},
+			expectedErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name:        "new password too short",
+			req:         dto.ChangePasswordRequest{CurrentPassword: "current-password", NewPassword: "short"},
+			expectedErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name: "user not found",
+			req:  dto.ChangePasswordRequest{NewPassword: "new-password"},
+			prepareMock: func() {
+				s.userRepo.On("FindUserByUserID", mock.Anything, 1).Return(nil, nil)
+			},
+			expectedErr: errs.NewNotFoundError("User", "id", 1),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			s.limiter.On("Allow", mock.Anything, mock.Anything, mock.Anything).Return(ratelimit.Result{Allowed: true}, nil)
+			if tc.prepareMock != nil {
+				tc.prepareMock()
+			}
+
+			// Actual
+			err := s.Svc.ChangePassword(s.T().Context(), 1, "refresh-token", tc.req)
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.userRepo.AssertExpectations(s.T())
+			s.authRepo.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *AuthServiceTestSuite) TestChangeEmail() {
+	user := &models.User{Id: 1, Email: "old@example.com"}
+
+	testCases := []struct {
+		name        string
+		email       string
+		prepareMock func()
+		expectedErr error
+	}{
+		{
+			name:  "success sends the code to the new address",
+			email: "new@example.com",
+			prepareMock: func() {
+				s.userRepo.On("FindUserByUserID", mock.Anything, 1).Return(user, nil)
+				s.userRepo.On("FindUserExistsByEmail", mock.Anything, "new@example.com").Return(false, nil)
+				s.verification.On("GenerateVerificationCode", mock.Anything).Return("12345", nil)
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.authRepo.On("StoreEmailChange", mock.Anything, 1, "new@example.com", utils.HashToken("12345")).Return(nil)
+				s.outbox.On("Queue", mock.Anything, "new@example.com", mailer.EmailChange{Code: "12345"}).Return(nil)
+			},
+		},
+		{
+			name:  "same email",
+			email: "OLD@example.com",
+			prepareMock: func() {
+				s.userRepo.On("FindUserByUserID", mock.Anything, 1).Return(user, nil)
+			},
+			expectedErr: errs.NewConflictErrorWithMsg("This is already your email."),
+		},
+		{
+			name:  "email taken",
+			email: "taken@example.com",
+			prepareMock: func() {
+				s.userRepo.On("FindUserByUserID", mock.Anything, 1).Return(user, nil)
+				s.userRepo.On("FindUserExistsByEmail", mock.Anything, "taken@example.com").Return(true, nil)
+			},
+			expectedErr: errs.NewConflictError("User", "email", "taken@example.com"),
+		},
+		{
+			name:        "invalid email",
+			email:       "not-an-email",
+			expectedErr: errs.NewBadRequestError("validation failed", nil),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			s.limiter.On("Allow", mock.Anything, mock.Anything, mock.Anything).Return(ratelimit.Result{Allowed: true}, nil)
+			if tc.prepareMock != nil {
+				tc.prepareMock()
+			}
+
+			// Actual
+			err := s.Svc.ChangeEmail(s.T().Context(), 1, dto.ChangeEmailRequest{Email: tc.email})
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.userRepo.AssertExpectations(s.T())
+			s.authRepo.AssertExpectations(s.T())
+			s.outbox.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *AuthServiceTestSuite) TestConfirmEmailChange() {
+	codeHash := utils.HashToken("12345")
+	pending := &models.EmailChange{Email: "new@example.com", CodeHash: codeHash, ExpiredAt: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}}
+	expired := &models.EmailChange{Email: "new@example.com", CodeHash: codeHash, ExpiredAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}}
+	otherCode := &models.EmailChange{Email: "new@example.com", CodeHash: utils.HashToken("54321"), ExpiredAt: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}}
+
+	testCases := []struct {
+		name        string
+		prepareMock func()
+		expectedErr error
+	}{
+		{
+			name: "success",
+			prepareMock: func() {
+				s.authRepo.On("FindEmailChange", mock.Anything, 1).Return(pending, nil)
+				s.userRepo.On("FindUserExistsByEmail", mock.Anything, "new@example.com").Return(false, nil)
+				s.authRepo.On("UpdateUserEmail", mock.Anything, 1, "new@example.com").Return(nil)
+			},
+		},
+		{
+			name: "no pending change",
+			prepareMock: func() {
+				s.authRepo.On("FindEmailChange", mock.Anything, 1).Return(nil, nil)
+			},
+			expectedErr: errs.NewNotFoundError("Verification", "code", "12345"),
+		},
+		{
+			name: "wrong code is counted",
+			prepareMock: func() {
+				s.authRepo.On("FindEmailChange", mock.Anything, 1).Return(otherCode, nil)
+				s.authRepo.On("FailEmailChange", mock.Anything, 1, maxEmailChangeAttempts).Return(nil)
+			},
+			expectedErr: errs.NewNotFoundError("Verification", "code", "12345"),
+		},
+		{
+			name: "FailEmailChange error",
+			prepareMock: func() {
+				s.authRepo.On("FindEmailChange", mock.Anything, 1).Return(otherCode, nil)
+				s.authRepo.On("FailEmailChange", mock.Anything, 1, maxEmailChangeAttempts).Return(errors.New("database failure"))
+			},
+			expectedErr: errors.New("database failure"),
+		},
+		{
+			name: "expired code",
+			prepareMock: func() {
+				s.authRepo.On("FindEmailChange", mock.Anything, 1).Return(expired, nil)
+			},
+			expectedErr: errs.NewGoneError("Verification Code", "code", "12345"),
+		},
+		{
+			name: "email taken meanwhile",
+			prepareMock: func() {
+				s.authRepo.On("FindEmailChange", mock.Anything, 1).Return(pending, nil)
+				s.userRepo.On("FindUserExistsByEmail", mock.Anything, "new@example.com").Return(true, nil)
+			},
+			expectedErr: errs.NewConflictError("User", "email", "new@example.com"),
+		},
+		{
+			name: "email taken by a racing registration",
+			prepareMock: func() {
+				s.authRepo.On("FindEmailChange", mock.Anything, 1).Return(pending, nil)
+				s.userRepo.On("FindUserExistsByEmail", mock.Anything, "new@example.com").Return(false, nil)
+				s.authRepo.On("UpdateUserEmail", mock.Anything, 1, "new@example.com").Return(errs.NewConflictError("User", "email", "new@example.com", errors.New("unique violation")))
+			},
+			expectedErr: errs.NewConflictError("User", "email", "new@example.com"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			s.limiter.On("Allow", mock.Anything, mock.Anything, mock.Anything).Return(ratelimit.Result{Allowed: true}, nil)
+			tc.prepareMock()
+
+			// Actual
+			err := s.Svc.ConfirmEmailChange(s.T().Context(), 1, dto.ConfirmEmailChangeRequest{Code: "12345"})
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.userRepo.AssertExpectations(s.T())
+			s.authRepo.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *AuthServiceTestSuite) TestOAuthAuthorize() {
+	s.Run("success stores the state with a PKCE verifier", func() {
+		s.ResetMocks()
+		s.oauth.On("Provider", "google").Return(s.provider, nil)
+		s.limiter.On("Allow", mock.Anything, oauthIPRule, mock.Anything).Return(ratelimit.Result{Allowed: true}, nil)
+		s.authRepo.On("StoreOAuthState", mock.Anything, mock.Anything, mock.MatchedBy(func(state models.OAuthState) bool {
+			return state.Provider == "google" && len(state.CodeVerifier) == 43 && time.Until(state.ExpiresAt) > 9*time.Minute
+		})).Return(nil)
+		s.provider.On("AuthCodeURL", mock.Anything, mock.Anything, mock.Anything).Return("https://accounts.example.com/auth", nil)
+
+		authURL, state, err := s.Svc.OAuthAuthorize(s.T().Context(), "google")
+		s.Require().NoError(err)
+		s.Equal("https://accounts.example.com/auth", authURL)
+		s.NotEmpty(state)
+
+		// Only the hash of the state is stored, the provider gets the challenge of the stored verifier
+		stored := s.authRepo.Calls[0].Arguments
+		s.Equal(utils.HashToken(state), stored.String(1))
+		authorize := s.provider.Calls[0].Arguments
+		s.Equal(state, authorize.String(1))
+		s.Equal(oauth.CodeChallenge(stored.Get(2).(models.OAuthState).CodeVerifier), authorize.String(2))
+	})
+
+	s.Run("provider not configured", func() {
+		s.ResetMocks()
+		s.oauth.On("Provider", "gitlab").Return(nil, oauth.ErrUnknownProvider)
+
+		_, _, err := s.Svc.OAuthAuthorize(s.T().Context(), "gitlab")
+		s.EqualError(err, "OAuth provider 'gitlab' is not configured.")
+	})
+
+	s.Run("client over the limit", func() {
+		s.ResetMocks()
+		s.oauth.On("Provider", "google").Return(s.provider, nil)
+		s.limiter.On("Allow", mock.Anything, oauthIPRule, mock.Anything).Return(ratelimit.Result{RetryAfter: time.Minute}, nil)
+
+		_, _, err := s.Svc.OAuthAuthorize(s.T().Context(), "google")
+		s.EqualError(err, "Too many attempts. Please try again later.")
+		s.authRepo.AssertNotCalled(s.T(), "StoreOAuthState", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func (s *AuthServiceTestSuite) TestOAuthProviderCallback() {
+	token := &oauth.Token{AccessToken: "provider-token", IDToken: "id-token"}
+	identity := func(verified bool) *oauth.Identity {
+		return &oauth.Identity{
+			Provider:      "google",
+			Subject:       "g-42",
+			Email:         "naff@example.com",
+			EmailVerified: verified,
+			Name:          "Naff",
+			Username:      "naff",
+			AvatarURL:     "https://example.com/naff.png",
+		}
+	}
+	existing := &models.User{Id: 1, Email: "naff@example.com", Username: sql.NullString{String: "naffy", Valid: true}, Role: "admin"}
+	signIn := func(userID int, username, role string) {
+		s.jwt.On("GenerateTokens", userID, username, role).Return("access-token", "refresh-token", nil)
+		s.tx.On("WithinTx", mock.Anything).Return()
+		s.authRepo.On("StoreSession", mock.Anything, mock.Anything).Return(3, nil)
+		s.authRepo.On("StoreRefreshToken", mock.Anything, userID, 3, utils.HashToken("refresh-token"), mock.Anything).Return(nil)
+	}
+	stateHash := utils.HashToken("state")
+	started := &models.OAuthState{Provider: "google", CodeVerifier: "verifier", ExpiresAt: time.Now().Add(time.Minute)}
+	consume := func(state *models.OAuthState) {
+		s.oauth.On("Provider", "google").Return(s.provider, nil)
+		s.authRepo.On("ConsumeOAuthState", mock.Anything, stateHash).Return(state, nil)
+	}
+	// The test requests have no user agent, a session without one is the same device
+	newDevice := func() {
+		s.authRepo.On("FindSessionsByUserID", mock.Anything, 1).Return([]models.Session{{UserAgent: "Firefox"}}, nil)
+		s.outbox.On("Queue", mock.Anything, "naff@example.com", mock.AnythingOfType("mailer.NewLogin")).Return(nil)
+	}
+	exchange := func(id *oauth.Identity) {
+		consume(started)
+		s.provider.On("Exchange", mock.Anything, "code", "verifier").Return(token, nil)
+		s.provider.On("Identity", mock.Anything, token).Return(id, nil)
+	}
+
+	invalidState := errs.NewUnauthorizedError("Invalid or expired sign in, please start again.")
+
+	testCases := []struct {
+		name        string
+		stateCookie string
+		prepareMock func()
+		expectedErr error
+	}{
+		{
+			name: "linked account signs in its user with their role",
+			prepareMock: func() {
+				exchange(identity(true))
+				s.authRepo.On("FindOAuthAccount", mock.Anything, "google", "g-42").Return(&models.OAuthAccount{UserID: 1}, nil)
+				s.userRepo.On("FindUserByUserID", mock.Anything, 1).Return(existing, nil)
+				newDevice()
+				signIn(1, "naffy", "admin")
+			},
+		},
+		{
+			name: "sign in from a known device sends no alert",
+			prepareMock: func() {
+				exchange(identity(true))
+				s.authRepo.On("FindOAuthAccount", mock.Anything, "google", "g-42").Return(&models.OAuthAccount{UserID: 1}, nil)
+				s.userRepo.On("FindUserByUserID", mock.Anything, 1).Return(existing, nil)
+				s.authRepo.On("FindSessionsByUserID", mock.Anything, 1).Return([]models.Session{{UserAgent: ""}}, nil)
+				signIn(1, "naffy", "admin")
+			},
+		},
+		{
+			name: "first sign in of a user sends no alert",
+			prepareMock: func() {
+				exchange(identity(true))
+				s.authRepo.On("FindOAuthAccount", mock.Anything, "google", "g-42").Return(&models.OAuthAccount{UserID: 1}, nil)
+				s.userRepo.On("FindUserByUserID", mock.Anything, 1).Return(existing, nil)
+				s.authRepo.On("FindSessionsByUserID", mock.Anything, 1).Return(nil, nil)
+				signIn(1, "naffy", "admin")
+			},
+		},
+		{
+			name: "user with the email gets the account linked",
+			prepareMock: func() {
+				exchange(identity(true))
+				s.authRepo.On("FindOAuthAccount", mock.Anything, "google", "g-42").Return(nil, nil)
+				s.userRepo.On("FindUserByEmail", mock.Anything, "naff@example.com").Return(existing, nil)
+				s.authRepo.On("StoreOAuthAccount", mock.Anything, 1, "google", "g-42").Return(nil)
+				newDevice()
+				signIn(1, "naffy", "admin")
+			},
+		},
+		{
+			name: "new user is registered with the avatar and its blur hash",
+			prepareMock: func() {
+				exchange(identity(true))
+				s.authRepo.On("FindOAuthAccount", mock.Anything, "google", "g-42").Return(nil, nil)
+				s.userRepo.On("FindUserByEmail", mock.Anything, "naff@example.com").Return(nil, nil)
+				avatar := dto.Image{Src: "https://example.com/naff.png", BlurHash: "LEHV6nWB2yk8pyo0adR*.7kCMdnj", Width: 460, Height: 460}
+				s.userRepo.On("FindUserExistsByUsername", mock.Anything, "naff").Return(false, nil)
+				s.images.On("RemoteImage", mock.Anything, "https://example.com/naff.png").Return(avatar, nil)
+				s.authRepo.On("StoreUserWithOAuthAccount", mock.Anything, mock.MatchedBy(func(input models.OAuthAccountInput) bool {
+					return input.ID == "g-42" && input.Provider == "google" && input.Username == "naff" && input.Email == "naff@example.com" &&
+						string(input.Image) == string(utils.ParseImageToByte(&avatar))
+				})).Return(2, nil)
+				signIn(2, "naff", "member")
+			},
+		},
+		{
+			name: "new user is registered without an avatar that does not load",
+			prepareMock: func() {
+				exchange(identity(true))
+				s.authRepo.On("FindOAuthAccount", mock.Anything, "google", "g-42").Return(nil, nil)
+				s.userRepo.On("FindUserByEmail", mock.Anything, "naff@example.com").Return(nil, nil)
+				s.userRepo.On("FindUserExistsByUsername", mock.Anything, "naff").Return(false, nil)
+				s.images.On("RemoteImage", mock.Anything, "https://example.com/naff.png").Return(dto.Image{}, errors.New("unexpected status 404"))
+				s.authRepo.On("StoreUserWithOAuthAccount", mock.Anything, mock.MatchedBy(func(input models.OAuthAccountInput) bool {
+					return input.ID == "g-42" && input.Image == nil
+				})).Return(2, nil)
+				signIn(2, "naff", "member")
+			},
+		},
+		{
+			name: "new user whose username is taken gets a numbered one",
+			prepareMock: func() {
+				exchange(identity(true))
+				s.authRepo.On("FindOAuthAccount", mock.Anything, "google", "g-42").Return(nil, nil)
+				s.userRepo.On("FindUserByEmail", mock.Anything, "naff@example.com").Return(nil, nil)
+				s.userRepo.On("FindUserExistsByUsername", mock.Anything, "naff").Return(true, nil)
+				s.userRepo.On("FindUserExistsByUsername", mock.Anything, "naff2").Return(false, nil)
+				s.images.On("RemoteImage", mock.Anything, mock.Anything).Return(dto.Image{}, errors.New("unexpected status 404"))
+				s.authRepo.On("StoreUserWithOAuthAccount", mock.Anything, mock.MatchedBy(func(input models.OAuthAccountInput) bool {
+					return input.Username == "naff2"
+				})).Return(2, nil)
+				signIn(2, "naff2", "member")
+			},
+		},
+		{
+			name: "new user username is cut to the column length",
+			prepareMock: func() {
+				long := identity(true)
+				long.Username = strings.Repeat("n", 150)
+				exchange(long)
+				s.authRepo.On("FindOAuthAccount", mock.Anything, "google", "g-42").Return(nil, nil)
+				s.userRepo.On("FindUserByEmail", mock.Anything, "naff@example.com").Return(nil, nil)
+				s.userRepo.On("FindUserExistsByUsername", mock.Anything, strings.Repeat("n", 100)).Return(true, nil)
+				s.userRepo.On("FindUserExistsByUsername", mock.Anything, strings.Repeat("n", 99)+"2").Return(false, nil)
+				s.images.On("RemoteImage", mock.Anything, mock.Anything).Return(dto.Image{}, errors.New("unexpected status 404"))
+				s.authRepo.On("StoreUserWithOAuthAccount", mock.Anything, mock.Anything).Return(2, nil)
+				signIn(2, strings.Repeat("n", 99)+"2", "member")
+			},
+		},
+		{
+			name: "new user without a free username is registered without one",
+			prepareMock: func() {
+				exchange(identity(true))
+				s.authRepo.On("FindOAuthAccount", mock.Anything, "google", "g-42").Return(nil, nil)
+				s.userRepo.On("FindUserByEmail", mock.Anything, "naff@example.com").Return(nil, nil)
+				s.userRepo.On("FindUserExistsByUsername", mock.Anything, mock.Anything).Return(true, nil).Times(usernameCandidates)
+				s.images.On("RemoteImage", mock.Anything, mock.Anything).Return(dto.Image{}, errors.New("unexpected status 404"))
+				s.authRepo.On("StoreUserWithOAuthAccount", mock.Anything, mock.MatchedBy(func(input models.OAuthAccountInput) bool {
+					return input.Username == ""
+				})).Return(2, nil)
+				signIn(2, "", "member")
+			},
+		},
+		{
+			name: "unverified email is refused",
+			prepareMock: func() {
+				exchange(identity(false))
+			},
+			expectedErr: errs.NewForbiddenError("Your google account has no verified email."),
+		},
+		{
+			name: "code refused by the provider",
+			prepareMock: func() {
+				consume(started)
+				s.provider.On("Exchange", mock.Anything, "code", "verifier").Return(nil, oauth.ErrInvalidGrant)
+			},
+			expectedErr: errs.NewUnauthorizedError("Bad credentials."),
+		},
+		{
+			name:        "state of another browser",
+			stateCookie: "other-state",
+			prepareMock: func() {
+				s.oauth.On("Provider", "google").Return(s.provider, nil)
+			},
+			expectedErr: invalidState,
+		},
+		{
+			name: "unknown or used state",
+			prepareMock: func() {
+				consume(nil)
+			},
+			expectedErr: invalidState,
+		},
+		{
+			name: "expired state",
+			prepareMock: func() {
+				consume(&models.OAuthState{Provider: "google", CodeVerifier: "verifier", ExpiresAt: time.Now().Add(-time.Second)})
+			},
+			expectedErr: invalidState,
+		},
+		{
+			name: "state started for another provider",
+			prepareMock: func() {
+				consume(&models.OAuthState{Provider: "github", CodeVerifier: "verifier", ExpiresAt: time.Now().Add(time.Minute)})
+			},
+			expectedErr: invalidState,
+		},
+		{
+			name: "provider not configured",
+			prepareMock: func() {
+				s.oauth.On("Provider", "google").Return(nil, oauth.ErrUnknownProvider)
+			},
+			expectedErr: errs.NewNotFoundErrorWithMsg("OAuth provider 'google' is not configured."),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			tc.prepareMock()
+
+			stateCookie := tc.stateCookie
+			if stateCookie == "" {
+				stateCookie = "state"
+			}
+
+			// Actual
+			accessToken, refreshToken, err := s.Svc.OAuthProviderCallback(s.T().Context(), "google", dto.OAuthCodeRequest{Code: "code", State: "state"}, stateCookie)
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+				s.Equal("access-token", accessToken)
+				s.Equal("refresh-token", refreshToken)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.provider.AssertExpectations(s.T())
+			s.userRepo.AssertExpectations(s.T())
+			s.authRepo.AssertExpectations(s.T())
+			s.images.AssertExpectations(s.T())
+			s.outbox.AssertExpectations(s.T())
+			s.jwt.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *AuthServiceTestSuite) TestOAuthCallback() {
+	token := &oauth.Token{IDToken: "id-token"}
+	verify := func(identity *oauth.Identity, err error) {
+		s.oauth.On("Provider", "google").Return(s.provider, nil)
+		s.limiter.On("Allow", mock.Anything, oauthIPRule, mock.Anything).Return(ratelimit.Result{Allowed: true}, nil)
+		s.provider.On("VerifyToken", mock.Anything, token).Return(identity, err)
+	}
+
+	testCases := []struct {
+		name        string
+		req         dto.OAuthRequest
+		prepareMock func()
+		expectedErr error
+	}{
+		{
+			name: "verified token signs in the user of the provider",
+			req:  dto.OAuthRequest{Provider: "google", IDToken: "id-token"},
+			prepareMock: func() {
+				verify(&oauth.Identity{Provider: "google", Subject: "g-42", Email: "naff@example.com", EmailVerified: true}, nil)
+				s.authRepo.On("FindOAuthAccount", mock.Anything, "google", "g-42").Return(&models.OAuthAccount{UserID: 1}, nil)
+				s.userRepo.On("FindUserByUserID", mock.Anything, 1).Return(&models.User{Id: 1, Username: sql.NullString{String: "naffy", Valid: true}, Role: "member"}, nil)
+				s.jwt.On("GenerateTokens", 1, "naffy", "member").Return("access-token", "refresh-token", nil)
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.authRepo.On("StoreSession", mock.Anything, mock.Anything).Return(3, nil)
+				s.authRepo.On("StoreRefreshToken", mock.Anything, 1, 3, utils.HashToken("refresh-token"), mock.Anything).Return(nil)
+			},
+		},
+		{
+			name: "forged or foreign token is refused",
+			req:  dto.OAuthRequest{Provider: "google", IDToken: "id-token"},
+			prepareMock: func() {
+				verify(nil, fmt.Errorf("%w: google id token is issued to %q", oauth.ErrInvalidToken, "other-app"))
+			},
+			expectedErr: errs.NewUnauthorizedError("Invalid provider token."),
+		},
+		{
+			name: "provider unreachable",
+			req:  dto.OAuthRequest{Provider: "google", IDToken: "id-token"},
+			prepareMock: func() {
+				verify(nil, errors.New("connection refused"))
+			},
+			expectedErr: errors.New("connection refused"),
+		},
+		{
+			name: "unverified email is refused",
+			req:  dto.OAuthRequest{Provider: "google", IDToken: "id-token"},
+			prepareMock: func() {
+				verify(&oauth.Identity{Provider: "google", Subject: "g-42", Email: "naff@example.com"}, nil)
+			},
+			expectedErr: errs.NewForbiddenError("Your google account has no verified email."),
+		},
+		{
+			name:        "no provider token",
+			req:         dto.OAuthRequest{Provider: "google"},
+			prepareMock: func() {},
+			expectedErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name: "provider not configured",
+			req:  dto.OAuthRequest{Provider: "google", IDToken: "id-token"},
+			prepareMock: func() {
+				s.oauth.On("Provider", "google").Return(nil, oauth.ErrUnknownProvider)
+			},
+			expectedErr: errs.NewNotFoundErrorWithMsg("OAuth provider 'google' is not configured."),
+		},
+		{
+			name: "client over the limit",
+			req:  dto.OAuthRequest{Provider: "google", IDToken: "id-token"},
+			prepareMock: func() {
+				s.oauth.On("Provider", "google").Return(s.provider, nil)
+				s.limiter.On("Allow", mock.Anything, oauthIPRule, mock.Anything).Return(ratelimit.Result{RetryAfter: time.Minute}, nil)
+			},
+			expectedErr: errs.NewTooManyRequestsError("Too many attempts. Please try again later.", time.Minute),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			tc.prepareMock()
+
+			// Actual
+			accessToken, refreshToken, err := s.Svc.OAuthCallback(s.T().Context(), tc.req)
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+				s.Equal("access-token", accessToken)
+				s.Equal("refresh-token", refreshToken)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.provider.AssertExpectations(s.T())
+			s.userRepo.AssertExpectations(s.T())
+			s.authRepo.AssertExpectations(s.T())
+			s.jwt.AssertExpectations(s.T())
+		})
+	}
+}
+
+func TestAuthServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthServiceTestSuite))
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type chartService struct {
+	repo      contracts.ChartRepository
+	genreRepo contracts.GenreRepository
+	log       *logrus.Logger
+}
+
+func NewChartService(repo contracts.ChartRepository, genreRepo contracts.GenreRepository, log *logrus.Logger) contracts.ChartService {
+	return &chartService{
+		repo:      repo,
+		genreRepo: genreRepo,
+		log:       log,
+	}
+}
+
+// chartFilter validates the chart request and makes sure the genre exists, window defaults to week.
+func (svc *chartService) chartFilter(ctx context.Context, req dto.ChartRequest, operation string) (filter models.ChartFilter, err error) {
+	if errorsMap, err := utils.RequestValidate(&req); err != nil {
+		return filter, errs.NewBadRequestError("validation failed", errorsMap)
+	}
+
+	filter = models.ChartFilter{Window: req.Window, GenreId: req.GenreId}
+	if filter.Window == "" {
+		filter.Window = models.ChartWindowWeek
+	}
+
+	if filter.GenreId > 0 {
+		exists, err := svc.genreRepo.FindExistsGenreById(ctx, filter.GenreId)
+		if err != nil {
+			utils.LogError(svc.log, ctx, "chart_service", operation, err)
+			return filter, err
+		}
+		if !exists {
+			notFoundErr := errs.NewNotFoundError("Genre", "id", filter.GenreId)
+			utils.LogWarn(svc.log, ctx, "chart_service", operation, notFoundErr)
+			return filter, notFoundErr
+		}
+	}
+
+	return filter, nil
+}
+
+func (svc *chartService) GetSongCharts(ctx context.Context, req dto.ChartRequest, pageSize, offset int) (songs []dto.ChartSong, total int, err error) {
+	filter, err := svc.chartFilter(ctx, req, "GetSongCharts")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err = svc.repo.FindCountSongs(ctx, filter)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "chart_service", "GetSongCharts", err)
+		return nil, 0, err
+	}
+
+	results, err := svc.repo.FindSongs(ctx, filter, pageSize, offset)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "chart_service", "GetSongCharts", err)
+		return nil, 0, err
+	}
+
+	songs = make([]dto.ChartSong, 0, len(results))
+	for _, result := range results {
+		songs = append(songs, dto.ChartSong{
+			Rank:      result.Rank,
+			PlayCount: result.PlayCount,
+			Song:      newSongDTO(result.Song),
+		})
+	}
+
+	return songs, total, nil
+}
+
+func (svc *chartService) GetArtistCharts(ctx context.Context, req dto.ChartRequest, pageSize, offset int) (artists []dto.ChartArtist, total int, err error) {
+	filter, err := svc.chartFilter(ctx, req, "GetArtistCharts")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err = svc.repo.FindCountArtists(ctx, filter)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "chart_service", "GetArtistCharts", err)
+		return nil, 0, err
+	}
+
+	results, err := svc.repo.FindArtists(ctx, filter, pageSize, offset)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "chart_service", "GetArtistCharts", err)
+		return nil, 0, err
+	}
+
+	artists = make([]dto.ChartArtist, 0, len(results))
+	for _, result := range results {
+		artists = append(artists, dto.ChartArtist{
+			Rank:      result.Rank,
+			PlayCount: result.PlayCount,
+			Artist: dto.Artist{
+				Id:    result.Artist.Id,
+				Name:  result.Artist.Name,
+				Slug:  result.Artist.Slug,
+				Image: utils.ParseImageToJSON(result.Artist.Image),
+			},
+		})
+	}
+
+	return artists, total, nil
+}
+
+func (svc *chartService) GetAlbumCharts(ctx context.Context, req dto.ChartRequest, pageSize, offset int) (albums []dto.ChartAlbum, total int, err error) {
+	filter, err := svc.chartFilter(ctx, req, "GetAlbumCharts")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err = svc.repo.FindCountAlbums(ctx, filter)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "chart_service", "GetAlbumCharts", err)
+		return nil, 0, err
+	}
+
+	results, err := svc.repo.FindAlbums(ctx, filter, pageSize, offset)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "chart_service", "GetAlbumCharts", err)
+		return nil, 0, err
+	}
+
+	albums = make([]dto.ChartAlbum, 0, len(results))
+	for _, result := range results {
+		albums = append(albums, dto.ChartAlbum{
+			Rank:      result.Rank,
+			PlayCount: result.PlayCount,
+			Album: dto.AlbumWithArtist{
+				Album: dto.Album{
+					Id:    result.Album.Id,
+					Name:  result.Album.Name,
+					Slug:  result.Album.Slug,
+					Image: utils.ParseImageToJSON(result.Album.Image),
+				},
+				Artist: dto.Artist{
+					Id:    result.Album.Artist.Id,
+					Name:  result.Album.Artist.Name,
+					Slug:  result.Album.Artist.Slug,
+					Image: utils.ParseImageToJSON(result.Album.Artist.Image),
+				},
+			},
+		})
+	}
+
+	return albums, total, nil
+}
+
+func (svc *chartService) RefreshCharts(ctx context.Context) (err error) {
+	if err = svc.repo.Refresh(ctx); err != nil {
+		utils.LogError(svc.log, ctx, "chart_service", "RefreshCharts", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/mocks"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type ChartServiceTestSuite struct {
+	suite.Suite
+	Svc       contracts.ChartService
+	chartRepo *mocks.MockChartRepository
+	genreRepo *mocks.MockGenreRepository
+}
+
+func (s *ChartServiceTestSuite) SetupTest() {
+	s.chartRepo = new(mocks.MockChartRepository)
+	s.genreRepo = new(mocks.MockGenreRepository)
+	s.Svc = NewChartService(s.chartRepo, s.genreRepo, nil)
+}
+
+func (s *ChartServiceTestSuite) ResetMocks() {
+	s.chartRepo.ExpectedCalls = nil
+	s.chartRepo.Calls = nil
+	s.genreRepo.ExpectedCalls = nil
+	s.genreRepo.Calls = nil
+}
+
+func (s *ChartServiceTestSuite) TestGetSongCharts() {
+	image := dto.Image{Src: "image.png", BlurHash: "abc"}
+	imageBytes := utils.ParseImageToByte(&image)
+
+	song := models.Song{
+		Id:       1,
+		AlbumId:  1,
+		Title:    "Aku pulang",
+		Audio:    "aku.mp3",
+		Duration: 352,
+		Image:    imageBytes,
+		Album: models.AlbumWithArtist{
+			Album:  models.Album{Id: 1, Name: "Album aku pulang", Slug: "album-aku-pulang", Image: imageBytes},
+			Artist: models.Artist{Id: 1, Name: "Noah", Slug: "noah", Image: imageBytes},
+		},
+	}
+	expectSong := dto.Song{
+		Id:       1,
+		Title:    "Aku pulang",
+		Audio:    "aku.mp3",
+		Duration: 352,
+		Image:    image,
+		Album: dto.AlbumWithArtist{
+			Album:  dto.Album{Id: 1, Name: "Album aku pulang", Slug: "album-aku-pulang", Image: image},
+			Artist: dto.Artist{Id: 1, Name: "Noah", Slug: "noah", Image: image},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		req           dto.ChartRequest
+		prepareMock   func()
+		expectResults []dto.ChartSong
+		expectTotal   int
+		expectErr     error
+	}{
+		{
+			name: "default window is week",
+			req:  dto.ChartRequest{},
+			prepareMock: func() {
+				filter := models.ChartFilter{Window: models.ChartWindowWeek}
+				s.chartRepo.On("FindCountSongs", mock.Anything, filter).Return(1, nil)
+				s.chartRepo.On("FindSongs", mock.Anything, filter, 10, 0).Return([]models.ChartSong{
+					{Rank: 1, PlayCount: 42, Song: song},
+				}, nil)
+			},
+			expectResults: []dto.ChartSong{{Rank: 1, PlayCount: 42, Song: expectSong}},
+			expectTotal:   1,
+		},
+		{
+			name: "filtered by genre",
+			req:  dto.ChartRequest{Window: "day", GenreId: 2},
+			prepareMock: func() {
+				filter := models.ChartFilter{Window: models.ChartWindowDay, GenreId: 2}
+				s.genreRepo.On("FindExistsGenreById", mock.Anything, 2).Return(true, nil)
+				s.chartRepo.On("FindCountSongs", mock.Anything, filter).Return(0, nil)
+				s.chartRepo.On("FindSongs", mock.Anything, filter, 10, 0).Return(nil, nil)
+			},
+			expectResults: []dto.ChartSong{},
+		},
+		{
+			name:      "invalid window",
+			req:       dto.ChartRequest{Window: "year"},
+			expectErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name: "genre not found",
+			req:  dto.ChartRequest{GenreId: 99},
+			prepareMock: func() {
+				s.genreRepo.On("FindExistsGenreById", mock.Anything, 99).Return(false, nil)
+			},
+			expectErr: errs.NewNotFoundError("Genre", "id", 99),
+		},
+		{
+			name: "FindCountSongs error",
+			req:  dto.ChartRequest{Window: "all"},
+			prepareMock: func() {
+				s.chartRepo.On("FindCountSongs", mock.Anything, models.ChartFilter{Window: models.ChartWindowAll}).Return(0, errors.New("database failure"))
+			},
+			expectErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			if tc.prepareMock != nil {
+				tc.prepareMock()
+			}
+
+			// Actual
+			results, total, err := s.Svc.GetSongCharts(s.T().Context(), tc.req, 10, 0)
+
+			// Assert
+			if tc.expectErr == nil {
+				s.NoError(err)
+				s.Equal(tc.expectTotal, total)
+				s.Equal(tc.expectResults, results)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectErr.Error())
+			}
+
+			s.chartRepo.AssertExpectations(s.T())
+			s.genreRepo.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *ChartServiceTestSuite) TestGetArtistAndAlbumCharts() {
+	image := dto.Image{Src: "image.png", BlurHash: "abc"}
+	imageBytes := utils.ParseImageToByte(&image)
+	filter := models.ChartFilter{Window: models.ChartWindowMonth}
+	artist := models.Artist{Id: 1, Name: "Noah", Slug: "noah", Image: imageBytes}
+	expectArtist := dto.Artist{Id: 1, Name: "Noah", Slug: "noah", Image: image}
+
+	s.Run("artists", func() {
+		s.ResetMocks()
+		s.chartRepo.On("FindCountArtists", mock.Anything, filter).Return(1, nil)
+		s.chartRepo.On("FindArtists", mock.Anything, filter, 10, 0).Return([]models.ChartArtist{
+			{Rank: 1, PlayCount: 100, Artist: artist},
+		}, nil)
+
+		results, total, err := s.Svc.GetArtistCharts(s.T().Context(), dto.ChartRequest{Window: "month"}, 10, 0)
+
+		s.NoError(err)
+		s.Equal(1, total)
+		s.Equal([]dto.ChartArtist{{Rank: 1, PlayCount: 100, Artist: expectArtist}}, results)
+		s.chartRepo.AssertExpectations(s.T())
+	})
+
+	s.Run("albums", func() {
+		s.ResetMocks()
+		s.chartRepo.On("FindCountAlbums", mock.Anything, filter).Return(1, nil)
+		s.chartRepo.On("FindAlbums", mock.Anything, filter, 10, 0).Return([]models.ChartAlbum{
+			{
+				Rank:      1,
+				PlayCount: 80,
+				Album: models.AlbumWithArtist{
+					Album:  models.Album{Id: 3, ArtistId: 1, Name: "Seperti Seharusnya", Slug: "seperti-seharusnya", Image: imageBytes},
+					Artist: artist,
+				},
+			},
+		}, nil)
+
+		results, total, err := s.Svc.GetAlbumCharts(s.T().Context(), dto.ChartRequest{Window: "month"}, 10, 0)
+
+		s.NoError(err)
+		s.Equal(1, total)
+		s.Equal([]dto.ChartAlbum{
+			{
+				Rank:      1,
+				PlayCount: 80,
+				Album: dto.AlbumWithArtist{
+					Album:  dto.Album{Id: 3, Name: "Seperti Seharusnya", Slug: "seperti-seharusnya", Image: image},
+					Artist: expectArtist,
+				},
+			},
+		}, results)
+		s.chartRepo.AssertExpectations(s.T())
+	})
+
+	s.Run("albums repository error", func() {
+		s.ResetMocks()
+		s.chartRepo.On("FindCountAlbums", mock.Anything, filter).Return(1, nil)
+		s.chartRepo.On("FindAlbums", mock.Anything, filter, 10, 0).Return(nil, errors.New("database failure"))
+
+		_, _, err := s.Svc.GetAlbumCharts(s.T().Context(), dto.ChartRequest{Window: "month"}, 10, 0)
+
+		s.EqualError(err, "database failure")
+		s.chartRepo.AssertExpectations(s.T())
+	})
+}
+
+func (s *ChartServiceTestSuite) TestRefreshCharts() {
+	s.chartRepo.On("Refresh", mock.Anything).Return(errors.New("database failure")).Once()
+	s.EqualError(s.Svc.RefreshCharts(s.T().Context()), "database failure")
+
+	s.chartRepo.On("Refresh", mock.Anything).Return(nil).Once()
+	s.NoError(s.Svc.RefreshCharts(s.T().Context()))
+}
+
+func TestChartServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ChartServiceTestSuite))
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+// decodeCursor parses the client cursor, a malformed one is a validation failure.
+func decodeCursor(token string) (cursor utils.Cursor, err error) {
+	cursor, err = utils.DecodeCursor(token)
+	if err != nil {
+		return cursor, errs.NewBadRequestError("validation failed", map[string]string{"cursor": "Invalid cursor"})
+	}
+
+	return cursor, nil
+}
+
+// checkCursorSpec keyset pages follow the id order, so a custom sort can't be combined with a cursor.
+func checkCursorSpec(spec query.Spec) error {
+	if len(spec.Sort) > 0 {
+		return errs.NewBadRequestError("validation failed", map[string]string{"sort": "Sort is not supported with cursor pagination"})
+	}
+
+	return nil
+}
+
+// cursorPage trims the extra row fetched to detect a next page,
+// the next cursor points at the last row kept.
+func cursorPage[T any](rows []T, pageSize int, key func(T) utils.Cursor) ([]T, dto.CursorPagination) {
+	pagination := dto.CursorPagination{PageSize: pageSize}
+	if len(rows) > pageSize {
+		rows = rows[:pageSize]
+		pagination.NextCursor = utils.EncodeCursor(key(rows[len(rows)-1]))
+	}
+
+	return rows, pagination
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
@@ -43,6 +44,7 @@ func (svc *favoriteService) GetFavoriteSongsByUserID(ctx context.Context, userID
 		song.Id = result.Id
 		song.Title = result.Title
 		song.Duration = result.Duration
+		song.ProcessingState = result.ProcessingState
 		song.Audio = result.Audio
 		song.Image = utils.ParseImageToJSON(result.Image)
 		song.Album = dto.AlbumWithArtist{
@@ -66,6 +68,42 @@ func (svc *favoriteService) GetFavoriteSongsByUserID(ctx context.Context, userID
 	return songs, total, nil
 }
 
+func (svc *favoriteService) GetFavoriteSongsByCursor(ctx context.Context, userID int, cursor string, pageSize int, withTotal bool) (songs []dto.Song, pagination dto.CursorPagination, err error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, pagination, err
+	}
+	// Favorites are ordered by when they were added, a cursor without that time is from another list
+	if !after.IsZero() && after.CreatedAt == nil {
+		return nil, pagination, errs.NewBadRequestError("validation failed", map[string]string{"cursor": "Invalid cursor"})
+	}
+
+	results, err := svc.favRepo.FindFavoriteSongsByCursor(ctx, userID, after, pageSize+1)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "favorite_service", "GetFavoriteSongsByCursor", err)
+		return nil, pagination, err
+	}
+	results, pagination = cursorPage(results, pageSize, func(favorite models.FavoriteSong) utils.Cursor {
+		return utils.Cursor{Id: favorite.Id, CreatedAt: &favorite.FavoritedAt}
+	})
+
+	if withTotal {
+		total, err := svc.favRepo.FindCountFavoriteSongsByUserID(ctx, userID)
+		if err != nil {
+			utils.LogError(svc.log, ctx, "favorite_service", "GetFavoriteSongsByCursor", err)
+			return nil, pagination, err
+		}
+		pagination.Total = &total
+	}
+
+	songs = make([]dto.Song, 0, len(results))
+	for _, result := range results {
+		songs = append(songs, newSongDTO(result.Song))
+	}
+
+	return songs, pagination, nil
+}
+
 func (svc *favoriteService) AddFavoriteSong(ctx context.Context, userID int, songID int) (err error) {
 	exists, err := svc.songRepo.FindExistsSongById(ctx, songID)
 	if err != nil {
@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -142,6 +143,54 @@ func (s *FavoriteServiceTestSuite) TestGetFavoriteSongsByUserID() {
 	}
 }
 
+func (s *FavoriteServiceTestSuite) TestGetFavoriteSongsByCursor() {
+	addedAt := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	favorites := []models.FavoriteSong{
+		{Song: models.Song{Id: 4, Title: "Song 4"}, FavoritedAt: addedAt},
+		{Song: models.Song{Id: 2, Title: "Song 2"}, FavoritedAt: addedAt.Add(-time.Hour)},
+	}
+
+	s.Run("next page keyed by favorited time", func() {
+		s.ResetMocks()
+		s.favRepo.On("FindFavoriteSongsByCursor", mock.Anything, 1, utils.Cursor{}, 2).Return(favorites, nil)
+
+		results, pagination, err := s.Svc.GetFavoriteSongsByCursor(s.T().Context(), 1, "", 1, false)
+
+		s.NoError(err)
+		s.Len(results, 1)
+		s.Equal(4, results[0].Id)
+		s.Equal(utils.EncodeCursor(utils.Cursor{Id: 4, CreatedAt: &addedAt}), pagination.NextCursor)
+		s.Nil(pagination.Total)
+		s.favRepo.AssertExpectations(s.T())
+	})
+
+	s.Run("continues after cursor", func() {
+		s.ResetMocks()
+		cursor := utils.Cursor{Id: 4, CreatedAt: &addedAt}
+		s.favRepo.On("FindFavoriteSongsByCursor", mock.Anything, 1, mock.MatchedBy(func(c utils.Cursor) bool {
+			return c.Id == 4 && c.CreatedAt != nil && c.CreatedAt.Equal(addedAt)
+		}), 2).Return(favorites[1:], nil)
+		s.favRepo.On("FindCountFavoriteSongsByUserID", mock.Anything, 1).Return(2, nil)
+
+		results, pagination, err := s.Svc.GetFavoriteSongsByCursor(s.T().Context(), 1, utils.EncodeCursor(cursor), 1, true)
+
+		s.NoError(err)
+		s.Len(results, 1)
+		s.Empty(pagination.NextCursor)
+		s.Equal(2, *pagination.Total)
+		s.favRepo.AssertExpectations(s.T())
+	})
+
+	s.Run("cursor without favorited time", func() {
+		s.ResetMocks()
+
+		_, _, err := s.Svc.GetFavoriteSongsByCursor(s.T().Context(), 1, utils.EncodeCursor(utils.Cursor{Id: 4}), 1, false)
+
+		s.EqualError(err, errs.NewBadRequestError("validation failed", nil).Error())
+		s.favRepo.AssertExpectations(s.T())
+	})
+}
+
 func (s *FavoriteServiceTestSuite) TestAddFavoriteSong() {
 	testCases := []struct {
 		name        string
@@ -56,6 +56,42 @@ func (svc *genreService) GetAll(ctx context.Context, pageSize int, offset int) (
 	return genres, total, nil
 }
 
+func (svc *genreService) GetAllByCursor(ctx context.Context, cursor string, pageSize int, withTotal bool) (genres []dto.Genre, pagination dto.CursorPagination, err error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, pagination, err
+	}
+
+	results, err := svc.repo.FindAllByCursor(ctx, after, pageSize+1)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "genre_service", "GetAllByCursor", err)
+		return nil, pagination, err
+	}
+	results, pagination = cursorPage(results, pageSize, func(genre models.Genre) utils.Cursor {
+		return utils.Cursor{Id: genre.Id}
+	})
+
+	if withTotal {
+		total, err := svc.repo.FindCount(ctx)
+		if err != nil {
+			utils.LogError(svc.log, ctx, "genre_service", "GetAllByCursor", err)
+			return nil, pagination, err
+		}
+		pagination.Total = &total
+	}
+
+	genres = make([]dto.Genre, 0, len(results))
+	for _, result := range results {
+		genres = append(genres, dto.Genre{
+			Id:    result.Id,
+			Name:  result.Name,
+			Image: utils.ParseImageToJSON(result.Image),
+		})
+	}
+
+	return genres, pagination, nil
+}
+
 func (svc *genreService) GetGenreById(ctx context.Context, id int) (genre dto.Genre, err error) {
 	result, err := svc.repo.FindGenreById(ctx, id)
 	if err != nil {
@@ -357,11 +393,12 @@ func (svc *genreService) GetAllSongs(ctx context.Context, genreId int, pageSize
 
 	for _, v := range results {
 		song := dto.Song{
-			Id:       v.Id,
-			Title:    v.Title,
-			Audio:    v.Audio,
-			Duration: v.Duration,
-			Image:    utils.ParseImageToJSON(v.Image),
+			Id:              v.Id,
+			Title:           v.Title,
+			Audio:           v.Audio,
+			Duration:        v.Duration,
+			ProcessingState: v.ProcessingState,
+			Image:           utils.ParseImageToJSON(v.Image),
 			Album: dto.AlbumWithArtist{
 				Album: dto.Album{
 					Id:    v.Album.Id,
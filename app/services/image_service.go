@@ -0,0 +1,248 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/imaging"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/storage"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+// imageVariantFile is the name a variant is stored and served under, like `640w.jpg`.
+var imageVariantFile = regexp.MustCompile(`^[0-9]+w\.(?:jpg|png)$`)
+
+const (
+	// remoteImageMaxSize caps the download of a remote image, avatars are far smaller
+	remoteImageMaxSize = 5 << 20
+	remoteImageTimeout = 10 * time.Second
+	// remoteImageRedirects caps the redirects followed to a remote image
+	remoteImageRedirects = 5
+)
+
+// internalPrefixes are the non-public ranges netip has no predicate for: shared address space,
+// the IETF protocol assignments, benchmarking and NAT64, which can embed any IPv4 address.
+var internalPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+type imageService struct {
+	store     storage.Storage
+	widths    []int
+	publicURL string
+	client    *http.Client
+	log       *logrus.Logger
+}
+
+func NewImageService(cfg *config.Config, store storage.Storage, log *logrus.Logger) contracts.ImageService {
+	return &imageService{
+		store:     store,
+		widths:    cfg.ImageWidths,
+		publicURL: cfg.ImagePublicURL,
+		client:    newRemoteImageClient(),
+		log:       log,
+	}
+}
+
+func (svc *imageService) UploadImage(ctx context.Context, file io.Reader) (image dto.Image, err error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "image_service", "UploadImage", err)
+		return image, err
+	}
+
+	result, err := imaging.Process(data, svc.widths)
+	if err != nil {
+		var message string
+		switch {
+		case errors.Is(err, imaging.ErrUnsupported):
+			message = "Unsupported image format, allowed: jpeg, png, webp"
+		case errors.Is(err, imaging.ErrInvalid):
+			message = "Image file is corrupt or truncated"
+		case errors.Is(err, imaging.ErrTooLarge):
+			message = fmt.Sprintf("Image is too large, maximum is %d megapixels", imaging.MaxPixels/1_000_000)
+		default:
+			utils.LogError(svc.log, ctx, "image_service", "UploadImage", err)
+			return image, err
+		}
+
+		utils.LogWarn(svc.log, ctx, "image_service", "UploadImage", err)
+		return image, errs.NewBadRequestError("validation failed", map[string]string{"image": message})
+	}
+
+	// Variants are never rewritten, a new upload gets a new id
+	id := uuid.NewString()
+	prefix := "images/" + id
+
+	image = dto.Image{
+		BlurHash: result.BlurHash,
+		Width:    result.Width,
+		Height:   result.Height,
+		Variants: make([]dto.ImageVariant, 0, len(result.Variants)),
+	}
+
+	for _, variant := range result.Variants {
+		name := fmt.Sprintf("%dw%s", variant.Width, variant.Ext)
+		if err := svc.store.Put(ctx, prefix+"/"+name, bytes.NewReader(variant.Data), variant.ContentType); err != nil {
+			utils.LogError(svc.log, ctx, "image_service", "UploadImage", err)
+			if delErr := svc.store.DeletePrefix(ctx, prefix); delErr != nil {
+				utils.LogWarn(svc.log, ctx, "image_service", "UploadImage", delErr)
+			}
+			return dto.Image{}, err
+		}
+
+		image.Variants = append(image.Variants, dto.ImageVariant{
+			Src:    fmt.Sprintf("%s/%s/%s", svc.publicURL, id, name),
+			Width:  variant.Width,
+			Height: variant.Height,
+		})
+	}
+
+	// The largest variant is the image itself
+	image.Src = image.Variants[len(image.Variants)-1].Src
+
+	return image, nil
+}
+
+func (svc *imageService) RemoteImage(ctx context.Context, src string) (image dto.Image, err error) {
+	if u, err := url.Parse(src); err != nil || u.Scheme != "https" {
+		err := fmt.Errorf("remote image %q is not an https url", src)
+		utils.LogWarn(svc.log, ctx, "image_service", "RemoteImage", err)
+		return image, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		utils.LogWarn(svc.log, ctx, "image_service", "RemoteImage", err)
+		return image, err
+	}
+	res, err := svc.client.Do(req)
+	if err != nil {
+		utils.LogWarn(svc.log, ctx, "image_service", "RemoteImage", err)
+		return image, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		err := fmt.Errorf("remote image %q: unexpected status %d", src, res.StatusCode)
+		utils.LogWarn(svc.log, ctx, "image_service", "RemoteImage", err)
+		return image, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, remoteImageMaxSize+1))
+	if err == nil && len(data) > remoteImageMaxSize {
+		err = fmt.Errorf("remote image %q is larger than %d bytes", src, remoteImageMaxSize)
+	}
+	if err != nil {
+		utils.LogWarn(svc.log, ctx, "image_service", "RemoteImage", err)
+		return image, err
+	}
+
+	result, err := imaging.Inspect(data)
+	if err != nil {
+		utils.LogWarn(svc.log, ctx, "image_service", "RemoteImage", err)
+		return image, err
+	}
+
+	return dto.Image{
+		Src:      src,
+		BlurHash: result.BlurHash,
+		Width:    result.Width,
+		Height:   result.Height,
+	}, nil
+}
+
+// newRemoteImageClient fetches images at URLs that providers, or whoever configures them, pick.
+// Only https is followed and every connection, redirects included, has to reach a public address.
+func newRemoteImageClient() *http.Client {
+	dialer := &net.Dialer{Timeout: remoteImageTimeout, Control: dialPublicOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Through a proxy the dialed address would be the proxy's
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   remoteImageTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("remote image redirected to %q, not an https url", req.URL)
+			}
+			if len(via) >= remoteImageRedirects {
+				return fmt.Errorf("remote image redirected more than %d times", remoteImageRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// dialPublicOnly is a net.Dialer Control refusing loopback, private, link-local and other internal
+// addresses. It sees the resolved address, a public name pointing inside is refused too.
+func dialPublicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	addr = addr.Unmap()
+	internal := !addr.IsGlobalUnicast() || addr.IsPrivate()
+	for _, prefix := range internalPrefixes {
+		internal = internal || prefix.Contains(addr)
+	}
+	if internal {
+		return fmt.Errorf("remote image address %s is not public", addr)
+	}
+
+	return nil
+}
+
+func (svc *imageService) OpenImage(ctx context.Context, id, file string) (image models.StoredFile, err error) {
+	if err := uuid.Validate(id); err != nil || !imageVariantFile.MatchString(file) {
+		notFoundErr := errs.NewNotFoundError("Image", "file", id+"/"+file)
+		utils.LogWarn(svc.log, ctx, "image_service", "OpenImage", notFoundErr)
+		return image, notFoundErr
+	}
+
+	body, obj, err := svc.store.Open(ctx, "images/"+id+"/"+file)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			notFoundErr := errs.NewNotFoundError("Image", "file", id+"/"+file)
+			utils.LogWarn(svc.log, ctx, "image_service", "OpenImage", notFoundErr)
+			return image, notFoundErr
+		}
+
+		utils.LogError(svc.log, ctx, "image_service", "OpenImage", err)
+		return image, err
+	}
+
+	return models.StoredFile{
+		Body:        body,
+		Size:        obj.Size,
+		ContentType: obj.ContentType,
+		ModTime:     obj.ModTime,
+	}, nil
+}
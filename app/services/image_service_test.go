@@ -0,0 +1,278 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/mocks"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/storage"
+)
+
+type ImageServiceTestSuite struct {
+	suite.Suite
+	Svc   contracts.ImageService
+	store *mocks.MockStorage
+}
+
+func (s *ImageServiceTestSuite) SetupTest() {
+	s.store = new(mocks.MockStorage)
+	cfg := &config.Config{ImageWidths: []int{320, 640, 1280}, ImagePublicURL: "/v1/images"}
+	s.Svc = NewImageService(cfg, s.store, nil)
+}
+
+func (s *ImageServiceTestSuite) ResetMocks() {
+	s.store.ExpectedCalls = nil
+	s.store.Calls = nil
+}
+
+// gradientPNG is an opaque 800x600 png, variants of it are stored as jpeg.
+func gradientPNG() []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 800, 600))
+	for y := 0; y < 600; y++ {
+		for x := 0; x < 800; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x / 4), G: uint8(y / 3), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func (s *ImageServiceTestSuite) TestUploadImage() {
+	file := gradientPNG()
+	isKey := func(name string) any {
+		return mock.MatchedBy(func(key string) bool {
+			return regexp.MustCompile(`^images/[0-9a-f-]{36}/` + name + `$`).MatchString(key)
+		})
+	}
+	isPrefix := mock.MatchedBy(func(prefix string) bool {
+		return regexp.MustCompile(`^images/[0-9a-f-]{36}$`).MatchString(prefix)
+	})
+
+	testCases := []struct {
+		name        string
+		file        []byte
+		prepareMock func()
+		expected    []dto.ImageVariant
+		expectedErr error
+	}{
+		{
+			name: "success stores variants up to the image width",
+			file: file,
+			prepareMock: func() {
+				s.store.On("Put", mock.Anything, isKey(`320w\.jpg`), mock.Anything, "image/jpeg").Return(nil)
+				s.store.On("Put", mock.Anything, isKey(`640w\.jpg`), mock.Anything, "image/jpeg").Return(nil)
+				s.store.On("Put", mock.Anything, isKey(`800w\.jpg`), mock.Anything, "image/jpeg").Return(nil)
+			},
+			expected: []dto.ImageVariant{
+				{Src: "320w.jpg", Width: 320, Height: 240},
+				{Src: "640w.jpg", Width: 640, Height: 480},
+				{Src: "800w.jpg", Width: 800, Height: 600},
+			},
+		},
+		{
+			name:        "unsupported format",
+			file:        []byte("GIF89a\x01\x00\x01\x00"),
+			prepareMock: func() {},
+			expectedErr: errs.NewBadRequestError("validation failed", map[string]string{"image": "Unsupported image format, allowed: jpeg, png, webp"}),
+		},
+		{
+			name:        "corrupt file",
+			file:        file[:200],
+			prepareMock: func() {},
+			expectedErr: errs.NewBadRequestError("validation failed", map[string]string{"image": "Image file is corrupt or truncated"}),
+		},
+		{
+			name: "storage failure removes stored variants",
+			file: file,
+			prepareMock: func() {
+				s.store.On("Put", mock.Anything, isKey(`320w\.jpg`), mock.Anything, "image/jpeg").Return(nil)
+				s.store.On("Put", mock.Anything, isKey(`640w\.jpg`), mock.Anything, "image/jpeg").Return(errors.New("disk full"))
+				s.store.On("DeletePrefix", mock.Anything, isPrefix).Return(nil)
+			},
+			expectedErr: errors.New("disk full"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			tc.prepareMock()
+
+			// Actual
+			img, err := s.Svc.UploadImage(s.T().Context(), bytes.NewReader(tc.file))
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+				s.Equal(800, img.Width)
+				s.Equal(600, img.Height)
+				s.Len(img.BlurHash, 28)
+				s.Require().Len(img.Variants, len(tc.expected))
+
+				src := regexp.MustCompile(`^/v1/images/[0-9a-f-]{36}/`)
+				for i, variant := range img.Variants {
+					s.Regexp(src, variant.Src)
+					s.Equal(tc.expected[i].Src, src.ReplaceAllString(variant.Src, ""))
+					s.Equal(tc.expected[i].Width, variant.Width)
+					s.Equal(tc.expected[i].Height, variant.Height)
+				}
+				s.Equal(img.Variants[2].Src, img.Src)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+				s.Equal(dto.Image{}, img)
+			}
+
+			s.store.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *ImageServiceTestSuite) TestOpenImage() {
+	id := "0b7f6c3e-2f43-4c7e-9d0a-5a1e8e2b9c11"
+	modTime := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	body := &fakeReadSeekCloser{Reader: bytes.NewReader([]byte("jpeg"))}
+
+	testCases := []struct {
+		name        string
+		id          string
+		file        string
+		prepareMock func()
+		expected    models.StoredFile
+		expectedErr error
+	}{
+		{
+			name: "success",
+			id:   id,
+			file: "640w.jpg",
+			prepareMock: func() {
+				s.store.On("Open", mock.Anything, "images/"+id+"/640w.jpg").Return(body, storage.Object{Size: 4, ContentType: "image/jpeg", ModTime: modTime}, nil)
+			},
+			expected: models.StoredFile{Body: body, Size: 4, ContentType: "image/jpeg", ModTime: modTime},
+		},
+		{
+			name:        "invalid id",
+			id:          "..",
+			file:        "640w.jpg",
+			prepareMock: func() {},
+			expectedErr: errs.NewNotFoundError("Image", "file", "../640w.jpg"),
+		},
+		{
+			name:        "invalid file",
+			id:          id,
+			file:        "original.jpg",
+			prepareMock: func() {},
+			expectedErr: errs.NewNotFoundError("Image", "file", id+"/original.jpg"),
+		},
+		{
+			name: "missing variant",
+			id:   id,
+			file: "1280w.jpg",
+			prepareMock: func() {
+				s.store.On("Open", mock.Anything, "images/"+id+"/1280w.jpg").Return(nil, storage.Object{}, storage.ErrNotFound)
+			},
+			expectedErr: errs.NewNotFoundError("Image", "file", id+"/1280w.jpg"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			tc.prepareMock()
+
+			// Actual
+			file, err := s.Svc.OpenImage(s.T().Context(), tc.id, tc.file)
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+				s.Equal(tc.expected, file)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.store.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *ImageServiceTestSuite) TestRemoteImage() {
+	avatar := gradientPNG()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/avatar.png":
+			_, _ = w.Write(avatar)
+		case "/not-an-image":
+			_, _ = w.Write([]byte("<html></html>"))
+		case "/to-http":
+			http.Redirect(w, r, "http://example.com/avatar.png", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	// The test server listens on loopback, which the service refuses
+	_, err := s.Svc.RemoteImage(s.T().Context(), srv.URL+"/avatar.png")
+	s.ErrorContains(err, "is not public")
+
+	// Trusting the test server and its address, the redirect policy stays
+	svc := s.Svc.(*imageService)
+	svc.client.Transport = srv.Client().Transport
+
+	image, err := s.Svc.RemoteImage(s.T().Context(), srv.URL+"/avatar.png")
+	s.Require().NoError(err)
+	s.Equal(srv.URL+"/avatar.png", image.Src)
+	s.NotEmpty(image.BlurHash)
+	s.Equal(800, image.Width)
+	s.Equal(600, image.Height)
+	s.Empty(image.Variants)
+
+	for _, src := range []string{
+		srv.URL + "/missing.png",
+		srv.URL + "/not-an-image",
+		srv.URL + "/to-http",
+		"http://example.com/avatar.png",
+		"file:///etc/passwd",
+	} {
+		_, err := s.Svc.RemoteImage(s.T().Context(), src)
+		s.Error(err, src)
+	}
+	s.store.AssertNotCalled(s.T(), "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *ImageServiceTestSuite) TestDialPublicOnly() {
+	for _, address := range []string{
+		"127.0.0.1:443", "10.1.2.3:443", "172.16.0.1:443", "192.168.1.1:443", "169.254.169.254:80",
+		"100.64.0.1:443", "0.0.0.0:443", "[::1]:443", "[fd00::1]:443", "[fe80::1]:443", "[::ffff:127.0.0.1]:443",
+		"[64:ff9b::a00:1]:443",
+	} {
+		s.Error(dialPublicOnly("tcp", address, nil), address)
+	}
+
+	for _, address := range []string{"93.184.216.34:443", "[2606:2800:220:1::]:443"} {
+		s.NoError(dialPublicOnly("tcp", address, nil), address)
+	}
+}
+
+func TestImageServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ImageServiceTestSuite))
+}
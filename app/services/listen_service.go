@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+// listenDedupWindow repeat events of the same user and song inside this window are dropped.
+const listenDedupWindow = 30 * time.Second
+
+type listenService struct {
+	listenRepo contracts.ListenRepository
+	songRepo   contracts.SongRepository
+	log        *logrus.Logger
+}
+
+func NewListenService(listenRepo contracts.ListenRepository, songRepo contracts.SongRepository, log *logrus.Logger) contracts.ListenService {
+	return &listenService{
+		listenRepo: listenRepo,
+		songRepo:   songRepo,
+		log:        log,
+	}
+}
+
+func (svc *listenService) RecordListen(ctx context.Context, req dto.CreateListenRequest, userID, songID int) (recorded bool, err error) {
+	if errorsMap, err := utils.RequestValidate(&req); err != nil {
+		return false, errs.NewBadRequestError("validation failed", errorsMap)
+	}
+
+	exists, err := svc.songRepo.FindExistsSongById(ctx, songID)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "listen_service", "RecordListen", err)
+		return false, err
+	}
+	if !exists {
+		notFoundErr := errs.NewNotFoundError("Song", "id", songID)
+		utils.LogWarn(svc.log, ctx, "listen_service", "RecordListen", notFoundErr)
+		return false, notFoundErr
+	}
+
+	input := models.CreateListenInput{
+		UserId:   userID,
+		SongId:   songID,
+		Duration: req.Duration,
+		Client:   req.Client,
+	}
+
+	recorded, err = svc.listenRepo.Store(ctx, input, listenDedupWindow)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "listen_service", "RecordListen", err)
+		return false, err
+	}
+
+	return recorded, nil
+}
+
+func (svc *listenService) GetHistory(ctx context.Context, userID, pageSize, offset int) (history []dto.ListenHistory, total int, err error) {
+	total, err = svc.listenRepo.FindCountHistoryByUserID(ctx, userID)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "listen_service", "GetHistory", err)
+		return nil, 0, err
+	}
+
+	results, err := svc.listenRepo.FindHistoryByUserID(ctx, userID, pageSize, offset)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "listen_service", "GetHistory", err)
+		return nil, 0, err
+	}
+
+	history = make([]dto.ListenHistory, 0, len(results))
+	for _, result := range results {
+		history = append(history, dto.ListenHistory{
+			Id:         result.Id,
+			Duration:   result.Duration,
+			Client:     result.Client.String,
+			ListenedAt: result.CreatedAt,
+			Song:       newSongDTO(result.Song),
+		})
+	}
+
+	return history, total, nil
+}
+
+func (svc *listenService) GetRecentlyPlayed(ctx context.Context, userID, pageSize, offset int) (songs []dto.RecentlyPlayed, total int, err error) {
+	total, err = svc.listenRepo.FindCountRecentlyPlayedByUserID(ctx, userID)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "listen_service", "GetRecentlyPlayed", err)
+		return nil, 0, err
+	}
+
+	results, err := svc.listenRepo.FindRecentlyPlayedByUserID(ctx, userID, pageSize, offset)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "listen_service", "GetRecentlyPlayed", err)
+		return nil, 0, err
+	}
+
+	songs = make([]dto.RecentlyPlayed, 0, len(results))
+	for _, result := range results {
+		songs = append(songs, dto.RecentlyPlayed{
+			LastPlayedAt: result.CreatedAt,
+			Song:         newSongDTO(result.Song),
+		})
+	}
+
+	return songs, total, nil
+}
+
+func (svc *listenService) ClearHistory(ctx context.Context, userID int, req dto.ClearHistoryRequest) (deleted int, err error) {
+	if errorsMap, err := utils.RequestValidate(&req); err != nil {
+		return 0, errs.NewBadRequestError("validation failed", errorsMap)
+	}
+
+	input := models.ClearHistoryInput{SongId: req.SongId}
+	if req.From != "" {
+		from, _ := time.Parse(time.RFC3339, req.From)
+		input.From = &from
+	}
+	if req.To != "" {
+		to, _ := time.Parse(time.RFC3339, req.To)
+		input.To = &to
+	}
+	if input.From != nil && input.To != nil && !input.From.Before(*input.To) {
+		return 0, errs.NewBadRequestError("validation failed", map[string]string{"to": "Must be after from"})
+	}
+
+	deleted, err = svc.listenRepo.DeleteHistory(ctx, userID, input)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "listen_service", "ClearHistory", err)
+		return 0, err
+	}
+
+	return deleted, nil
+}
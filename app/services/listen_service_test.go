@@ -0,0 +1,308 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/mocks"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type ListenServiceTestSuite struct {
+	suite.Suite
+	Svc        contracts.ListenService
+	listenRepo *mocks.MockListenRepository
+	songRepo   *mocks.MockSongRepository
+}
+
+func (s *ListenServiceTestSuite) SetupTest() {
+	s.listenRepo = new(mocks.MockListenRepository)
+	s.songRepo = new(mocks.MockSongRepository)
+	s.Svc = NewListenService(s.listenRepo, s.songRepo, nil)
+}
+
+func (s *ListenServiceTestSuite) ResetMocks() {
+	s.listenRepo.ExpectedCalls = nil
+	s.listenRepo.Calls = nil
+	s.songRepo.ExpectedCalls = nil
+	s.songRepo.Calls = nil
+}
+
+func (s *ListenServiceTestSuite) TestRecordListen() {
+	input := models.CreateListenInput{UserId: 1, SongId: 1, Duration: 120, Client: "web"}
+
+	testCases := []struct {
+		name           string
+		req            dto.CreateListenRequest
+		prepareMock    func()
+		expectRecorded bool
+		expectErr      error
+	}{
+		{
+			name: "success",
+			req:  dto.CreateListenRequest{Duration: 120, Client: "web"},
+			prepareMock: func() {
+				s.songRepo.On("FindExistsSongById", mock.Anything, 1).Return(true, nil)
+				s.listenRepo.On("Store", mock.Anything, input, listenDedupWindow).Return(true, nil)
+			},
+			expectRecorded: true,
+		},
+		{
+			name: "repeat within window is dropped",
+			req:  dto.CreateListenRequest{Duration: 120, Client: "web"},
+			prepareMock: func() {
+				s.songRepo.On("FindExistsSongById", mock.Anything, 1).Return(true, nil)
+				s.listenRepo.On("Store", mock.Anything, input, listenDedupWindow).Return(false, nil)
+			},
+			expectRecorded: false,
+		},
+		{
+			name:      "validation failure",
+			req:       dto.CreateListenRequest{Duration: -1},
+			expectErr: errs.NewBadRequestError("validation failed", map[string]string{"duration": "Minimum value is 0"}),
+		},
+		{
+			name: "song not found",
+			req:  dto.CreateListenRequest{Duration: 120, Client: "web"},
+			prepareMock: func() {
+				s.songRepo.On("FindExistsSongById", mock.Anything, 1).Return(false, nil)
+			},
+			expectErr: errs.NewNotFoundError("Song", "id", 1),
+		},
+		{
+			name: "FindExistsSongById error",
+			req:  dto.CreateListenRequest{Duration: 120, Client: "web"},
+			prepareMock: func() {
+				s.songRepo.On("FindExistsSongById", mock.Anything, 1).Return(false, errors.New("database failure"))
+			},
+			expectErr: errors.New("database failure"),
+		},
+		{
+			name: "Store error",
+			req:  dto.CreateListenRequest{Duration: 120, Client: "web"},
+			prepareMock: func() {
+				s.songRepo.On("FindExistsSongById", mock.Anything, 1).Return(true, nil)
+				s.listenRepo.On("Store", mock.Anything, input, listenDedupWindow).Return(false, errors.New("database failure"))
+			},
+			expectErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			if tc.prepareMock != nil {
+				tc.prepareMock()
+			}
+
+			// Actual
+			recorded, err := s.Svc.RecordListen(s.T().Context(), tc.req, 1, 1)
+
+			// Assert
+			if tc.expectErr == nil {
+				s.NoError(err)
+				s.Equal(tc.expectRecorded, recorded)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectErr.Error())
+			}
+
+			s.listenRepo.AssertExpectations(s.T())
+			s.songRepo.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *ListenServiceTestSuite) TestGetHistory() {
+	image := dto.Image{Src: "image.png", BlurHash: "abc"}
+	imageBytes := utils.ParseImageToByte(&image)
+	listenedAt := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	song := models.Song{
+		Id:       1,
+		Title:    "Aku pulang",
+		Audio:    "aku.mp3",
+		Duration: 352,
+		Image:    imageBytes,
+		Album: models.AlbumWithArtist{
+			Album:  models.Album{Id: 1, Name: "Album aku pulang", Slug: "album-aku-pulang", Image: imageBytes},
+			Artist: models.Artist{Id: 1, Name: "Noah", Slug: "noah", Image: imageBytes},
+		},
+	}
+	expectSong := dto.Song{
+		Id:       1,
+		Title:    "Aku pulang",
+		Audio:    "aku.mp3",
+		Duration: 352,
+		Image:    image,
+		Album: dto.AlbumWithArtist{
+			Album:  dto.Album{Id: 1, Name: "Album aku pulang", Slug: "album-aku-pulang", Image: image},
+			Artist: dto.Artist{Id: 1, Name: "Noah", Slug: "noah", Image: image},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		prepareMock   func()
+		expectResults []dto.ListenHistory
+		expectTotal   int
+		expectErr     error
+	}{
+		{
+			name: "success",
+			prepareMock: func() {
+				s.listenRepo.On("FindCountHistoryByUserID", mock.Anything, 1).Return(1, nil)
+				s.listenRepo.On("FindHistoryByUserID", mock.Anything, 1, 10, 0).Return([]models.Listen{
+					{
+						Id:        7,
+						UserId:    1,
+						SongId:    1,
+						Duration:  120,
+						Client:    sql.NullString{String: "web", Valid: true},
+						CreatedAt: listenedAt,
+						Song:      song,
+					},
+				}, nil)
+			},
+			expectResults: []dto.ListenHistory{
+				{Id: 7, Duration: 120, Client: "web", ListenedAt: listenedAt, Song: expectSong},
+			},
+			expectTotal: 1,
+		},
+		{
+			name: "FindCountHistoryByUserID error",
+			prepareMock: func() {
+				s.listenRepo.On("FindCountHistoryByUserID", mock.Anything, 1).Return(0, errors.New("database failure"))
+			},
+			expectErr: errors.New("database failure"),
+		},
+		{
+			name: "FindHistoryByUserID error",
+			prepareMock: func() {
+				s.listenRepo.On("FindCountHistoryByUserID", mock.Anything, 1).Return(1, nil)
+				s.listenRepo.On("FindHistoryByUserID", mock.Anything, 1, 10, 0).Return(nil, errors.New("database failure"))
+			},
+			expectErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			tc.prepareMock()
+
+			// Actual
+			results, total, err := s.Svc.GetHistory(s.T().Context(), 1, 10, 0)
+
+			// Assert
+			if tc.expectErr == nil {
+				s.NoError(err)
+				s.Equal(tc.expectTotal, total)
+				s.Equal(tc.expectResults, results)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectErr.Error())
+			}
+
+			s.listenRepo.AssertExpectations(s.T())
+		})
+	}
+
+	s.Run("recently played", func() {
+		s.ResetMocks()
+		s.listenRepo.On("FindCountRecentlyPlayedByUserID", mock.Anything, 1).Return(1, nil)
+		s.listenRepo.On("FindRecentlyPlayedByUserID", mock.Anything, 1, 10, 0).Return([]models.Listen{
+			{UserId: 1, SongId: 1, CreatedAt: listenedAt, Song: song},
+		}, nil)
+
+		results, total, err := s.Svc.GetRecentlyPlayed(s.T().Context(), 1, 10, 0)
+
+		s.NoError(err)
+		s.Equal(1, total)
+		s.Equal([]dto.RecentlyPlayed{{LastPlayedAt: listenedAt, Song: expectSong}}, results)
+		s.listenRepo.AssertExpectations(s.T())
+	})
+}
+
+func (s *ListenServiceTestSuite) TestClearHistory() {
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		req           dto.ClearHistoryRequest
+		prepareMock   func()
+		expectDeleted int
+		expectErr     error
+	}{
+		{
+			name: "clear all",
+			req:  dto.ClearHistoryRequest{},
+			prepareMock: func() {
+				s.listenRepo.On("DeleteHistory", mock.Anything, 1, models.ClearHistoryInput{}).Return(12, nil)
+			},
+			expectDeleted: 12,
+		},
+		{
+			name: "clear song within range",
+			req:  dto.ClearHistoryRequest{SongId: 3, From: "2025-05-01T00:00:00Z", To: "2025-05-02T00:00:00Z"},
+			prepareMock: func() {
+				s.listenRepo.On("DeleteHistory", mock.Anything, 1, models.ClearHistoryInput{SongId: 3, From: &from, To: &to}).Return(2, nil)
+			},
+			expectDeleted: 2,
+		},
+		{
+			name:      "invalid datetime",
+			req:       dto.ClearHistoryRequest{From: "yesterday"},
+			expectErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name:      "from after to",
+			req:       dto.ClearHistoryRequest{From: "2025-05-02T00:00:00Z", To: "2025-05-01T00:00:00Z"},
+			expectErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name: "DeleteHistory error",
+			req:  dto.ClearHistoryRequest{},
+			prepareMock: func() {
+				s.listenRepo.On("DeleteHistory", mock.Anything, 1, models.ClearHistoryInput{}).Return(0, errors.New("database failure"))
+			},
+			expectErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			if tc.prepareMock != nil {
+				tc.prepareMock()
+			}
+
+			// Actual
+			deleted, err := s.Svc.ClearHistory(s.T().Context(), 1, tc.req)
+
+			// Assert
+			if tc.expectErr == nil {
+				s.NoError(err)
+				s.Equal(tc.expectDeleted, deleted)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectErr.Error())
+			}
+
+			s.listenRepo.AssertExpectations(s.T())
+		})
+	}
+}
+
+func TestListenServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ListenServiceTestSuite))
+}
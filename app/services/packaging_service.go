@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/hls"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/jobs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/storage"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type packagingService struct {
+	songRepo contracts.SongRepository
+	store    storage.Storage
+	packager hls.Packager
+	jobs     jobs.Enqueuer
+	log      *logrus.Logger
+}
+
+func NewPackagingService(songRepo contracts.SongRepository, store storage.Storage, packager hls.Packager, jobs jobs.Enqueuer, log *logrus.Logger) contracts.PackagingService {
+	return &packagingService{
+		songRepo: songRepo,
+		store:    store,
+		packager: packager,
+		jobs:     jobs,
+		log:      log,
+	}
+}
+
+func (svc *packagingService) Enqueue(ctx context.Context, songID int) (err error) {
+	// The job reads the audio when it runs, one pending job covers any number of uploads
+	job := models.PackageSongJob{SongID: songID}
+	if err := svc.jobs.Enqueue(ctx, job, jobs.MaxAttempts(3), jobs.Unique(fmt.Sprintf("%s:%d", job.Kind(), songID))); err != nil {
+		utils.LogError(svc.log, ctx, "packaging_service", "Enqueue", err)
+		return err
+	}
+
+	return nil
+}
+
+func (svc *packagingService) Package(ctx context.Context, songID int) (err error) {
+	song, err := svc.songRepo.FindSongById(ctx, songID)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "packaging_service", "Package", err)
+		return err
+	}
+	// Deleted meanwhile, or an external URL saved before uploads existed
+	if song == nil || !storage.IsKey(song.Audio) {
+		return nil
+	}
+
+	prefix, err := svc.packageAudio(ctx, song)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "packaging_service", "Package", err)
+		svc.finish(ctx, song, models.SongProcessingFailed, "")
+		return err
+	}
+
+	if !svc.finish(ctx, song, models.SongProcessingReady, prefix) {
+		if err := svc.store.DeletePrefix(ctx, prefix); err != nil {
+			utils.LogWarn(svc.log, ctx, "packaging_service", "Package", err)
+		}
+	}
+
+	return nil
+}
+
+// finish records the outcome and removes the package of the previous audio once replaced.
+// It reports false when the song no longer points to the packaged audio.
+func (svc *packagingService) finish(ctx context.Context, song *models.Song, state, prefix string) (updated bool) {
+	input := models.SongProcessingInput{
+		Audio: song.Audio,
+		State: state,
+		HLS:   prefix,
+	}
+
+	updated, err := svc.songRepo.UpdateProcessing(ctx, song.Id, input)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "packaging_service", "Package", err)
+		return false
+	}
+	// A newer upload is queued and finishes the song
+	if !updated {
+		return false
+	}
+
+	if song.HLS != "" {
+		if err := svc.store.DeletePrefix(ctx, song.HLS); err != nil {
+			utils.LogWarn(svc.log, ctx, "packaging_service", "Package", err)
+		}
+	}
+
+	return true
+}
+
+// packageAudio runs the packager on a local copy of the audio and moves its output to storage
+// under a fresh prefix, so players holding the previous playlists never mix packages.
+func (svc *packagingService) packageAudio(ctx context.Context, song *models.Song) (prefix string, err error) {
+	workDir, err := os.MkdirTemp("", "mulo-hls-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(workDir)
+
+	input := filepath.Join(workDir, "input"+path.Ext(song.Audio))
+	if err := svc.download(ctx, song.Audio, input); err != nil {
+		return "", err
+	}
+
+	outDir := filepath.Join(workDir, "hls")
+	if err := os.Mkdir(outDir, 0o755); err != nil {
+		return "", err
+	}
+	if err := svc.packager.Package(ctx, input, outDir); err != nil {
+		return "", err
+	}
+
+	prefix = fmt.Sprintf("songs/%d/hls/%s", song.Id, uuid.NewString())
+	if err := svc.upload(ctx, outDir, prefix); err != nil {
+		if delErr := svc.store.DeletePrefix(ctx, prefix); delErr != nil {
+			utils.LogWarn(svc.log, ctx, "packaging_service", "Package", delErr)
+		}
+		return "", err
+	}
+
+	return prefix, nil
+}
+
+func (svc *packagingService) download(ctx context.Context, key, name string) (err error) {
+	body, _, err := svc.store.Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, body); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+func (svc *packagingService) upload(ctx context.Context, outDir, prefix string) (err error) {
+	return filepath.WalkDir(outDir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(outDir, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !hls.ValidFileName(rel) {
+			return fmt.Errorf("packaging: unexpected file %q in package", rel)
+		}
+
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		return svc.store.Put(ctx, prefix+"/"+rel, file, utils.ContentTypeByExtension(path.Ext(rel)))
+	})
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/mocks"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/storage"
+)
+
+type PackagingServiceTestSuite struct {
+	suite.Suite
+	Svc      contracts.PackagingService
+	songRepo *mocks.MockSongRepository
+	store    *mocks.MockStorage
+	packager *mocks.MockPackager
+	jobs     *mocks.MockEnqueuer
+}
+
+func (s *PackagingServiceTestSuite) SetupTest() {
+	s.songRepo = new(mocks.MockSongRepository)
+	s.store = new(mocks.MockStorage)
+	s.packager = new(mocks.MockPackager)
+	s.jobs = new(mocks.MockEnqueuer)
+	s.Svc = NewPackagingService(s.songRepo, s.store, s.packager, s.jobs, nil)
+}
+
+func (s *PackagingServiceTestSuite) ResetMocks() {
+	s.songRepo.ExpectedCalls = nil
+	s.songRepo.Calls = nil
+	s.store.ExpectedCalls = nil
+	s.store.Calls = nil
+	s.packager.ExpectedCalls = nil
+	s.packager.Calls = nil
+	s.jobs.ExpectedCalls = nil
+	s.jobs.Calls = nil
+}
+
+func (s *PackagingServiceTestSuite) TestPackage() {
+	song := &models.Song{Id: 1, Audio: "songs/1/a.mp3", ProcessingState: models.SongProcessingPending, HLS: "songs/1/hls/old"}
+	audio := func() any {
+		return &fakeReadSeekCloser{Reader: bytes.NewReader([]byte("audio"))}
+	}
+	isPackageKey := func(name string) any {
+		return mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, "songs/1/hls/") && strings.HasSuffix(key, "/"+name) && !strings.HasPrefix(key, "songs/1/hls/old/")
+		})
+	}
+	isNewPrefix := mock.MatchedBy(func(prefix string) bool {
+		return strings.HasPrefix(prefix, "songs/1/hls/") && prefix != "songs/1/hls/old"
+	})
+	isProcessing := func(state string) any {
+		return mock.MatchedBy(func(input models.SongProcessingInput) bool {
+			packaged := strings.HasPrefix(input.HLS, "songs/1/hls/") && input.HLS != "songs/1/hls/old"
+			return input.Audio == song.Audio && input.State == state && packaged == (state == models.SongProcessingReady)
+		})
+	}
+	// writePackage makes the packager output a master playlist and one segment
+	writePackage := func(args mock.Arguments) {
+		outDir := args.String(2)
+		s.Require().NoError(os.WriteFile(filepath.Join(outDir, "master.m3u8"), []byte("#EXTM3U"), 0o644))
+		s.Require().NoError(os.MkdirAll(filepath.Join(outDir, "128k"), 0o755))
+		s.Require().NoError(os.WriteFile(filepath.Join(outDir, "128k", "segment_000.ts"), []byte("ts"), 0o644))
+	}
+	isInput := mock.MatchedBy(func(input string) bool {
+		data, err := os.ReadFile(input)
+		return err == nil && string(data) == "audio" && filepath.Ext(input) == ".mp3"
+	})
+
+	testCases := []struct {
+		name        string
+		prepareMock func()
+		expectedErr error
+	}{
+		{
+			name: "success replaces the previous package",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(song, nil)
+				s.store.On("Open", mock.Anything, "songs/1/a.mp3").Return(audio(), storage.Object{}, nil)
+				s.packager.On("Package", mock.Anything, isInput, mock.Anything).Run(writePackage).Return(nil)
+				s.store.On("Put", mock.Anything, isPackageKey("master.m3u8"), []byte("#EXTM3U"), "application/vnd.apple.mpegurl").Return(nil)
+				s.store.On("Put", mock.Anything, isPackageKey("128k/segment_000.ts"), []byte("ts"), "video/mp2t").Return(nil)
+				s.songRepo.On("UpdateProcessing", mock.Anything, 1, isProcessing(models.SongProcessingReady)).Return(true, nil)
+				s.store.On("DeletePrefix", mock.Anything, "songs/1/hls/old").Return(nil)
+			},
+		},
+		{
+			name: "song deleted meanwhile",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(nil, nil)
+			},
+		},
+		{
+			name: "external url is skipped",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1, Audio: "https://cdn.example.com/a.mp3"}, nil)
+			},
+		},
+		{
+			name: "packager failure marks the song failed",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(song, nil)
+				s.store.On("Open", mock.Anything, "songs/1/a.mp3").Return(audio(), storage.Object{}, nil)
+				s.packager.On("Package", mock.Anything, isInput, mock.Anything).Return(errors.New("ffmpeg failure"))
+				s.songRepo.On("UpdateProcessing", mock.Anything, 1, isProcessing(models.SongProcessingFailed)).Return(true, nil)
+				s.store.On("DeletePrefix", mock.Anything, "songs/1/hls/old").Return(nil)
+			},
+			expectedErr: errors.New("ffmpeg failure"),
+		},
+		{
+			name: "upload failure removes the partial package",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(song, nil)
+				s.store.On("Open", mock.Anything, "songs/1/a.mp3").Return(audio(), storage.Object{}, nil)
+				s.packager.On("Package", mock.Anything, isInput, mock.Anything).Run(writePackage).Return(nil)
+				s.store.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("disk full"))
+				s.store.On("DeletePrefix", mock.Anything, isNewPrefix).Return(nil).Once()
+				s.songRepo.On("UpdateProcessing", mock.Anything, 1, isProcessing(models.SongProcessingFailed)).Return(true, nil)
+				s.store.On("DeletePrefix", mock.Anything, "songs/1/hls/old").Return(nil)
+			},
+			expectedErr: errors.New("disk full"),
+		},
+		{
+			name: "audio replaced during packaging drops the package",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(song, nil)
+				s.store.On("Open", mock.Anything, "songs/1/a.mp3").Return(audio(), storage.Object{}, nil)
+				s.packager.On("Package", mock.Anything, isInput, mock.Anything).Run(writePackage).Return(nil)
+				s.store.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				s.songRepo.On("UpdateProcessing", mock.Anything, 1, isProcessing(models.SongProcessingReady)).Return(false, nil)
+				s.store.On("DeletePrefix", mock.Anything, isNewPrefix).Return(nil)
+			},
+		},
+		{
+			name: "FindSongById error",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(nil, errors.New("database failure"))
+			},
+			expectedErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			tc.prepareMock()
+
+			// Actual
+			err := s.Svc.Package(s.T().Context(), 1)
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.songRepo.AssertExpectations(s.T())
+			s.store.AssertExpectations(s.T())
+			s.packager.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *PackagingServiceTestSuite) TestEnqueue() {
+	s.Run("success", func() {
+		s.ResetMocks()
+		s.jobs.On("Enqueue", mock.Anything, models.PackageSongJob{SongID: 1}, 2).Return(nil)
+
+		s.NoError(s.Svc.Enqueue(s.T().Context(), 1))
+		s.jobs.AssertExpectations(s.T())
+	})
+
+	s.Run("queue error", func() {
+		s.ResetMocks()
+		s.jobs.On("Enqueue", mock.Anything, models.PackageSongJob{SongID: 1}, 2).Return(errors.New("database failure"))
+
+		s.EqualError(s.Svc.Enqueue(s.T().Context(), 1), "database failure")
+	})
+}
+
+func TestPackagingServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(PackagingServiceTestSuite))
+}
@@ -49,6 +49,41 @@ func (svc *playlistService) GetAll(ctx context.Context, userRole string, userId,
 	return playlists, total, nil
 }
 
+func (svc *playlistService) GetAllByCursor(ctx context.Context, userRole string, userId int, cursor string, pageSize int, withTotal bool) (playlists []dto.Playlist, pagination dto.CursorPagination, err error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, pagination, err
+	}
+
+	results, err := svc.repo.FindAllByCursor(ctx, userRole, userId, after, pageSize+1)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "playlist_service", "GetAllByCursor", err)
+		return nil, pagination, err
+	}
+	results, pagination = cursorPage(results, pageSize, func(playlist models.Playlist) utils.Cursor {
+		return utils.Cursor{Id: playlist.Id}
+	})
+
+	if withTotal {
+		total, err := svc.repo.FindCount(ctx, userRole, userId)
+		if err != nil {
+			utils.LogError(svc.log, ctx, "playlist_service", "GetAllByCursor", err)
+			return nil, pagination, err
+		}
+		pagination.Total = &total
+	}
+
+	playlists = make([]dto.Playlist, 0, len(results))
+	for _, result := range results {
+		playlists = append(playlists, dto.Playlist{
+			Id:   result.Id,
+			Name: result.Name,
+		})
+	}
+
+	return playlists, pagination, nil
+}
+
 func (svc *playlistService) GetPlaylistById(ctx context.Context, userRole string, userId, id int) (playlist dto.Playlist, err error) {
 	result, err := svc.repo.FindById(ctx, userRole, userId, id)
 	if err != nil {
@@ -156,11 +191,12 @@ func (svc *playlistService) GetPlaylistSongs(ctx context.Context, role string, u
 	songs = make([]dto.Song, 0, len(results))
 	for _, v := range results {
 		song := dto.Song{
-			Id:       v.Id,
-			Title:    v.Title,
-			Audio:    v.Audio,
-			Duration: v.Duration,
-			Image:    utils.ParseImageToJSON(v.Image),
+			Id:              v.Id,
+			Title:           v.Title,
+			Audio:           v.Audio,
+			Duration:        v.Duration,
+			ProcessingState: v.ProcessingState,
+			Image:           utils.ParseImageToJSON(v.Image),
 			Album: dto.AlbumWithArtist{
 				Album: dto.Album{
 					Id:    v.Album.Id,
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type searchService struct {
+	repo contracts.SearchRepository
+	log  *logrus.Logger
+}
+
+func NewSearchService(repo contracts.SearchRepository, log *logrus.Logger) contracts.SearchService {
+	return &searchService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+func (svc *searchService) Search(ctx context.Context, req dto.SearchRequest, pageSize, offset int) (result dto.SearchResult, err error) {
+	if errorsMap, err := utils.RequestValidate(&req); err != nil {
+		return result, errs.NewBadRequestError("validation failed", errorsMap)
+	}
+
+	tsQuery := utils.BuildPrefixTsQuery(req.Query)
+	if tsQuery == "" {
+		return result, errs.NewBadRequestError("validation failed", map[string]string{"q": "Must contain a letter or digit"})
+	}
+
+	if req.Type == "" || req.Type == "song" {
+		songs, err := svc.repo.SearchSongs(ctx, tsQuery, pageSize, offset)
+		if err != nil {
+			utils.LogError(svc.log, ctx, "search_service", "Search", err)
+			return result, err
+		}
+		for _, song := range songs {
+			result.Songs = append(result.Songs, newSongDTO(song))
+		}
+	}
+
+	if req.Type == "" || req.Type == "album" {
+		albums, err := svc.repo.SearchAlbums(ctx, tsQuery, pageSize, offset)
+		if err != nil {
+			utils.LogError(svc.log, ctx, "search_service", "Search", err)
+			return result, err
+		}
+		for _, album := range albums {
+			result.Albums = append(result.Albums, dto.AlbumWithArtist{
+				Album: dto.Album{
+					Id:    album.Id,
+					Name:  album.Name,
+					Slug:  album.Slug,
+					Image: utils.ParseImageToJSON(album.Image),
+				},
+				Artist: dto.Artist{
+					Id:    album.Artist.Id,
+					Name:  album.Artist.Name,
+					Slug:  album.Artist.Slug,
+					Image: utils.ParseImageToJSON(album.Artist.Image),
+				},
+			})
+		}
+	}
+
+	if req.Type == "" || req.Type == "artist" {
+		artists, err := svc.repo.SearchArtists(ctx, tsQuery, pageSize, offset)
+		if err != nil {
+			utils.LogError(svc.log, ctx, "search_service", "Search", err)
+			return result, err
+		}
+		for _, artist := range artists {
+			result.Artists = append(result.Artists, dto.Artist{
+				Id:    artist.Id,
+				Name:  artist.Name,
+				Slug:  artist.Slug,
+				Image: utils.ParseImageToJSON(artist.Image),
+			})
+		}
+	}
+
+	if req.Type == "" || req.Type == "genre" {
+		genres, err := svc.repo.SearchGenres(ctx, tsQuery, pageSize, offset)
+		if err != nil {
+			utils.LogError(svc.log, ctx, "search_service", "Search", err)
+			return result, err
+		}
+		for _, genre := range genres {
+			result.Genres = append(result.Genres, dto.Genre{
+				Id:    genre.Id,
+				Name:  genre.Name,
+				Image: utils.ParseImageToJSON(genre.Image),
+			})
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/app/mocks"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type SearchServiceTestSuite struct {
+	suite.Suite
+	Svc        contracts.SearchService
+	searchRepo *mocks.MockSearchRepository
+}
+
+func (s *SearchServiceTestSuite) SetupTest() {
+	s.searchRepo = new(mocks.MockSearchRepository)
+	s.Svc = NewSearchService(s.searchRepo, nil)
+}
+
+func (s *SearchServiceTestSuite) ResetMocks() {
+	s.searchRepo.ExpectedCalls = nil
+	s.searchRepo.Calls = nil
+}
+
+func (s *SearchServiceTestSuite) TestSearch() {
+	image := dto.Image{Src: "image.png", BlurHash: "abc"}
+	imageBytes := utils.ParseImageToByte(&image)
+	artist := models.Artist{Id: 1, Name: "Noah", Slug: "noah", Image: imageBytes}
+	expectArtist := dto.Artist{Id: 1, Name: "Noah", Slug: "noah", Image: image}
+
+	testCases := []struct {
+		name         string
+		req          dto.SearchRequest
+		prepareMock  func()
+		expectResult dto.SearchResult
+		expectErr    error
+	}{
+		{
+			name: "all types with prefix query",
+			req:  dto.SearchRequest{Query: "  Noäh, Band! "},
+			prepareMock: func() {
+				s.searchRepo.On("SearchSongs", mock.Anything, "noäh:* & band:*", 10, 0).Return(nil, nil)
+				s.searchRepo.On("SearchAlbums", mock.Anything, "noäh:* & band:*", 10, 0).Return([]models.AlbumWithArtist{
+					{Album: models.Album{Id: 2, ArtistId: 1, Name: "Noah band", Slug: "noah-band", Image: imageBytes}, Artist: artist},
+				}, nil)
+				s.searchRepo.On("SearchArtists", mock.Anything, "noäh:* & band:*", 10, 0).Return([]models.Artist{artist}, nil)
+				s.searchRepo.On("SearchGenres", mock.Anything, "noäh:* & band:*", 10, 0).Return(nil, nil)
+			},
+			expectResult: dto.SearchResult{
+				Albums: []dto.AlbumWithArtist{
+					{Album: dto.Album{Id: 2, Name: "Noah band", Slug: "noah-band", Image: image}, Artist: expectArtist},
+				},
+				Artists: []dto.Artist{expectArtist},
+			},
+		},
+		{
+			name: "only genres",
+			req:  dto.SearchRequest{Query: "po", Type: "genre"},
+			prepareMock: func() {
+				s.searchRepo.On("SearchGenres", mock.Anything, "po:*", 10, 0).Return([]models.Genre{
+					{Id: 3, Name: "Pop", Image: imageBytes},
+				}, nil)
+			},
+			expectResult: dto.SearchResult{
+				Genres: []dto.Genre{{Id: 3, Name: "Pop", Image: image}},
+			},
+		},
+		{
+			name:      "missing query",
+			req:       dto.SearchRequest{},
+			expectErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name:      "invalid type",
+			req:       dto.SearchRequest{Query: "noah", Type: "playlist"},
+			expectErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name:      "query without words",
+			req:       dto.SearchRequest{Query: "&|!:*"},
+			expectErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name: "SearchSongs error",
+			req:  dto.SearchRequest{Query: "aku", Type: "song"},
+			prepareMock: func() {
+				s.searchRepo.On("SearchSongs", mock.Anything, "aku:*", 10, 0).Return(nil, errors.New("database failure"))
+			},
+			expectErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			if tc.prepareMock != nil {
+				tc.prepareMock()
+			}
+
+			// Actual
+			result, err := s.Svc.Search(s.T().Context(), tc.req, 10, 0)
+
+			// Assert
+			if tc.expectErr == nil {
+				s.NoError(err)
+				s.Equal(tc.expectResult, result)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectErr.Error())
+			}
+
+			s.searchRepo.AssertExpectations(s.T())
+		})
+	}
+}
+
+func TestSearchServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(SearchServiceTestSuite))
+}
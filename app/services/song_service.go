@@ -1,38 +1,60 @@
 package services
 
 import (
+	"bytes"
 	"context"
-
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/database"
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/audiometa"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/hls"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/storage"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
+// audioDurationTolerance seconds a client supplied duration may differ from the uploaded file.
+const audioDurationTolerance = 2
+
 type songService struct {
 	songRepo  contracts.SongRepository
 	albumRepo contracts.AlbumRepository
+	store     storage.Storage
+	packaging contracts.PackagingService
+	tx        database.Transactor
 	log       *logrus.Logger
 }
 
-func NewSongService(songRepo contracts.SongRepository, albumRepo contracts.AlbumRepository, log *logrus.Logger) contracts.SongService {
+func NewSongService(songRepo contracts.SongRepository, albumRepo contracts.AlbumRepository, store storage.Storage, packaging contracts.PackagingService, tx database.Transactor, log *logrus.Logger) contracts.SongService {
 	return &songService{
 		songRepo:  songRepo,
 		albumRepo: albumRepo,
+		store:     store,
+		packaging: packaging,
+		tx:        tx,
 		log:       log,
 	}
 }
 
-func (svc *songService) GetAll(ctx context.Context, pageSize int, offset int) (songs []dto.Song, total int, err error) {
-	total, err = svc.songRepo.FindCount(ctx)
+func (svc *songService) GetAll(ctx context.Context, spec query.Spec, pageSize int, offset int) (songs []dto.Song, total int, err error) {
+	total, err = svc.songRepo.FindCount(ctx, spec)
 	if err != nil {
 		utils.LogError(svc.log, ctx, "song_service", "GetAll", err)
 		return nil, 0, err
 	}
 
-	results, err := svc.songRepo.FindAll(ctx, pageSize, offset)
+	results, err := svc.songRepo.FindAll(ctx, spec, pageSize, offset)
 	if err != nil {
 		utils.LogError(svc.log, ctx, "song_service", "GetAll", err)
 		return nil, 0, err
@@ -42,11 +64,12 @@ func (svc *songService) GetAll(ctx context.Context, pageSize int, offset int) (s
 
 	for _, v := range results {
 		song := dto.Song{
-			Id:       v.Id,
-			Title:    v.Title,
-			Audio:    v.Audio,
-			Duration: v.Duration,
-			Image:    utils.ParseImageToJSON(v.Image),
+			Id:              v.Id,
+			Title:           v.Title,
+			Audio:           v.Audio,
+			Duration:        v.Duration,
+			ProcessingState: v.ProcessingState,
+			Image:           utils.ParseImageToJSON(v.Image),
 			Album: dto.AlbumWithArtist{
 				Album: dto.Album{
 					Id:    v.Album.Id,
@@ -68,6 +91,42 @@ func (svc *songService) GetAll(ctx context.Context, pageSize int, offset int) (s
 	return songs, total, nil
 }
 
+func (svc *songService) GetAllByCursor(ctx context.Context, spec query.Spec, cursor string, pageSize int, withTotal bool) (songs []dto.Song, pagination dto.CursorPagination, err error) {
+	if err := checkCursorSpec(spec); err != nil {
+		return nil, pagination, err
+	}
+
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, pagination, err
+	}
+
+	results, err := svc.songRepo.FindAllByCursor(ctx, spec, after, pageSize+1)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "song_service", "GetAllByCursor", err)
+		return nil, pagination, err
+	}
+	results, pagination = cursorPage(results, pageSize, func(song models.Song) utils.Cursor {
+		return utils.Cursor{Id: song.Id}
+	})
+
+	if withTotal {
+		total, err := svc.songRepo.FindCount(ctx, spec)
+		if err != nil {
+			utils.LogError(svc.log, ctx, "song_service", "GetAllByCursor", err)
+			return nil, pagination, err
+		}
+		pagination.Total = &total
+	}
+
+	songs = make([]dto.Song, 0, len(results))
+	for _, result := range results {
+		songs = append(songs, newSongDTO(result))
+	}
+
+	return songs, pagination, nil
+}
+
 func (svc *songService) GetSongById(ctx context.Context, id int) (song dto.Song, err error) {
 	result, err := svc.songRepo.FindSongById(ctx, id)
 	if err != nil {
@@ -85,6 +144,7 @@ func (svc *songService) GetSongById(ctx context.Context, id int) (song dto.Song,
 	song.Title = result.Title
 	song.Audio = result.Audio
 	song.Duration = result.Duration
+	song.ProcessingState = result.ProcessingState
 	song.Image = utils.ParseImageToJSON(result.Image)
 	song.Album = dto.AlbumWithArtist{
 		Album: dto.Album{
@@ -123,7 +183,6 @@ func (svc *songService) CreateSong(ctx context.Context, req dto.CreateSongReques
 	input := models.CreateSongInput{
 		AlbumId:  req.AlbumId,
 		Title:    req.Title,
-		Audio:    req.Audio,
 		Duration: req.Duration,
 		Image:    utils.ParseImageToByte(req.Image),
 	}
@@ -166,7 +225,6 @@ func (svc *songService) UpdateSong(ctx context.Context, req dto.CreateSongReques
 	input := models.CreateSongInput{
 		AlbumId:  req.AlbumId,
 		Title:    req.Title,
-		Audio:    req.Audio,
 		Duration: req.Duration,
 		Image:    utils.ParseImageToByte(req.Image),
 	}
@@ -179,6 +237,238 @@ func (svc *songService) UpdateSong(ctx context.Context, req dto.CreateSongReques
 	return
 }
 
+func (svc *songService) UploadAudio(ctx context.Context, id int, file io.Reader) (upload dto.SongAudioUpload, err error) {
+	song, err := svc.songRepo.FindSongById(ctx, id)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "song_service", "UploadAudio", err)
+		return upload, err
+	}
+	if song == nil {
+		notFoundErr := errs.NewNotFoundError("Song", "id", id)
+		utils.LogWarn(svc.log, ctx, "song_service", "UploadAudio", notFoundErr)
+		return upload, notFoundErr
+	}
+
+	head := make([]byte, utils.AudioSniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		utils.LogError(svc.log, ctx, "song_service", "UploadAudio", err)
+		return upload, err
+	}
+	head = head[:n]
+
+	contentType, ext := utils.DetectAudioType(head)
+	if contentType == "" {
+		return upload, errs.NewBadRequestError("validation failed", map[string]string{
+			"audio": "Unsupported audio format, allowed: mp3, flac, ogg, wav, m4a",
+		})
+	}
+
+	// A fresh key per upload, so a cached copy of the previous file is never served for the new one
+	key := fmt.Sprintf("songs/%d/%s%s", id, uuid.NewString(), ext)
+	if err := svc.store.Put(ctx, key, io.MultiReader(bytes.NewReader(head), file), contentType); err != nil {
+		utils.LogError(svc.log, ctx, "song_service", "UploadAudio", err)
+		return upload, err
+	}
+
+	meta, err := svc.readAudioMetadata(ctx, key)
+	if err != nil {
+		if delErr := svc.store.Delete(ctx, key); delErr != nil {
+			utils.LogWarn(svc.log, ctx, "song_service", "UploadAudio", delErr)
+		}
+		if errors.Is(err, audiometa.ErrInvalid) {
+			utils.LogWarn(svc.log, ctx, "song_service", "UploadAudio", err)
+			return upload, errs.NewBadRequestError("validation failed", map[string]string{
+				"audio": "Audio file is corrupt or truncated",
+			})
+		}
+
+		utils.LogError(svc.log, ctx, "song_service", "UploadAudio", err)
+		return upload, err
+	}
+
+	input := models.SongAudioInput{
+		Audio:      key,
+		Duration:   song.Duration,
+		Bitrate:    meta.Bitrate,
+		SampleRate: meta.SampleRate,
+		Channels:   meta.Channels,
+		TagTitle:   meta.Title,
+		TagArtist:  meta.Artist,
+		TagAlbum:   meta.Album,
+	}
+	if meta.Duration > 0 {
+		input.Duration = int(meta.Duration.Round(time.Second) / time.Second)
+	}
+
+	// Packaging is queued with the update, a song never stays pending without a job
+	err = svc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := svc.songRepo.UpdateAudio(ctx, id, input); err != nil {
+			return err
+		}
+		return svc.packaging.Enqueue(ctx, id)
+	})
+	if err != nil {
+		utils.LogError(svc.log, ctx, "song_service", "UploadAudio", err)
+		if delErr := svc.store.Delete(ctx, key); delErr != nil {
+			utils.LogWarn(svc.log, ctx, "song_service", "UploadAudio", delErr)
+		}
+		return upload, err
+	}
+
+	// Songs created before uploads keep an external URL, there is nothing stored to remove
+	if storage.IsKey(song.Audio) {
+		if err := svc.store.Delete(ctx, song.Audio); err != nil {
+			utils.LogWarn(svc.log, ctx, "song_service", "UploadAudio", err)
+		}
+	}
+
+	upload = dto.SongAudioUpload{
+		Audio:      key,
+		Format:     meta.Format,
+		Duration:   input.Duration,
+		Bitrate:    meta.Bitrate,
+		SampleRate: meta.SampleRate,
+		Channels:   meta.Channels,
+		Title:      meta.Title,
+		Artist:     meta.Artist,
+		Album:      meta.Album,
+		Mismatches: audioMismatches(*song, input),
+	}
+
+	return upload, nil
+}
+
+// readAudioMetadata parses the stored file. Formats without a parser, wav and m4a, give empty
+// metadata and the song keeps the values it was created with.
+func (svc *songService) readAudioMetadata(ctx context.Context, key string) (meta audiometa.Metadata, err error) {
+	body, _, err := svc.store.Open(ctx, key)
+	if err != nil {
+		return meta, err
+	}
+	defer body.Close()
+
+	meta, err = audiometa.Parse(body)
+	if errors.Is(err, audiometa.ErrUnsupported) {
+		return audiometa.Metadata{}, nil
+	}
+
+	return meta, err
+}
+
+// audioMismatches compares the song with the uploaded file, values the file does not carry
+// are not compared. Durations within audioDurationTolerance seconds are taken as equal.
+func audioMismatches(song models.Song, input models.SongAudioInput) []dto.AudioMismatch {
+	mismatches := make([]dto.AudioMismatch, 0)
+
+	if song.Duration > 0 && input.Duration != song.Duration {
+		if diff := input.Duration - song.Duration; diff > audioDurationTolerance || diff < -audioDurationTolerance {
+			mismatches = append(mismatches, dto.AudioMismatch{
+				Field:  "duration",
+				Song:   strconv.Itoa(song.Duration),
+				Parsed: strconv.Itoa(input.Duration),
+			})
+		}
+	}
+
+	tags := []struct {
+		field  string
+		song   string
+		parsed string
+	}{
+		{"title", song.Title, input.TagTitle},
+		{"artist", song.Album.Artist.Name, input.TagArtist},
+		{"album", song.Album.Name, input.TagAlbum},
+	}
+	for _, tag := range tags {
+		parsed := strings.TrimSpace(tag.parsed)
+		if parsed != "" && !strings.EqualFold(parsed, strings.TrimSpace(tag.song)) {
+			mismatches = append(mismatches, dto.AudioMismatch{Field: tag.field, Song: tag.song, Parsed: parsed})
+		}
+	}
+
+	return mismatches
+}
+
+func (svc *songService) OpenAudio(ctx context.Context, id int) (audio models.SongAudio, err error) {
+	song, err := svc.songRepo.FindSongById(ctx, id)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "song_service", "OpenAudio", err)
+		return audio, err
+	}
+	if song == nil {
+		notFoundErr := errs.NewNotFoundError("Song", "id", id)
+		utils.LogWarn(svc.log, ctx, "song_service", "OpenAudio", notFoundErr)
+		return audio, notFoundErr
+	}
+
+	// Nothing uploaded yet, or an external URL saved before uploads existed
+	if !storage.IsKey(song.Audio) {
+		notFoundErr := errs.NewNotFoundError("Song audio", "id", id)
+		utils.LogWarn(svc.log, ctx, "song_service", "OpenAudio", notFoundErr)
+		return audio, notFoundErr
+	}
+
+	body, obj, err := svc.store.Open(ctx, song.Audio)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			notFoundErr := errs.NewNotFoundError("Song audio", "id", id)
+			utils.LogWarn(svc.log, ctx, "song_service", "OpenAudio", notFoundErr)
+			return audio, notFoundErr
+		}
+
+		utils.LogError(svc.log, ctx, "song_service", "OpenAudio", err)
+		return audio, err
+	}
+
+	return models.SongAudio{
+		SongId:      song.Id,
+		Duration:    song.Duration,
+		Body:        body,
+		Size:        obj.Size,
+		ContentType: obj.ContentType,
+		ModTime:     obj.ModTime,
+	}, nil
+}
+
+func (svc *songService) OpenHLS(ctx context.Context, id int, name string) (file models.StoredFile, err error) {
+	song, err := svc.songRepo.FindSongById(ctx, id)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "song_service", "OpenHLS", err)
+		return file, err
+	}
+	if song == nil {
+		notFoundErr := errs.NewNotFoundError("Song", "id", id)
+		utils.LogWarn(svc.log, ctx, "song_service", "OpenHLS", notFoundErr)
+		return file, notFoundErr
+	}
+
+	if song.ProcessingState != models.SongProcessingReady || song.HLS == "" || !hls.ValidFileName(name) {
+		notFoundErr := errs.NewNotFoundError("Song HLS file", "name", name)
+		utils.LogWarn(svc.log, ctx, "song_service", "OpenHLS", notFoundErr)
+		return file, notFoundErr
+	}
+
+	body, obj, err := svc.store.Open(ctx, song.HLS+"/"+name)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			notFoundErr := errs.NewNotFoundError("Song HLS file", "name", name)
+			utils.LogWarn(svc.log, ctx, "song_service", "OpenHLS", notFoundErr)
+			return file, notFoundErr
+		}
+
+		utils.LogError(svc.log, ctx, "song_service", "OpenHLS", err)
+		return file, err
+	}
+
+	return models.StoredFile{
+		Body:        body,
+		Size:        obj.Size,
+		ContentType: obj.ContentType,
+		ModTime:     obj.ModTime,
+	}, nil
+}
+
 func (svc *songService) DeleteSong(ctx context.Context, id int) (err error) {
 	exists, err := svc.songRepo.FindExistsSongById(ctx, id)
 	if err != nil {
@@ -196,6 +486,11 @@ func (svc *songService) DeleteSong(ctx context.Context, id int) (err error) {
 		return err
 	}
 
+	// The uploaded audio and its HLS packages go with the song, the song is deleted either way
+	if err := svc.store.DeletePrefix(ctx, fmt.Sprintf("songs/%d", id)); err != nil {
+		utils.LogWarn(svc.log, ctx, "song_service", "DeleteSong", err)
+	}
+
 	return
 }
 
@@ -216,11 +511,12 @@ func (svc *songService) GetSongsByAlbumId(ctx context.Context, albumId, pageSize
 
 	for _, v := range results {
 		song := dto.Song{
-			Id:       v.Id,
-			Title:    v.Title,
-			Audio:    v.Audio,
-			Duration: v.Duration,
-			Image:    utils.ParseImageToJSON(v.Image),
+			Id:              v.Id,
+			Title:           v.Title,
+			Audio:           v.Audio,
+			Duration:        v.Duration,
+			ProcessingState: v.ProcessingState,
+			Image:           utils.ParseImageToJSON(v.Image),
 			Album: dto.AlbumWithArtist{
 				Album: dto.Album{
 					Id:    v.Album.Id,
@@ -241,3 +537,29 @@ func (svc *songService) GetSongsByAlbumId(ctx context.Context, albumId, pageSize
 
 	return songs, total, nil
 }
+
+// newSongDTO maps a song model with its album and artist to the dto shape.
+func newSongDTO(v models.Song) dto.Song {
+	return dto.Song{
+		Id:              v.Id,
+		Title:           v.Title,
+		Audio:           v.Audio,
+		Duration:        v.Duration,
+		ProcessingState: v.ProcessingState,
+		Image:           utils.ParseImageToJSON(v.Image),
+		Album: dto.AlbumWithArtist{
+			Album: dto.Album{
+				Id:    v.Album.Id,
+				Name:  v.Album.Name,
+				Slug:  v.Album.Slug,
+				Image: utils.ParseImageToJSON(v.Album.Image),
+			},
+			Artist: dto.Artist{
+				Id:    v.Album.Artist.Id,
+				Name:  v.Album.Artist.Name,
+				Slug:  v.Album.Artist.Slug,
+				Image: utils.ParseImageToJSON(v.Album.Artist.Image),
+			},
+		},
+	}
+}
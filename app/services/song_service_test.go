@@ -1,8 +1,11 @@
 package services
 
 import (
+	"bytes"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -11,6 +14,8 @@ import (
 	"github.com/wahyusahajaa/mulo-api-go/app/mocks"
 	"github.com/wahyusahajaa/mulo-api-go/app/models"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/query"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/storage"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
 )
 
@@ -19,12 +24,18 @@ type SongServiceTestSuite struct {
 	Svc       contracts.SongService
 	songRepo  *mocks.MockSongRepository
 	albumRepo *mocks.MockAlbumRepository
+	store     *mocks.MockStorage
+	packaging *mocks.MockPackagingService
+	tx        *mocks.MockTransactor
 }
 
 func (s *SongServiceTestSuite) SetupTest() {
 	s.songRepo = new(mocks.MockSongRepository)
 	s.albumRepo = new(mocks.MockAlbumRepository)
-	s.Svc = NewSongService(s.songRepo, s.albumRepo, nil)
+	s.store = new(mocks.MockStorage)
+	s.packaging = new(mocks.MockPackagingService)
+	s.tx = new(mocks.MockTransactor)
+	s.Svc = NewSongService(s.songRepo, s.albumRepo, s.store, s.packaging, s.tx, nil)
 }
 
 func (s *SongServiceTestSuite) ResetMocks() {
@@ -32,6 +43,12 @@ func (s *SongServiceTestSuite) ResetMocks() {
 	s.songRepo.Calls = nil
 	s.albumRepo.ExpectedCalls = nil
 	s.albumRepo.Calls = nil
+	s.store.ExpectedCalls = nil
+	s.store.Calls = nil
+	s.packaging.ExpectedCalls = nil
+	s.packaging.Calls = nil
+	s.tx.ExpectedCalls = nil
+	s.tx.Calls = nil
 }
 
 func (s *SongServiceTestSuite) TestGetAll() {
@@ -49,8 +66,8 @@ func (s *SongServiceTestSuite) TestGetAll() {
 			name: "success",
 			prepareMock: func() {
 				// Setup mocks
-				s.songRepo.On("FindCount", mock.Anything).Return(1, nil)
-				s.songRepo.On("FindAll", mock.Anything, 10, 0).Return([]models.Song{
+				s.songRepo.On("FindCount", mock.Anything, query.Spec{}).Return(1, nil)
+				s.songRepo.On("FindAll", mock.Anything, query.Spec{}, 10, 0).Return([]models.Song{
 					{
 						Id:       1,
 						AlbumId:  1,
@@ -104,15 +121,15 @@ func (s *SongServiceTestSuite) TestGetAll() {
 		{
 			name: "FindCountError",
 			prepareMock: func() {
-				s.songRepo.On("FindCount", mock.Anything).Return(0, errors.New("database failure"))
+				s.songRepo.On("FindCount", mock.Anything, query.Spec{}).Return(0, errors.New("database failure"))
 			},
 			expectedErr: errors.New("database failure"),
 		},
 		{
 			name: "findAllError",
 			prepareMock: func() {
-				s.songRepo.On("FindCount", mock.Anything).Return(1, nil)
-				s.songRepo.On("FindAll", mock.Anything, 10, 0).Return(nil, errors.New("database failed"))
+				s.songRepo.On("FindCount", mock.Anything, query.Spec{}).Return(1, nil)
+				s.songRepo.On("FindAll", mock.Anything, query.Spec{}, 10, 0).Return(nil, errors.New("database failed"))
 			},
 			expectedErr: errors.New("database failed"),
 		},
@@ -124,7 +141,7 @@ func (s *SongServiceTestSuite) TestGetAll() {
 			tc.prepareMock()
 
 			// Actual
-			results, total, err := s.Svc.GetAll(s.T().Context(), 10, 0)
+			results, total, err := s.Svc.GetAll(s.T().Context(), query.Spec{}, 10, 0)
 
 			// Assert
 			if tc.expectedErr == nil {
@@ -141,6 +158,98 @@ func (s *SongServiceTestSuite) TestGetAll() {
 	}
 }
 
+func (s *SongServiceTestSuite) TestGetAllByCursor() {
+	songs := []models.Song{{Id: 9, Title: "Song 9"}, {Id: 8, Title: "Song 8"}, {Id: 7, Title: "Song 7"}}
+	total := 30
+
+	testCases := []struct {
+		name          string
+		spec          query.Spec
+		cursor        string
+		withTotal     bool
+		prepareMock   func()
+		expectedIds   []int
+		expectedNext  string
+		expectedTotal *int
+		expectedErr   error
+	}{
+		{
+			name: "first page with next cursor",
+			prepareMock: func() {
+				s.songRepo.On("FindAllByCursor", mock.Anything, query.Spec{}, utils.Cursor{}, 3).Return(songs, nil)
+			},
+			expectedIds:  []int{9, 8},
+			expectedNext: utils.EncodeCursor(utils.Cursor{Id: 8}),
+		},
+		{
+			name:      "last page with total",
+			cursor:    utils.EncodeCursor(utils.Cursor{Id: 8}),
+			withTotal: true,
+			prepareMock: func() {
+				s.songRepo.On("FindAllByCursor", mock.Anything, query.Spec{}, utils.Cursor{Id: 8}, 3).Return(songs[2:], nil)
+				s.songRepo.On("FindCount", mock.Anything, query.Spec{}).Return(total, nil)
+			},
+			expectedIds:   []int{7},
+			expectedTotal: &total,
+		},
+		{
+			name:        "invalid cursor",
+			cursor:      "not-a-cursor",
+			expectedErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name:        "sort with cursor",
+			spec:        query.Spec{Sort: []query.Sort{{Field: "title"}}},
+			expectedErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name: "filtered page",
+			spec: query.Spec{Filters: []query.Filter{{Field: "album_id", Value: 1}}},
+			prepareMock: func() {
+				s.songRepo.On("FindAllByCursor", mock.Anything, query.Spec{Filters: []query.Filter{{Field: "album_id", Value: 1}}}, utils.Cursor{}, 3).Return(songs[2:], nil)
+			},
+			expectedIds: []int{7},
+		},
+		{
+			name: "FindAllByCursor error",
+			prepareMock: func() {
+				s.songRepo.On("FindAllByCursor", mock.Anything, query.Spec{}, utils.Cursor{}, 3).Return(nil, errors.New("database failure"))
+			},
+			expectedErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			if tc.prepareMock != nil {
+				tc.prepareMock()
+			}
+
+			// Actual
+			results, pagination, err := s.Svc.GetAllByCursor(s.T().Context(), tc.spec, tc.cursor, 2, tc.withTotal)
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+				ids := make([]int, 0, len(results))
+				for _, result := range results {
+					ids = append(ids, result.Id)
+				}
+				s.Equal(tc.expectedIds, ids)
+				s.Equal(2, pagination.PageSize)
+				s.Equal(tc.expectedNext, pagination.NextCursor)
+				s.Equal(tc.expectedTotal, pagination.Total)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.songRepo.AssertExpectations(s.T())
+		})
+	}
+}
+
 func (s *SongServiceTestSuite) TestGetSongById() {
 	image1 := dto.Image{Src: "image1.png", BlurHash: "abc"}
 	image1Bytes := utils.ParseImageToByte(&image1)
@@ -254,7 +363,6 @@ func (s *SongServiceTestSuite) TestCreateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image1,
 			},
@@ -263,7 +371,6 @@ func (s *SongServiceTestSuite) TestCreateSong() {
 				s.songRepo.On("Store", mock.Anything, models.CreateSongInput{
 					AlbumId:  1,
 					Title:    "Aku bukanlah superman",
-					Audio:    "song-1.mp3",
 					Duration: 350,
 					Image:    utils.ParseImageToByte(&image1),
 				}).Return(nil)
@@ -274,15 +381,12 @@ func (s *SongServiceTestSuite) TestCreateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  0,
 				Title:    "",
-				Audio:    "",
 				Duration: 0,
 			},
 			expectedErr: validationErr,
 			expectedValErrMap: map[string]string{
 				"album_id": "Field is required",
 				"title":    "Field is required",
-				"audio":    "Field is required",
-				"duration": "Field is required",
 				"image":    "Field is required",
 			},
 		},
@@ -291,7 +395,6 @@ func (s *SongServiceTestSuite) TestCreateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  0,
 				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image1,
 			},
@@ -303,7 +406,6 @@ func (s *SongServiceTestSuite) TestCreateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image1,
 			},
@@ -311,35 +413,21 @@ func (s *SongServiceTestSuite) TestCreateSong() {
 			expectedValErrMap: map[string]string{"title": "Field is required"},
 		},
 		{
-			name: "ValidationFailed_AudioRequired",
-			createSongRequest: dto.CreateSongRequest{
-				AlbumId:  1,
-				Title:    "Aku bukanlah superman",
-				Audio:    "",
-				Duration: 350,
-				Image:    &image1,
-			},
-			expectedErr:       validationErr,
-			expectedValErrMap: map[string]string{"audio": "Field is required"},
-		},
-		{
-			name: "ValidationFailed_DurationRequired",
+			name: "ValidationFailed_DurationNegative",
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
-				Duration: 0,
+				Duration: -1,
 				Image:    &image1,
 			},
 			expectedErr:       validationErr,
-			expectedValErrMap: map[string]string{"duration": "Field is required"},
+			expectedValErrMap: map[string]string{"duration": "Minimum value is 0"},
 		},
 		{
 			name: "FindExistsAlbumById_Error",
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image1,
 			},
@@ -353,7 +441,6 @@ func (s *SongServiceTestSuite) TestCreateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image1,
 			},
@@ -362,7 +449,6 @@ func (s *SongServiceTestSuite) TestCreateSong() {
 				s.songRepo.On("Store", mock.Anything, models.CreateSongInput{
 					AlbumId:  1,
 					Title:    "Aku bukanlah superman",
-					Audio:    "song-1.mp3",
 					Duration: 350,
 					Image:    utils.ParseImageToByte(&image1),
 				}).Return(errors.New("database failure"))
@@ -414,7 +500,6 @@ func (s *SongServiceTestSuite) TestUpdateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image,
 			},
@@ -424,7 +509,6 @@ func (s *SongServiceTestSuite) TestUpdateSong() {
 				s.songRepo.On("Update", mock.Anything, models.CreateSongInput{
 					AlbumId:  1,
 					Title:    "Aku bukanlah superman",
-					Audio:    "song-1.mp3",
 					Duration: 350,
 					Image:    utils.ParseImageToByte(&image),
 				}, 1).Return(nil)
@@ -435,15 +519,12 @@ func (s *SongServiceTestSuite) TestUpdateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  0,
 				Title:    "",
-				Audio:    "",
 				Duration: 0,
 			},
 			expectedErr: validationErr,
 			expectedValErrorMap: map[string]string{
 				"album_id": "Field is required",
 				"title":    "Field is required",
-				"audio":    "Field is required",
-				"duration": "Field is required",
 				"image":    "Field is required",
 			},
 		},
@@ -452,7 +533,6 @@ func (s *SongServiceTestSuite) TestUpdateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  0,
 				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image,
 			},
@@ -464,7 +544,6 @@ func (s *SongServiceTestSuite) TestUpdateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image,
 			},
@@ -472,35 +551,21 @@ func (s *SongServiceTestSuite) TestUpdateSong() {
 			expectedValErrorMap: map[string]string{"title": "Field is required"},
 		},
 		{
-			name: "ValidationFailed_AudioRequired",
+			name: "ValidationFailed_DurationNegative",
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "Aku bukanlah superman",
-				Audio:    "",
-				Duration: 350,
+				Duration: -1,
 				Image:    &image,
 			},
 			expectedErr:         validationErr,
-			expectedValErrorMap: map[string]string{"audio": "Field is required"},
-		},
-		{
-			name: "ValidationFailed_DurationRequired",
-			createSongRequest: dto.CreateSongRequest{
-				AlbumId:  1,
-				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
-				Duration: 0,
-				Image:    &image,
-			},
-			expectedErr:         validationErr,
-			expectedValErrorMap: map[string]string{"duration": "Field is required"},
+			expectedValErrorMap: map[string]string{"duration": "Minimum value is 0"},
 		},
 		{
 			name: "FindExistsSongById_NotFound",
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image,
 			},
@@ -514,7 +579,6 @@ func (s *SongServiceTestSuite) TestUpdateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image,
 			},
@@ -528,7 +592,6 @@ func (s *SongServiceTestSuite) TestUpdateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image,
 			},
@@ -543,7 +606,6 @@ func (s *SongServiceTestSuite) TestUpdateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image,
 			},
@@ -558,7 +620,6 @@ func (s *SongServiceTestSuite) TestUpdateSong() {
 			createSongRequest: dto.CreateSongRequest{
 				AlbumId:  1,
 				Title:    "Aku bukanlah superman",
-				Audio:    "song-1.mp3",
 				Duration: 350,
 				Image:    &image,
 			},
@@ -568,7 +629,6 @@ func (s *SongServiceTestSuite) TestUpdateSong() {
 				s.songRepo.On("Update", mock.Anything, models.CreateSongInput{
 					AlbumId:  1,
 					Title:    "Aku bukanlah superman",
-					Audio:    "song-1.mp3",
 					Duration: 350,
 					Image:    utils.ParseImageToByte(&image),
 				}, 1).Return(errors.New("database failure"))
@@ -606,17 +666,361 @@ func (s *SongServiceTestSuite) TestUpdateSong() {
 
 }
 
-func (s *SongServiceTestSuite) TestDeleteSong() {
+func (s *SongServiceTestSuite) TestUploadAudio() {
+	// 240 frames of 128 kbps 44.1 kHz stereo, 6.255 seconds, with an ID3v1 title
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	tag := make([]byte, 128)
+	copy(tag, "TAG")
+	copy(tag[3:], "aku bukanlah superman ")
+	copy(tag[33:], "Other Artist")
+	mp3 := append(bytes.Repeat(frame, 240), tag...)
+	corrupt := append([]byte("ID3"), make([]byte, 64)...)
+	wav := append([]byte("RIFF\x00\x00\x00\x00WAVE"), make([]byte, 64)...)
+
+	song := &models.Song{
+		Id:       1,
+		Title:    "Aku Bukanlah Superman",
+		Duration: 200,
+		Audio:    "songs/1/old.mp3",
+		Album: models.AlbumWithArtist{
+			Album:  models.Album{Name: "Album"},
+			Artist: models.Artist{Name: "Lirik Lagu"},
+		},
+	}
+
+	isKey := func(ext string) any {
+		return mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, "songs/1/") && strings.HasSuffix(key, ext)
+		})
+	}
+	openAs := func(file []byte) func() {
+		return func() {
+			s.store.On("Open", mock.Anything, isKey("")).Return(&fakeReadSeekCloser{Reader: bytes.NewReader(file)}, storage.Object{}, nil)
+		}
+	}
+	isInput := func(expected models.SongAudioInput) any {
+		return mock.MatchedBy(func(input models.SongAudioInput) bool {
+			expected.Audio = input.Audio
+			return strings.HasPrefix(input.Audio, "songs/1/") && input == expected
+		})
+	}
+	parsedInput := models.SongAudioInput{Duration: 6, Bitrate: 128, SampleRate: 44100, Channels: 2, TagTitle: "aku bukanlah superman", TagArtist: "Other Artist"}
+
+	testCases := []struct {
+		name        string
+		file        []byte
+		prepareMock func()
+		expected    dto.SongAudioUpload
+		expectedErr error
+	}{
+		{
+			name: "success replaces stored audio and reports mismatches",
+			file: mp3,
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(song, nil)
+				s.store.On("Put", mock.Anything, isKey(".mp3"), mp3, "audio/mpeg").Return(nil)
+				openAs(mp3)()
+				s.songRepo.On("UpdateAudio", mock.Anything, 1, isInput(parsedInput)).Return(nil)
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.packaging.On("Enqueue", mock.Anything, 1).Return(nil)
+				s.store.On("Delete", mock.Anything, "songs/1/old.mp3").Return(nil)
+			},
+			expected: dto.SongAudioUpload{
+				Format: "mp3", Duration: 6, Bitrate: 128, SampleRate: 44100, Channels: 2,
+				Title: "aku bukanlah superman", Artist: "Other Artist",
+				Mismatches: []dto.AudioMismatch{
+					{Field: "duration", Song: "200", Parsed: "6"},
+					{Field: "artist", Song: "Lirik Lagu", Parsed: "Other Artist"},
+				},
+			},
+		},
+		{
+			name: "success keeps external url",
+			file: mp3,
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1, Audio: "https://cdn.example.com/old.mp3"}, nil)
+				s.store.On("Put", mock.Anything, isKey(".mp3"), mp3, "audio/mpeg").Return(nil)
+				openAs(mp3)()
+				s.songRepo.On("UpdateAudio", mock.Anything, 1, isInput(parsedInput)).Return(nil)
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.packaging.On("Enqueue", mock.Anything, 1).Return(nil)
+			},
+			expected: dto.SongAudioUpload{
+				Format: "mp3", Duration: 6, Bitrate: 128, SampleRate: 44100, Channels: 2,
+				Title: "aku bukanlah superman", Artist: "Other Artist",
+				Mismatches: []dto.AudioMismatch{
+					{Field: "title", Song: "", Parsed: "aku bukanlah superman"},
+					{Field: "artist", Song: "", Parsed: "Other Artist"},
+				},
+			},
+		},
+		{
+			name: "format without a parser keeps the song duration",
+			file: wav,
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1, Duration: 200}, nil)
+				s.store.On("Put", mock.Anything, isKey(".wav"), wav, "audio/wav").Return(nil)
+				openAs(wav)()
+				s.songRepo.On("UpdateAudio", mock.Anything, 1, isInput(models.SongAudioInput{Duration: 200})).Return(nil)
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.packaging.On("Enqueue", mock.Anything, 1).Return(nil)
+			},
+			expected: dto.SongAudioUpload{Duration: 200, Mismatches: []dto.AudioMismatch{}},
+		},
+		{
+			name: "song not found",
+			file: mp3,
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(nil, nil)
+			},
+			expectedErr: errs.NewNotFoundError("Song", "id", 1),
+		},
+		{
+			name: "unsupported format",
+			file: []byte("<html>not audio</html>"),
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1}, nil)
+			},
+			expectedErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name: "corrupt file removes the new object",
+			file: corrupt,
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1}, nil)
+				s.store.On("Put", mock.Anything, isKey(".mp3"), corrupt, "audio/mpeg").Return(nil)
+				openAs(corrupt)()
+				s.store.On("Delete", mock.Anything, isKey(".mp3")).Return(nil)
+			},
+			expectedErr: errs.NewBadRequestError("validation failed", nil),
+		},
+		{
+			name: "UpdateAudio error removes the new object",
+			file: mp3,
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1}, nil)
+				s.store.On("Put", mock.Anything, isKey(".mp3"), mp3, "audio/mpeg").Return(nil)
+				openAs(mp3)()
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.songRepo.On("UpdateAudio", mock.Anything, 1, mock.Anything).Return(errors.New("database failure"))
+				s.store.On("Delete", mock.Anything, isKey(".mp3")).Return(nil)
+			},
+			expectedErr: errors.New("database failure"),
+		},
+		{
+			name: "Enqueue error removes the new object",
+			file: mp3,
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1}, nil)
+				s.store.On("Put", mock.Anything, isKey(".mp3"), mp3, "audio/mpeg").Return(nil)
+				openAs(mp3)()
+				s.tx.On("WithinTx", mock.Anything).Return()
+				s.songRepo.On("UpdateAudio", mock.Anything, 1, mock.Anything).Return(nil)
+				s.packaging.On("Enqueue", mock.Anything, 1).Return(errors.New("database failure"))
+				s.store.On("Delete", mock.Anything, isKey(".mp3")).Return(nil)
+			},
+			expectedErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			tc.prepareMock()
+
+			// Actual
+			upload, err := s.Svc.UploadAudio(s.T().Context(), 1, bytes.NewReader(tc.file))
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+				s.True(strings.HasPrefix(upload.Audio, "songs/1/"))
+				tc.expected.Audio = upload.Audio
+				s.Equal(tc.expected, upload)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.songRepo.AssertExpectations(s.T())
+			s.store.AssertExpectations(s.T())
+			s.packaging.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *SongServiceTestSuite) TestOpenAudio() {
+	modTime := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	body := &fakeReadSeekCloser{Reader: bytes.NewReader([]byte("audio"))}
+
 	testCases := []struct {
 		name        string
 		prepareMock func()
+		expected    models.SongAudio
 		expectedErr error
 	}{
 		{
 			name: "success",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1, Duration: 200, Audio: "songs/1/a.mp3"}, nil)
+				s.store.On("Open", mock.Anything, "songs/1/a.mp3").Return(body, storage.Object{Key: "songs/1/a.mp3", Size: 5, ContentType: "audio/mpeg", ModTime: modTime}, nil)
+			},
+			expected: models.SongAudio{SongId: 1, Duration: 200, Body: body, Size: 5, ContentType: "audio/mpeg", ModTime: modTime},
+		},
+		{
+			name: "song not found",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(nil, nil)
+			},
+			expectedErr: errs.NewNotFoundError("Song", "id", 1),
+		},
+		{
+			name: "external url is not streamed",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1, Audio: "https://cdn.example.com/a.mp3"}, nil)
+			},
+			expectedErr: errs.NewNotFoundError("Song audio", "id", 1),
+		},
+		{
+			name: "stored object missing",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1, Audio: "songs/1/a.mp3"}, nil)
+				s.store.On("Open", mock.Anything, "songs/1/a.mp3").Return(nil, storage.Object{}, storage.ErrNotFound)
+			},
+			expectedErr: errs.NewNotFoundError("Song audio", "id", 1),
+		},
+		{
+			name: "FindSongById error",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(nil, errors.New("database failure"))
+			},
+			expectedErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			tc.prepareMock()
+
+			// Actual
+			audio, err := s.Svc.OpenAudio(s.T().Context(), 1)
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+				s.Equal(tc.expected, audio)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.songRepo.AssertExpectations(s.T())
+			s.store.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *SongServiceTestSuite) TestOpenHLS() {
+	modTime := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	body := &fakeReadSeekCloser{Reader: bytes.NewReader([]byte("#EXTM3U"))}
+	ready := &models.Song{Id: 1, Audio: "songs/1/a.mp3", ProcessingState: models.SongProcessingReady, HLS: "songs/1/hls/p1"}
+
+	testCases := []struct {
+		name        string
+		file        string
+		prepareMock func()
+		expected    models.StoredFile
+		expectedErr error
+	}{
+		{
+			name: "success",
+			file: "128k/index.m3u8",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(ready, nil)
+				s.store.On("Open", mock.Anything, "songs/1/hls/p1/128k/index.m3u8").Return(body, storage.Object{Size: 7, ContentType: "application/vnd.apple.mpegurl", ModTime: modTime}, nil)
+			},
+			expected: models.StoredFile{Body: body, Size: 7, ContentType: "application/vnd.apple.mpegurl", ModTime: modTime},
+		},
+		{
+			name: "song not found",
+			file: "master.m3u8",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(nil, nil)
+			},
+			expectedErr: errs.NewNotFoundError("Song", "id", 1),
+		},
+		{
+			name: "not packaged yet",
+			file: "master.m3u8",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1, Audio: "songs/1/a.mp3", ProcessingState: models.SongProcessingPending}, nil)
+			},
+			expectedErr: errs.NewNotFoundError("Song HLS file", "name", "master.m3u8"),
+		},
+		{
+			name: "file outside the package",
+			file: "../../a.mp3",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(ready, nil)
+			},
+			expectedErr: errs.NewNotFoundError("Song HLS file", "name", "../../a.mp3"),
+		},
+		{
+			name: "missing segment",
+			file: "128k/segment_999.ts",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(ready, nil)
+				s.store.On("Open", mock.Anything, "songs/1/hls/p1/128k/segment_999.ts").Return(nil, storage.Object{}, storage.ErrNotFound)
+			},
+			expectedErr: errs.NewNotFoundError("Song HLS file", "name", "128k/segment_999.ts"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			tc.prepareMock()
+
+			// Actual
+			file, err := s.Svc.OpenHLS(s.T().Context(), 1, tc.file)
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+				s.Equal(tc.expected, file)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.songRepo.AssertExpectations(s.T())
+			s.store.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *SongServiceTestSuite) TestDeleteSong() {
+	testCases := []struct {
+		name        string
+		prepareMock func()
+		expectedErr error
+	}{
+		{
+			name: "success removes the stored audio",
+			prepareMock: func() {
+				s.songRepo.On("FindExistsSongById", mock.Anything, 1).Return(true, nil)
+				s.songRepo.On("Delete", mock.Anything, 1).Return(nil)
+				s.store.On("DeletePrefix", mock.Anything, "songs/1").Return(nil)
+			},
+		},
+		{
+			name: "storage error still deletes the song",
 			prepareMock: func() {
 				s.songRepo.On("FindExistsSongById", mock.Anything, 1).Return(true, nil)
 				s.songRepo.On("Delete", mock.Anything, 1).Return(nil)
+				s.store.On("DeletePrefix", mock.Anything, "songs/1").Return(errors.New("permission denied"))
 			},
 		},
 		{
@@ -660,6 +1064,7 @@ func (s *SongServiceTestSuite) TestDeleteSong() {
 			}
 
 			s.songRepo.AssertExpectations(s.T())
+			s.store.AssertExpectations(s.T())
 		})
 	}
 }
@@ -774,3 +1179,9 @@ func (s *SongServiceTestSuite) TestGetSongsByAlbumId() {
 func TestSongServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(SongServiceTestSuite))
 }
+
+type fakeReadSeekCloser struct {
+	*bytes.Reader
+}
+
+func (f *fakeReadSeekCloser) Close() error { return nil }
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/signedurl"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/storage"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type streamService struct {
+	songRepo contracts.SongRepository
+	signer   signedurl.SignedURLService
+	log      *logrus.Logger
+}
+
+func NewStreamService(songRepo contracts.SongRepository, signer signedurl.SignedURLService, log *logrus.Logger) contracts.StreamService {
+	return &streamService{
+		songRepo: songRepo,
+		signer:   signer,
+		log:      log,
+	}
+}
+
+// streamResource scopes a signature to one song, a URL signed for a song can't stream another.
+func streamResource(songID int) string {
+	return fmt.Sprintf("song:%d:stream", songID)
+}
+
+func (svc *streamService) SignStream(ctx context.Context, songID, userID int) (params signedurl.Params, err error) {
+	song, err := svc.songRepo.FindSongById(ctx, songID)
+	if err != nil {
+		utils.LogError(svc.log, ctx, "stream_service", "SignStream", err)
+		return params, err
+	}
+	if song == nil {
+		notFoundErr := errs.NewNotFoundError("Song", "id", songID)
+		utils.LogWarn(svc.log, ctx, "stream_service", "SignStream", notFoundErr)
+		return params, notFoundErr
+	}
+	if !storage.IsKey(song.Audio) {
+		notFoundErr := errs.NewNotFoundError("Song audio", "id", songID)
+		utils.LogWarn(svc.log, ctx, "stream_service", "SignStream", notFoundErr)
+		return params, notFoundErr
+	}
+
+	return svc.signer.Sign(streamResource(songID), userID), nil
+}
+
+func (svc *streamService) AuthorizeStream(ctx context.Context, songID int, params signedurl.Params) (userID int, err error) {
+	if err := svc.signer.Verify(streamResource(songID), params); err != nil {
+		utils.LogWarn(svc.log, ctx, "stream_service", "AuthorizeStream", err)
+		return 0, err
+	}
+
+	return params.UserId, nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/mocks"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/signedurl"
+)
+
+type StreamServiceTestSuite struct {
+	suite.Suite
+	Svc      contracts.StreamService
+	songRepo *mocks.MockSongRepository
+	signer   *mocks.MockSignedURLService
+}
+
+func (s *StreamServiceTestSuite) SetupTest() {
+	s.songRepo = new(mocks.MockSongRepository)
+	s.signer = new(mocks.MockSignedURLService)
+	s.Svc = NewStreamService(s.songRepo, s.signer, nil)
+}
+
+func (s *StreamServiceTestSuite) ResetMocks() {
+	s.songRepo.ExpectedCalls = nil
+	s.songRepo.Calls = nil
+	s.signer.ExpectedCalls = nil
+	s.signer.Calls = nil
+}
+
+func (s *StreamServiceTestSuite) TestSignStream() {
+	params := signedurl.Params{UserId: 7, Expires: 1740823200, Signature: "sig"}
+
+	testCases := []struct {
+		name        string
+		prepareMock func()
+		expected    signedurl.Params
+		expectedErr error
+	}{
+		{
+			name: "success",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1, Audio: "songs/1/a.mp3"}, nil)
+				s.signer.On("Sign", "song:1:stream", 7).Return(params)
+			},
+			expected: params,
+		},
+		{
+			name: "song not found",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(nil, nil)
+			},
+			expectedErr: errs.NewNotFoundError("Song", "id", 1),
+		},
+		{
+			name: "no uploaded audio",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(&models.Song{Id: 1}, nil)
+			},
+			expectedErr: errs.NewNotFoundError("Song audio", "id", 1),
+		},
+		{
+			name: "FindSongById error",
+			prepareMock: func() {
+				s.songRepo.On("FindSongById", mock.Anything, 1).Return(nil, errors.New("database failure"))
+			},
+			expectedErr: errors.New("database failure"),
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.ResetMocks()
+			tc.prepareMock()
+
+			// Actual
+			result, err := s.Svc.SignStream(s.T().Context(), 1, 7)
+
+			// Assert
+			if tc.expectedErr == nil {
+				s.NoError(err)
+				s.Equal(tc.expected, result)
+			} else {
+				s.Error(err)
+				s.EqualError(err, tc.expectedErr.Error())
+			}
+
+			s.songRepo.AssertExpectations(s.T())
+			s.signer.AssertExpectations(s.T())
+		})
+	}
+}
+
+func (s *StreamServiceTestSuite) TestAuthorizeStream() {
+	params := signedurl.Params{UserId: 7, Expires: 1740823200, Signature: "sig"}
+
+	s.Run("success", func() {
+		s.ResetMocks()
+		s.signer.On("Verify", "song:1:stream", params).Return(nil)
+
+		userID, err := s.Svc.AuthorizeStream(s.T().Context(), 1, params)
+
+		s.NoError(err)
+		s.Equal(7, userID)
+	})
+
+	s.Run("invalid", func() {
+		s.ResetMocks()
+		s.signer.On("Verify", "song:1:stream", params).Return(errs.NewForbiddenError("Signed URL is expired."))
+
+		userID, err := s.Svc.AuthorizeStream(s.T().Context(), 1, params)
+
+		s.EqualError(err, "Signed URL is expired.")
+		s.Zero(userID)
+	})
+}
+
+func TestStreamServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(StreamServiceTestSuite))
+}
@@ -0,0 +1,81 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/app/contracts"
+	"github.com/wahyusahajaa/mulo-api-go/app/models"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/jobs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/mailer"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+// packagingTimeout bounds a single ffmpeg run and the upload of its output.
+const packagingTimeout = 15 * time.Minute
+
+// Workers registers the job handlers of the app and works the queue.
+type Workers struct {
+	queue        *jobs.Queue
+	outbox       mailer.Outbox
+	auth         contracts.AuthService
+	packaging    contracts.PackagingService
+	charts       contracts.ChartService
+	chartRefresh time.Duration
+	log          *logrus.Logger
+}
+
+func NewWorkers(queue *jobs.Queue, outbox mailer.Outbox, auth contracts.AuthService, packaging contracts.PackagingService, charts contracts.ChartService, cfg *config.Config, log *logrus.Logger) *Workers {
+	w := &Workers{
+		queue:        queue,
+		outbox:       outbox,
+		auth:         auth,
+		packaging:    packaging,
+		charts:       charts,
+		chartRefresh: cfg.ChartRefresh,
+		log:          log,
+	}
+
+	jobs.Handle(queue, w.sendEmail)
+	jobs.Handle(queue, w.sendPasswordReset)
+	jobs.Handle(queue, w.packageSong, jobs.Timeout(packagingTimeout), jobs.Concurrency(cfg.PackagingWorkers))
+	jobs.Handle(queue, w.refreshCharts, jobs.Timeout(cfg.ChartRefresh))
+
+	return w
+}
+
+// Run schedules the chart refresh unless one is pending and works jobs until ctx is done.
+// It returns once running jobs finished or were stopped by the shutdown timeout.
+func (w *Workers) Run(ctx context.Context) {
+	if err := w.queue.Enqueue(ctx, models.RefreshChartsJob{}, jobs.Unique(models.RefreshChartsJob{}.Kind())); err != nil {
+		utils.LogError(w.log, ctx, "workers", "Run", err)
+	}
+
+	w.queue.Run(ctx)
+}
+
+func (w *Workers) sendEmail(ctx context.Context, job *jobs.Job[mailer.SendJob]) (err error) {
+	return w.outbox.Deliver(ctx, job.Args.EmailID, job.Attempt >= job.MaxAttempts)
+}
+
+func (w *Workers) sendPasswordReset(ctx context.Context, job *jobs.Job[models.PasswordResetJob]) (err error) {
+	return w.auth.SendPasswordReset(ctx, job.Args.Email)
+}
+
+func (w *Workers) packageSong(ctx context.Context, job *jobs.Job[models.PackageSongJob]) (err error) {
+	return w.packaging.Package(ctx, job.Args.SongID)
+}
+
+// refreshCharts schedules the next run before refreshing, a failed refresh is logged by the
+// service and retried by the next run rather than by the queue.
+func (w *Workers) refreshCharts(ctx context.Context, job *jobs.Job[models.RefreshChartsJob]) (err error) {
+	next := models.RefreshChartsJob{}
+	if err := w.queue.Enqueue(ctx, next, jobs.Delay(w.chartRefresh), jobs.Unique(next.Kind())); err != nil {
+		return err
+	}
+
+	_ = w.charts.RefreshCharts(ctx)
+	return nil
+}
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/wahyusahajaa/mulo-api-go/app/di"
+	"github.com/wahyusahajaa/mulo-api-go/migrations"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/logger"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/migrate"
 )
 
 // @title Mulo Music Streaming API
@@ -19,13 +26,54 @@ import (
 // @name Authorization
 // @description Type: Bearer token
 func main() {
+	// Run the migrate subcommand instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatalf("failed to migrate: %v", err)
+		}
+		return
+	}
+
 	// Initialized the application with all dependencies
 	app, err := di.InitializedApp()
 	if err != nil {
 		log.Fatalf("failed to Initialized app: %v", err)
 	}
 
+	// Apply pending migrations on startup when AUTO_MIGRATE is enabled
+	if app.Config.AutoMigrate {
+		migrator, err := migrate.NewMigrator(app.DB.DB, migrations.FS, logger.NewLogger())
+		if err != nil {
+			log.Fatalf("failed to load migrations: %v", err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatalf("failed to migrate: %v", err)
+		}
+	}
+
+	// Stop serving and working jobs on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Work background jobs, emails, password reset links, HLS packaging and the charts refresh
+	workersDone := make(chan struct{})
+	go func() {
+		app.Workers.Run(ctx)
+		close(workersDone)
+	}()
+
+	go func() {
+		<-ctx.Done()
+		if err := app.App.ShutdownWithTimeout(app.Config.JobShutdownTimeout); err != nil {
+			log.Printf("failed to shutdown server: %v", err)
+		}
+	}()
+
 	if err := app.App.Listen(":" + app.Config.AppPort); err != nil {
 		log.Fatalf("failed to start server: %v", err)
 	}
+
+	// Running jobs get the shutdown timeout to finish
+	stop()
+	<-workersDone
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/app/database"
+	"github.com/wahyusahajaa/mulo-api-go/migrations"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/logger"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/migrate"
+)
+
+const migrateUsage = `Usage: main migrate <command>
+
+Commands:
+  up              apply all pending migrations
+  down <version>  roll back applied migrations newer than <version> (0 rolls back everything)
+  status          list migrations and whether they are applied`
+
+// runMigrate handles the `migrate` subcommand.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n\n%s", migrateUsage)
+	}
+
+	cfg := config.NewConfig()
+	db, err := database.NewDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := migrate.NewMigrator(db.DB, migrations.FS, logger.NewLogger())
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s)\n", len(applied))
+	case "down":
+		if len(args) < 2 {
+			return fmt.Errorf("missing target version\n\n%s", migrateUsage)
+		}
+		target, err := strconv.Atoi(args[1])
+		if err != nil || target < 0 {
+			return fmt.Errorf("invalid target version %q", args[1])
+		}
+		rolledBack, err := migrator.Down(ctx, target)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("rolled back %d migration(s)\n", len(rolledBack))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(os.Stdout, "%03d  %-40s %s\n", status.Version, status.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", args[0], migrateUsage)
+	}
+
+	return nil
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "users";
+
+DROP TYPE IF EXISTS "u"."role";
+
+DROP SCHEMA IF EXISTS "u";
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "oauth_accounts";
\ No newline at end of file
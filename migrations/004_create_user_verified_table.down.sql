@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "user_verified";
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "artists";
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "song_genres";
\ No newline at end of file
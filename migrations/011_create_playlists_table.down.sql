@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "playlists";
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "playlist_songs";
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "song_favorites";
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "song_listens";
\ No newline at end of file
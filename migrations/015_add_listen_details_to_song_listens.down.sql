@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "song_listens_user_id_song_id_created_at_idx";
+
+ALTER TABLE "song_listens" DROP COLUMN IF EXISTS "client";
+
+ALTER TABLE "song_listens" DROP COLUMN IF EXISTS "duration";
\ No newline at end of file
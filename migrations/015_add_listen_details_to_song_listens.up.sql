@@ -0,0 +1,5 @@
+ALTER TABLE "song_listens" ADD COLUMN "duration" int NOT NULL DEFAULT 0;
+
+ALTER TABLE "song_listens" ADD COLUMN "client" varchar(50);
+
+CREATE INDEX "song_listens_user_id_song_id_created_at_idx" ON "song_listens" ("user_id", "song_id", "created_at");
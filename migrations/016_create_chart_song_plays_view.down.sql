@@ -0,0 +1 @@
+DROP MATERIALIZED VIEW IF EXISTS "chart_song_plays";
@@ -0,0 +1,11 @@
+CREATE MATERIALIZED VIEW "chart_song_plays" AS
+SELECT
+  "song_id",
+  COUNT(*) FILTER (WHERE "created_at" >= now() - interval '1 day') AS "plays_day",
+  COUNT(*) FILTER (WHERE "created_at" >= now() - interval '7 days') AS "plays_week",
+  COUNT(*) FILTER (WHERE "created_at" >= now() - interval '30 days') AS "plays_month",
+  COUNT(*) AS "plays_all"
+FROM "song_listens"
+GROUP BY "song_id";
+
+CREATE UNIQUE INDEX "chart_song_plays_song_id_idx" ON "chart_song_plays" ("song_id");
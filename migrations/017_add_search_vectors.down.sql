@@ -0,0 +1,11 @@
+ALTER TABLE "genres" DROP COLUMN IF EXISTS "search_vector";
+
+ALTER TABLE "artists" DROP COLUMN IF EXISTS "search_vector";
+
+ALTER TABLE "albums" DROP COLUMN IF EXISTS "search_vector";
+
+ALTER TABLE "songs" DROP COLUMN IF EXISTS "search_vector";
+
+DROP FUNCTION IF EXISTS "f_unaccent"(text);
+
+DROP EXTENSION IF EXISTS "unaccent";
@@ -0,0 +1,26 @@
+CREATE EXTENSION IF NOT EXISTS "unaccent";
+
+-- unaccent() is only STABLE, generated columns and indexes need an IMMUTABLE wrapper with a fixed dictionary
+CREATE OR REPLACE FUNCTION "f_unaccent"(text) RETURNS text
+  LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+  AS $$ SELECT public.unaccent('public.unaccent', $1) $$;
+
+ALTER TABLE "songs" ADD COLUMN "search_vector" tsvector
+  GENERATED ALWAYS AS (to_tsvector('simple', f_unaccent(coalesce("title", '')))) STORED;
+
+ALTER TABLE "albums" ADD COLUMN "search_vector" tsvector
+  GENERATED ALWAYS AS (to_tsvector('simple', f_unaccent(coalesce("name", '')))) STORED;
+
+ALTER TABLE "artists" ADD COLUMN "search_vector" tsvector
+  GENERATED ALWAYS AS (to_tsvector('simple', f_unaccent(coalesce("name", '')))) STORED;
+
+ALTER TABLE "genres" ADD COLUMN "search_vector" tsvector
+  GENERATED ALWAYS AS (to_tsvector('simple', f_unaccent(coalesce("name", '')))) STORED;
+
+CREATE INDEX "songs_search_vector_idx" ON "songs" USING GIN ("search_vector");
+
+CREATE INDEX "albums_search_vector_idx" ON "albums" USING GIN ("search_vector");
+
+CREATE INDEX "artists_search_vector_idx" ON "artists" USING GIN ("search_vector");
+
+CREATE INDEX "genres_search_vector_idx" ON "genres" USING GIN ("search_vector");
@@ -0,0 +1,2 @@
+ALTER TABLE "songs" ALTER COLUMN "audio" DROP NOT NULL;
+ALTER TABLE "songs" ALTER COLUMN "audio" DROP DEFAULT;
@@ -0,0 +1,5 @@
+-- audio now holds a storage key, an empty string until the file is uploaded
+UPDATE "songs" SET "audio" = '' WHERE "audio" IS NULL;
+
+ALTER TABLE "songs" ALTER COLUMN "audio" SET DEFAULT '';
+ALTER TABLE "songs" ALTER COLUMN "audio" SET NOT NULL;
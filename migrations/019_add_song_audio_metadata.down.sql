@@ -0,0 +1,7 @@
+ALTER TABLE "songs"
+  DROP COLUMN "bitrate",
+  DROP COLUMN "sample_rate",
+  DROP COLUMN "channels",
+  DROP COLUMN "tag_title",
+  DROP COLUMN "tag_artist",
+  DROP COLUMN "tag_album";
@@ -0,0 +1,8 @@
+-- Read from the uploaded audio file, zero or empty until a parsable file is uploaded
+ALTER TABLE "songs"
+  ADD COLUMN "bitrate" INT NOT NULL DEFAULT 0,
+  ADD COLUMN "sample_rate" INT NOT NULL DEFAULT 0,
+  ADD COLUMN "channels" SMALLINT NOT NULL DEFAULT 0,
+  ADD COLUMN "tag_title" VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN "tag_artist" VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN "tag_album" VARCHAR(255) NOT NULL DEFAULT '';
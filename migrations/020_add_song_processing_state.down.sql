@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "songs_processing_pending_idx";
+
+ALTER TABLE "songs"
+  DROP COLUMN "processing_state",
+  DROP COLUMN "hls";
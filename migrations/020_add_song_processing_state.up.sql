@@ -0,0 +1,12 @@
+-- hls holds the storage prefix of the packaged playlists and segments, set once packaging is ready
+ALTER TABLE "songs"
+  ADD COLUMN "processing_state" VARCHAR(16) NOT NULL DEFAULT 'pending'
+    CHECK ("processing_state" IN ('pending', 'ready', 'failed')),
+  ADD COLUMN "hls" VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Songs without audio or with an external URL have nothing to package, packaging leaves them as is
+UPDATE "songs" SET "processing_state" = 'ready' WHERE "audio" = '' OR "audio" LIKE '%://%';
+
+-- Packaging workers pick up songs left pending with uploaded audio on startup
+CREATE INDEX "songs_processing_pending_idx" ON "songs" ("id")
+  WHERE "processing_state" = 'pending' AND "audio" <> '';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "jobs";
+
+CREATE INDEX IF NOT EXISTS "songs_processing_pending_idx" ON "songs" ("id")
+  WHERE "processing_state" = 'pending' AND "audio" <> '';
@@ -0,0 +1,30 @@
+-- Background jobs, completed jobs are deleted and dead ones are kept with their last error
+CREATE TABLE "jobs" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "kind" VARCHAR(100) NOT NULL,
+  "payload" JSONB NOT NULL DEFAULT '{}',
+  "state" VARCHAR(16) NOT NULL DEFAULT 'pending'
+    CHECK ("state" IN ('pending', 'running', 'dead')),
+  "attempts" INT NOT NULL DEFAULT 0,
+  "max_attempts" INT NOT NULL DEFAULT 5,
+  "run_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  "locked_until" TIMESTAMPTZ,
+  "unique_key" VARCHAR(255),
+  "last_error" TEXT,
+  "failed_at" TIMESTAMPTZ,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  "updated_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "jobs_due_idx" ON "jobs" ("run_at", "id") WHERE "state" = 'pending';
+CREATE INDEX "jobs_lease_idx" ON "jobs" ("locked_until") WHERE "state" = 'running';
+CREATE UNIQUE INDEX "jobs_unique_key_idx" ON "jobs" ("unique_key") WHERE "state" = 'pending';
+
+-- Songs waiting for packaging were queued in memory before, queue them as jobs
+INSERT INTO "jobs" ("kind", "payload", "max_attempts", "unique_key")
+SELECT 'songs.package', json_build_object('song_id', "id"), 3, 'songs.package:' || "id"
+FROM "songs"
+WHERE "processing_state" = 'pending' AND "audio" <> '' AND "audio" NOT LIKE '%://%';
+
+-- Pending songs were looked up on startup, the jobs table replaces that
+DROP INDEX IF EXISTS "songs_processing_pending_idx";
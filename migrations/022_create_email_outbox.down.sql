@@ -0,0 +1,3 @@
+DELETE FROM "jobs" WHERE "kind" = 'email.send';
+
+DROP TABLE IF EXISTS "email_outbox";
@@ -0,0 +1,26 @@
+-- Transactional emails, each row is delivered by an email.send job and rendered when sent
+CREATE TABLE "email_outbox" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "template" VARCHAR(50) NOT NULL,
+  "recipient" VARCHAR(255) NOT NULL,
+  "data" JSONB NOT NULL DEFAULT '{}',
+  "attempts" INT NOT NULL DEFAULT 0,
+  "last_error" TEXT,
+  "sent_at" TIMESTAMPTZ,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Verification emails were jobs of their own, move the unsent ones to the outbox
+WITH "moved" AS (
+  DELETE FROM "jobs"
+  WHERE "kind" = 'email.verification' AND "state" <> 'dead'
+  RETURNING "payload"
+), "queued" AS (
+  INSERT INTO "email_outbox" ("template", "recipient", "data")
+  SELECT 'verification', "payload" ->> 'email', json_build_object('code', "payload" ->> 'code')
+  FROM "moved"
+  RETURNING "id"
+)
+INSERT INTO "jobs" ("kind", "payload", "max_attempts")
+SELECT 'email.send', json_build_object('email_id', "id"), 8
+FROM "queued";
@@ -0,0 +1,14 @@
+-- Password reset tokens, only the SHA-256 of the emailed token is stored
+CREATE TABLE "password_resets" (
+  "id" serial,
+  "user_id" int NOT NULL,
+  "token_hash" varchar(64) UNIQUE NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp,
+  "created_at" timestamp DEFAULT (now()),
+  PRIMARY KEY ("id")
+);
+
+CREATE INDEX ON "password_resets" ("user_id");
+
+ALTER TABLE "password_resets" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
@@ -0,0 +1,3 @@
+DELETE FROM "user_verified" WHERE "email" IS NOT NULL;
+
+ALTER TABLE "user_verified" DROP COLUMN IF EXISTS "email";
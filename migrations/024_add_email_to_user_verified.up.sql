@@ -0,0 +1,2 @@
+-- A code with an email confirms a change to that address, registration codes leave it NULL
+ALTER TABLE "user_verified" ADD COLUMN "email" varchar(100);
@@ -0,0 +1,10 @@
+-- Fixed window hit counters of the rate limiter, a key is a rule name and the limited value
+CREATE TABLE "rate_limits" (
+  "key" VARCHAR(255) NOT NULL,
+  "window_start" TIMESTAMPTZ NOT NULL,
+  "count" INT NOT NULL DEFAULT 0,
+  "expires_at" TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY ("key", "window_start")
+);
+
+CREATE INDEX ON "rate_limits" ("expires_at");
@@ -0,0 +1,3 @@
+ALTER TABLE "refresh_tokens" DROP COLUMN IF EXISTS "session_id";
+
+DROP TABLE IF EXISTS "sessions";
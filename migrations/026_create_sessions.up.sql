@@ -0,0 +1,32 @@
+-- A session is a signed in device, its refresh token is rotated on every refresh
+CREATE TABLE "sessions" (
+  "id" serial,
+  "user_id" int NOT NULL,
+  "user_agent" text NOT NULL DEFAULT '',
+  "device" varchar(100) NOT NULL DEFAULT '',
+  "ip" varchar(45) NOT NULL DEFAULT '',
+  "created_at" timestamp DEFAULT (now()),
+  "last_used_at" timestamp DEFAULT (now()),
+  PRIMARY KEY ("id")
+);
+
+CREATE INDEX ON "sessions" ("user_id");
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+-- Every active token becomes a session of its own, the device it was issued to is unknown
+ALTER TABLE "refresh_tokens" ADD COLUMN "session_id" int;
+
+UPDATE "refresh_tokens" SET "session_id" = nextval(pg_get_serial_sequence('sessions', 'id'))
+WHERE "revoked" = FALSE AND "expires_at" > NOW();
+
+INSERT INTO "sessions" ("id", "user_id", "created_at", "last_used_at")
+SELECT "session_id", "user_id", "created_at", "created_at" FROM "refresh_tokens" WHERE "session_id" IS NOT NULL;
+
+DELETE FROM "refresh_tokens" WHERE "session_id" IS NULL;
+
+ALTER TABLE "refresh_tokens" ALTER COLUMN "session_id" SET NOT NULL;
+
+CREATE INDEX ON "refresh_tokens" ("session_id");
+
+ALTER TABLE "refresh_tokens" ADD FOREIGN KEY ("session_id") REFERENCES "sessions" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
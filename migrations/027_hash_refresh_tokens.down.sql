@@ -0,0 +1,6 @@
+-- The tokens cannot be recovered from their hashes, everyone signs in again
+DELETE FROM "sessions";
+
+ALTER TABLE "refresh_tokens" ALTER COLUMN "token_hash" TYPE TEXT;
+
+ALTER TABLE "refresh_tokens" RENAME COLUMN "token_hash" TO "token";
@@ -0,0 +1,6 @@
+-- Refresh tokens are stored as their SHA-256, the tokens already issued keep working
+ALTER TABLE "refresh_tokens" RENAME COLUMN "token" TO "token_hash";
+
+UPDATE "refresh_tokens" SET "token_hash" = encode(sha256(convert_to("token_hash", 'UTF8')), 'hex');
+
+ALTER TABLE "refresh_tokens" ALTER COLUMN "token_hash" TYPE varchar(64);
@@ -0,0 +1,12 @@
+-- A started OAuth sign in, consumed by its callback. The state is stored hashed, the code
+-- verifier is the PKCE secret the code is exchanged with
+CREATE TABLE "oauth_states" (
+  "state_hash" varchar(64) NOT NULL,
+  "provider" varchar(50) NOT NULL,
+  "code_verifier" varchar(128) NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "created_at" timestamp DEFAULT (now()),
+  PRIMARY KEY ("state_hash")
+);
+
+CREATE INDEX ON "oauth_states" ("expires_at");
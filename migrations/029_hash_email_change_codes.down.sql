@@ -0,0 +1,6 @@
+-- The codes cannot be recovered from their hashes, pending email changes are dropped
+DELETE FROM "user_verified" WHERE "email" IS NOT NULL;
+
+ALTER TABLE "user_verified" DROP COLUMN IF EXISTS "attempts";
+
+ALTER TABLE "user_verified" ALTER COLUMN "code" TYPE varchar(5);
@@ -0,0 +1,7 @@
+-- Email change codes are stored as their SHA-256 and dropped after too many wrong guesses,
+-- registration codes stay as they are
+ALTER TABLE "user_verified" ALTER COLUMN "code" TYPE varchar(64);
+
+ALTER TABLE "user_verified" ADD COLUMN "attempts" int NOT NULL DEFAULT 0;
+
+UPDATE "user_verified" SET "code" = encode(sha256(convert_to("code", 'UTF8')), 'hex') WHERE "email" IS NOT NULL;
@@ -0,0 +1,2 @@
+-- The cleared data cannot be restored
+SELECT 1;
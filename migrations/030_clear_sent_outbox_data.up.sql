@@ -0,0 +1,8 @@
+-- Emails hold reset links and codes, their data is cleared once sent or dead-lettered
+UPDATE "email_outbox" SET "data" = '{}' WHERE "sent_at" IS NOT NULL;
+
+UPDATE "email_outbox" SET "data" = '{}'
+WHERE "sent_at" IS NULL AND NOT EXISTS (
+  SELECT 1 FROM "jobs"
+  WHERE "jobs"."kind" = 'email.send' AND ("jobs"."payload" ->> 'email_id')::bigint = "email_outbox"."id" AND "jobs"."state" <> 'dead'
+);
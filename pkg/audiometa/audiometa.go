@@ -0,0 +1,100 @@
+// Package audiometa reads duration, stream properties and tags out of MP3, FLAC and Ogg
+// (Vorbis and Opus) files without decoding the audio.
+package audiometa
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// ErrUnsupported the file is not in one of the parsed formats.
+	ErrUnsupported = errors.New("audiometa: unsupported format")
+	// ErrInvalid the file looks like a parsed format but its headers are broken.
+	ErrInvalid = errors.New("audiometa: invalid file")
+)
+
+const (
+	FormatMP3  = "mp3"
+	FormatFLAC = "flac"
+	FormatOgg  = "ogg"
+)
+
+// maxTagSize caps how much of a tag or comment block is read, larger blocks are embedded pictures.
+const maxTagSize = 1 << 20
+
+// maxTextLength caps the runes of a title, artist or album, the length of the columns they fill.
+const maxTextLength = 255
+
+// Metadata is what could be read from the file, zero values are unknown.
+type Metadata struct {
+	Format     string
+	Duration   time.Duration
+	Bitrate    int // kbps
+	SampleRate int // Hz
+	Channels   int
+	Title      string
+	Artist     string
+	Album      string
+}
+
+// Parse reads the metadata of an audio file, the format is detected from its content.
+func Parse(r io.ReadSeeker) (meta Metadata, err error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return meta, err
+	}
+
+	head := make([]byte, 4)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return meta, err
+	}
+	if _, err := io.ReadFull(r, head); err != nil {
+		return meta, ErrUnsupported
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return meta, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return parseFLAC(r, size)
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return parseOgg(r, size)
+	case bytes.HasPrefix(head, []byte("ID3")), head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		return parseMP3(r, size)
+	default:
+		return meta, ErrUnsupported
+	}
+}
+
+// bitrateOf averages the bitrate in kbps over the whole duration.
+func bitrateOf(byteCount int64, duration time.Duration) int {
+	if duration <= 0 || byteCount <= 0 {
+		return 0
+	}
+	return int(float64(byteCount*8) / duration.Seconds() / 1000)
+}
+
+func durationOf(samples int64, sampleRate int) time.Duration {
+	if sampleRate <= 0 || samples <= 0 {
+		return 0
+	}
+	return time.Duration(float64(samples) / float64(sampleRate) * float64(time.Second))
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalid, fmt.Sprintf(format, args...))
+}
+
+// readAt reads exactly len(p) bytes at offset.
+func readAt(r io.ReadSeeker, offset int64, p []byte) error {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(r, p)
+	return err
+}
@@ -0,0 +1,283 @@
+package audiometa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AudioMetaTestSuite struct {
+	suite.Suite
+}
+
+// mpeg1Layer3Frame is a 128 kbps 44.1 kHz frame header, 417 bytes long.
+var mpeg1Layer3Frame = []byte{0xFF, 0xFB, 0x90, 0x00}
+
+const mpeg1Layer3FrameLen = 417
+
+func mp3Frames(count int, mono bool) []byte {
+	header := bytes.Clone(mpeg1Layer3Frame)
+	if mono {
+		header[3] = 0xC0
+	}
+
+	frame := make([]byte, mpeg1Layer3FrameLen)
+	copy(frame, header)
+	return bytes.Repeat(frame, count)
+}
+
+func id3v2Tag(version byte, frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	body = append(body, make([]byte, 16)...) // padding
+
+	size := len(body)
+	header := []byte{'I', 'D', '3', version, 0, 0,
+		byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(header, body...)
+}
+
+func id3v23Frame(id string, encoding byte, text []byte) []byte {
+	frame := []byte(id)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(text)+1))
+	frame = append(frame, 0, 0, encoding)
+	return append(frame, text...)
+}
+
+func utf16LE(s string) []byte {
+	out := []byte{0xFF, 0xFE}
+	for _, r := range s {
+		out = binary.LittleEndian.AppendUint16(out, uint16(r))
+	}
+	return out
+}
+
+func id3v1Tag(title, artist, album string) []byte {
+	tag := make([]byte, id3v1Len)
+	copy(tag, "TAG")
+	copy(tag[3:], title)
+	copy(tag[33:], artist)
+	copy(tag[63:], album)
+	return tag
+}
+
+func vorbisComments(comments ...string) []byte {
+	block := binary.LittleEndian.AppendUint32(nil, 4)
+	block = append(block, "test"...)
+	block = binary.LittleEndian.AppendUint32(block, uint32(len(comments)))
+	for _, comment := range comments {
+		block = binary.LittleEndian.AppendUint32(block, uint32(len(comment)))
+		block = append(block, comment...)
+	}
+	return block
+}
+
+func flacFile(sampleRate, channels int, samples int64, audio int, comments ...string) []byte {
+	file := []byte("fLaC")
+
+	streamInfo := make([]byte, flacStreamInfoLen)
+	packed := uint64(sampleRate)<<44 | uint64(channels-1)<<41 | uint64(15)<<36 | uint64(samples)
+	binary.BigEndian.PutUint64(streamInfo[10:18], packed)
+	file = append(file, flacBlockStreamInfo, 0, 0, flacStreamInfoLen)
+	file = append(file, streamInfo...)
+
+	block := vorbisComments(comments...)
+	file = append(file, 0x80|flacBlockVorbisComment, byte(len(block)>>16), byte(len(block)>>8), byte(len(block)))
+	file = append(file, block...)
+
+	return append(file, make([]byte, audio)...)
+}
+
+// oggPages splits each packet into 255 byte segments, a packet longer than 255 segments spans pages.
+func oggPages(serial uint32, lastGranule int64, packets ...[]byte) []byte {
+	var file []byte
+	var segments []byte
+	var data []byte
+	sequence := uint32(0)
+
+	flush := func(granule int64) {
+		header := []byte("OggS")
+		header = append(header, 0, 0)
+		header = binary.LittleEndian.AppendUint64(header, uint64(granule))
+		header = binary.LittleEndian.AppendUint32(header, serial)
+		header = binary.LittleEndian.AppendUint32(header, sequence)
+		header = append(header, 0, 0, 0, 0, byte(len(segments)))
+		file = append(file, header...)
+		file = append(file, segments...)
+		file = append(file, data...)
+		segments, data = nil, nil
+		sequence++
+	}
+
+	for i, packet := range packets {
+		for {
+			lacing := min(len(packet), 255)
+			segments = append(segments, byte(lacing))
+			data = append(data, packet[:lacing]...)
+			packet = packet[lacing:]
+			if len(segments) == 255 {
+				flush(-1)
+			}
+			if lacing < 255 {
+				break
+			}
+		}
+
+		granule := int64(0)
+		if i == len(packets)-1 {
+			granule = lastGranule
+		}
+		if len(segments) > 0 {
+			flush(granule)
+		}
+	}
+
+	return file
+}
+
+func vorbisIdent(channels, sampleRate, nominal int) []byte {
+	packet := []byte("\x01vorbis")
+	packet = binary.LittleEndian.AppendUint32(packet, 0)
+	packet = append(packet, byte(channels))
+	packet = binary.LittleEndian.AppendUint32(packet, uint32(sampleRate))
+	packet = binary.LittleEndian.AppendUint32(packet, 0)
+	packet = binary.LittleEndian.AppendUint32(packet, uint32(nominal))
+	packet = binary.LittleEndian.AppendUint32(packet, 0)
+	return append(packet, 0xB8, 0x01)
+}
+
+func opusHead(channels, preSkip int) []byte {
+	packet := []byte("OpusHead")
+	packet = append(packet, 1, byte(channels))
+	packet = binary.LittleEndian.AppendUint16(packet, uint16(preSkip))
+	packet = binary.LittleEndian.AppendUint32(packet, 44100)
+	return append(packet, 0, 0, 0)
+}
+
+func (s *AudioMetaTestSuite) TestParse() {
+	xingFrame := mp3Frames(1, false)
+	copy(xingFrame[36:], "Xing")
+	binary.BigEndian.PutUint32(xingFrame[40:], 0x03)
+	binary.BigEndian.PutUint32(xingFrame[44:], 1000)
+	binary.BigEndian.PutUint32(xingFrame[48:], 1000*mpeg1Layer3FrameLen)
+
+	bigPicture := "METADATA_BLOCK_PICTURE=" + string(bytes.Repeat([]byte{'x'}, 70000))
+
+	testCases := []struct {
+		name   string
+		file   []byte
+		expect Metadata
+	}{
+		{
+			name: "mp3 cbr with id3v2.3",
+			file: append(id3v2Tag(3,
+				id3v23Frame("TIT2", 0, []byte("Caf\xe9")),
+				id3v23Frame("APIC", 0, make([]byte, 64)),
+				id3v23Frame("TPE1", 1, utf16LE("Naff")),
+				id3v23Frame("TALB", 3, []byte("Album\x00Other")),
+			), mp3Frames(100, false)...),
+			expect: Metadata{
+				Format: FormatMP3, Duration: 2606250 * time.Microsecond, Bitrate: 128, SampleRate: 44100, Channels: 2,
+				Title: "Café", Artist: "Naff", Album: "Album",
+			},
+		},
+		{
+			name: "mp3 xing vbr",
+			file: append(xingFrame, mp3Frames(20, false)...),
+			expect: Metadata{
+				Format: FormatMP3, Duration: durationOf(1000*1152, 44100), Bitrate: 127, SampleRate: 44100, Channels: 2,
+			},
+		},
+		{
+			name: "mp3 with junk before the first frame and id3v1",
+			file: bytes.Join([][]byte{mpeg1Layer3Frame, mp3Frames(10, true), id3v1Tag("Title", "Artist", "Album")}, nil),
+			expect: Metadata{
+				Format: FormatMP3, Duration: 260625 * time.Microsecond, Bitrate: 128, SampleRate: 44100, Channels: 1,
+				Title: "Title", Artist: "Artist", Album: "Album",
+			},
+		},
+		{
+			name: "flac",
+			file: flacFile(44100, 2, 441000, 100000, "title=Song", "ARTIST=Naff", "ALBUM=Album", "ARTIST=Second"),
+			expect: Metadata{
+				Format: FormatFLAC, Duration: 10 * time.Second, Bitrate: 80, SampleRate: 44100, Channels: 2,
+				Title: "Song", Artist: "Naff", Album: "Album",
+			},
+		},
+		{
+			name: "flac with overlong and invalid utf-8 tags",
+			file: flacFile(44100, 2, 441000, 100000, "TITLE="+strings.Repeat("é", 300), "ARTIST=Na\xffff\x00", "ALBUM=\x00\xc3"),
+			expect: Metadata{
+				Format: FormatFLAC, Duration: 10 * time.Second, Bitrate: 80, SampleRate: 44100, Channels: 2,
+				Title: strings.Repeat("é", 255), Artist: "Naff",
+			},
+		},
+		{
+			name: "mp3 with invalid utf-8 id3v2 frame",
+			file: append(id3v2Tag(3,
+				id3v23Frame("TIT2", 3, []byte("Caf\xe9")),
+				id3v23Frame("TPE1", 3, bytes.Repeat([]byte("a"), 1000)),
+			), mp3Frames(100, false)...),
+			expect: Metadata{
+				Format: FormatMP3, Duration: 2606250 * time.Microsecond, Bitrate: 128, SampleRate: 44100, Channels: 2,
+				Title: "Caf", Artist: strings.Repeat("a", 255),
+			},
+		},
+		{
+			name: "ogg vorbis with comment packet spanning pages",
+			file: oggPages(7, 88200,
+				vorbisIdent(2, 44100, 160000),
+				append([]byte("\x03vorbis"), vorbisComments("TITLE=Song", "ARTIST=Naff", bigPicture)...),
+				make([]byte, 100),
+			),
+			expect: Metadata{
+				Format: FormatOgg, Duration: 2 * time.Second, Bitrate: 160, SampleRate: 44100, Channels: 2,
+				Title: "Song", Artist: "Naff",
+			},
+		},
+		{
+			name: "ogg opus",
+			file: oggPages(9, 3*48000+312,
+				opusHead(2, 312),
+				append([]byte("OpusTags"), vorbisComments("ALBUM=Album")...),
+				make([]byte, 3000),
+			),
+			expect: Metadata{
+				Format: FormatOgg, Duration: 3 * time.Second, SampleRate: 48000, Channels: 2, Album: "Album",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			meta, err := Parse(bytes.NewReader(tc.file))
+			s.Require().NoError(err)
+
+			if tc.expect.Format == FormatOgg && tc.expect.Bitrate == 0 {
+				tc.expect.Bitrate = bitrateOf(int64(len(tc.file)), tc.expect.Duration)
+			}
+			s.Equal(tc.expect, meta)
+		})
+	}
+}
+
+func (s *AudioMetaTestSuite) TestParseErrors() {
+	truncatedFlac := flacFile(44100, 2, 441000, 0)[:20]
+
+	s.ErrorIs(errOf(Parse(bytes.NewReader([]byte("RIFF\x00\x00\x00\x00WAVE")))), ErrUnsupported)
+	s.ErrorIs(errOf(Parse(bytes.NewReader([]byte("ab")))), ErrUnsupported)
+	s.ErrorIs(errOf(Parse(bytes.NewReader(truncatedFlac))), ErrInvalid)
+	s.ErrorIs(errOf(Parse(bytes.NewReader(append(id3v2Tag(3), make([]byte, 500)...)))), ErrInvalid)
+	s.ErrorIs(errOf(Parse(bytes.NewReader([]byte("OggS\x00\x00")))), ErrInvalid)
+}
+
+func errOf(_ Metadata, err error) error {
+	return err
+}
+
+func TestAudioMetaTestSuite(t *testing.T) {
+	suite.Run(t, new(AudioMetaTestSuite))
+}
@@ -0,0 +1,76 @@
+package audiometa
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const (
+	flacBlockStreamInfo    = 0
+	flacBlockVorbisComment = 4
+	flacStreamInfoLen      = 34
+)
+
+// parseFLAC walks the metadata blocks after the `fLaC` marker. STREAMINFO carries the stream
+// properties and total sample count, VORBIS_COMMENT the tags, everything else is skipped.
+func parseFLAC(r io.ReadSeeker, size int64) (meta Metadata, err error) {
+	meta.Format = FormatFLAC
+
+	offset := int64(4)
+	seenStreamInfo := false
+	var samples int64
+
+	for {
+		header := make([]byte, 4)
+		if err := readAt(r, offset, header); err != nil {
+			return meta, invalid("flac metadata block header: %v", err)
+		}
+
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7F
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		offset += 4
+
+		if offset+length > size {
+			return meta, invalid("flac metadata block past end of file")
+		}
+
+		switch blockType {
+		case flacBlockStreamInfo:
+			if length < flacStreamInfoLen {
+				return meta, invalid("flac streaminfo too short")
+			}
+			block := make([]byte, flacStreamInfoLen)
+			if err := readAt(r, offset, block); err != nil {
+				return meta, invalid("flac streaminfo: %v", err)
+			}
+
+			// 20 bits sample rate, 3 bits channels - 1, 5 bits bits per sample - 1, 36 bits total samples
+			packed := binary.BigEndian.Uint64(block[10:18])
+			meta.SampleRate = int(packed >> 44)
+			meta.Channels = int((packed>>41)&0x07) + 1
+			samples = int64(packed & 0xFFFFFFFFF)
+			seenStreamInfo = true
+		case flacBlockVorbisComment:
+			block := make([]byte, min(length, maxTagSize))
+			if err := readAt(r, offset, block); err != nil {
+				return meta, invalid("flac vorbis comment: %v", err)
+			}
+			applyVorbisComments(block, &meta)
+		}
+
+		offset += length
+		if last {
+			break
+		}
+	}
+
+	if !seenStreamInfo || meta.SampleRate == 0 {
+		return meta, invalid("flac streaminfo missing")
+	}
+
+	meta.Duration = durationOf(samples, meta.SampleRate)
+	meta.Bitrate = bitrateOf(size-offset, meta.Duration)
+
+	return meta, nil
+}
@@ -0,0 +1,185 @@
+package audiometa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	id3v2HeaderLen = 10
+	id3v1Len       = 128
+)
+
+// id3v2Frames maps the text frames read from ID3v2.3/2.4 and the 3 character ID3v2.2 ids.
+var id3v2Frames = map[string]func(meta *Metadata) *string{
+	"TIT2": func(meta *Metadata) *string { return &meta.Title },
+	"TPE1": func(meta *Metadata) *string { return &meta.Artist },
+	"TALB": func(meta *Metadata) *string { return &meta.Album },
+	"TT2":  func(meta *Metadata) *string { return &meta.Title },
+	"TP1":  func(meta *Metadata) *string { return &meta.Artist },
+	"TAL":  func(meta *Metadata) *string { return &meta.Album },
+}
+
+// readID3v2 reads the title, artist and album frames of a leading ID3v2 tag and returns where
+// the audio starts. Other frames, pictures included, are skipped without being read.
+func readID3v2(r io.ReadSeeker, size int64, meta *Metadata) (audioStart int64, err error) {
+	header := make([]byte, id3v2HeaderLen)
+	if err := readAt(r, 0, header); err != nil || !bytes.HasPrefix(header, []byte("ID3")) {
+		return 0, nil
+	}
+
+	version := header[3]
+	flags := header[5]
+	tagSize := int64(syncsafe(header[6:10]))
+	audioStart = id3v2HeaderLen + tagSize
+	if flags&0x10 != 0 {
+		audioStart += id3v2HeaderLen // footer
+	}
+	if audioStart > size {
+		return 0, invalid("id3v2 tag past end of file")
+	}
+	// Unsynchronised tags need the whole tag rewritten before reading, rare enough to skip
+	if version < 2 || version > 4 || flags&0x80 != 0 {
+		return audioStart, nil
+	}
+
+	offset := int64(id3v2HeaderLen)
+	end := id3v2HeaderLen + tagSize
+
+	if flags&0x40 != 0 && version >= 3 {
+		ext := make([]byte, 4)
+		if err := readAt(r, offset, ext); err != nil {
+			return audioStart, nil
+		}
+		if version == 4 {
+			offset += int64(syncsafe(ext))
+		} else {
+			offset += 4 + int64(binary.BigEndian.Uint32(ext))
+		}
+	}
+
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+
+	frameHeader := make([]byte, headerLen)
+	for offset+int64(headerLen) <= end {
+		if err := readAt(r, offset, frameHeader); err != nil {
+			break
+		}
+		// Padding
+		if frameHeader[0] == 0 {
+			break
+		}
+
+		id := string(frameHeader[:idLen])
+		var frameSize int64
+		switch version {
+		case 2:
+			frameSize = int64(frameHeader[3])<<16 | int64(frameHeader[4])<<8 | int64(frameHeader[5])
+		case 3:
+			frameSize = int64(binary.BigEndian.Uint32(frameHeader[4:8]))
+		default:
+			frameSize = int64(syncsafe(frameHeader[4:8]))
+		}
+		offset += int64(headerLen)
+
+		if frameSize <= 0 || offset+frameSize > end {
+			break
+		}
+
+		if field, ok := id3v2Frames[id]; ok && frameSize <= maxTagSize {
+			data := make([]byte, frameSize)
+			if err := readAt(r, offset, data); err == nil {
+				setIfEmpty(field(meta), decodeID3Text(data))
+			}
+		}
+
+		offset += frameSize
+	}
+
+	return audioStart, nil
+}
+
+// readID3v1 fills fields still empty from a trailing ID3v1 tag, reporting whether one is there.
+func readID3v1(r io.ReadSeeker, size int64, meta *Metadata) bool {
+	if size < id3v1Len {
+		return false
+	}
+
+	tag := make([]byte, id3v1Len)
+	if err := readAt(r, size-id3v1Len, tag); err != nil || !bytes.HasPrefix(tag, []byte("TAG")) {
+		return false
+	}
+
+	// Fields are padded with NUL or spaces
+	setIfEmpty(&meta.Title, strings.TrimRight(latin1(trimNul(tag[3:33])), " "))
+	setIfEmpty(&meta.Artist, strings.TrimRight(latin1(trimNul(tag[33:63])), " "))
+	setIfEmpty(&meta.Album, strings.TrimRight(latin1(trimNul(tag[63:93])), " "))
+
+	return true
+}
+
+// decodeID3Text decodes a text frame body, the first byte picks the encoding.
+func decodeID3Text(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	encoding, text := data[0], data[1:]
+	var value string
+	switch encoding {
+	case 1: // UTF-16 with BOM
+		value = decodeUTF16(text, true)
+	case 2: // UTF-16BE without BOM
+		value = decodeUTF16(text, false)
+	case 3: // UTF-8
+		value = string(text)
+	default: // ISO-8859-1
+		value = latin1(text)
+	}
+
+	// ID3v2.4 separates multiple values with NUL, keep the first
+	value, _, _ = strings.Cut(value, "\x00")
+	return value
+}
+
+func decodeUTF16(text []byte, withBOM bool) string {
+	order := binary.ByteOrder(binary.BigEndian)
+	if withBOM && len(text) >= 2 {
+		if text[0] == 0xFF && text[1] == 0xFE {
+			order = binary.LittleEndian
+		}
+		text = text[2:]
+	}
+
+	units := make([]uint16, 0, len(text)/2)
+	for i := 0; i+1 < len(text); i += 2 {
+		units = append(units, order.Uint16(text[i:]))
+	}
+
+	return string(utf16.Decode(units))
+}
+
+func latin1(text []byte) string {
+	runes := make([]rune, len(text))
+	for i, b := range text {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+func trimNul(text []byte) []byte {
+	if i := bytes.IndexByte(text, 0); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
+
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7F)<<21 | uint32(b[1]&0x7F)<<14 | uint32(b[2]&0x7F)<<7 | uint32(b[3]&0x7F)
+}
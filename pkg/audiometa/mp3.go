@@ -0,0 +1,210 @@
+package audiometa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// mp3SyncSearchLen is how far past the ID3v2 tag the first frame is looked for.
+const mp3SyncSearchLen = 64 * 1024
+
+const (
+	mpeg25 = 0
+	mpeg2  = 2
+	mpeg1  = 3
+
+	layer3 = 1
+	layer2 = 2
+	layer1 = 3
+)
+
+// mp3Bitrates in kbps by [mpeg1][layer][index], MPEG 2 and 2.5 share a table.
+var mp3Bitrates = [2][4][16]int{
+	{ // MPEG 2 and 2.5
+		{},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+	},
+	{ // MPEG 1
+		{},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+	},
+}
+
+var mp3SampleRates = [4][3]int{
+	mpeg25: {11025, 12000, 8000},
+	mpeg2:  {22050, 24000, 16000},
+	mpeg1:  {44100, 48000, 32000},
+}
+
+type mp3Frame struct {
+	version    int
+	layer      int
+	bitrate    int
+	sampleRate int
+	channels   int
+	length     int
+	samples    int
+}
+
+// parseMP3 reads the tags and the first frame. The duration comes from a Xing/Info or VBRI
+// header when the encoder wrote one, otherwise the stream is taken as constant bitrate.
+func parseMP3(r io.ReadSeeker, size int64) (meta Metadata, err error) {
+	meta.Format = FormatMP3
+
+	audioStart, err := readID3v2(r, size, &meta)
+	if err != nil {
+		return meta, err
+	}
+	audioEnd := size
+	if readID3v1(r, size, &meta) {
+		audioEnd -= id3v1Len
+	}
+
+	if audioEnd <= audioStart {
+		return meta, invalid("mp3 has no audio frames")
+	}
+
+	window := make([]byte, min(mp3SyncSearchLen, audioEnd-audioStart))
+	if err := readAt(r, audioStart, window); err != nil {
+		return meta, invalid("mp3 audio: %v", err)
+	}
+
+	frameOffset, frame, ok := findMP3Frame(window)
+	if !ok {
+		return meta, invalid("mp3 frame sync not found")
+	}
+	audioStart += int64(frameOffset)
+
+	meta.SampleRate = frame.sampleRate
+	meta.Channels = frame.channels
+
+	if frames, byteCount, ok := readVBRHeader(window[frameOffset:], frame); ok {
+		meta.Duration = durationOf(int64(frames)*int64(frame.samples), frame.sampleRate)
+		if byteCount == 0 {
+			byteCount = audioEnd - audioStart
+		}
+		meta.Bitrate = bitrateOf(byteCount, meta.Duration)
+		return meta, nil
+	}
+
+	meta.Bitrate = frame.bitrate
+	meta.Duration = time.Duration(float64(audioEnd-audioStart) * 8 / float64(frame.bitrate*1000) * float64(time.Second))
+
+	return meta, nil
+}
+
+// findMP3Frame finds the first frame header followed by another valid header where the frame
+// length says it should be, a lone sync pattern inside junk data is not enough.
+func findMP3Frame(window []byte) (offset int, frame mp3Frame, ok bool) {
+	for i := 0; i+4 <= len(window); i++ {
+		if window[i] != 0xFF {
+			continue
+		}
+
+		frame, ok := parseMP3FrameHeader(window[i:])
+		if !ok {
+			continue
+		}
+
+		next := i + frame.length
+		if next+4 <= len(window) {
+			if _, ok := parseMP3FrameHeader(window[next:]); !ok {
+				continue
+			}
+		}
+
+		return i, frame, true
+	}
+
+	return 0, mp3Frame{}, false
+}
+
+func parseMP3FrameHeader(b []byte) (frame mp3Frame, ok bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return frame, false
+	}
+
+	frame.version = int(b[1]>>3) & 0x03
+	frame.layer = int(b[1]>>1) & 0x03
+	bitrateIndex := int(b[2]>>4) & 0x0F
+	sampleRateIndex := int(b[2]>>2) & 0x03
+	padding := int(b[2]>>1) & 0x01
+
+	// Reserved version, layer and sample rate, free format and bad bitrates are not supported
+	if frame.version == 1 || frame.layer == 0 || sampleRateIndex == 3 || bitrateIndex == 0 || bitrateIndex == 15 {
+		return frame, false
+	}
+
+	table := 0
+	if frame.version == mpeg1 {
+		table = 1
+	}
+	frame.bitrate = mp3Bitrates[table][frame.layer][bitrateIndex]
+	frame.sampleRate = mp3SampleRates[frame.version][sampleRateIndex]
+
+	frame.channels = 2
+	if b[3]>>6 == 3 {
+		frame.channels = 1
+	}
+
+	switch {
+	case frame.layer == layer1:
+		frame.samples = 384
+		frame.length = (12*frame.bitrate*1000/frame.sampleRate + padding) * 4
+	case frame.layer == layer3 && frame.version != mpeg1:
+		frame.samples = 576
+		frame.length = 72*frame.bitrate*1000/frame.sampleRate + padding
+	default:
+		frame.samples = 1152
+		frame.length = 144*frame.bitrate*1000/frame.sampleRate + padding
+	}
+
+	return frame, frame.length > 4
+}
+
+// readVBRHeader reads the frame and byte counts of a Xing/Info or VBRI header in the first frame.
+func readVBRHeader(b []byte, frame mp3Frame) (frames uint32, byteCount int64, ok bool) {
+	// Xing sits after the side information, its size depends on version and channels
+	sideInfo := 32
+	switch {
+	case frame.version == mpeg1 && frame.channels == 1:
+		sideInfo = 17
+	case frame.version != mpeg1 && frame.channels == 1:
+		sideInfo = 9
+	case frame.version != mpeg1:
+		sideInfo = 17
+	}
+
+	if xing := 4 + sideInfo; len(b) >= xing+16 && (hasTag(b[xing:], "Xing") || hasTag(b[xing:], "Info")) {
+		flags := binary.BigEndian.Uint32(b[xing+4:])
+		pos := xing + 8
+		if flags&0x01 == 0 {
+			return 0, 0, false
+		}
+		frames = binary.BigEndian.Uint32(b[pos:])
+		pos += 4
+		if flags&0x02 != 0 && len(b) >= pos+4 {
+			byteCount = int64(binary.BigEndian.Uint32(b[pos:]))
+		}
+		return frames, byteCount, frames > 0
+	}
+
+	// VBRI always sits 32 bytes after the header
+	if vbri := 4 + 32; len(b) >= vbri+18 && hasTag(b[vbri:], "VBRI") {
+		byteCount = int64(binary.BigEndian.Uint32(b[vbri+10:]))
+		frames = binary.BigEndian.Uint32(b[vbri+14:])
+		return frames, byteCount, frames > 0
+	}
+
+	return 0, 0, false
+}
+
+func hasTag(b []byte, tag string) bool {
+	return bytes.HasPrefix(b, []byte(tag))
+}
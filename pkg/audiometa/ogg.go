@@ -0,0 +1,183 @@
+package audiometa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	oggPageHeaderLen = 27
+	// oggMaxPageLen is the largest possible page, header plus 255 segments of 255 bytes
+	oggMaxPageLen = oggPageHeaderLen + 255 + 255*255
+	// opusSampleRate Opus granule positions always count 48 kHz samples
+	opusSampleRate = 48000
+)
+
+type oggPage struct {
+	granule  int64
+	serial   uint32
+	segments []byte
+	dataLen  int64
+}
+
+// parseOgg reads the identification and comment packets of the first logical stream and takes
+// the duration from the granule position of its last page.
+func parseOgg(r io.ReadSeeker, size int64) (meta Metadata, err error) {
+	meta.Format = FormatOgg
+
+	packets, serial, err := readOggPackets(r, size, 2)
+	if err != nil {
+		return meta, err
+	}
+	ident, comment := packets[0], packets[1]
+
+	var samples int64
+	granule, err := lastOggGranule(r, size, serial)
+	if err != nil {
+		return meta, err
+	}
+
+	switch {
+	case len(ident) >= 30 && bytes.HasPrefix(ident, []byte("\x01vorbis")):
+		meta.Channels = int(ident[11])
+		meta.SampleRate = int(binary.LittleEndian.Uint32(ident[12:16]))
+		if nominal := int32(binary.LittleEndian.Uint32(ident[20:24])); nominal > 0 {
+			meta.Bitrate = int(nominal / 1000)
+		}
+		samples = granule
+
+		if bytes.HasPrefix(comment, []byte("\x03vorbis")) {
+			applyVorbisComments(comment[7:], &meta)
+		}
+	case len(ident) >= 19 && bytes.HasPrefix(ident, []byte("OpusHead")):
+		meta.Channels = int(ident[9])
+		meta.SampleRate = opusSampleRate
+		preSkip := int64(binary.LittleEndian.Uint16(ident[10:12]))
+		samples = granule - preSkip
+
+		if bytes.HasPrefix(comment, []byte("OpusTags")) {
+			applyVorbisComments(comment[8:], &meta)
+		}
+	default:
+		return meta, ErrUnsupported
+	}
+
+	if meta.SampleRate == 0 {
+		return meta, invalid("ogg sample rate is zero")
+	}
+
+	meta.Duration = durationOf(samples, meta.SampleRate)
+	if meta.Bitrate == 0 {
+		meta.Bitrate = bitrateOf(size, meta.Duration)
+	}
+
+	return meta, nil
+}
+
+// readOggPackets reassembles the first n packets of the first logical stream. Packets past
+// maxTagSize are cut, that only happens to comment packets carrying pictures.
+func readOggPackets(r io.ReadSeeker, size int64, n int) (packets [][]byte, serial uint32, err error) {
+	packets = make([][]byte, 0, n)
+	var current []byte
+	offset := int64(0)
+	first := true
+
+	for len(packets) < n {
+		page, err := readOggPage(r, offset, size)
+		if err != nil {
+			return nil, 0, err
+		}
+		dataOffset := offset + oggPageHeaderLen + int64(len(page.segments))
+		offset = dataOffset + page.dataLen
+
+		if first {
+			serial = page.serial
+			first = false
+		}
+		// Pages of other multiplexed streams are skipped
+		if page.serial != serial {
+			continue
+		}
+
+		data := make([]byte, page.dataLen)
+		if err := readAt(r, dataOffset, data); err != nil {
+			return nil, 0, invalid("ogg page data: %v", err)
+		}
+
+		for _, lacing := range page.segments {
+			segment := data[:lacing]
+			data = data[lacing:]
+
+			if len(current) < maxTagSize {
+				current = append(current, segment[:min(len(segment), maxTagSize-len(current))]...)
+			}
+
+			// A lacing value below 255 ends the packet
+			if lacing < 255 {
+				packets = append(packets, current)
+				current = nil
+				if len(packets) == n {
+					break
+				}
+			}
+		}
+	}
+
+	return packets, serial, nil
+}
+
+func readOggPage(r io.ReadSeeker, offset, size int64) (page oggPage, err error) {
+	if offset+oggPageHeaderLen > size {
+		return page, invalid("ogg stream ends before its header packets")
+	}
+
+	header := make([]byte, oggPageHeaderLen)
+	if err := readAt(r, offset, header); err != nil {
+		return page, invalid("ogg page header: %v", err)
+	}
+	if !bytes.HasPrefix(header, []byte("OggS")) {
+		return page, invalid("ogg page capture pattern missing at %d", offset)
+	}
+
+	page.granule = int64(binary.LittleEndian.Uint64(header[6:14]))
+	page.serial = binary.LittleEndian.Uint32(header[14:18])
+	page.segments = make([]byte, header[26])
+	if err := readAt(r, offset+oggPageHeaderLen, page.segments); err != nil {
+		return page, invalid("ogg segment table: %v", err)
+	}
+
+	for _, lacing := range page.segments {
+		page.dataLen += int64(lacing)
+	}
+
+	return page, nil
+}
+
+// lastOggGranule scans the tail of the file backwards for the last page of the stream with a
+// granule position, a page is never longer than oggMaxPageLen so the tail always holds one.
+func lastOggGranule(r io.ReadSeeker, size int64, serial uint32) (granule int64, err error) {
+	start := max(size-2*oggMaxPageLen, 0)
+	tail := make([]byte, size-start)
+	if err := readAt(r, start, tail); err != nil {
+		return 0, invalid("ogg tail: %v", err)
+	}
+
+	for end := len(tail); ; {
+		i := bytes.LastIndex(tail[:end], []byte("OggS"))
+		if i < 0 {
+			return 0, invalid("ogg last page not found")
+		}
+		end = i
+
+		if i+oggPageHeaderLen > len(tail) {
+			continue
+		}
+		header := tail[i : i+oggPageHeaderLen]
+		granule := int64(binary.LittleEndian.Uint64(header[6:14]))
+		// -1 marks a page where no packet ends
+		if binary.LittleEndian.Uint32(header[14:18]) == serial && granule != -1 {
+			return granule, nil
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package audiometa
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// applyVorbisComments reads a Vorbis comment block, shared by FLAC, Ogg Vorbis and Opus.
+// A truncated block keeps whatever fields were read before the cut.
+func applyVorbisComments(block []byte, meta *Metadata) {
+	next := func(n uint32) ([]byte, bool) {
+		if uint64(n) > uint64(len(block)) {
+			return nil, false
+		}
+		value := block[:n]
+		block = block[n:]
+		return value, true
+	}
+	u32 := func() (uint32, bool) {
+		value, ok := next(4)
+		if !ok {
+			return 0, false
+		}
+		return binary.LittleEndian.Uint32(value), true
+	}
+
+	vendorLen, ok := u32()
+	if !ok {
+		return
+	}
+	if _, ok := next(vendorLen); !ok {
+		return
+	}
+
+	count, ok := u32()
+	if !ok {
+		return
+	}
+
+	for range count {
+		length, ok := u32()
+		if !ok {
+			return
+		}
+		comment, ok := next(length)
+		if !ok {
+			return
+		}
+
+		key, value, found := strings.Cut(string(comment), "=")
+		if !found {
+			continue
+		}
+
+		// The first value wins when a field repeats
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			setIfEmpty(&meta.Title, value)
+		case "ARTIST":
+			setIfEmpty(&meta.Artist, value)
+		case "ALBUM":
+			setIfEmpty(&meta.Album, value)
+		}
+	}
+}
+
+// setIfEmpty keeps tags storable as text: invalid UTF-8 and NUL are dropped and the value is cut
+// at maxTextLength runes.
+func setIfEmpty(field *string, value string) {
+	value = strings.ReplaceAll(strings.ToValidUTF8(value, ""), "\x00", "")
+	if runes := []rune(value); len(runes) > maxTextLength {
+		value = string(runes[:maxTextLength])
+	}
+	value = strings.TrimSpace(value)
+	if *field == "" && value != "" {
+		*field = value
+	}
+}
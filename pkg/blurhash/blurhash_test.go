@@ -0,0 +1,73 @@
+package blurhash
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BlurHashTestSuite struct {
+	suite.Suite
+}
+
+func filled(width, height int, at func(x, y int) color.Color) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, at(x, y))
+		}
+	}
+	return img
+}
+
+func (s *BlurHashTestSuite) TestEncode() {
+	// Expected hashes come from a port of the reference TypeScript encoder
+	pattern := filled(12, 9, func(x, y int) color.Color {
+		return color.NRGBA{R: uint8(x*37 + y*11), G: uint8(x*7 + y*53), B: uint8(x*x + y*3), A: 255}
+	})
+	red := filled(3, 3, func(int, int) color.Color { return color.NRGBA{R: 255, A: 255} })
+	// Left half black, right half white
+	split := filled(4, 1, func(x, _ int) color.Color {
+		if x < 2 {
+			return color.Black
+		}
+		return color.White
+	})
+
+	testCases := []struct {
+		name     string
+		img      image.Image
+		x, y     int
+		expected string
+	}{
+		{"landscape components", pattern, 4, 3, "LWG+WZRzI]2pizocFJWCRlZ.bZt4"},
+		{"portrait components", pattern, 3, 4, "TVG+WZRzI]izocFJRSZ.bZuueokB"},
+		{"dc only", red, 1, 1, "00TI:j"},
+		{"horizontal edge", split, 2, 1, "1wLqe900"},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			hash, err := Encode(tc.img, tc.x, tc.y)
+			s.Require().NoError(err)
+			s.Equal(tc.expected, hash)
+		})
+	}
+}
+
+func (s *BlurHashTestSuite) TestEncodeErrors() {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+
+	_, err := Encode(img, 0, 3)
+	s.ErrorIs(err, ErrComponents)
+	_, err = Encode(img, 4, 10)
+	s.ErrorIs(err, ErrComponents)
+	_, err = Encode(image.NewNRGBA(image.Rect(0, 0, 0, 0)), 4, 3)
+	s.Error(err)
+}
+
+func TestBlurHashTestSuite(t *testing.T) {
+	suite.Run(t, new(BlurHashTestSuite))
+}
@@ -1,6 +1,9 @@
 package errs
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type BaseError struct {
 	Message string
@@ -180,3 +183,25 @@ func NewTimeOut(message string, cause ...error) *TimeOut {
 		},
 	}
 }
+
+// TooManyRequestsError represents a 429 error, RetryAfter is sent in the Retry-After header.
+type TooManyRequestsError struct {
+	*BaseError
+	RetryAfter time.Duration
+}
+
+func NewTooManyRequestsError(message string, retryAfter time.Duration, cause ...error) *TooManyRequestsError {
+	var underlying error
+	if len(cause) > 0 {
+		underlying = cause[0]
+	}
+
+	return &TooManyRequestsError{
+		BaseError: &BaseError{
+			Message: message,
+			Code:    429,
+			Cause:   underlying,
+		},
+		RetryAfter: retryAfter,
+	}
+}
@@ -1,6 +1,9 @@
 package errs
 
 import (
+	"math"
+	"strconv"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
@@ -45,6 +48,13 @@ func HandleHTTPError(c *fiber.Ctx, log *logrus.Logger, layer, operation string,
 		return c.Status(fiber.StatusRequestTimeout).JSON(fiber.Map{
 			"message": e.Message,
 		})
+	case *TooManyRequestsError:
+		utils.LogWarn(log, c.Context(), layer, operation, err)
+		// Retry-After is in whole seconds, rounded up so a client retrying on time is not early
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(int(math.Ceil(e.RetryAfter.Seconds())), 1)))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"message": e.Message,
+		})
 	default:
 		utils.LogError(log, c.Context(), layer, operation, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
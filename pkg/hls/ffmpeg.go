@@ -0,0 +1,65 @@
+package hls
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stderrTail is how much of ffmpeg's error output is kept in the returned error.
+const stderrTail = 1024
+
+// ffmpegPackager encodes every variant to AAC in MPEG-TS segments with a single ffmpeg run.
+type ffmpegPackager struct {
+	bin             string
+	variants        []Variant
+	segmentDuration time.Duration
+}
+
+func (p *ffmpegPackager) Package(ctx context.Context, input, outDir string) (err error) {
+	if len(p.variants) == 0 {
+		return fmt.Errorf("hls: no variants configured")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.bin, p.args(input, outDir)...)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		output := stderr.Bytes()
+		if len(output) > stderrTail {
+			output = output[len(output)-stderrTail:]
+		}
+		return fmt.Errorf("hls: ffmpeg: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	// ffmpeg can write a master playlist too, but without CODECS, which some players require
+	return os.WriteFile(filepath.Join(outDir, MasterPlaylist), MasterPlaylistOf(p.variants), 0o644)
+}
+
+func (p *ffmpegPackager) args(input, outDir string) []string {
+	args := []string{"-hide_banner", "-loglevel", "error", "-nostdin", "-y", "-i", input}
+
+	streamMap := make([]string, 0, len(p.variants))
+	for i, v := range p.variants {
+		args = append(args, "-map", "0:a:0", fmt.Sprintf("-b:a:%d", i), fmt.Sprintf("%dk", v.Bitrate))
+		streamMap = append(streamMap, fmt.Sprintf("a:%d,name:%s", i, v.Name()))
+	}
+
+	return append(args,
+		"-c:a", "aac",
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(max(int(p.segmentDuration.Seconds()), 1)),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_type", "mpegts",
+		"-hls_segment_filename", filepath.Join(outDir, "%v", "segment_%03d.ts"),
+		"-var_stream_map", strings.Join(streamMap, " "),
+		filepath.Join(outDir, "%v", mediaPlaylist),
+	)
+}
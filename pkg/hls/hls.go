@@ -0,0 +1,124 @@
+// Package hls packages uploaded audio into HTTP Live Streaming renditions and signs the
+// playlists when they are served.
+package hls
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+)
+
+const (
+	// MasterPlaylist is the entry point clients load, it lists one media playlist per variant.
+	MasterPlaylist = "master.m3u8"
+	mediaPlaylist  = "index.m3u8"
+	// codecs every variant is encoded with, AAC-LC
+	codecs = "mp4a.40.2"
+	// muxOverhead MPEG-TS packets add roughly 10% on top of the audio bitrate
+	muxOverhead = 1.1
+)
+
+// fileNamePattern matches the files a package holds, the master playlist, a variant media
+// playlist or a segment. Anything else is never served.
+var fileNamePattern = regexp.MustCompile(`^(?:[0-9]+k/)?[a-z0-9_]+\.(?:m3u8|ts)$`)
+
+// uriAttrPattern matches URI attributes of tags like EXT-X-MAP.
+var uriAttrPattern = regexp.MustCompile(`URI="([^"]*)"`)
+
+// Variant is one rendition of the package.
+type Variant struct {
+	Bitrate int // kbps
+}
+
+// Name is the directory holding the variant media playlist and segments.
+func (v Variant) Name() string {
+	return fmt.Sprintf("%dk", v.Bitrate)
+}
+
+// Packager segments an audio file into a package of playlists and segments.
+type Packager interface {
+	// Package writes the master playlist, and per variant a media playlist and its segments, into
+	// outDir. Paths inside the package are relative so it can be moved to storage as is.
+	Package(ctx context.Context, input, outDir string) (err error)
+}
+
+// NewPackager builds the ffmpeg packager with the configured bitrate ladder.
+func NewPackager(cfg *config.Config) Packager {
+	return &ffmpegPackager{
+		bin:             cfg.FFmpegPath,
+		variants:        variantsOf(cfg.HLSBitrates),
+		segmentDuration: cfg.HLSSegmentDuration,
+	}
+}
+
+func variantsOf(bitrates []int) []Variant {
+	bitrates = slices.Clone(bitrates)
+	slices.Sort(bitrates)
+	bitrates = slices.Compact(bitrates)
+
+	variants := make([]Variant, 0, len(bitrates))
+	for _, bitrate := range bitrates {
+		variants = append(variants, Variant{Bitrate: bitrate})
+	}
+	return variants
+}
+
+// MasterPlaylistOf lists the variant media playlists, lowest bitrate first.
+func MasterPlaylistOf(variants []Variant) []byte {
+	var b bytes.Buffer
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, v := range variants {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,CODECS=\"%s\"\n", int(float64(v.Bitrate*1000)*muxOverhead), codecs)
+		fmt.Fprintf(&b, "%s/%s\n", v.Name(), mediaPlaylist)
+	}
+	return b.Bytes()
+}
+
+// ValidFileName reports whether name is a file a package can hold, so a request path can be
+// turned into a storage key safely.
+func ValidFileName(name string) bool {
+	return fileNamePattern.MatchString(name)
+}
+
+// IsPlaylist reports whether name is a playlist rather than a segment.
+func IsPlaylist(name string) bool {
+	return strings.HasSuffix(name, ".m3u8")
+}
+
+// SignPlaylist appends the query to every URI in the playlist. Players resolve the URIs
+// relative to the playlist and drop its query, without this a signed master playlist would
+// point to unsigned media playlists and segments.
+func SignPlaylist(playlist []byte, query string) []byte {
+	if query == "" {
+		return playlist
+	}
+
+	sign := func(uri string) string {
+		if strings.Contains(uri, "?") {
+			return uri + "&" + query
+		}
+		return uri + "?" + query
+	}
+
+	lines := strings.Split(string(playlist), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, "\r")
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#"):
+			lines[i] = uriAttrPattern.ReplaceAllStringFunc(line, func(attr string) string {
+				uri := uriAttrPattern.FindStringSubmatch(attr)[1]
+				return `URI="` + sign(uri) + `"`
+			})
+		default:
+			lines[i] = sign(trimmed) + line[len(trimmed):]
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,109 @@
+package hls
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+)
+
+type HLSTestSuite struct {
+	suite.Suite
+}
+
+func (s *HLSTestSuite) TestNewPackager() {
+	packager := NewPackager(&config.Config{FFmpegPath: "ffmpeg", HLSBitrates: []int{256, 64, 128, 64}, HLSSegmentDuration: 6 * time.Second})
+
+	s.Equal([]Variant{{Bitrate: 64}, {Bitrate: 128}, {Bitrate: 256}}, packager.(*ffmpegPackager).variants)
+}
+
+func (s *HLSTestSuite) TestMasterPlaylistOf() {
+	expected := "#EXTM3U\n#EXT-X-VERSION:3\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=70400,CODECS=\"mp4a.40.2\"\n64k/index.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=140800,CODECS=\"mp4a.40.2\"\n128k/index.m3u8\n"
+
+	s.Equal(expected, string(MasterPlaylistOf([]Variant{{Bitrate: 64}, {Bitrate: 128}})))
+}
+
+func (s *HLSTestSuite) TestSignPlaylist() {
+	playlist := "#EXTM3U\r\n#EXT-X-MAP:URI=\"init.mp4\"\r\n#EXTINF:6.0,\r\nsegment_000.ts\r\n\r\n#EXTINF:2.5,\r\nsegment_001.ts?v=1\r\n#EXT-X-ENDLIST\r\n"
+	expected := "#EXTM3U\r\n#EXT-X-MAP:URI=\"init.mp4?uid=1&sig=x\"\r\n#EXTINF:6.0,\r\nsegment_000.ts?uid=1&sig=x\r\n\r\n#EXTINF:2.5,\r\nsegment_001.ts?v=1&uid=1&sig=x\r\n#EXT-X-ENDLIST\r\n"
+
+	s.Equal(expected, string(SignPlaylist([]byte(playlist), "uid=1&sig=x")))
+	s.Equal(playlist, string(SignPlaylist([]byte(playlist), "")))
+}
+
+func (s *HLSTestSuite) TestValidFileName() {
+	testCases := []struct {
+		name  string
+		valid bool
+	}{
+		{"master.m3u8", true},
+		{"128k/index.m3u8", true},
+		{"128k/segment_004.ts", true},
+		{"../master.m3u8", false},
+		{"128k/../../audio.mp3", false},
+		{"128k/segment_004.mp3", false},
+		{"/master.m3u8", false},
+		{"a/b/index.m3u8", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		s.Equal(tc.valid, ValidFileName(tc.name), tc.name)
+	}
+}
+
+func (s *HLSTestSuite) TestPackageArgs() {
+	packager := &ffmpegPackager{bin: "ffmpeg", variants: []Variant{{Bitrate: 64}, {Bitrate: 128}}, segmentDuration: 6 * time.Second}
+
+	s.Equal([]string{
+		"-hide_banner", "-loglevel", "error", "-nostdin", "-y", "-i", "in.flac",
+		"-map", "0:a:0", "-b:a:0", "64k",
+		"-map", "0:a:0", "-b:a:1", "128k",
+		"-c:a", "aac",
+		"-f", "hls",
+		"-hls_time", "6",
+		"-hls_playlist_type", "vod",
+		"-hls_segment_type", "mpegts",
+		"-hls_segment_filename", filepath.Join("out", "%v", "segment_%03d.ts"),
+		"-var_stream_map", "a:0,name:64k a:1,name:128k",
+		filepath.Join("out", "%v", "index.m3u8"),
+	}, packager.args("in.flac", "out"))
+}
+
+func (s *HLSTestSuite) TestPackage() {
+	dir := s.T().TempDir()
+
+	ok := filepath.Join(dir, "ok.sh")
+	s.Require().NoError(os.WriteFile(ok, []byte("#!/bin/sh\nexit 0\n"), 0o755))
+	failing := filepath.Join(dir, "failing.sh")
+	s.Require().NoError(os.WriteFile(failing, []byte("#!/bin/sh\necho 'in.flac: Invalid data found' >&2\nexit 1\n"), 0o755))
+
+	variants := []Variant{{Bitrate: 128}}
+
+	s.Run("writes the master playlist", func() {
+		outDir := s.T().TempDir()
+		packager := &ffmpegPackager{bin: ok, variants: variants, segmentDuration: 6 * time.Second}
+
+		s.Require().NoError(packager.Package(s.T().Context(), "in.flac", outDir))
+
+		master, err := os.ReadFile(filepath.Join(outDir, MasterPlaylist))
+		s.Require().NoError(err)
+		s.Equal(MasterPlaylistOf(variants), master)
+	})
+
+	s.Run("ffmpeg failure keeps its output", func() {
+		packager := &ffmpegPackager{bin: failing, variants: variants, segmentDuration: 6 * time.Second}
+
+		err := packager.Package(s.T().Context(), "in.flac", s.T().TempDir())
+		s.ErrorContains(err, "in.flac: Invalid data found")
+	})
+}
+
+func TestHLSTestSuite(t *testing.T) {
+	suite.Run(t, new(HLSTestSuite))
+}
@@ -0,0 +1,199 @@
+// Package imaging decodes uploaded JPEG, PNG and WebP images and re-encodes resized variants.
+// Variants are encoded from the decoded pixels, so EXIF and every other metadata block of the
+// upload is dropped, the EXIF orientation is applied to the pixels first.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"slices"
+
+	"github.com/wahyusahajaa/mulo-api-go/pkg/blurhash"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	// ErrUnsupported the upload is not a JPEG, PNG or WebP image.
+	ErrUnsupported = errors.New("imaging: unsupported image format")
+	// ErrInvalid the upload looks like a supported format but does not decode.
+	ErrInvalid = errors.New("imaging: invalid image")
+	// ErrTooLarge the image has more than MaxPixels pixels.
+	ErrTooLarge = errors.New("imaging: image too large")
+)
+
+const (
+	// MaxPixels guards against decompression bombs, a small file declaring huge dimensions.
+	MaxPixels = 50_000_000
+	// blurHashSize is the longest side of the thumbnail the BlurHash is computed from
+	blurHashSize = 32
+	jpegQuality  = 85
+)
+
+// supportedFormats are the image.DecodeConfig format names accepted on upload.
+var supportedFormats = map[string]bool{"jpeg": true, "png": true, "webp": true}
+
+// Variant is one encoded size of the image.
+type Variant struct {
+	Width       int
+	Height      int
+	ContentType string
+	Ext         string
+	Data        []byte
+}
+
+// Result is the processed image, Variants are ordered by width, the largest last.
+type Result struct {
+	Width    int
+	Height   int
+	BlurHash string
+	Variants []Variant
+}
+
+// Process decodes the image and encodes one variant per width. Images are never upscaled,
+// widths past the image width give a single variant at the image's own width. Opaque images
+// are encoded as JPEG, images with transparency as PNG.
+func Process(data []byte, widths []int) (result Result, err error) {
+	img, err := decode(data)
+	if err != nil {
+		return result, err
+	}
+	result.Width, result.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	result.BlurHash, err = blurHashOf(img)
+	if err != nil {
+		return result, err
+	}
+
+	opaque := img.Opaque()
+	for _, width := range variantWidths(widths, result.Width) {
+		variant, err := encode(resize(img, width), opaque)
+		if err != nil {
+			return result, err
+		}
+		result.Variants = append(result.Variants, variant)
+	}
+
+	return result, nil
+}
+
+// Inspect decodes the image for its size and BlurHash only, for images stored elsewhere such
+// as the avatar of an OAuth provider. The result has no variants.
+func Inspect(data []byte) (result Result, err error) {
+	img, err := decode(data)
+	if err != nil {
+		return result, err
+	}
+	result.Width, result.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	result.BlurHash, err = blurHashOf(img)
+	return result, err
+}
+
+// decode checks the format and size before decoding, a JPEG comes out in its EXIF orientation.
+func decode(data []byte) (*image.NRGBA, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) || (err == nil && !supportedFormats[format]) {
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrInvalid
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	img := toNRGBA(decoded)
+	if format == "jpeg" {
+		img = orient(img, exifOrientation(data))
+	}
+	return img, nil
+}
+
+// variantWidths keeps the widths below the image width, and the image width itself when a
+// larger variant was asked for or no width fits.
+func variantWidths(widths []int, imageWidth int) []int {
+	widths = slices.Clone(widths)
+	slices.Sort(widths)
+	widths = slices.Compact(widths)
+
+	out := make([]int, 0, len(widths)+1)
+	for _, width := range widths {
+		if width > 0 && width < imageWidth {
+			out = append(out, width)
+		}
+	}
+	if len(out) < len(widths) || len(out) == 0 {
+		out = append(out, imageWidth)
+	}
+	return out
+}
+
+func blurHashOf(img *image.NRGBA) (string, error) {
+	bounds := img.Bounds()
+	thumbWidth := blurHashSize
+	if bounds.Dy() > bounds.Dx() {
+		thumbWidth = blurHashSize * bounds.Dx() / bounds.Dy()
+	}
+	thumb := resize(img, min(max(thumbWidth, 1), bounds.Dx()))
+
+	// More components along the longer side
+	if bounds.Dy() > bounds.Dx() {
+		return blurhash.Encode(thumb, 3, 4)
+	}
+	return blurhash.Encode(thumb, 4, 3)
+}
+
+func resize(img *image.NRGBA, width int) *image.NRGBA {
+	bounds := img.Bounds()
+	if width == bounds.Dx() {
+		return img
+	}
+
+	height := max(1, (bounds.Dy()*width+bounds.Dx()/2)/bounds.Dx())
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+func encode(img *image.NRGBA, opaque bool) (variant Variant, err error) {
+	var buf bytes.Buffer
+	variant.Width, variant.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	if opaque {
+		variant.ContentType, variant.Ext = "image/jpeg", ".jpg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		variant.ContentType, variant.Ext = "image/png", ".png"
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return variant, err
+	}
+
+	variant.Data = buf.Bytes()
+	return variant, nil
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba
+	}
+
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,208 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ImagingTestSuite struct {
+	suite.Suite
+}
+
+// webp1x1 is a lossless 1x1 WebP.
+const webp1x1 = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+// quadrants is red on the top left, green on the top right and blue on the bottom half.
+func quadrants(w, h int, alpha uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{B: 255, A: alpha}
+			if y < h/2 && x < w/2 {
+				c = color.NRGBA{R: 255, A: alpha}
+			} else if y < h/2 {
+				c = color.NRGBA{G: 255, A: alpha}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(img image.Image) []byte {
+	var buf bytes.Buffer
+	_ = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95})
+	return buf.Bytes()
+}
+
+// withExif inserts an APP1 segment with the orientation tag right after the SOI marker.
+func withExif(data []byte, orientation uint16, order binary.AppendByteOrder) []byte {
+	tiff := []byte("MM")
+	if order == binary.AppendByteOrder(binary.LittleEndian) {
+		tiff = []byte("II")
+	}
+	tiff = order.AppendUint16(tiff, 42)
+	tiff = order.AppendUint32(tiff, 8)
+	tiff = order.AppendUint16(tiff, 1)
+	tiff = order.AppendUint16(tiff, exifOrientationTag)
+	tiff = order.AppendUint16(tiff, 3)
+	tiff = order.AppendUint32(tiff, 1)
+	tiff = order.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	return bytes.Join([][]byte{data[:2], app1, data[2:]}, nil)
+}
+
+func (s *ImagingTestSuite) TestProcess() {
+	s.Run("jpeg variants below the image width", func() {
+		result, err := Process(encodeJPEG(quadrants(800, 400, 255)), []int{640, 320, 1280})
+		s.Require().NoError(err)
+
+		s.Equal(800, result.Width)
+		s.Equal(400, result.Height)
+		s.NotEmpty(result.BlurHash)
+		s.Require().Len(result.Variants, 3)
+
+		for i, expect := range [][2]int{{320, 160}, {640, 320}, {800, 400}} {
+			variant := result.Variants[i]
+			s.Equal(expect[0], variant.Width)
+			s.Equal(expect[1], variant.Height)
+			s.Equal("image/jpeg", variant.ContentType)
+			s.Equal(".jpg", variant.Ext)
+
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(variant.Data))
+			s.Require().NoError(err)
+			s.Equal("jpeg", format)
+			s.Equal(expect[0], cfg.Width)
+		}
+	})
+
+	s.Run("exif orientation is applied and stripped", func() {
+		// Stored landscape, a camera held upright writes orientation 6
+		data := withExif(encodeJPEG(quadrants(200, 100, 255)), 6, binary.LittleEndian)
+		s.Equal(6, exifOrientation(data))
+
+		result, err := Process(data, []int{1280})
+		s.Require().NoError(err)
+		s.Equal(100, result.Width)
+		s.Equal(200, result.Height)
+
+		variant := result.Variants[0]
+		s.Equal(1, exifOrientation(variant.Data))
+		s.NotContains(string(variant.Data), "Exif")
+
+		img, err := jpeg.Decode(bytes.NewReader(variant.Data))
+		s.Require().NoError(err)
+		// Red, the stored top left, is now the top right
+		r, g, b, _ := img.At(90, 10).RGBA()
+		s.Greater(r, uint32(0xC000))
+		s.Less(g|b, uint32(0x4000))
+	})
+
+	s.Run("png with transparency stays png", func() {
+		var buf bytes.Buffer
+		s.Require().NoError(png.Encode(&buf, quadrants(300, 600, 128)))
+
+		result, err := Process(buf.Bytes(), []int{100, 640})
+		s.Require().NoError(err)
+		s.Require().Len(result.Variants, 2)
+		s.Equal(100, result.Variants[0].Width)
+		s.Equal(200, result.Variants[0].Height)
+		s.Equal(300, result.Variants[1].Width)
+		s.Equal("image/png", result.Variants[1].ContentType)
+		s.Equal(".png", result.Variants[1].Ext)
+	})
+
+	s.Run("webp", func() {
+		data, _ := base64.StdEncoding.DecodeString(webp1x1)
+
+		result, err := Process(data, []int{320})
+		s.Require().NoError(err)
+		s.Equal(1, result.Width)
+		s.Equal(1, result.Height)
+		s.Len(result.Variants, 1)
+		s.Len(result.BlurHash, 28)
+	})
+}
+
+func (s *ImagingTestSuite) TestProcessErrors() {
+	s.ErrorIs(errOf(Process([]byte("GIF89a\x01\x00\x01\x00"), []int{320})), ErrUnsupported)
+	s.ErrorIs(errOf(Process([]byte("not an image"), []int{320})), ErrUnsupported)
+
+	truncated := encodeJPEG(quadrants(64, 64, 255))
+	s.ErrorIs(errOf(Process(truncated[:len(truncated)/2], []int{320})), ErrInvalid)
+
+	// A PNG header declaring 10000x10000 pixels
+	var buf bytes.Buffer
+	s.Require().NoError(png.Encode(&buf, quadrants(1, 1, 255)))
+	huge := buf.Bytes()
+	binary.BigEndian.PutUint32(huge[16:], 10000)
+	binary.BigEndian.PutUint32(huge[20:], 10000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	s.ErrorIs(errOf(Process(huge, []int{320})), ErrTooLarge)
+}
+
+func (s *ImagingTestSuite) TestInspect() {
+	data := encodeJPEG(quadrants(64, 32, 255))
+
+	result, err := Inspect(data)
+	s.Require().NoError(err)
+	s.Equal(64, result.Width)
+	s.Equal(32, result.Height)
+	s.Empty(result.Variants)
+
+	processed, err := Process(data, []int{32})
+	s.Require().NoError(err)
+	s.Equal(processed.BlurHash, result.BlurHash)
+
+	s.ErrorIs(errOf(Inspect([]byte("not an image"))), ErrUnsupported)
+}
+
+func (s *ImagingTestSuite) TestExifOrientation() {
+	plain := encodeJPEG(quadrants(8, 8, 255))
+
+	s.Equal(1, exifOrientation(plain))
+	s.Equal(3, exifOrientation(withExif(plain, 3, binary.BigEndian)))
+	s.Equal(8, exifOrientation(withExif(plain, 8, binary.LittleEndian)))
+	s.Equal(1, exifOrientation(withExif(plain, 9, binary.BigEndian)))
+	s.Equal(1, exifOrientation([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF}))
+}
+
+func (s *ImagingTestSuite) TestOrient() {
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+
+	// Where the top left pixel lands and the resulting size
+	testCases := map[int][3]int{
+		1: {0, 0, 3}, 2: {2, 0, 3}, 3: {2, 1, 3}, 4: {0, 1, 3},
+		5: {0, 0, 2}, 6: {1, 0, 2}, 7: {1, 2, 2}, 8: {0, 2, 2},
+	}
+
+	for orientation, expect := range testCases {
+		dst := orient(src, orientation)
+		s.Equal(expect[2], dst.Bounds().Dx(), "orientation %d", orientation)
+		s.Equal(uint8(255), dst.NRGBAAt(expect[0], expect[1]).R, "orientation %d", orientation)
+	}
+}
+
+func errOf(_ Result, err error) error {
+	return err
+}
+
+func TestImagingTestSuite(t *testing.T) {
+	suite.Run(t, new(ImagingTestSuite))
+}
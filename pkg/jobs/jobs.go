@@ -0,0 +1,120 @@
+// Package jobs is a background job queue stored in the jobs table of Postgres. Workers claim due
+// jobs with FOR UPDATE SKIP LOCKED, so any number of instances can work the same table. Failed
+// jobs are retried with exponential backoff and dead-lettered once out of attempts.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	StatePending = "pending"
+	StateRunning = "running"
+	StateDead    = "dead"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultTimeout     = time.Minute
+)
+
+// Args is the payload of a job, stored as JSON. Kind names the handler it is worked by.
+type Args interface {
+	Kind() string
+}
+
+// Job is a claimed job handed to its handler, Attempt counts from 1.
+type Job[T Args] struct {
+	ID          int64
+	Attempt     int
+	MaxAttempts int
+	Args        T
+}
+
+// HandlerFunc works a job, a returned error retries it until its attempts run out.
+type HandlerFunc[T Args] func(ctx context.Context, job *Job[T]) (err error)
+
+// Enqueuer inserts jobs, called with a ctx inside database.WithinTx the job is inserted in that
+// transaction and only becomes visible to workers once it commits.
+type Enqueuer interface {
+	Enqueue(ctx context.Context, args Args, opts ...Option) (err error)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error as not worth retrying, the job is dead-lettered right away.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+type insertParams struct {
+	runAt       time.Time
+	maxAttempts int
+	uniqueKey   string
+}
+
+// Option changes how a job is enqueued.
+type Option func(p *insertParams)
+
+// RunAt delays the job until t.
+func RunAt(t time.Time) Option {
+	return func(p *insertParams) {
+		p.runAt = t
+	}
+}
+
+// Delay delays the job by d.
+func Delay(d time.Duration) Option {
+	return func(p *insertParams) {
+		p.runAt = time.Now().Add(d)
+	}
+}
+
+// MaxAttempts is how often the job runs before it is dead-lettered, 5 by default.
+func MaxAttempts(n int) Option {
+	return func(p *insertParams) {
+		p.maxAttempts = max(n, 1)
+	}
+}
+
+// Unique skips the insert while a pending job holds the same key. Once claimed the job no longer
+// holds it, work enqueued while it runs is not lost.
+func Unique(key string) Option {
+	return func(p *insertParams) {
+		p.uniqueKey = key
+	}
+}
+
+type handlerOptions struct {
+	timeout     time.Duration
+	concurrency int
+}
+
+// HandlerOption changes how jobs of a kind are worked.
+type HandlerOption func(o *handlerOptions)
+
+// Timeout bounds a single run of the handler, one minute by default.
+func Timeout(d time.Duration) HandlerOption {
+	return func(o *handlerOptions) {
+		o.timeout = d
+	}
+}
+
+// Concurrency caps how many jobs of the kind run at once in this process, unlimited by default.
+func Concurrency(n int) HandlerOption {
+	return func(o *handlerOptions) {
+		o.concurrency = n
+	}
+}
@@ -0,0 +1,393 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// memStore keeps jobs in memory, the lease is ignored since nothing crashes in a test. The
+// outcome of a run is fenced by the attempt like the pg store.
+type memStore struct {
+	mu     sync.Mutex
+	nextID int64
+	jobs   []*memJob
+}
+
+type memJob struct {
+	claimed
+	state     string
+	runAt     time.Time
+	uniqueKey string
+	lastError string
+}
+
+func (s *memStore) insert(_ context.Context, kind string, payload []byte, params insertParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if params.uniqueKey != "" && s.pendingKey(params.uniqueKey) {
+		return nil
+	}
+	if params.runAt.IsZero() {
+		params.runAt = time.Now()
+	}
+
+	s.nextID++
+	s.jobs = append(s.jobs, &memJob{
+		claimed:   claimed{id: s.nextID, kind: kind, payload: payload, maxAttempts: params.maxAttempts},
+		state:     StatePending,
+		runAt:     params.runAt,
+		uniqueKey: params.uniqueKey,
+	})
+	return nil
+}
+
+func (s *memStore) claim(_ context.Context, kinds []string, _ time.Duration) (*claimed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.state == StatePending && !job.runAt.After(time.Now()) && slices.Contains(kinds, job.kind) {
+			job.state = StateRunning
+			job.attempt++
+			claimed := job.claimed
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memStore) complete(_ context.Context, claimed *claimed) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.find(claimed) == nil {
+		return errLeaseLost
+	}
+	s.jobs = slices.DeleteFunc(s.jobs, func(job *memJob) bool { return job.id == claimed.id })
+	return nil
+}
+
+func (s *memStore) retry(_ context.Context, claimed *claimed, runAt time.Time, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.find(claimed)
+	if job == nil {
+		return errLeaseLost
+	}
+	job.state, job.runAt, job.lastError = StatePending, runAt, lastError
+	return nil
+}
+
+func (s *memStore) release(_ context.Context, claimed *claimed) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.find(claimed)
+	if job == nil {
+		return errLeaseLost
+	}
+	job.state, job.runAt = StatePending, time.Now()
+	job.attempt--
+	return nil
+}
+
+func (s *memStore) kill(_ context.Context, claimed *claimed, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.find(claimed)
+	if job == nil {
+		return errLeaseLost
+	}
+	job.state, job.lastError = StateDead, lastError
+	return nil
+}
+
+// find returns the job while it is still running the claimed attempt.
+func (s *memStore) find(claimed *claimed) *memJob {
+	for _, job := range s.jobs {
+		if job.id == claimed.id && job.state == StateRunning && job.attempt == claimed.attempt {
+			return job
+		}
+	}
+	return nil
+}
+
+func (s *memStore) pendingKey(key string) bool {
+	for _, job := range s.jobs {
+		if job.state == StatePending && job.uniqueKey == key {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot copies the stored jobs, safe to read while workers run.
+func (s *memStore) snapshot() []memJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]memJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		out = append(out, *job)
+	}
+	return out
+}
+
+type greetArgs struct {
+	Name string `json:"name"`
+}
+
+func (greetArgs) Kind() string { return "test.greet" }
+
+type slowArgs struct{}
+
+func (slowArgs) Kind() string { return "test.slow" }
+
+type JobsTestSuite struct {
+	suite.Suite
+	store *memStore
+	queue *Queue
+}
+
+func (s *JobsTestSuite) SetupTest() {
+	s.store = &memStore{}
+	s.queue = newQueue(s.store, 2, 5*time.Millisecond, 50*time.Millisecond, nil)
+	s.queue.backoff = func(int) time.Duration { return 0 }
+}
+
+// runUntil works the queue until cond holds or a second passes, then shuts it down.
+func (s *JobsTestSuite) runUntil(cond func() bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.queue.Run(ctx)
+		close(done)
+	}()
+
+	s.Eventually(cond, time.Second, 2*time.Millisecond)
+	cancel()
+	<-done
+}
+
+func (s *JobsTestSuite) TestWorksTypedJobs() {
+	var mu sync.Mutex
+	var names []string
+	Handle(s.queue, func(ctx context.Context, job *Job[greetArgs]) error {
+		mu.Lock()
+		defer mu.Unlock()
+		names = append(names, job.Args.Name)
+		return nil
+	})
+
+	s.Require().NoError(s.queue.Enqueue(context.Background(), greetArgs{Name: "naff"}))
+	s.Require().NoError(s.queue.Enqueue(context.Background(), greetArgs{Name: "noah"}))
+
+	s.runUntil(func() bool { return len(s.store.snapshot()) == 0 })
+	s.ElementsMatch([]string{"naff", "noah"}, names)
+}
+
+func (s *JobsTestSuite) TestRetriesThenSucceeds() {
+	var attempts []int
+	Handle(s.queue, func(ctx context.Context, job *Job[greetArgs]) error {
+		attempts = append(attempts, job.Attempt)
+		if job.Attempt < 3 {
+			return errors.New("smtp unavailable")
+		}
+		return nil
+	})
+
+	s.Require().NoError(s.queue.Enqueue(context.Background(), greetArgs{}))
+
+	s.runUntil(func() bool { return len(s.store.snapshot()) == 0 })
+	s.Equal([]int{1, 2, 3}, attempts)
+}
+
+func (s *JobsTestSuite) TestDeadLetters() {
+	Handle(s.queue, func(ctx context.Context, job *Job[greetArgs]) error {
+		if job.Args.Name == "permanent" {
+			return Permanent(errors.New("address rejected"))
+		}
+		return errors.New("smtp unavailable")
+	})
+
+	s.Require().NoError(s.queue.Enqueue(context.Background(), greetArgs{Name: "retried"}, MaxAttempts(2)))
+	s.Require().NoError(s.queue.Enqueue(context.Background(), greetArgs{Name: "permanent"}))
+
+	dead := func() []memJob {
+		return slices.DeleteFunc(s.store.snapshot(), func(job memJob) bool { return job.state != StateDead })
+	}
+	s.runUntil(func() bool { return len(dead()) == 2 })
+
+	jobs := dead()
+	s.Equal(2, jobs[0].attempt)
+	s.Equal("smtp unavailable", jobs[0].lastError)
+	s.Equal(1, jobs[1].attempt)
+	s.Equal("address rejected", jobs[1].lastError)
+}
+
+func (s *JobsTestSuite) TestPanicAndUndecodablePayload() {
+	Handle(s.queue, func(ctx context.Context, job *Job[greetArgs]) error {
+		panic("boom")
+	})
+
+	s.Require().NoError(s.queue.Enqueue(context.Background(), greetArgs{}, MaxAttempts(1)))
+	s.Require().NoError(s.store.insert(context.Background(), "test.greet", []byte(`{"name":1}`), insertParams{maxAttempts: 5}))
+
+	s.runUntil(func() bool {
+		jobs := s.store.snapshot()
+		return len(jobs) == 2 && jobs[0].state == StateDead && jobs[1].state == StateDead
+	})
+
+	jobs := s.store.snapshot()
+	s.Contains(jobs[0].lastError, "test.greet panicked: boom")
+	s.Contains(jobs[1].lastError, "decode test.greet payload")
+	s.Equal(1, jobs[1].attempt)
+}
+
+func (s *JobsTestSuite) TestDelayedAndUniqueJobs() {
+	var worked atomic.Int32
+	Handle(s.queue, func(ctx context.Context, job *Job[greetArgs]) error {
+		worked.Add(1)
+		return nil
+	})
+
+	ctx := context.Background()
+	s.Require().NoError(s.queue.Enqueue(ctx, greetArgs{}, Unique("greet"), Delay(30*time.Millisecond)))
+	s.Require().NoError(s.queue.Enqueue(ctx, greetArgs{}, Unique("greet")))
+	s.Require().NoError(s.queue.Enqueue(ctx, greetArgs{}, RunAt(time.Now().Add(time.Hour))))
+	s.Len(s.store.snapshot(), 2)
+
+	start := time.Now()
+	s.runUntil(func() bool { return worked.Load() == 1 })
+	s.GreaterOrEqual(time.Since(start), 30*time.Millisecond)
+
+	// The job an hour out is still waiting
+	jobs := s.store.snapshot()
+	s.Require().Len(jobs, 1)
+	s.Equal(StatePending, jobs[0].state)
+}
+
+func (s *JobsTestSuite) TestGracefulShutdown() {
+	started := make(chan struct{}, 2)
+	Handle(s.queue, func(ctx context.Context, job *Job[slowArgs]) error {
+		started <- struct{}{}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			return nil
+		}
+	})
+	Handle(s.queue, func(ctx context.Context, job *Job[greetArgs]) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	s.Require().NoError(s.queue.Enqueue(context.Background(), slowArgs{}))
+	s.Require().NoError(s.queue.Enqueue(context.Background(), greetArgs{Name: "stuck"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.queue.Run(ctx)
+		close(done)
+	}()
+
+	<-started
+	<-started
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		s.FailNow("Run did not return after the shutdown timeout")
+	}
+
+	// The slow job finished within the shutdown timeout, the stuck one is put back unspent
+	jobs := s.store.snapshot()
+	s.Require().Len(jobs, 1)
+	s.Equal("test.greet", jobs[0].kind)
+	s.Equal(StatePending, jobs[0].state)
+	s.Equal(0, jobs[0].attempt)
+}
+
+func (s *JobsTestSuite) TestConcurrency() {
+	var running, peak atomic.Int32
+	Handle(s.queue, func(ctx context.Context, job *Job[slowArgs]) error {
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if now <= old || peak.CompareAndSwap(old, now) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}, Concurrency(1))
+
+	for range 4 {
+		s.Require().NoError(s.queue.Enqueue(context.Background(), slowArgs{}))
+	}
+
+	s.runUntil(func() bool { return len(s.store.snapshot()) == 0 })
+	s.Equal(int32(1), peak.Load())
+}
+
+func (s *JobsTestSuite) TestHandleTwicePanics() {
+	Handle(s.queue, func(ctx context.Context, job *Job[greetArgs]) error { return nil })
+
+	s.Panics(func() {
+		Handle(s.queue, func(ctx context.Context, job *Job[greetArgs]) error { return nil })
+	})
+}
+
+func (s *JobsTestSuite) TestLostLease() {
+	ctx := context.Background()
+	s.Require().NoError(s.store.insert(ctx, "test.greet", []byte(`{"name":"late"}`), insertParams{maxAttempts: 5}))
+
+	stale, err := s.store.claim(ctx, []string{"test.greet"}, time.Minute)
+	s.Require().NoError(err)
+
+	// The lease expired and another worker claimed the job again
+	s.store.jobs[0].state = StatePending
+	current, err := s.store.claim(ctx, []string{"test.greet"}, time.Minute)
+	s.Require().NoError(err)
+	s.Equal(stale.attempt+1, current.attempt)
+
+	// The late outcome of the first run changes nothing
+	s.queue.finish(stale, nil, false)
+	s.queue.finish(stale, Permanent(errors.New("boom")), false)
+	s.ErrorIs(s.store.retry(ctx, stale, time.Now(), "boom"), errLeaseLost)
+
+	jobs := s.store.snapshot()
+	s.Require().Len(jobs, 1)
+	s.Equal(StateRunning, jobs[0].state)
+	s.Equal(current.attempt, jobs[0].attempt)
+
+	s.queue.finish(current, nil, false)
+	s.Empty(s.store.snapshot())
+}
+
+func (s *JobsTestSuite) TestBackoff() {
+	for attempt, expected := range map[int]time.Duration{1: 5 * time.Second, 2: 10 * time.Second, 5: 80 * time.Second, 12: time.Hour, 40: time.Hour} {
+		wait := backoff(attempt)
+		s.GreaterOrEqual(wait, expected, "attempt %d", attempt)
+		s.LessOrEqual(wait, expected+expected/10, "attempt %d", attempt)
+	}
+}
+
+func TestJobsTestSuite(t *testing.T) {
+	suite.Run(t, new(JobsTestSuite))
+}
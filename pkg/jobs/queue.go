@@ -0,0 +1,281 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/app/database"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+const (
+	backoffBase = 5 * time.Second
+	backoffMax  = time.Hour
+	// leaseMargin is added to the longest handler timeout, a job still running past its lease is
+	// taken for the job of a crashed worker and claimed again.
+	leaseMargin = time.Minute
+	// bookkeepingTimeout bounds recording the outcome of a job, it runs even while shutting down
+	bookkeepingTimeout = 10 * time.Second
+)
+
+type handler struct {
+	handlerOptions
+	work  func(ctx context.Context, job *claimed) error
+	slots chan struct{}
+}
+
+// Queue enqueues jobs and works them with the handlers registered by Handle.
+type Queue struct {
+	store           store
+	handlers        map[string]*handler
+	workers         int
+	pollInterval    time.Duration
+	shutdownTimeout time.Duration
+	backoff         func(attempt int) time.Duration
+	wake            chan struct{}
+	log             *logrus.Logger
+}
+
+func NewQueue(db *database.DB, cfg *config.Config, log *logrus.Logger) *Queue {
+	return newQueue(&pgStore{db: db.DB}, cfg.JobWorkers, cfg.JobPollInterval, cfg.JobShutdownTimeout, log)
+}
+
+func newQueue(store store, workers int, pollInterval, shutdownTimeout time.Duration, log *logrus.Logger) *Queue {
+	return &Queue{
+		store:           store,
+		handlers:        make(map[string]*handler),
+		workers:         max(workers, 1),
+		pollInterval:    pollInterval,
+		shutdownTimeout: shutdownTimeout,
+		backoff:         backoff,
+		wake:            make(chan struct{}, 1),
+		log:             log,
+	}
+}
+
+// Handle registers the handler of the jobs of kind T, registering a kind twice panics.
+// Handlers are registered before Run.
+func Handle[T Args](q *Queue, fn HandlerFunc[T], opts ...HandlerOption) {
+	var zero T
+	kind := zero.Kind()
+	if _, ok := q.handlers[kind]; ok {
+		panic(fmt.Sprintf("jobs: handler for %q registered twice", kind))
+	}
+
+	h := &handler{handlerOptions: handlerOptions{timeout: defaultTimeout}}
+	for _, opt := range opts {
+		opt(&h.handlerOptions)
+	}
+	if h.concurrency > 0 {
+		h.slots = make(chan struct{}, h.concurrency)
+	}
+
+	h.work = func(ctx context.Context, job *claimed) error {
+		typed := &Job[T]{ID: job.id, Attempt: job.attempt, MaxAttempts: job.maxAttempts}
+		if err := json.Unmarshal(job.payload, &typed.Args); err != nil {
+			return Permanent(fmt.Errorf("jobs: decode %s payload: %w", kind, err))
+		}
+		return fn(ctx, typed)
+	}
+
+	q.handlers[kind] = h
+}
+
+func (q *Queue) Enqueue(ctx context.Context, args Args, opts ...Option) (err error) {
+	params := insertParams{maxAttempts: defaultMaxAttempts}
+	for _, opt := range opts {
+		opt(&params)
+	}
+
+	payload, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("jobs: encode %s payload: %w", args.Kind(), err)
+	}
+
+	if err := q.store.insert(ctx, args.Kind(), payload, params); err != nil {
+		utils.LogError(q.log, ctx, "jobs", "Enqueue", err)
+		return err
+	}
+
+	// A worker of this process may be idle, jobs enqueued in a transaction are seen after it commits
+	if params.runAt.IsZero() || !params.runAt.After(time.Now()) {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Run works due jobs until ctx is done. Running jobs then get the shutdown timeout to finish,
+// jobs cut short are put back without counting the attempt. Run returns once all have stopped.
+func (q *Queue) Run(ctx context.Context) {
+	lease := leaseMargin
+	for _, h := range q.handlers {
+		lease = max(lease, h.timeout+leaseMargin)
+	}
+
+	// Cancelled shutdownTimeout after ctx, jobs run on it so they outlive ctx until then
+	jobsCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(q.shutdownTimeout, cancelJobs)
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	for range q.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.worker(ctx, jobsCtx, lease)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (q *Queue) worker(ctx, jobsCtx context.Context, lease time.Duration) {
+	for ctx.Err() == nil {
+		if q.next(ctx, jobsCtx, lease) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-q.wake:
+		case <-time.After(q.pollInterval):
+		}
+	}
+}
+
+// next claims and works one job, reporting whether there was one.
+func (q *Queue) next(ctx, jobsCtx context.Context, lease time.Duration) (found bool) {
+	kinds, release := q.acquireSlots()
+	defer release("")
+	if len(kinds) == 0 {
+		return false
+	}
+
+	job, err := q.store.claim(ctx, kinds, lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			utils.LogError(q.log, ctx, "jobs", "Run", err)
+		}
+		return false
+	}
+	if job == nil {
+		return false
+	}
+	release(job.kind)
+
+	h := q.handlers[job.kind]
+	defer func() {
+		if h.slots != nil {
+			<-h.slots
+		}
+	}()
+
+	// Claimed again after its lease expired on the last attempt, the worker running it is gone
+	if job.attempt > job.maxAttempts {
+		q.finish(job, fmt.Errorf("jobs: lease expired on the last attempt"), false)
+		return true
+	}
+
+	runCtx, cancel := context.WithTimeout(jobsCtx, h.timeout)
+	err = q.run(runCtx, h, job)
+	cancel()
+
+	q.finish(job, err, jobsCtx.Err() != nil)
+	return true
+}
+
+// acquireSlots lists the kinds with a free concurrency slot, taking one of each limited kind.
+// release gives the slots back except the one of the claimed kind, it is safe to call twice.
+func (q *Queue) acquireSlots() (kinds []string, release func(claimed string)) {
+	var taken []*handler
+	var takenKinds []string
+
+	for kind, h := range q.handlers {
+		if h.slots != nil {
+			select {
+			case h.slots <- struct{}{}:
+				taken = append(taken, h)
+				takenKinds = append(takenKinds, kind)
+			default:
+				continue
+			}
+		}
+		kinds = append(kinds, kind)
+	}
+
+	released := false
+	return kinds, func(claimed string) {
+		if released {
+			return
+		}
+		released = true
+		for i, h := range taken {
+			if takenKinds[i] != claimed {
+				<-h.slots
+			}
+		}
+	}
+}
+
+func (q *Queue) run(ctx context.Context, h *handler, job *claimed) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("jobs: %s panicked: %v\n%s", job.kind, p, debug.Stack())
+		}
+	}()
+
+	return h.work(ctx, job)
+}
+
+// finish records the outcome, shutdown tells the job was cut short by the shutdown timeout.
+func (q *Queue) finish(job *claimed, err error, shutdown bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer cancel()
+
+	var storeErr error
+	switch {
+	case err == nil:
+		storeErr = q.store.complete(ctx, job)
+	case shutdown && !isPermanent(err):
+		utils.LogWarn(q.log, ctx, "jobs", "Run", fmt.Errorf("%s job %d stopped by shutdown: %w", job.kind, job.id, err))
+		storeErr = q.store.release(ctx, job)
+	case isPermanent(err) || job.attempt >= job.maxAttempts:
+		utils.LogError(q.log, ctx, "jobs", "Run", fmt.Errorf("%s job %d dead after %d attempts: %w", job.kind, job.id, job.attempt, err))
+		storeErr = q.store.kill(ctx, job, err.Error())
+	default:
+		utils.LogWarn(q.log, ctx, "jobs", "Run", fmt.Errorf("%s job %d attempt %d failed: %w", job.kind, job.id, job.attempt, err))
+		storeErr = q.store.retry(ctx, job, time.Now().Add(q.backoff(job.attempt)), err.Error())
+	}
+
+	switch {
+	case errors.Is(storeErr, errLeaseLost):
+		utils.LogWarn(q.log, ctx, "jobs", "Run", fmt.Errorf("%s job %d attempt %d: %w", job.kind, job.id, job.attempt, storeErr))
+	case storeErr != nil && !errors.Is(storeErr, context.Canceled):
+		utils.LogError(q.log, ctx, "jobs", "Run", storeErr)
+	}
+}
+
+// backoff doubles the wait after every failed attempt up to an hour, with up to 10% jitter so
+// jobs failing together do not retry together.
+func backoff(attempt int) time.Duration {
+	wait := backoffMax
+	if attempt < 20 {
+		wait = min(backoffBase<<(attempt-1), backoffMax)
+	}
+	return wait + rand.N(wait/10+1)
+}
@@ -0,0 +1,144 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wahyusahajaa/mulo-api-go/app/database"
+)
+
+// errLeaseLost is returned when recording the outcome of a job whose lease expired and that
+// another worker claimed since, the outcome is that worker's to record.
+var errLeaseLost = errors.New("jobs: lease lost, the job was claimed again")
+
+// claimed is a job taken by a worker, attempt already counts the current run.
+type claimed struct {
+	id          int64
+	kind        string
+	payload     []byte
+	attempt     int
+	maxAttempts int
+}
+
+type store interface {
+	insert(ctx context.Context, kind string, payload []byte, params insertParams) (err error)
+	// claim takes the next due job of the kinds, nil when there is none.
+	claim(ctx context.Context, kinds []string, lease time.Duration) (job *claimed, err error)
+	// complete, retry, release and kill only apply to the run of the job that was claimed,
+	// errLeaseLost when it was claimed again since.
+	complete(ctx context.Context, job *claimed) (err error)
+	retry(ctx context.Context, job *claimed, runAt time.Time, lastError string) (err error)
+	// release puts a job cut short by shutdown back without counting the attempt.
+	release(ctx context.Context, job *claimed) (err error)
+	kill(ctx context.Context, job *claimed, lastError string) (err error)
+}
+
+type pgStore struct {
+	db *sql.DB
+}
+
+func (s *pgStore) insert(ctx context.Context, kind string, payload []byte, params insertParams) (err error) {
+	query := `
+		INSERT INTO jobs (kind, payload, max_attempts, run_at, unique_key)
+		VALUES ($1, $2, $3, COALESCE($4, NOW()), NULLIF($5, ''))
+		ON CONFLICT (unique_key) WHERE state = 'pending' DO NOTHING`
+	runAt := sql.NullTime{Time: params.runAt, Valid: !params.runAt.IsZero()}
+
+	_, err = database.Conn(ctx, s.db).ExecContext(ctx, query, kind, string(payload), params.maxAttempts, runAt, params.uniqueKey)
+	return err
+}
+
+func (s *pgStore) claim(ctx context.Context, kinds []string, lease time.Duration) (job *claimed, err error) {
+	// A running job past its lease belongs to a worker that is gone
+	query := `
+		UPDATE jobs
+		SET state = 'running', attempts = attempts + 1, locked_until = NOW() + $2::bigint * INTERVAL '1 millisecond', updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE kind = ANY($1)
+				AND ((state = 'pending' AND run_at <= NOW()) OR (state = 'running' AND locked_until < NOW()))
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, kind, payload, attempts, max_attempts`
+	job = &claimed{}
+
+	if err := s.db.QueryRowContext(ctx, query, pq.Array(kinds), lease.Milliseconds()).Scan(
+		&job.id,
+		&job.kind,
+		&job.payload,
+		&job.attempt,
+		&job.maxAttempts,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// The attempts count fences the updates below, every claim increments it.
+
+func (s *pgStore) complete(ctx context.Context, job *claimed) (err error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM jobs WHERE id = $1 AND state = 'running' AND attempts = $2`, job.id, job.attempt)
+	return fenced(result, err)
+}
+
+func (s *pgStore) retry(ctx context.Context, job *claimed, runAt time.Time, lastError string) (err error) {
+	return s.requeue(ctx, job, runAt, lastError, 0)
+}
+
+func (s *pgStore) release(ctx context.Context, job *claimed) (err error) {
+	return s.requeue(ctx, job, time.Now(), "", 1)
+}
+
+// requeue makes the job pending again. A unique job whose key was taken by a newer pending job
+// while it ran is dropped instead, the newer job does the same work.
+func (s *pgStore) requeue(ctx context.Context, job *claimed, runAt time.Time, lastError string, refund int) (err error) {
+	query := `
+		UPDATE jobs
+		SET state = 'pending', run_at = $3, attempts = attempts - $5, locked_until = NULL,
+			last_error = COALESCE(NULLIF($4, ''), last_error), updated_at = NOW()
+		WHERE id = $1 AND state = 'running' AND attempts = $2 AND NOT EXISTS (
+			SELECT 1 FROM jobs other WHERE other.unique_key = jobs.unique_key AND other.state = 'pending'
+		)`
+
+	result, err := s.db.ExecContext(ctx, query, job.id, job.attempt, runAt, lastError, refund)
+	if err != nil {
+		return err
+	}
+	// Either a newer pending job took the key or the lease is lost, complete tells which
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return s.complete(ctx, job)
+	}
+
+	return nil
+}
+
+func (s *pgStore) kill(ctx context.Context, job *claimed, lastError string) (err error) {
+	query := `
+		UPDATE jobs SET state = 'dead', locked_until = NULL, last_error = $3, failed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND state = 'running' AND attempts = $2`
+
+	result, err := s.db.ExecContext(ctx, query, job.id, job.attempt, lastError)
+	return fenced(result, err)
+}
+
+// fenced turns an update of no row into errLeaseLost.
+func fenced(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return errLeaseLost
+	}
+	return nil
+}
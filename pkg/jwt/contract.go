@@ -10,6 +10,8 @@ type JWTService interface {
 	ParseToken(token, tokenType string) (claims *dto.JWTCustomClaims, err error)
 	ParseAccessToken(tokenString string) (claims *dto.JWTCustomClaims, err error)
 	ParseRefreshToken(tokenString string) (claims *dto.JWTCustomClaims, err error)
+	// JWKS is the public part of every key tokens are verified with, empty with the shared secrets.
+	JWKS() JWKS
 	ExtractTokenFromHeader(authHeader string) (string, error)
 	AddTokenCookies(c *fiber.Ctx, accessToken, refreshToken string)
 	ClearTokenCookies(c *fiber.Ctx)
@@ -0,0 +1,213 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits is the smallest RSA modulus accepted for signing or verifying tokens.
+const minRSABits = 2048
+
+// Key is one of the asymmetric keys tokens are signed or verified with, Private is nil for a
+// retired key that only verifies the tokens it signed before the rotation.
+type Key struct {
+	ID      string
+	Method  jwtlib.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// JWK is the public part of a key as published on the JWKS endpoint (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// N and E are the modulus and exponent of an RSA key
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Crv is the curve of an Ed25519 or EC key, X its public key or with Y the point of an EC key
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is the key set other services verify Mulo tokens with.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadKeys parses JWT_KEYS entries of the form `kid=source`. The source is the path of a PEM
+// file or the PEM itself, with `\n` standing in for the line breaks an env var can't hold.
+func LoadKeys(entries []string) (map[string]*Key, error) {
+	keys := make(map[string]*Key, len(entries))
+	for _, entry := range entries {
+		kid, source, ok := strings.Cut(entry, "=")
+		kid, source = strings.TrimSpace(kid), strings.TrimSpace(source)
+		if !ok || kid == "" || source == "" {
+			return nil, fmt.Errorf("jwt: key %q must be of the form kid=source", entry)
+		}
+		if _, exists := keys[kid]; exists {
+			return nil, fmt.Errorf("jwt: duplicate key id %q", kid)
+		}
+
+		var data []byte
+		if strings.HasPrefix(source, "-----BEGIN") {
+			data = []byte(strings.ReplaceAll(source, `\n`, "\n"))
+		} else {
+			var err error
+			if data, err = os.ReadFile(source); err != nil {
+				return nil, fmt.Errorf("jwt: read key %q: %w", kid, err)
+			}
+		}
+
+		key, err := ParseKey(kid, data)
+		if err != nil {
+			return nil, err
+		}
+		keys[kid] = key
+	}
+
+	return keys, nil
+}
+
+// ParseKey reads an RSA or Ed25519 key from PEM, a private key signs and verifies while a
+// public key only verifies.
+func ParseKey(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("jwt: key %q is not PEM encoded", kid)
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("jwt: key %q has unsupported PEM type %q", kid, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jwt: parse key %q: %w", kid, err)
+	}
+
+	key := &Key{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwtlib.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwtlib.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwtlib.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwtlib.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("jwt: key %q must be RSA or Ed25519, got %T", kid, parsed)
+	}
+
+	if pub, ok := key.Public.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("jwt: RSA key %q must be at least %d bits", kid, minRSABits)
+	}
+
+	return key, nil
+}
+
+// JWK returns the public part of the key.
+func (k *Key) JWK() JWK {
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+
+	return jwk
+}
+
+// PublicKey decodes the key of a JWKS fetched from another issuer, such as an OpenID provider.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: jwk %q: invalid n: %w", k.Kid, err)
+		}
+		e, err := decode(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("jwt: jwk %q: invalid e", k.Kid)
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("jwt: jwk %q: RSA key must be at least %d bits", k.Kid, minRSABits)
+		}
+		return pub, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("jwt: jwk %q: unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, errX := decode(k.X)
+		y, errY := decode(k.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("jwt: jwk %q: invalid point", k.Kid)
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, fmt.Errorf("jwt: jwk %q: point is not on the curve", k.Kid)
+		}
+		return pub, nil
+	case "OKP":
+		x, err := decode(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwt: jwk %q: invalid Ed25519 key", k.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("jwt: jwk %q: unsupported key type %q", k.Kid, k.Kty)
+	}
+}
+
+// keySet publishes every key, ordered by id so the response is stable.
+func keySet(keys map[string]*Key) JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+
+	return set
+}
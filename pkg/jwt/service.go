@@ -2,32 +2,82 @@ package jwt
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/wahyusahajaa/mulo-api-go/app/config"
 	"github.com/wahyusahajaa/mulo-api-go/app/dto"
 	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
 )
 
+var errUnknownKey = errors.New("jwt: unknown signing key")
+
 type jwtService struct {
+	// keys verify tokens by their kid header, signingKey signs new ones. Without keys tokens are
+	// signed with the shared secrets and carry no kid.
+	keys                map[string]*Key
+	signingKey          *Key
 	JwtSecret           []byte
 	RefreshSecret       []byte
 	AccessTokenExpires  time.Duration
 	RefreshTokenExpires time.Duration
 }
 
-func NewJWTService(cfg *config.Config) JWTService {
-	return &jwtService{
+// NewJWTService signs with the JWT_KEYS key named by JWT_SIGNING_KEY_ID, the other keys only
+// verify so a rotated out key keeps its tokens valid until they expire.
+func NewJWTService(cfg *config.Config) (JWTService, error) {
+	keys, err := LoadKeys(cfg.JwtKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &jwtService{
+		keys:                keys,
 		JwtSecret:           []byte(cfg.JwtSecret),
 		RefreshSecret:       []byte(cfg.RefreshSecret),
 		AccessTokenExpires:  15 * time.Minute,
-		RefreshTokenExpires: 7 * 24 * time.Hour,
+		RefreshTokenExpires: cfg.RefreshTokenTTL,
+	}
+
+	kid := cfg.JwtSigningKeyID
+	if kid == "" && len(keys) == 1 {
+		for id := range keys {
+			kid = id
+		}
+	}
+	if kid == "" {
+		if len(keys) > 0 {
+			return nil, errors.New("jwt: JWT_SIGNING_KEY_ID must name one of JWT_KEYS")
+		}
+		return j, nil
+	}
+
+	signingKey, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("jwt: signing key %q is not one of JWT_KEYS", kid)
 	}
+	if signingKey.Private == nil {
+		return nil, fmt.Errorf("jwt: signing key %q is a public key", kid)
+	}
+	j.signingKey = signingKey
+
+	return j, nil
+}
+
+// sign uses the signing key when one is configured and the shared secret otherwise.
+func (j *jwtService) sign(claims dto.JWTCustomClaims, secret []byte) (string, error) {
+	if j.signingKey == nil {
+		return jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, claims).SignedString(secret)
+	}
+
+	token := jwtlib.NewWithClaims(j.signingKey.Method, claims)
+	token.Header["kid"] = j.signingKey.ID
+	return token.SignedString(j.signingKey.Private)
 }
 
 func (j *jwtService) GenerateTokens(id int, username string, role string) (accessToken, refreshToken string, err error) {
@@ -43,13 +93,12 @@ func (j *jwtService) GenerateTokens(id int, username string, role string) (acces
 			ID:        strconv.Itoa(id),
 		},
 	}
-	accessJwt := jwtlib.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err = accessJwt.SignedString(j.JwtSecret)
+	accessToken, err = j.sign(accessClaims, j.JwtSecret)
 	if err != nil {
 		return "", "", err
 	}
 
-	// Generate Refresh Token
+	// Generate Refresh Token, the random id keeps two tokens issued within a second apart
 	refreshClaims := dto.JWTCustomClaims{
 		ID:        id,
 		Username:  username,
@@ -58,11 +107,10 @@ func (j *jwtService) GenerateTokens(id int, username string, role string) (acces
 		RegisteredClaims: jwtlib.RegisteredClaims{
 			ExpiresAt: jwtlib.NewNumericDate(time.Now().Add(j.RefreshTokenExpires)),
 			IssuedAt:  jwtlib.NewNumericDate(time.Now()),
-			ID:        strconv.Itoa(id),
+			ID:        uuid.NewString(),
 		},
 	}
-	refreshJwt := jwtlib.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshToken, err = refreshJwt.SignedString(j.RefreshSecret)
+	refreshToken, err = j.sign(refreshClaims, j.RefreshSecret)
 	if err != nil {
 		return "", "", err
 	}
@@ -71,17 +119,34 @@ func (j *jwtService) GenerateTokens(id int, username string, role string) (acces
 }
 
 func (j *jwtService) ParseToken(tokenString, tokenType string) (claims *dto.JWTCustomClaims, err error) {
-	token, err := jwt.ParseWithClaims(tokenString, &dto.JWTCustomClaims{}, func(token *jwtlib.Token) (interface{}, error) {
-		if tokenType == "access" {
-			return j.JwtSecret, nil
-		} else {
-			return j.RefreshSecret, nil
+	token, err := jwtlib.ParseWithClaims(tokenString, &dto.JWTCustomClaims{}, func(token *jwtlib.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			// Tokens signed with the shared secrets carry no kid, they verify while the secrets are set
+			secret := j.RefreshSecret
+			if tokenType == "access" {
+				secret = j.JwtSecret
+			}
+			if len(secret) == 0 || token.Method != jwtlib.SigningMethodHS256 {
+				return nil, errUnknownKey
+			}
+			return secret, nil
+		}
+
+		// The algorithm is pinned by the key, a token can't pick another one for it
+		key, ok := j.keys[kid]
+		if !ok || token.Method.Alg() != key.Method.Alg() {
+			return nil, errUnknownKey
 		}
-	}, jwtlib.WithValidMethods([]string{"HS256"}))
+		return key.Public, nil
+	}, jwtlib.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}))
 	if err != nil {
 		if errors.Is(err, jwtlib.ErrTokenExpired) {
 			return nil, errs.NewForbiddenError("Token is expired or no longer valid.")
 		}
+		if errors.Is(err, errUnknownKey) {
+			return nil, errs.NewForbiddenError("Token is signed with an unknown key.")
+		}
 		if errors.Is(err, jwtlib.ErrSignatureInvalid) {
 			return nil, errs.NewForbiddenError("Token signature is invalid.")
 		}
@@ -124,6 +189,10 @@ func (j *jwtService) ParseRefreshToken(tokenString string) (claims *dto.JWTCusto
 	return claims, nil
 }
 
+func (j *jwtService) JWKS() JWKS {
+	return keySet(j.keys)
+}
+
 func (j *jwtService) ExtractTokenFromHeader(authHeader string) (string, error) {
 	if authHeader == "" {
 		return "", errs.NewForbiddenError("No Authorization header provided. Please include a valid token.")
@@ -0,0 +1,278 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+)
+
+type JWTServiceTestSuite struct {
+	suite.Suite
+	dir     string
+	rsaPEM  []byte
+	rsaPub  []byte
+	edPEM   []byte
+	edPub   []byte
+	rsaPath string
+}
+
+func (s *JWTServiceTestSuite) SetupSuite() {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	s.Require().NoError(err)
+
+	s.rsaPEM, s.rsaPub = s.encode(rsaKey, &rsaKey.PublicKey)
+	s.edPEM, s.edPub = s.encode(edKey, edKey.Public())
+
+	s.dir = s.T().TempDir()
+	s.rsaPath = filepath.Join(s.dir, "rsa.pem")
+	s.Require().NoError(os.WriteFile(s.rsaPath, s.rsaPEM, 0o600))
+}
+
+func (s *JWTServiceTestSuite) encode(private, public any) (privatePEM, publicPEM []byte) {
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	s.Require().NoError(err)
+	pubDer, err := x509.MarshalPKIXPublicKey(public)
+	s.Require().NoError(err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer})
+}
+
+// inline escapes the line breaks of a PEM the way it is put in an env var.
+func inline(pemBytes []byte) string {
+	return strings.ReplaceAll(strings.TrimSpace(string(pemBytes)), "\n", `\n`)
+}
+
+func (s *JWTServiceTestSuite) newService(cfg *config.Config) *jwtService {
+	cfg.RefreshTokenTTL = time.Hour
+	svc, err := NewJWTService(cfg)
+	s.Require().NoError(err)
+	return svc.(*jwtService)
+}
+
+func (s *JWTServiceTestSuite) header(token string) map[string]any {
+	parsed, _, err := jwtlib.NewParser().ParseUnverified(token, &dto.JWTCustomClaims{})
+	s.Require().NoError(err)
+	return parsed.Header
+}
+
+func (s *JWTServiceTestSuite) TestSignAndParse() {
+	testCases := []struct {
+		name string
+		cfg  *config.Config
+		alg  string
+		kid  string
+	}{
+		{
+			name: "rsa key from a file",
+			cfg:  &config.Config{JwtKeys: []string{"rsa-1=" + s.rsaPath}},
+			alg:  "RS256",
+			kid:  "rsa-1",
+		},
+		{
+			name: "ed25519 key inline",
+			cfg:  &config.Config{JwtKeys: []string{"ed-1=" + inline(s.edPEM), "rsa-1=" + s.rsaPath}, JwtSigningKeyID: "ed-1"},
+			alg:  "EdDSA",
+			kid:  "ed-1",
+		},
+		{
+			name: "shared secrets",
+			cfg:  &config.Config{JwtSecret: "access-secret", RefreshSecret: "refresh-secret"},
+			alg:  "HS256",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			svc := s.newService(tc.cfg)
+
+			accessToken, refreshToken, err := svc.GenerateTokens(1, "naff", "member")
+			s.Require().NoError(err)
+
+			header := s.header(accessToken)
+			s.Equal(tc.alg, header["alg"])
+			if tc.kid == "" {
+				s.NotContains(header, "kid")
+			} else {
+				s.Equal(tc.kid, header["kid"])
+			}
+
+			claims, err := svc.ParseAccessToken(accessToken)
+			s.Require().NoError(err)
+			s.Equal(1, claims.ID)
+			s.Equal("member", claims.UserRole)
+
+			claims, err = svc.ParseRefreshToken(refreshToken)
+			s.Require().NoError(err)
+			s.Equal("refresh", claims.TokenType)
+
+			_, err = svc.ParseRefreshToken(accessToken)
+			s.Error(err)
+		})
+	}
+}
+
+func (s *JWTServiceTestSuite) TestRotation() {
+	old := s.newService(&config.Config{JwtKeys: []string{"rsa-1=" + s.rsaPath}})
+	oldAccess, _, err := old.GenerateTokens(1, "naff", "member")
+	s.Require().NoError(err)
+
+	// The old key is kept as a public key only, the new one signs
+	rotated := s.newService(&config.Config{
+		JwtKeys:         []string{"rsa-1=" + inline(s.rsaPub), "ed-1=" + inline(s.edPEM)},
+		JwtSigningKeyID: "ed-1",
+	})
+
+	_, err = rotated.ParseAccessToken(oldAccess)
+	s.NoError(err)
+
+	newAccess, _, err := rotated.GenerateTokens(1, "naff", "member")
+	s.Require().NoError(err)
+	s.Equal("ed-1", s.header(newAccess)["kid"])
+
+	// Once dropped the old key no longer verifies
+	dropped := s.newService(&config.Config{JwtKeys: []string{"ed-1=" + inline(s.edPEM)}})
+	_, err = dropped.ParseAccessToken(oldAccess)
+	s.EqualError(err, "Token is signed with an unknown key.")
+	var forbidden *errs.Fobidden
+	s.True(errors.As(err, &forbidden))
+}
+
+func (s *JWTServiceTestSuite) TestParseRejects() {
+	svc := s.newService(&config.Config{JwtKeys: []string{"rsa-1=" + s.rsaPath}})
+	claims := dto.JWTCustomClaims{
+		ID:        1,
+		TokenType: "access",
+		RegisteredClaims: jwtlib.RegisteredClaims{
+			ExpiresAt: jwtlib.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	s.Require().NoError(err)
+
+	testCases := []struct {
+		name  string
+		token func() string
+	}{
+		{
+			name: "unknown kid",
+			token: func() string {
+				token := jwtlib.NewWithClaims(jwtlib.SigningMethodEdDSA, claims)
+				token.Header["kid"] = "other"
+				signed, _ := token.SignedString(edPrivate)
+				return signed
+			},
+		},
+		{
+			name: "algorithm other than the key's",
+			token: func() string {
+				// An HMAC keyed with the public key must not pass as the RSA key
+				token := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, claims)
+				token.Header["kid"] = "rsa-1"
+				signed, _ := token.SignedString(s.rsaPub)
+				return signed
+			},
+		},
+		{
+			name: "no kid without shared secrets",
+			token: func() string {
+				signed, _ := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, claims).SignedString([]byte(""))
+				return signed
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			_, err := svc.ParseAccessToken(tc.token())
+			s.EqualError(err, "Token is signed with an unknown key.")
+		})
+	}
+}
+
+func (s *JWTServiceTestSuite) TestJWKS() {
+	svc := s.newService(&config.Config{
+		JwtKeys:         []string{"rsa-1=" + s.rsaPath, "ed-1=" + inline(s.edPub)},
+		JwtSigningKeyID: "rsa-1",
+	})
+
+	set := svc.JWKS()
+	s.Require().Len(set.Keys, 2)
+
+	ed, rsaJWK := set.Keys[0], set.Keys[1]
+	s.Equal(JWK{Kty: "OKP", Kid: "ed-1", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: ed.X}, ed)
+	s.Len(ed.X, 43)
+	s.Equal("RSA", rsaJWK.Kty)
+	s.Equal("RS256", rsaJWK.Alg)
+	s.Equal("AQAB", rsaJWK.E)
+	s.NotEmpty(rsaJWK.N)
+
+	s.Empty(s.newService(&config.Config{JwtSecret: "secret"}).JWKS().Keys)
+}
+
+func (s *JWTServiceTestSuite) TestNewJWTServiceErrors() {
+	testCases := []struct {
+		name      string
+		cfg       *config.Config
+		expectMsg string
+	}{
+		{
+			name:      "signing key not configured",
+			cfg:       &config.Config{JwtKeys: []string{"rsa-1=" + s.rsaPath, "ed-1=" + inline(s.edPEM)}},
+			expectMsg: "jwt: JWT_SIGNING_KEY_ID must name one of JWT_KEYS",
+		},
+		{
+			name:      "unknown signing key",
+			cfg:       &config.Config{JwtKeys: []string{"rsa-1=" + s.rsaPath}, JwtSigningKeyID: "rsa-2"},
+			expectMsg: `jwt: signing key "rsa-2" is not one of JWT_KEYS`,
+		},
+		{
+			name:      "public signing key",
+			cfg:       &config.Config{JwtKeys: []string{"ed-1=" + inline(s.edPub)}},
+			expectMsg: `jwt: signing key "ed-1" is a public key`,
+		},
+		{
+			name:      "duplicate key id",
+			cfg:       &config.Config{JwtKeys: []string{"rsa-1=" + s.rsaPath, "rsa-1=" + s.rsaPath}},
+			expectMsg: `jwt: duplicate key id "rsa-1"`,
+		},
+		{
+			name:      "missing kid",
+			cfg:       &config.Config{JwtKeys: []string{s.rsaPath}},
+			expectMsg: `jwt: key "` + s.rsaPath + `" must be of the form kid=source`,
+		},
+		{
+			name:      "unreadable key file",
+			cfg:       &config.Config{JwtKeys: []string{"rsa-1=" + filepath.Join(s.dir, "missing.pem")}},
+			expectMsg: `jwt: read key "rsa-1"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			_, err := NewJWTService(tc.cfg)
+			s.Require().Error(err)
+			s.Contains(err.Error(), tc.expectMsg)
+		})
+	}
+}
+
+func TestJWTServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(JWTServiceTestSuite))
+}
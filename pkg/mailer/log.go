@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type logMailer struct {
+	dir  string
+	from *mail.Address
+	log  *logrus.Logger
+	now  func() time.Time
+}
+
+// NewLogMailer logs every message instead of sending it, for development and tests. With a dir
+// the full message is also written there as a .eml file that mail clients open.
+func NewLogMailer(dir, from string, log *logrus.Logger) Mailer {
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		address = &mail.Address{Address: from}
+	}
+
+	return &logMailer{dir: dir, from: address, log: log, now: time.Now}
+}
+
+func (m *logMailer) Send(ctx context.Context, msg Message) (err error) {
+	fields := logrus.Fields{"to": strings.Join(msg.To, ", "), "subject": msg.Subject}
+
+	if m.dir != "" {
+		data, err := buildMIME(m.from, msg, m.now())
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(m.dir, 0o755); err != nil {
+			return err
+		}
+
+		name := msg.ID
+		if name == "" {
+			name = fmt.Sprintf("%d", m.now().UnixNano())
+		}
+		path := filepath.Join(m.dir, filepath.Base(name)+".eml")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return err
+		}
+		fields["file"] = path
+	}
+
+	if m.log != nil {
+		m.log.WithContext(ctx).WithFields(fields).Info("email not sent, logged by the log mailer")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Package mailer renders the transactional emails of the app from embedded templates and sends
+// them through Resend, SMTP or, in development and tests, a log. Emails are queued in the
+// email_outbox table and delivered by a job, a send that fails is retried by the queue.
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+)
+
+// Message is a rendered email.
+type Message struct {
+	// ID identifies the message across retried sends, providers that deduplicate use it.
+	ID      string
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer sends a message through a provider.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) (err error)
+}
+
+// NewMailer builds the provider picked by MAIL_DRIVER.
+func NewMailer(cfg *config.Config, log *logrus.Logger) (Mailer, error) {
+	switch cfg.MailDriver {
+	case "", "resend":
+		return NewResendMailer(cfg.ResendKey, cfg.MailFrom), nil
+	case "smtp":
+		return NewSMTPMailer(SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		}, cfg.MailFrom)
+	case "log":
+		return NewLogMailer(cfg.MailLogDir, cfg.MailFrom, log), nil
+	default:
+		return nil, fmt.Errorf("mailer: unknown driver %q", cfg.MailDriver)
+	}
+}
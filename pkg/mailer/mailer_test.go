@@ -0,0 +1,281 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MailerTestSuite struct {
+	suite.Suite
+}
+
+func (s *MailerTestSuite) TestRender() {
+	at := time.Date(2025, 3, 4, 5, 6, 0, 0, time.FixedZone("WIB", 7*3600))
+
+	testCases := []struct {
+		name        string
+		email       Email
+		subject     string
+		contains    []string
+		htmlOnly    []string
+		notContains []string
+	}{
+		{
+			name:     "verification",
+			email:    Verification{Code: "123456"},
+			subject:  "Mulo Email Verification",
+			contains: []string{"123456"},
+		},
+		{
+			name:     "email change",
+			email:    EmailChange{Code: "54321"},
+			subject:  "Confirm your new Mulo email",
+			contains: []string{"54321"},
+		},
+		{
+			name:     "password reset",
+			email:    PasswordReset{URL: "https://mulo.example.com/reset?token=a&b=c", ExpiresInMinutes: 30},
+			subject:  "Reset your Mulo password",
+			contains: []string{"30 minutes"},
+			htmlOnly: []string{`href="https://mulo.example.com/reset?token=a&amp;b=c"`},
+		},
+		{
+			name:        "new login escapes user input",
+			email:       NewLogin{Time: at, IP: "10.0.0.1", UserAgent: "<script>alert(1)</script>"},
+			subject:     "New sign-in to your Mulo account",
+			contains:    []string{"3 March 2025 22:06 UTC", "10.0.0.1"},
+			htmlOnly:    []string{"&lt;script&gt;"},
+			notContains: []string{"<script>"},
+		},
+		{
+			name:        "new login without device",
+			email:       NewLogin{Time: at},
+			subject:     "New sign-in to your Mulo account",
+			notContains: []string{"IP address", "Device"},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			msg, err := Render(tc.email)
+			s.Require().NoError(err)
+
+			s.Equal(tc.subject, msg.Subject)
+			s.Contains(msg.HTML, "<html")
+			for _, want := range tc.contains {
+				s.Contains(msg.Text, want)
+				s.Contains(msg.HTML, want)
+			}
+			for _, want := range tc.htmlOnly {
+				s.Contains(msg.HTML, want)
+			}
+			for _, unwanted := range tc.notContains {
+				s.NotContains(msg.HTML, unwanted)
+			}
+		})
+	}
+}
+
+func (s *MailerTestSuite) TestRenderUnknownTemplate() {
+	_, err := Render(unknownEmail{})
+	s.ErrorIs(err, ErrUnknownTemplate)
+}
+
+type unknownEmail struct{}
+
+func (unknownEmail) Template() string { return "unknown" }
+
+func (s *MailerTestSuite) TestLogMailerWritesEml() {
+	dir := s.T().TempDir()
+	m := NewLogMailer(dir, "Mulo <noreply@mulo.example.com>", nil)
+
+	msg := Message{ID: "email-outbox-7", To: []string{"user@example.com"}, Subject: "Café", HTML: "<p>Hi ☺</p>", Text: "Hi ☺\n"}
+	s.Require().NoError(m.Send(context.Background(), msg))
+
+	data, err := os.ReadFile(filepath.Join(dir, "email-outbox-7.eml"))
+	s.Require().NoError(err)
+	s.assertMIME(string(data), msg)
+}
+
+func (s *MailerTestSuite) TestLogMailerWithoutDir() {
+	m := NewLogMailer("", "noreply@mulo.example.com", nil)
+	s.NoError(m.Send(context.Background(), Message{To: []string{"user@example.com"}, Subject: "Hi"}))
+}
+
+func (s *MailerTestSuite) TestSMTPMailer() {
+	addr, received := fakeSMTPServer(s)
+	host, port, _ := net.SplitHostPort(addr)
+	portNumber, _ := strconv.Atoi(port)
+
+	m, err := NewSMTPMailer(SMTPConfig{Host: host, Port: portNumber, Username: "user", Password: "secret"}, "Mulo <noreply@mulo.example.com>")
+	s.Require().NoError(err)
+
+	msg := Message{ID: "email-outbox-1", To: []string{"a@example.com", "b@example.com"}, Subject: "Hello", HTML: "<p>Hello</p>", Text: "Hello\n"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.Require().NoError(m.Send(ctx, msg))
+
+	session := <-received
+	s.Equal("\x00user\x00secret", session.auth)
+	s.Equal("<noreply@mulo.example.com>", session.from)
+	s.Equal([]string{"<a@example.com>", "<b@example.com>"}, session.to)
+	s.assertMIME(session.data, msg)
+}
+
+func (s *MailerTestSuite) TestSMTPMailerConfig() {
+	_, err := NewSMTPMailer(SMTPConfig{Port: 587}, "noreply@mulo.example.com")
+	s.Error(err)
+
+	_, err = NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", Port: 587}, "not an address")
+	s.Error(err)
+}
+
+func (s *MailerTestSuite) TestResendMailer() {
+	var header http.Header
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		s.NoError(json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"re_1"}`))
+	}))
+	defer srv.Close()
+
+	m := NewResendMailer("re_key", "Mulo <noreply@mulo.example.com>").(*resendMailer)
+	m.client.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	msg := Message{ID: "email-outbox-3", To: []string{"user@example.com"}, Subject: "Hello", HTML: "<p>Hello</p>", Text: "Hello"}
+	s.Require().NoError(m.Send(context.Background(), msg))
+
+	s.Equal("email-outbox-3", header.Get("Idempotency-Key"))
+	s.Equal("Bearer re_key", header.Get("Authorization"))
+	s.Equal("Mulo <noreply@mulo.example.com>", body["from"])
+	s.Equal([]any{"user@example.com"}, body["to"])
+	s.Equal("<p>Hello</p>", body["html"])
+	s.Equal("Hello", body["text"])
+}
+
+// assertMIME parses the message and checks its headers and both decoded parts.
+func (s *MailerTestSuite) assertMIME(raw string, msg Message) {
+	parsed, err := mail.ReadMessage(strings.NewReader(raw))
+	s.Require().NoError(err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	s.Require().NoError(err)
+	s.Equal(msg.Subject, subject)
+	s.Equal(strings.Join(msg.To, ", "), parsed.Header.Get("To"))
+	s.Equal("<"+msg.ID+"@mulo.example.com>", parsed.Header.Get("Message-ID"))
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	s.Require().NoError(err)
+	s.Equal("multipart/alternative", mediaType)
+
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	for _, want := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		part, err := parts.NextPart()
+		s.Require().NoError(err)
+		s.Equal(want.contentType, part.Header.Get("Content-Type"))
+		content, err := io.ReadAll(part)
+		s.Require().NoError(err)
+		// Quoted-printable ends lines with CRLF as mail requires
+		s.Equal(want.content, strings.ReplaceAll(string(content), "\r\n", "\n"))
+	}
+}
+
+type smtpSession struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer accepts a single plain text session offering AUTH PLAIN.
+func fakeSMTPServer(s *MailerTestSuite) (addr string, received chan smtpSession) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	s.T().Cleanup(func() { ln.Close() })
+
+	received = make(chan smtpSession, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		var session smtpSession
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			verb, arg, _ := strings.Cut(line, " ")
+
+			switch strings.ToUpper(verb) {
+			case "EHLO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+				session.auth = string(credentials)
+				reply("235 authenticated")
+			case "MAIL":
+				session.from = strings.TrimPrefix(arg, "FROM:")
+				reply("250 ok")
+			case "RCPT":
+				session.to = append(session.to, strings.TrimPrefix(arg, "TO:"))
+				reply("250 ok")
+			case "DATA":
+				reply("354 end with .")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(line, "."))
+				}
+				session.data = data.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				received <- session
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), received
+}
+
+func TestMailerTestSuite(t *testing.T) {
+	suite.Run(t, new(MailerTestSuite))
+}
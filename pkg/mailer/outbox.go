@@ -0,0 +1,180 @@
+package mailer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/database"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/jobs"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+// sendAttempts spreads retries of a failing provider over about 20 minutes.
+const sendAttempts = 8
+
+// SendJob delivers an email stored in the outbox.
+type SendJob struct {
+	EmailID int64 `json:"email_id"`
+}
+
+func (SendJob) Kind() string { return "email.send" }
+
+// Outbox makes sends durable, an email is stored with the job that delivers it.
+type Outbox interface {
+	// Queue stores the email for the recipient, inside database.WithinTx it is only delivered
+	// once the transaction commits.
+	Queue(ctx context.Context, to string, email Email) (err error)
+
+	// Deliver renders and sends a queued email, one sent already is skipped. The data of the email
+	// is cleared once it is sent or fails for the last time, it may hold links and codes.
+	Deliver(ctx context.Context, id int64, lastAttempt bool) (err error)
+}
+
+// queued is an email row of the outbox.
+type queued struct {
+	id        int64
+	template  string
+	recipient string
+	data      []byte
+	sent      bool
+}
+
+type outboxStore interface {
+	insert(ctx context.Context, template, recipient string, data []byte) (id int64, err error)
+	// find returns the email, nil when it was deleted.
+	find(ctx context.Context, id int64) (email *queued, err error)
+	// markSent and markFailed with clear set replace the data with an empty object.
+	markSent(ctx context.Context, id int64) (err error)
+	markFailed(ctx context.Context, id int64, lastError string, clear bool) (err error)
+}
+
+type outbox struct {
+	store  outboxStore
+	jobs   jobs.Enqueuer
+	mailer Mailer
+	tx     database.Transactor
+	log    *logrus.Logger
+}
+
+func NewOutbox(db *database.DB, jobs jobs.Enqueuer, mailer Mailer, log *logrus.Logger) Outbox {
+	return &outbox{
+		store:  &pgOutboxStore{db: db.DB},
+		jobs:   jobs,
+		mailer: mailer,
+		tx:     db,
+		log:    log,
+	}
+}
+
+func (o *outbox) Queue(ctx context.Context, to string, email Email) (err error) {
+	if _, ok := emails[email.Template()]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTemplate, email.Template())
+	}
+	data, err := json.Marshal(email)
+	if err != nil {
+		return err
+	}
+
+	return o.tx.WithinTx(ctx, func(ctx context.Context) error {
+		id, err := o.store.insert(ctx, email.Template(), to, data)
+		if err != nil {
+			return err
+		}
+		return o.jobs.Enqueue(ctx, SendJob{EmailID: id}, jobs.MaxAttempts(sendAttempts))
+	})
+}
+
+func (o *outbox) Deliver(ctx context.Context, id int64, lastAttempt bool) (err error) {
+	email, err := o.store.find(ctx, id)
+	if err != nil {
+		utils.LogError(o.log, ctx, "mailer_outbox", "Deliver", err)
+		return err
+	}
+	if email == nil || email.sent {
+		return nil
+	}
+
+	// Templates are rendered when sent, an email that no longer renders will not later either
+	data, err := decode(email.template, email.data)
+	if err != nil {
+		utils.LogError(o.log, ctx, "mailer_outbox", "Deliver", err)
+		o.fail(ctx, id, err, true)
+		return jobs.Permanent(err)
+	}
+	msg, err := Render(data)
+	if err != nil {
+		utils.LogError(o.log, ctx, "mailer_outbox", "Deliver", err)
+		o.fail(ctx, id, err, true)
+		return jobs.Permanent(err)
+	}
+	msg.ID = fmt.Sprintf("email-outbox-%d", id)
+	msg.To = []string{email.recipient}
+
+	if err := o.mailer.Send(ctx, msg); err != nil {
+		utils.LogError(o.log, ctx, "mailer_outbox", "Deliver", err)
+		o.fail(ctx, id, err, lastAttempt)
+		return err
+	}
+
+	// Failing here sends the email again on retry, providers deduplicate on the message ID
+	if err := o.store.markSent(ctx, id); err != nil {
+		utils.LogError(o.log, ctx, "mailer_outbox", "Deliver", err)
+		return err
+	}
+
+	return nil
+}
+
+// fail records the attempt, the error returned to the queue decides whether it is retried. The
+// data of an email that is not retried is cleared.
+func (o *outbox) fail(ctx context.Context, id int64, cause error, final bool) {
+	if err := o.store.markFailed(ctx, id, cause.Error(), final); err != nil {
+		utils.LogWarn(o.log, ctx, "mailer_outbox", "Deliver", err)
+	}
+}
+
+type pgOutboxStore struct {
+	db *sql.DB
+}
+
+func (s *pgOutboxStore) insert(ctx context.Context, template, recipient string, data []byte) (id int64, err error) {
+	query := `INSERT INTO email_outbox (template, recipient, data) VALUES ($1, $2, $3) RETURNING id`
+
+	err = database.Conn(ctx, s.db).QueryRowContext(ctx, query, template, recipient, string(data)).Scan(&id)
+	return id, err
+}
+
+func (s *pgOutboxStore) find(ctx context.Context, id int64) (email *queued, err error) {
+	query := `SELECT id, template, recipient, data, sent_at IS NOT NULL FROM email_outbox WHERE id = $1`
+
+	var e queued
+	err = s.db.QueryRowContext(ctx, query, id).Scan(&e.id, &e.template, &e.recipient, &e.data, &e.sent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+func (s *pgOutboxStore) markSent(ctx context.Context, id int64) (err error) {
+	query := `UPDATE email_outbox SET attempts = attempts + 1, last_error = NULL, sent_at = NOW(), data = '{}' WHERE id = $1`
+
+	_, err = s.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (s *pgOutboxStore) markFailed(ctx context.Context, id int64, lastError string, clear bool) (err error) {
+	query := `
+		UPDATE email_outbox SET attempts = attempts + 1, last_error = $2, data = CASE WHEN $3 THEN '{}' ELSE data END
+		WHERE id = $1`
+
+	_, err = s.db.ExecContext(ctx, query, id, lastError, clear)
+	return err
+}
@@ -0,0 +1,174 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/jobs"
+)
+
+// memOutboxStore keeps emails in memory.
+type memOutboxStore struct {
+	emails   []*memEmail
+	insertFn func() error
+}
+
+type memEmail struct {
+	queued
+	attempts  int
+	lastError string
+}
+
+func (s *memOutboxStore) insert(_ context.Context, template, recipient string, data []byte) (int64, error) {
+	if s.insertFn != nil {
+		if err := s.insertFn(); err != nil {
+			return 0, err
+		}
+	}
+	id := int64(len(s.emails) + 1)
+	s.emails = append(s.emails, &memEmail{queued: queued{id: id, template: template, recipient: recipient, data: data}})
+	return id, nil
+}
+
+func (s *memOutboxStore) find(_ context.Context, id int64) (*queued, error) {
+	if id < 1 || int(id) > len(s.emails) {
+		return nil, nil
+	}
+	email := s.emails[id-1].queued
+	return &email, nil
+}
+
+func (s *memOutboxStore) markSent(_ context.Context, id int64) error {
+	email := s.emails[id-1]
+	email.sent, email.attempts, email.lastError, email.data = true, email.attempts+1, "", []byte(`{}`)
+	return nil
+}
+
+func (s *memOutboxStore) markFailed(_ context.Context, id int64, lastError string, clear bool) error {
+	email := s.emails[id-1]
+	email.attempts, email.lastError = email.attempts+1, lastError
+	if clear {
+		email.data = []byte(`{}`)
+	}
+	return nil
+}
+
+type recordingEnqueuer struct {
+	enqueued []jobs.Args
+}
+
+func (e *recordingEnqueuer) Enqueue(_ context.Context, args jobs.Args, _ ...jobs.Option) error {
+	e.enqueued = append(e.enqueued, args)
+	return nil
+}
+
+type recordingMailer struct {
+	sent []Message
+	err  error
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// passthroughTx runs fn without a transaction.
+type passthroughTx struct{}
+
+func (passthroughTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type OutboxTestSuite struct {
+	suite.Suite
+	store  *memOutboxStore
+	jobs   *recordingEnqueuer
+	mailer *recordingMailer
+	outbox Outbox
+}
+
+func (s *OutboxTestSuite) SetupTest() {
+	s.store = &memOutboxStore{}
+	s.jobs = &recordingEnqueuer{}
+	s.mailer = &recordingMailer{}
+	s.outbox = &outbox{store: s.store, jobs: s.jobs, mailer: s.mailer, tx: passthroughTx{}}
+}
+
+func (s *OutboxTestSuite) TestQueueAndDeliver() {
+	ctx := context.Background()
+
+	s.Require().NoError(s.outbox.Queue(ctx, "user@example.com", Verification{Code: "123456"}))
+	s.Equal([]jobs.Args{SendJob{EmailID: 1}}, s.jobs.enqueued)
+	s.Equal(TemplateVerification, s.store.emails[0].template)
+	s.JSONEq(`{"code":"123456"}`, string(s.store.emails[0].data))
+
+	s.Require().NoError(s.outbox.Deliver(ctx, 1, false))
+	s.Require().Len(s.mailer.sent, 1)
+	s.Equal("email-outbox-1", s.mailer.sent[0].ID)
+	s.Equal([]string{"user@example.com"}, s.mailer.sent[0].To)
+	s.Equal("Mulo Email Verification", s.mailer.sent[0].Subject)
+	s.Contains(s.mailer.sent[0].Text, "123456")
+	s.True(s.store.emails[0].sent)
+	// The code is not kept once it went out
+	s.JSONEq(`{}`, string(s.store.emails[0].data))
+
+	// A job run again after the email went out does not send it twice
+	s.Require().NoError(s.outbox.Deliver(ctx, 1, false))
+	s.Len(s.mailer.sent, 1)
+}
+
+func (s *OutboxTestSuite) TestQueueError() {
+	s.store.insertFn = func() error { return errors.New("database failure") }
+
+	s.EqualError(s.outbox.Queue(context.Background(), "user@example.com", Verification{Code: "1"}), "database failure")
+	s.Empty(s.jobs.enqueued)
+
+	s.ErrorIs(s.outbox.Queue(context.Background(), "user@example.com", unknownEmail{}), ErrUnknownTemplate)
+}
+
+func (s *OutboxTestSuite) TestDeliverFailure() {
+	ctx := context.Background()
+	s.Require().NoError(s.outbox.Queue(ctx, "user@example.com", Verification{Code: "123456"}))
+	s.mailer.err = errors.New("provider down")
+
+	err := s.outbox.Deliver(ctx, 1, false)
+	s.EqualError(err, "provider down")
+	s.False(s.store.emails[0].sent)
+	s.Equal(1, s.store.emails[0].attempts)
+	s.Equal("provider down", s.store.emails[0].lastError)
+	s.JSONEq(`{"code":"123456"}`, string(s.store.emails[0].data))
+
+	// The last attempt dead-letters the email, its data goes with it
+	err = s.outbox.Deliver(ctx, 1, true)
+	s.EqualError(err, "provider down")
+	s.Equal(2, s.store.emails[0].attempts)
+	s.JSONEq(`{}`, string(s.store.emails[0].data))
+}
+
+func (s *OutboxTestSuite) TestDeliverUndecodable() {
+	ctx := context.Background()
+	_, _ = s.store.insert(ctx, TemplateVerification, "user@example.com", []byte(`{"code":1}`))
+	_, _ = s.store.insert(ctx, "removed", "user@example.com", []byte(`{}`))
+
+	for _, id := range []int64{1, 2} {
+		err := s.outbox.Deliver(ctx, id, false)
+		s.Error(err)
+		s.Equal(err.Error(), s.store.emails[id-1].lastError)
+		s.JSONEq(`{}`, string(s.store.emails[id-1].data))
+	}
+	s.Empty(s.mailer.sent)
+}
+
+func (s *OutboxTestSuite) TestDeliverDeleted() {
+	s.NoError(s.outbox.Deliver(context.Background(), 42, false))
+	s.Empty(s.mailer.sent)
+}
+
+func TestOutboxTestSuite(t *testing.T) {
+	suite.Run(t, new(OutboxTestSuite))
+}
@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"context"
+
+	resendlib "github.com/resend/resend-go/v2"
+)
+
+type resendMailer struct {
+	client *resendlib.Client
+	from   string
+}
+
+// NewResendMailer sends through the Resend API, the message ID doubles as idempotency key so a
+// retried send is not delivered twice.
+func NewResendMailer(apiKey, from string) Mailer {
+	return &resendMailer{
+		client: resendlib.NewClient(apiKey),
+		from:   from,
+	}
+}
+
+func (m *resendMailer) Send(ctx context.Context, msg Message) (err error) {
+	params := &resendlib.SendEmailRequest{
+		From:    m.from,
+		To:      msg.To,
+		Subject: msg.Subject,
+		Html:    msg.HTML,
+		Text:    msg.Text,
+	}
+
+	_, err = m.client.Emails.SendWithOptions(ctx, params, &resendlib.SendEmailOptions{IdempotencyKey: msg.ID})
+	return err
+}
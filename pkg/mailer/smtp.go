@@ -0,0 +1,108 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig addresses the relay, port 465 is implicit TLS and other ports upgrade with STARTTLS
+// when the server offers it.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+type smtpMailer struct {
+	cfg  SMTPConfig
+	from *mail.Address
+	now  func() time.Time
+}
+
+func NewSMTPMailer(cfg SMTPConfig, from string) (Mailer, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("mailer: smtp host is empty")
+	}
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("mailer: invalid from address %q: %w", from, err)
+	}
+
+	return &smtpMailer{cfg: cfg, from: address, now: time.Now}, nil
+}
+
+func (m *smtpMailer) Send(ctx context.Context, msg Message) (err error) {
+	data, err := buildMIME(m.from, msg, m.now())
+	if err != nil {
+		return err
+	}
+
+	conn, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// net/smtp does not take a context, the deadline bounds the whole conversation instead
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if _, implicitTLS := conn.(*tls.Conn); !implicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+				return err
+			}
+		}
+	}
+	if m.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (m *smtpMailer) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	if m.cfg.Port == 465 {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: m.cfg.Host}}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", addr)
+}
@@ -0,0 +1,126 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+const (
+	TemplateVerification  = "verification"
+	TemplateEmailChange   = "email_change"
+	TemplatePasswordReset = "password_reset"
+	TemplateNewLogin      = "new_login"
+)
+
+var ErrUnknownTemplate = errors.New("mailer: unknown template")
+
+// Email is the data of a templated email, Template names the files it is rendered from.
+type Email interface {
+	Template() string
+}
+
+// Verification carries the code a new user confirms their email with.
+type Verification struct {
+	Code string `json:"code"`
+}
+
+func (Verification) Template() string { return TemplateVerification }
+
+// EmailChange carries the code confirming a new email address, sent to that address.
+type EmailChange struct {
+	Code string `json:"code"`
+}
+
+func (EmailChange) Template() string { return TemplateEmailChange }
+
+// PasswordReset links to the page that sets a new password.
+type PasswordReset struct {
+	URL              string `json:"url"`
+	ExpiresInMinutes int    `json:"expires_in_minutes"`
+}
+
+func (PasswordReset) Template() string { return TemplatePasswordReset }
+
+// NewLogin tells a user their account was signed in to.
+type NewLogin struct {
+	Time      time.Time `json:"time"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+}
+
+func (NewLogin) Template() string { return TemplateNewLogin }
+
+// emails decodes the data stored in the outbox back into its type.
+var emails = map[string]func() Email{
+	TemplateVerification:  func() Email { return &Verification{} },
+	TemplateEmailChange:   func() Email { return &EmailChange{} },
+	TemplatePasswordReset: func() Email { return &PasswordReset{} },
+	TemplateNewLogin:      func() Email { return &NewLogin{} },
+}
+
+//go:embed templates
+var templateFS embed.FS
+
+// Every email has a {name}.html page wrapped by layout.html and a {name}.txt alternative, the
+// text file also defines the subject.
+type compiled struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+var templates = func() map[string]compiled {
+	parsed := make(map[string]compiled, len(emails))
+	for name := range emails {
+		parsed[name] = compiled{
+			html: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")),
+			text: texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/"+name+".txt")),
+		}
+	}
+	return parsed
+}()
+
+// Render renders the subject and both bodies of the email, To and ID are left to the caller.
+func Render(email Email) (msg Message, err error) {
+	tmpl, ok := templates[email.Template()]
+	if !ok {
+		return msg, fmt.Errorf("%w: %q", ErrUnknownTemplate, email.Template())
+	}
+
+	var subject, html, text bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", email); err != nil {
+		return msg, err
+	}
+	if err := tmpl.text.Execute(&text, email); err != nil {
+		return msg, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout.html", email); err != nil {
+		return msg, err
+	}
+
+	// A line break in a header would start a new one
+	msg.Subject = strings.Join(strings.Fields(subject.String()), " ")
+	msg.HTML = html.String()
+	msg.Text = strings.TrimSpace(text.String()) + "\n"
+	return msg, nil
+}
+
+// decode reads email data stored by the outbox.
+func decode(template string, data []byte) (email Email, err error) {
+	newEmail, ok := emails[template]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTemplate, template)
+	}
+
+	email = newEmail()
+	if err := json.Unmarshal(data, email); err != nil {
+		return nil, fmt.Errorf("mailer: decode %s data: %w", template, err)
+	}
+	return email, nil
+}
@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrChecksumMismatch is returned when an applied migration file has been edited afterwards.
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+	// ErrMissingMigration is returned when an applied version has no matching file anymore.
+	ErrMissingMigration = errors.New("applied migration file is missing")
+	// ErrMissingDown is returned when a rollback needs a .down.sql file that does not exist.
+	ErrMissingDown = errors.New("down migration file is missing")
+)
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([\w-]+)\.(up|down)\.sql$`)
+
+// Migration is a single versioned schema change read from NNN_name.up.sql and NNN_name.down.sql.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// HasDown reports whether the migration can be rolled back.
+func (m Migration) HasDown() bool {
+	return m.Down != ""
+}
+
+// Status describes a known migration and whether it has been applied.
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Load reads every migration file on the root of fsys, sorted by version.
+// Files that don't follow the NNN_name.(up|down).sql format are ignored.
+func Load(fsys fs.FS) (migrations []Migration, err error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, migration.Name, matches[2])
+		}
+
+		switch matches[3] {
+		case "up":
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		case "down":
+			migration.Down = string(content)
+		}
+	}
+
+	migrations = make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %03d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
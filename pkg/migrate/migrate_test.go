@@ -0,0 +1,97 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/migrations"
+)
+
+type LoadTestSuite struct {
+	suite.Suite
+}
+
+func (s *LoadTestSuite) TestLoad() {
+	testCases := []struct {
+		name           string
+		files          fstest.MapFS
+		expectVersions []int
+		expectErr      string
+	}{
+		{
+			name: "sorted by version with optional down",
+			files: fstest.MapFS{
+				"002_create_albums.up.sql":   {Data: []byte("CREATE TABLE albums();")},
+				"001_create_users.up.sql":    {Data: []byte("CREATE TABLE users();")},
+				"001_create_users.down.sql":  {Data: []byte("DROP TABLE users;")},
+				"README.md":                  {Data: []byte("ignored")},
+				"010_create_songs.up.sql":    {Data: []byte("CREATE TABLE songs();")},
+				"010_create_songs.down.sql":  {Data: []byte("DROP TABLE songs;")},
+				"nested/003_ignored.up.sql":  {Data: []byte("ignored")},
+				"002_create_albums.down.sql": {Data: []byte("DROP TABLE albums;")},
+			},
+			expectVersions: []int{1, 2, 10},
+		},
+		{
+			name: "down without up",
+			files: fstest.MapFS{
+				"001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+			},
+			expectErr: "migration 001_create_users has no up file",
+		},
+		{
+			name: "duplicate version",
+			files: fstest.MapFS{
+				"001_create_users.up.sql":  {Data: []byte("CREATE TABLE users();")},
+				"001_create_albums.up.sql": {Data: []byte("CREATE TABLE albums();")},
+			},
+			expectErr: `migration version 1 is used by both "create_albums" and "create_users"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			results, err := Load(tc.files)
+
+			if tc.expectErr != "" {
+				s.EqualError(err, tc.expectErr)
+				return
+			}
+
+			s.NoError(err)
+			versions := make([]int, 0, len(results))
+			for _, result := range results {
+				versions = append(versions, result.Version)
+				s.Len(result.Checksum, 64)
+				s.True(result.HasDown())
+			}
+			s.Equal(tc.expectVersions, versions)
+		})
+	}
+}
+
+func (s *LoadTestSuite) TestChecksumChangesWithContent() {
+	before, err := Load(fstest.MapFS{"001_create_users.up.sql": {Data: []byte("CREATE TABLE users();")}})
+	s.Require().NoError(err)
+
+	after, err := Load(fstest.MapFS{"001_create_users.up.sql": {Data: []byte("CREATE TABLE users(id int);")}})
+	s.Require().NoError(err)
+
+	s.NotEqual(before[0].Checksum, after[0].Checksum)
+}
+
+func (s *LoadTestSuite) TestEmbeddedMigrations() {
+	results, err := Load(migrations.FS)
+	s.Require().NoError(err)
+	s.NotEmpty(results)
+
+	for i, result := range results {
+		s.Equal(i+1, result.Version, "migration versions must be contiguous")
+		s.True(result.HasDown(), "migration %03d_%s has no down file", result.Version, result.Name)
+	}
+}
+
+func TestLoadTestSuite(t *testing.T) {
+	suite.Run(t, new(LoadTestSuite))
+}
@@ -0,0 +1,321 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// lockID is the key of the postgres advisory lock, so only one runner migrates at a time.
+const lockID = 7_261_947_001
+
+type appliedMigration struct {
+	Version   int
+	Checksum  string
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	log        *logrus.Logger
+}
+
+func NewMigrator(db *sql.DB, fsys fs.FS, log *logrus.Logger) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		log:        log,
+	}, nil
+}
+
+// Up applies every pending migration in version order, each one inside its own transaction.
+// It returns the versions that were applied.
+func (m *Migrator) Up(ctx context.Context) (applied []int, err error) {
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		history, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := history[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration.Version)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back every applied migration with a version greater than target, newest first.
+// A target of 0 rolls back everything. It returns the versions that were rolled back.
+func (m *Migrator) Down(ctx context.Context, target int) (rolledBack []int, err error) {
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		history, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version <= target {
+				break
+			}
+			if _, ok := history[migration.Version]; !ok {
+				continue
+			}
+			if !migration.HasDown() {
+				return fmt.Errorf("%w: %03d_%s", ErrMissingDown, migration.Version, migration.Name)
+			}
+
+			if err := m.rollback(ctx, conn, migration); err != nil {
+				return err
+			}
+			rolledBack = append(rolledBack, migration.Version)
+		}
+
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) (statuses []Status, err error) {
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		history, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		statuses = make([]Status, 0, len(m.migrations))
+		for _, migration := range m.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if row, ok := history[migration.Version]; ok {
+				status.Applied = true
+				status.AppliedAt = row.AppliedAt
+			}
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, unlockErr := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to release migration lock: %w", unlockErr)
+		}
+	}()
+
+	if err = m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// ensureTable creates schema_migrations. A table left by golang-migrate (version, dirty)
+// is kept as schema_migrations_legacy and its versions are adopted with the current checksums.
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) (err error) {
+	var hasTable, hasChecksum bool
+	query := `
+		SELECT
+			EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'schema_migrations'),
+			EXISTS (SELECT 1 FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = 'schema_migrations' AND column_name = 'checksum')
+	`
+	if err = conn.QueryRowContext(ctx, query).Scan(&hasTable, &hasChecksum); err != nil {
+		return fmt.Errorf("failed to inspect schema_migrations: %w", err)
+	}
+
+	if hasTable && hasChecksum {
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	legacyVersion := 0
+	if hasTable {
+		var dirty bool
+		if err = tx.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&legacyVersion, &dirty); err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to read legacy schema_migrations: %w", err)
+		}
+		if dirty {
+			return fmt.Errorf("legacy schema_migrations is dirty at version %d, fix it manually before migrating", legacyVersion)
+		}
+		if _, err = tx.ExecContext(ctx, `ALTER TABLE schema_migrations RENAME TO schema_migrations_legacy`); err != nil {
+			return fmt.Errorf("failed to rename legacy schema_migrations: %w", err)
+		}
+	}
+
+	createQuery := `
+		CREATE TABLE schema_migrations (
+			"version" int NOT NULL,
+			"name" varchar(255) NOT NULL,
+			"checksum" char(64) NOT NULL,
+			"applied_at" timestamp NOT NULL DEFAULT (now()),
+			PRIMARY KEY ("version")
+		)
+	`
+	if _, err = tx.ExecContext(ctx, createQuery); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version > legacyVersion {
+			break
+		}
+		if err = recordApplied(ctx, tx, migration); err != nil {
+			return err
+		}
+	}
+
+	if legacyVersion > 0 {
+		m.logInfo("adopted legacy migration history", logrus.Fields{"version": legacyVersion})
+	}
+
+	return nil
+}
+
+// verify loads the applied versions and refuses to continue when a file was edited or removed.
+func (m *Migrator) verify(ctx context.Context, conn *sql.Conn) (history map[int]appliedMigration, err error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	history = make(map[int]appliedMigration)
+	for rows.Next() {
+		var row appliedMigration
+		if err := rows.Scan(&row.Version, &row.Checksum, &row.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		history[row.Version] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	known := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	for version, row := range history {
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: version %d", ErrMissingMigration, version)
+		}
+		if migration.Checksum != row.Checksum {
+			return nil, fmt.Errorf("%w: %03d_%s was edited after it was applied", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+
+	return history, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) (err error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, migration.Up); err != nil {
+		return fmt.Errorf("failed to apply %03d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if err = recordApplied(ctx, tx, migration); err != nil {
+		return err
+	}
+
+	m.logInfo("migration applied", logrus.Fields{"version": migration.Version, "name": migration.Name})
+
+	return nil
+}
+
+func (m *Migrator) rollback(ctx context.Context, conn *sql.Conn, migration Migration) (err error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, migration.Down); err != nil {
+		return fmt.Errorf("failed to roll back %03d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+		return fmt.Errorf("failed to remove version %d from schema_migrations: %w", migration.Version, err)
+	}
+
+	m.logInfo("migration rolled back", logrus.Fields{"version": migration.Version, "name": migration.Name})
+
+	return nil
+}
+
+func recordApplied(ctx context.Context, tx *sql.Tx, migration Migration) error {
+	query := `INSERT INTO schema_migrations(version, name, checksum) VALUES($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, query, migration.Version, migration.Name, migration.Checksum); err != nil {
+		return fmt.Errorf("failed to record version %d: %w", migration.Version, err)
+	}
+	return nil
+}
+
+func (m *Migrator) logInfo(msg string, fields logrus.Fields) {
+	if m.log != nil {
+		m.log.WithFields(fields).Info(msg)
+	}
+}
@@ -2,15 +2,57 @@ package oauth
 
 import (
 	"context"
+	"errors"
+	"time"
+)
 
-	"github.com/wahyusahajaa/mulo-api-go/app/dto"
+var (
+	ErrUnknownProvider = errors.New("oauth: unknown provider")
+	// ErrInvalidGrant is returned for a code the provider refused, such as an expired or used one.
+	ErrInvalidGrant = errors.New("oauth: the code is invalid or expired")
+	// ErrInvalidToken is returned for a token that fails verification or was issued to another client.
+	ErrInvalidToken = errors.New("oauth: the token is invalid or not issued to this client")
 )
 
-type OAuthService interface {
-	// GithubAccessToken for get access token from github by github code from redirect url
-	GithubAccessToken(ctx context.Context, code string) (accessToken string, err error)
-	// GithubUserInfo for get user info by access token
-	GithubUserInfo(ctx context.Context, token string) (user *dto.GithubUser, err error)
-	// GithubUserEmail for get user email, it's call while email on user info is missing or empty
-	GithubUserEmail(ctx context.Context, token string) (email string, err error)
+// Token is what a provider hands out for the code of the callback.
+type Token struct {
+	AccessToken string
+	TokenType   string
+	// IDToken is the signed identity of the user, only OpenID providers issue it
+	IDToken string
+	Expiry  time.Time
+}
+
+// Identity is the user of a provider, normalized over the providers.
+type Identity struct {
+	Provider string
+	// Subject is the id of the user at the provider, stable across email or username changes
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Username      string
+	AvatarURL     string
+}
+
+type Provider interface {
+	Name() string
+	// AuthCodeURL is the consent page the user is sent to, state comes back on the callback.
+	// codeChallenge is the PKCE challenge of the verifier the code is exchanged with.
+	AuthCodeURL(ctx context.Context, state, codeChallenge string) (authURL string, err error)
+	// Exchange trades the code of the callback for a token, ErrInvalidGrant when the code is refused.
+	Exchange(ctx context.Context, code, codeVerifier string) (token *Token, err error)
+	// Identity reads the user the token was issued for.
+	Identity(ctx context.Context, token *Token) (identity *Identity, err error)
+	// VerifyToken reads the user of a token a client obtained from the provider on its own, an ID
+	// token for OpenID providers and an access token for GitHub. ErrInvalidToken when the token is
+	// forged, expired or issued to an app that is not trusted.
+	VerifyToken(ctx context.Context, token *Token) (identity *Identity, err error)
+}
+
+type Registry interface {
+	// Provider looks up a configured provider by name, ErrUnknownProvider when there is none.
+	Provider(name string) (provider Provider, err error)
+	// Names lists the configured providers.
+	Names() []string
 }
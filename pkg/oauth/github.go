@@ -0,0 +1,215 @@
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+// githubProvider signs in with a GitHub OAuth app, GitHub is not an OpenID provider so the user
+// comes from its REST API.
+type githubProvider struct {
+	name         string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	authURL      string
+	tokenURL     string
+	apiURL       string
+	client       *http.Client
+	log          *logrus.Logger
+}
+
+type githubUser struct {
+	ID        int    `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// githubAuthorization is the token check response, the app the token was issued to.
+type githubAuthorization struct {
+	App struct {
+		ClientID string `json:"client_id"`
+	} `json:"app"`
+}
+
+func newGithubProvider(pc config.OAuthProvider, client *http.Client, log *logrus.Logger) *githubProvider {
+	scopes := pc.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"read:user", "user:email"}
+	}
+
+	return &githubProvider{
+		name:         pc.Name,
+		clientID:     pc.ClientID,
+		clientSecret: pc.ClientSecret,
+		redirectURL:  pc.RedirectURL,
+		scopes:       scopes,
+		authURL:      "https://github.com/login/oauth/authorize",
+		tokenURL:     "https://github.com/login/oauth/access_token",
+		apiURL:       "https://api.github.com",
+		client:       client,
+		log:          log,
+	}
+}
+
+func (p *githubProvider) Name() string {
+	return p.name
+}
+
+func (p *githubProvider) AuthCodeURL(ctx context.Context, state, codeChallenge string) (string, error) {
+	params := url.Values{
+		"client_id": {p.clientID},
+		"scope":     {strings.Join(p.scopes, " ")},
+		"state":     {state},
+		// GitHub apps and OAuth apps verify PKCE too
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	if p.redirectURL != "" {
+		params.Set("redirect_uri", p.redirectURL)
+	}
+
+	return p.authURL + "?" + params.Encode(), nil
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	form := url.Values{
+		"client_id":     {p.clientID},
+		"client_secret": {p.clientSecret},
+		"code":          {code},
+		"code_verifier": {codeVerifier},
+	}
+	if p.redirectURL != "" {
+		form.Set("redirect_uri", p.redirectURL)
+	}
+
+	token, err := exchangeCode(ctx, p.client, p.tokenURL, form)
+	if err != nil && !errors.Is(err, ErrInvalidGrant) {
+		utils.LogError(p.log, ctx, "oauth_github", "Exchange", err)
+	}
+
+	return token, err
+}
+
+func (p *githubProvider) Identity(ctx context.Context, token *Token) (*Identity, error) {
+	var user githubUser
+	if err := getJSON(ctx, p.client, p.apiURL+"/user", token.AccessToken, &user); err != nil {
+		utils.LogError(p.log, ctx, "oauth_github", "Identity", err)
+		return nil, err
+	}
+
+	identity := &Identity{
+		Provider:  p.name,
+		Subject:   strconv.Itoa(user.ID),
+		Email:     user.Email,
+		Name:      user.Name,
+		Username:  user.Login,
+		AvatarURL: user.AvatarURL,
+	}
+	// Use login as fallback name
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+
+	// The profile email is whatever the user made public, only the emails endpoint tells
+	// whether an address is verified
+	var emails []githubEmail
+	if err := getJSON(ctx, p.client, p.apiURL+"/user/emails", token.AccessToken, &emails); err != nil {
+		utils.LogWarn(p.log, ctx, "oauth_github", "Identity", err)
+		return identity, nil
+	}
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			identity.Email, identity.EmailVerified = e.Email, true
+			break
+		}
+		if e.Verified && strings.EqualFold(e.Email, identity.Email) {
+			identity.EmailVerified = true
+		}
+	}
+
+	return identity, nil
+}
+
+func (p *githubProvider) VerifyToken(ctx context.Context, token *Token) (*Identity, error) {
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("%w: %s needs an access token", ErrInvalidToken, p.name)
+	}
+
+	// Any GitHub token reads /user, only the app a token was issued to can check it
+	if err := p.checkToken(ctx, token.AccessToken); err != nil {
+		if !errors.Is(err, ErrInvalidToken) {
+			utils.LogError(p.log, ctx, "oauth_github", "VerifyToken", err)
+		}
+		return nil, err
+	}
+
+	return p.Identity(ctx, token)
+}
+
+// checkToken asks GitHub whether the access token is valid and was issued to this OAuth app.
+func (p *githubProvider) checkToken(ctx context.Context, accessToken string) error {
+	body, err := json.Marshal(map[string]string{"access_token": accessToken})
+	if err != nil {
+		return err
+	}
+
+	endpoint := p.apiURL + "/applications/" + url.PathEscape(p.clientID) + "/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.SetBasicAuth(p.clientID, p.clientSecret)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusUnprocessableEntity:
+		return fmt.Errorf("%w: %s refused the access token", ErrInvalidToken, p.name)
+	default:
+		return fmt.Errorf("oauth: POST %s: unexpected status %d: %s", endpoint, res.StatusCode, data)
+	}
+
+	var auth githubAuthorization
+	if err := json.Unmarshal(data, &auth); err != nil {
+		return err
+	}
+	if auth.App.ClientID != p.clientID {
+		return fmt.Errorf("%w: %s access token is issued to %q", ErrInvalidToken, p.name, auth.App.ClientID)
+	}
+
+	return nil
+}
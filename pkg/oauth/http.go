@@ -0,0 +1,90 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxResponseSize caps the bodies read from a provider.
+const maxResponseSize = 1 << 20
+
+// tokenResponse is the token endpoint response of RFC 6749, GitHub reports errors in it with a 200.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode posts the authorization code grant to the token endpoint.
+func exchangeCode(ctx context.Context, client *http.Client, tokenURL string, form url.Values) (*Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var body tokenResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("oauth: token response status %d: %w", res.StatusCode, err)
+	}
+
+	switch {
+	case body.Error == "invalid_grant" || body.Error == "bad_verification_code":
+		return nil, ErrInvalidGrant
+	case body.Error != "":
+		return nil, fmt.Errorf("oauth: token error %s: %s", body.Error, body.ErrorDescription)
+	case res.StatusCode != http.StatusOK || body.AccessToken == "":
+		return nil, fmt.Errorf("oauth: token response status %d without access token", res.StatusCode)
+	}
+
+	token := &Token{AccessToken: body.AccessToken, TokenType: body.TokenType, IDToken: body.IDToken}
+	if body.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
+
+// getJSON decodes a GET response into out, the request carries accessToken when one is given.
+func getJSON(ctx context.Context, client *http.Client, endpoint, accessToken string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("oauth: GET %s: unexpected status %d: %s", endpoint, res.StatusCode, body)
+	}
+
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,532 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/jwt"
+)
+
+// OIDCTestSuite runs the providers against an httptest stand-in of the provider.
+type OIDCTestSuite struct {
+	suite.Suite
+	srv      *httptest.Server
+	key      *rsa.PrivateKey
+	provider *oidcProvider
+	now      time.Time
+
+	mu        sync.Mutex
+	jwks      jwt.JWKS
+	idToken   string
+	userInfo  map[string]any
+	jwksHits  int
+	tokenForm url.Values
+}
+
+func (s *OIDCTestSuite) SetupSuite() {
+	var err error
+	s.key, err = rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 s.srv.URL,
+			"authorization_endpoint": s.srv.URL + "/authorize",
+			"token_endpoint":         s.srv.URL + "/token",
+			"userinfo_endpoint":      s.srv.URL + "/userinfo",
+			"jwks_uri":               s.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		s.Require().NoError(r.ParseForm())
+		s.mu.Lock()
+		s.tokenForm = r.PostForm
+		s.mu.Unlock()
+
+		if r.PostForm.Get("code") != "good-code" {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"access_token": "provider-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     s.idToken,
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.jwksHits++
+		s.mu.Unlock()
+		s.writeJSON(w, http.StatusOK, s.jwks)
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer provider-access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, s.userInfo)
+	})
+	s.srv = httptest.NewServer(mux)
+}
+
+func (s *OIDCTestSuite) TearDownSuite() {
+	s.srv.Close()
+}
+
+func (s *OIDCTestSuite) SetupTest() {
+	s.now = time.Now()
+	s.jwks = jwt.JWKS{Keys: []jwt.JWK{rsaJWK("key-1", &s.key.PublicKey)}}
+	s.jwksHits = 0
+	s.userInfo = nil
+	s.provider = newOIDCProvider(config.OAuthProvider{
+		Name:         "keycloak",
+		Issuer:       s.srv.URL,
+		ClientID:     "mulo",
+		ClientSecret: "secret",
+		RedirectURL:  "https://mulo.example.com/oauth/keycloak/callback",
+	}, s.srv.Client(), nil)
+	s.provider.now = func() time.Time { return s.now }
+}
+
+func (s *OIDCTestSuite) writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	s.NoError(json.NewEncoder(w).Encode(body))
+}
+
+func rsaJWK(kid string, pub *rsa.PublicKey) jwt.JWK {
+	return jwt.JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+// claims are the claims of a valid ID token for the test client.
+func (s *OIDCTestSuite) claims() jwtlib.MapClaims {
+	return jwtlib.MapClaims{
+		"iss":                s.srv.URL,
+		"aud":                "mulo",
+		"sub":                "user-42",
+		"iat":                s.now.Unix(),
+		"exp":                s.now.Add(5 * time.Minute).Unix(),
+		"email":              "naff@example.com",
+		"email_verified":     true,
+		"name":               "Naff",
+		"preferred_username": "naff",
+		"picture":            "https://example.com/naff.png",
+	}
+}
+
+func (s *OIDCTestSuite) sign(claims jwtlib.MapClaims, kid string, key *rsa.PrivateKey) string {
+	token := jwtlib.NewWithClaims(jwtlib.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	s.Require().NoError(err)
+	return signed
+}
+
+func (s *OIDCTestSuite) TestAuthCodeURL() {
+	authURL, err := s.provider.AuthCodeURL(s.T().Context(), "state-1", CodeChallenge("verifier"))
+	s.Require().NoError(err)
+
+	parsed, err := url.Parse(authURL)
+	s.Require().NoError(err)
+	s.Equal(s.srv.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+
+	query := parsed.Query()
+	s.Equal("code", query.Get("response_type"))
+	s.Equal("mulo", query.Get("client_id"))
+	s.Equal("openid email profile", query.Get("scope"))
+	s.Equal("state-1", query.Get("state"))
+	s.Equal("https://mulo.example.com/oauth/keycloak/callback", query.Get("redirect_uri"))
+	s.Equal(CodeChallenge("verifier"), query.Get("code_challenge"))
+	s.Equal("S256", query.Get("code_challenge_method"))
+}
+
+func (s *OIDCTestSuite) TestCodeChallenge() {
+	// The S256 example of RFC 7636 appendix B
+	s.Equal("E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	verifier, err := NewCodeVerifier()
+	s.Require().NoError(err)
+	s.Len(verifier, 43)
+}
+
+func (s *OIDCTestSuite) TestExchangeAndIdentity() {
+	s.idToken = s.sign(s.claims(), "key-1", s.key)
+
+	token, err := s.provider.Exchange(s.T().Context(), "good-code", "verifier")
+	s.Require().NoError(err)
+	s.Equal("authorization_code", s.tokenForm.Get("grant_type"))
+	s.Equal("verifier", s.tokenForm.Get("code_verifier"))
+	s.Equal("secret", s.tokenForm.Get("client_secret"))
+
+	identity, err := s.provider.Identity(s.T().Context(), token)
+	s.Require().NoError(err)
+	s.Equal(&Identity{
+		Provider:      "keycloak",
+		Subject:       "user-42",
+		Email:         "naff@example.com",
+		EmailVerified: true,
+		Name:          "Naff",
+		Username:      "naff",
+		AvatarURL:     "https://example.com/naff.png",
+	}, identity)
+}
+
+func (s *OIDCTestSuite) TestExchangeInvalidGrant() {
+	_, err := s.provider.Exchange(s.T().Context(), "used-code", "verifier")
+	s.ErrorIs(err, ErrInvalidGrant)
+}
+
+func (s *OIDCTestSuite) TestIdentityFromUserInfo() {
+	claims := s.claims()
+	delete(claims, "email")
+	delete(claims, "email_verified")
+	delete(claims, "name")
+	s.userInfo = map[string]any{"sub": "user-42", "email": "naff@example.com", "email_verified": "true", "name": "Naff"}
+
+	identity, err := s.provider.Identity(s.T().Context(), &Token{AccessToken: "provider-access-token", IDToken: s.sign(claims, "key-1", s.key)})
+	s.Require().NoError(err)
+	s.Equal("naff@example.com", identity.Email)
+	s.True(identity.EmailVerified)
+	s.Equal("Naff", identity.Name)
+
+	// The userinfo of another subject is never mixed in
+	s.userInfo["sub"] = "user-7"
+	_, err = s.provider.Identity(s.T().Context(), &Token{AccessToken: "provider-access-token", IDToken: s.sign(claims, "key-1", s.key)})
+	s.Error(err)
+}
+
+func (s *OIDCTestSuite) TestIDTokenRejects() {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+
+	testCases := []struct {
+		name    string
+		idToken func() string
+	}{
+		{
+			name: "other audience",
+			idToken: func() string {
+				claims := s.claims()
+				claims["aud"] = "other-client"
+				return s.sign(claims, "key-1", s.key)
+			},
+		},
+		{
+			name: "other authorized party",
+			idToken: func() string {
+				claims := s.claims()
+				claims["aud"] = []string{"mulo", "other-client"}
+				claims["azp"] = "other-client"
+				return s.sign(claims, "key-1", s.key)
+			},
+		},
+		{
+			name: "other issuer",
+			idToken: func() string {
+				claims := s.claims()
+				claims["iss"] = "https://evil.example.com"
+				return s.sign(claims, "key-1", s.key)
+			},
+		},
+		{
+			name: "expired",
+			idToken: func() string {
+				claims := s.claims()
+				claims["exp"] = s.now.Add(-2 * time.Minute).Unix()
+				return s.sign(claims, "key-1", s.key)
+			},
+		},
+		{
+			name: "signed by another key under a known kid",
+			idToken: func() string {
+				return s.sign(s.claims(), "key-1", otherKey)
+			},
+		},
+		{
+			name: "unknown kid",
+			idToken: func() string {
+				return s.sign(s.claims(), "key-9", otherKey)
+			},
+		},
+		{
+			name: "shared secret",
+			idToken: func() string {
+				token := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, s.claims())
+				token.Header["kid"] = "key-1"
+				signed, _ := token.SignedString([]byte("secret"))
+				return signed
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			_, err := s.provider.Identity(s.T().Context(), &Token{AccessToken: "provider-access-token", IDToken: tc.idToken()})
+			s.ErrorIs(err, ErrInvalidToken)
+		})
+	}
+}
+
+func (s *OIDCTestSuite) TestVerifyToken() {
+	s.provider = newOIDCProvider(config.OAuthProvider{
+		Name:      "keycloak",
+		Issuer:    s.srv.URL,
+		ClientID:  "mulo",
+		Audiences: []string{"mulo-mobile"},
+	}, s.srv.Client(), nil)
+	s.provider.now = func() time.Time { return s.now }
+
+	s.Run("id token of a trusted client", func() {
+		claims := s.claims()
+		claims["aud"] = "mulo-mobile"
+
+		identity, err := s.provider.VerifyToken(s.T().Context(), &Token{IDToken: s.sign(claims, "key-1", s.key)})
+		s.Require().NoError(err)
+		s.Equal("user-42", identity.Subject)
+		s.True(identity.EmailVerified)
+	})
+
+	s.Run("id token of another client", func() {
+		claims := s.claims()
+		claims["aud"] = "other-client"
+
+		_, err := s.provider.VerifyToken(s.T().Context(), &Token{IDToken: s.sign(claims, "key-1", s.key)})
+		s.ErrorIs(err, ErrInvalidToken)
+	})
+
+	s.Run("access token alone", func() {
+		_, err := s.provider.VerifyToken(s.T().Context(), &Token{AccessToken: "provider-access-token"})
+		s.ErrorIs(err, ErrInvalidToken)
+	})
+}
+
+func (s *OIDCTestSuite) TestKeyRotation() {
+	s.idToken = s.sign(s.claims(), "key-1", s.key)
+	_, err := s.provider.Identity(s.T().Context(), &Token{IDToken: s.idToken})
+	s.Require().NoError(err)
+
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+	s.jwks = jwt.JWKS{Keys: []jwt.JWK{rsaJWK("key-2", &newKey.PublicKey)}}
+	rotated := &Token{IDToken: s.sign(s.claims(), "key-2", newKey)}
+
+	// Right after a fetch an unknown kid doesn't refetch
+	_, err = s.provider.Identity(s.T().Context(), rotated)
+	s.Error(err)
+	s.Equal(1, s.jwksHits)
+
+	s.now = s.now.Add(2 * time.Minute)
+	rotated.IDToken = s.sign(s.claims(), "key-2", newKey)
+	_, err = s.provider.Identity(s.T().Context(), rotated)
+	s.NoError(err)
+	s.Equal(2, s.jwksHits)
+}
+
+func (s *OIDCTestSuite) TestDiscoveryIssuerMismatch() {
+	provider := newOIDCProvider(config.OAuthProvider{Name: "keycloak", Issuer: s.srv.URL + "/", ClientID: "mulo"}, s.srv.Client(), nil)
+
+	_, err := provider.AuthCodeURL(s.T().Context(), "state-1", CodeChallenge("verifier"))
+	s.ErrorContains(err, "does not match")
+}
+
+func TestOIDCTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCTestSuite))
+}
+
+type GithubTestSuite struct {
+	suite.Suite
+	srv      *httptest.Server
+	provider *githubProvider
+	emails   []githubEmail
+	// appTokens are the tokens the check token API knows, by the client they were issued to
+	appTokens map[string]string
+}
+
+func (s *GithubTestSuite) SetupTest() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		s.Require().NoError(r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		// GitHub reports a refused code with a 200
+		if r.Form.Get("code") != "good-code" || r.Form.Get("code_verifier") != "verifier" {
+			_, _ = w.Write([]byte(`{"error":"bad_verification_code"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"gh-token","token_type":"bearer"}`))
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		s.Equal("Bearer gh-token", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"id":42,"login":"naff","name":"","email":"public@example.com","avatar_url":"https://example.com/naff.png"}`))
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		s.NoError(json.NewEncoder(w).Encode(s.emails))
+	})
+	mux.HandleFunc("POST /applications/gh-client/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, _ := r.BasicAuth()
+		s.Equal("gh-client", clientID)
+		s.Equal("gh-secret", clientSecret)
+
+		var body struct {
+			AccessToken string `json:"access_token"`
+		}
+		s.NoError(json.NewDecoder(r.Body).Decode(&body))
+		issuedTo, ok := s.appTokens[body.AccessToken]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		s.NoError(json.NewEncoder(w).Encode(map[string]any{"app": map[string]string{"client_id": issuedTo}}))
+	})
+	s.srv = httptest.NewServer(mux)
+
+	s.provider = newGithubProvider(config.OAuthProvider{Name: "github", ClientID: "gh-client", ClientSecret: "gh-secret"}, s.srv.Client(), nil)
+	s.provider.authURL = s.srv.URL + "/login/oauth/authorize"
+	s.provider.tokenURL = s.srv.URL + "/login/oauth/access_token"
+	s.provider.apiURL = s.srv.URL
+}
+
+func (s *GithubTestSuite) TearDownTest() {
+	s.srv.Close()
+}
+
+func (s *GithubTestSuite) TestIdentity() {
+	testCases := []struct {
+		name     string
+		emails   []githubEmail
+		email    string
+		verified bool
+	}{
+		{
+			name:     "primary verified email",
+			emails:   []githubEmail{{Email: "public@example.com"}, {Email: "naff@example.com", Primary: true, Verified: true}},
+			email:    "naff@example.com",
+			verified: true,
+		},
+		{
+			name:   "no verified email",
+			emails: []githubEmail{{Email: "public@example.com", Primary: true}},
+			email:  "public@example.com",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.emails = tc.emails
+
+			token, err := s.provider.Exchange(s.T().Context(), "good-code", "verifier")
+			s.Require().NoError(err)
+
+			identity, err := s.provider.Identity(s.T().Context(), token)
+			s.Require().NoError(err)
+			s.Equal("42", identity.Subject)
+			s.Equal("naff", identity.Name)
+			s.Equal(tc.email, identity.Email)
+			s.Equal(tc.verified, identity.EmailVerified)
+		})
+	}
+}
+
+func (s *GithubTestSuite) TestExchangeInvalidGrant() {
+	_, err := s.provider.Exchange(s.T().Context(), "used-code", "verifier")
+	s.True(errors.Is(err, ErrInvalidGrant))
+}
+
+func (s *GithubTestSuite) TestVerifyToken() {
+	s.emails = []githubEmail{{Email: "naff@example.com", Primary: true, Verified: true}}
+	s.appTokens = map[string]string{"gh-token": "gh-client"}
+
+	identity, err := s.provider.VerifyToken(s.T().Context(), &Token{AccessToken: "gh-token"})
+	s.Require().NoError(err)
+	s.Equal("42", identity.Subject)
+	s.True(identity.EmailVerified)
+
+	// A token GitHub doesn't know for this app, such as one of another app, is refused
+	_, err = s.provider.VerifyToken(s.T().Context(), &Token{AccessToken: "other-app-token"})
+	s.ErrorIs(err, ErrInvalidToken)
+
+	_, err = s.provider.VerifyToken(s.T().Context(), &Token{IDToken: "id-token"})
+	s.ErrorIs(err, ErrInvalidToken)
+}
+
+func TestGithubTestSuite(t *testing.T) {
+	suite.Run(t, new(GithubTestSuite))
+}
+
+type RegistryTestSuite struct {
+	suite.Suite
+}
+
+func (s *RegistryTestSuite) TestNewRegistry() {
+	r, err := NewRegistry(&config.Config{
+		GithubClientID: "gh-client",
+		OAuthProviders: []config.OAuthProvider{
+			{Name: "google", ClientID: "g-client", Issuer: "https://accounts.google.com"},
+		},
+	}, nil)
+	s.Require().NoError(err)
+	s.Equal([]string{"github", "google"}, r.Names())
+
+	provider, err := r.Provider("google")
+	s.Require().NoError(err)
+	s.Equal("google", provider.Name())
+
+	_, err = r.Provider("gitlab")
+	s.ErrorIs(err, ErrUnknownProvider)
+}
+
+func (s *RegistryTestSuite) TestNewRegistryErrors() {
+	testCases := []struct {
+		name      string
+		providers []config.OAuthProvider
+		expectMsg string
+	}{
+		{
+			name:      "oidc without issuer",
+			providers: []config.OAuthProvider{{Name: "gitlab", ClientID: "client"}},
+			expectMsg: `oauth: oidc provider "gitlab" has no issuer`,
+		},
+		{
+			name:      "unknown type",
+			providers: []config.OAuthProvider{{Name: "gitlab", Type: "saml", ClientID: "client"}},
+			expectMsg: `oauth: provider "gitlab" has unknown type "saml"`,
+		},
+		{
+			name:      "no client id",
+			providers: []config.OAuthProvider{{Name: "gitlab", Issuer: "https://gitlab.com"}},
+			expectMsg: `oauth: provider "gitlab" has no client id`,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			_, err := NewRegistry(&config.Config{OAuthProviders: tc.providers}, nil)
+			s.EqualError(err, tc.expectMsg)
+		})
+	}
+}
+
+func TestRegistryTestSuite(t *testing.T) {
+	suite.Run(t, new(RegistryTestSuite))
+}
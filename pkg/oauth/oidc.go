@@ -0,0 +1,378 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/jwt"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+// keysRefreshInterval spaces the JWKS fetches an unknown kid triggers, a provider that rotated
+// its keys is picked up while forged kids can't hammer it.
+const keysRefreshInterval = time.Minute
+
+// idTokenMethods are the algorithms an ID token may be signed with, never a shared secret.
+var idTokenMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// oidcProvider signs in with any OpenID Connect provider, such as Google, GitLab or Keycloak.
+// Its endpoints and keys are discovered from the issuer on first use.
+type oidcProvider struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	// audiences are the clients whose ID tokens are accepted, this client first
+	audiences []string
+	client    *http.Client
+	now       func() time.Time
+	log       *logrus.Logger
+
+	mu          sync.Mutex
+	discovery   *discovery
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+// discovery is the part of the provider metadata sign in needs, OpenID Connect Discovery 1.0.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type idTokenClaims struct {
+	Email             string   `json:"email"`
+	EmailVerified     flexBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Picture           string   `json:"picture"`
+	AuthorizedParty   string   `json:"azp"`
+	jwtlib.RegisteredClaims
+}
+
+type userInfo struct {
+	Subject           string   `json:"sub"`
+	Email             string   `json:"email"`
+	EmailVerified     flexBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Picture           string   `json:"picture"`
+}
+
+// flexBool accepts the "true" string some providers send for email_verified.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = s == "true"
+		return nil
+	}
+
+	var v bool
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*b = flexBool(v)
+	return nil
+}
+
+func newOIDCProvider(pc config.OAuthProvider, client *http.Client, log *logrus.Logger) *oidcProvider {
+	scopes := pc.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	if !slices.Contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+
+	return &oidcProvider{
+		name:         pc.Name,
+		issuer:       pc.Issuer,
+		clientID:     pc.ClientID,
+		clientSecret: pc.ClientSecret,
+		redirectURL:  pc.RedirectURL,
+		scopes:       scopes,
+		audiences:    append([]string{pc.ClientID}, pc.Audiences...),
+		client:       client,
+		now:          time.Now,
+		log:          log,
+	}
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state, codeChallenge string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {strings.Join(p.scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	return d.AuthorizationEndpoint + "?" + params.Encode(), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := exchangeCode(ctx, p.client, d.TokenEndpoint, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"client_id":     {p.clientID},
+		"client_secret": {p.clientSecret},
+		"code_verifier": {codeVerifier},
+	})
+	if err != nil && !errors.Is(err, ErrInvalidGrant) {
+		utils.LogError(p.log, ctx, "oauth_oidc", "Exchange", err)
+	}
+
+	return token, err
+}
+
+func (p *oidcProvider) Identity(ctx context.Context, token *Token) (*Identity, error) {
+	if token.IDToken == "" {
+		err := fmt.Errorf("oauth: %s returned no id token", p.name)
+		utils.LogError(p.log, ctx, "oauth_oidc", "Identity", err)
+		return nil, err
+	}
+
+	claims, err := p.verifyIDToken(ctx, token.IDToken)
+	if errors.Is(err, ErrInvalidToken) {
+		utils.LogWarn(p.log, ctx, "oauth_oidc", "Identity", err)
+		return nil, err
+	}
+	if err != nil {
+		utils.LogError(p.log, ctx, "oauth_oidc", "Identity", err)
+		return nil, err
+	}
+
+	identity := &Identity{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		Username:      claims.PreferredUsername,
+		AvatarURL:     claims.Picture,
+	}
+
+	// Providers like Keycloak keep the ID token lean, the rest of the profile is on the userinfo endpoint
+	if (identity.Email == "" || identity.Name == "") && token.AccessToken != "" {
+		if err := p.fillFromUserInfo(ctx, token.AccessToken, identity); err != nil {
+			utils.LogError(p.log, ctx, "oauth_oidc", "Identity", err)
+			return nil, err
+		}
+	}
+
+	if identity.Username == "" {
+		identity.Username, _, _ = strings.Cut(identity.Email, "@")
+	}
+	if identity.Name == "" {
+		identity.Name = identity.Username
+	}
+
+	return identity, nil
+}
+
+func (p *oidcProvider) VerifyToken(ctx context.Context, token *Token) (*Identity, error) {
+	// An access token alone can't tell which client it was issued to, the ID token names it
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: %s needs an id token", ErrInvalidToken, p.name)
+	}
+
+	return p.Identity(ctx, token)
+}
+
+func (p *oidcProvider) fillFromUserInfo(ctx context.Context, accessToken string, identity *Identity) error {
+	d, err := p.discover(ctx)
+	if err != nil || d.UserinfoEndpoint == "" {
+		return err
+	}
+
+	var info userInfo
+	if err := getJSON(ctx, p.client, d.UserinfoEndpoint, accessToken, &info); err != nil {
+		return err
+	}
+	// The userinfo of another user must not be mixed into the identity
+	if info.Subject != identity.Subject {
+		return fmt.Errorf("oauth: %s userinfo subject %q does not match the id token", p.name, info.Subject)
+	}
+
+	if identity.Email == "" {
+		identity.Email, identity.EmailVerified = info.Email, bool(info.EmailVerified)
+	}
+	if identity.Name == "" {
+		identity.Name = info.Name
+	}
+	if identity.Username == "" {
+		identity.Username = info.PreferredUsername
+	}
+	if identity.AvatarURL == "" {
+		identity.AvatarURL = info.Picture
+	}
+
+	return nil
+}
+
+// verifyIDToken checks the signature against the provider keys and that the token was issued
+// by the provider for a trusted client and is still valid. A token that fails is ErrInvalidToken,
+// a provider that can't be reached is not.
+func (p *oidcProvider) verifyIDToken(ctx context.Context, raw string) (*idTokenClaims, error) {
+	var keyErr error
+	claims := &idTokenClaims{}
+	_, err := jwtlib.ParseWithClaims(raw, claims, func(token *jwtlib.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.key(ctx, kid)
+		keyErr = err
+		return key, err
+	},
+		jwtlib.WithValidMethods(idTokenMethods),
+		jwtlib.WithIssuer(p.issuer),
+		jwtlib.WithExpirationRequired(),
+		jwtlib.WithIssuedAt(),
+		jwtlib.WithLeeway(time.Minute),
+		jwtlib.WithTimeFunc(p.now),
+	)
+	if keyErr != nil && !errors.Is(keyErr, ErrInvalidToken) {
+		return nil, keyErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s id token: %v", ErrInvalidToken, p.name, err)
+	}
+
+	if !slices.ContainsFunc(claims.Audience, p.trusts) {
+		return nil, fmt.Errorf("%w: %s id token is issued to %q", ErrInvalidToken, p.name, claims.Audience)
+	}
+	// A token issued to several clients names the one it is for
+	if len(claims.Audience) > 1 && !p.trusts(claims.AuthorizedParty) {
+		return nil, fmt.Errorf("%w: %s id token is authorized for %q", ErrInvalidToken, p.name, claims.AuthorizedParty)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: %s id token has no subject", ErrInvalidToken, p.name)
+	}
+
+	return claims, nil
+}
+
+// trusts tells whether an ID token issued to the client is accepted.
+func (p *oidcProvider) trusts(clientID string) bool {
+	return clientID != "" && slices.Contains(p.audiences, clientID)
+}
+
+// discover fetches the provider metadata once, a failed fetch is retried on the next sign in.
+func (p *oidcProvider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d discovery
+	wellKnown := strings.TrimSuffix(p.issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, p.client, wellKnown, "", &d); err != nil {
+		utils.LogError(p.log, ctx, "oauth_oidc", "discover", err)
+		return nil, err
+	}
+
+	// The issuer must match exactly, or tokens of another issuer could pass as this one's
+	if d.Issuer != p.issuer {
+		err := fmt.Errorf("oauth: %s discovery issuer %q does not match %q", p.name, d.Issuer, p.issuer)
+		utils.LogError(p.log, ctx, "oauth_oidc", "discover", err)
+		return nil, err
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		err := fmt.Errorf("oauth: %s discovery is missing endpoints", p.name)
+		utils.LogError(p.log, ctx, "oauth_oidc", "discover", err)
+		return nil, err
+	}
+
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// key returns the provider key with the kid, refetching the JWKS when the kid is unknown.
+func (p *oidcProvider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if p.keys != nil && p.now().Sub(p.keysFetched) < keysRefreshInterval {
+		return nil, fmt.Errorf("%w: %s has no key %q", ErrInvalidToken, p.name, kid)
+	}
+
+	var set jwt.JWKS
+	if err := getJSON(ctx, p.client, d.JWKSURI, "", &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// A key of an unsupported type is skipped, the others still verify
+		key, err := jwk.PublicKey()
+		if err != nil {
+			utils.LogWarn(p.log, ctx, "oauth_oidc", "key", err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	p.keys, p.keysFetched = keys, p.now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: %s has no key %q", ErrInvalidToken, p.name, kid)
+}
+
+// lookupKey accepts a token without kid only when the provider has a single key.
+func (p *oidcProvider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok && kid != ""
+}
@@ -0,0 +1,71 @@
+package oauth
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+)
+
+type registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry builds the providers of OAUTH_PROVIDERS, plus GitHub from GITHUB_CLIENT_ID when
+// OAUTH_PROVIDERS doesn't configure it.
+func NewRegistry(cfg *config.Config, log *logrus.Logger) (Registry, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	r := &registry{providers: make(map[string]Provider)}
+
+	for _, pc := range cfg.OAuthProviders {
+		if _, exists := r.providers[pc.Name]; exists {
+			return nil, fmt.Errorf("oauth: provider %q is configured twice", pc.Name)
+		}
+		if pc.ClientID == "" {
+			return nil, fmt.Errorf("oauth: provider %q has no client id", pc.Name)
+		}
+
+		switch pc.Type {
+		case "", "oidc":
+			if pc.Issuer == "" {
+				return nil, fmt.Errorf("oauth: oidc provider %q has no issuer", pc.Name)
+			}
+			r.providers[pc.Name] = newOIDCProvider(pc, client, log)
+		case "github":
+			r.providers[pc.Name] = newGithubProvider(pc, client, log)
+		default:
+			return nil, fmt.Errorf("oauth: provider %q has unknown type %q", pc.Name, pc.Type)
+		}
+	}
+
+	if _, exists := r.providers["github"]; !exists && cfg.GithubClientID != "" {
+		r.providers["github"] = newGithubProvider(config.OAuthProvider{
+			Name:         "github",
+			ClientID:     cfg.GithubClientID,
+			ClientSecret: cfg.GithubClientSecret,
+			RedirectURL:  cfg.GithubRedirectURL,
+		}, client, log)
+	}
+
+	return r, nil
+}
+
+func (r *registry) Provider(name string) (Provider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+func (r *registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
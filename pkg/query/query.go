@@ -0,0 +1,150 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+)
+
+// MaxSortFields caps how many fields one sort param may list.
+const MaxSortFields = 3
+
+// Sort orders a list by an allow-listed field, Desc for the `-field` form.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Filter narrows a list by an allow-listed field, filter values are integers.
+type Filter struct {
+	Field string
+	Value int
+}
+
+// Spec is the sorting and filtering asked for on a list endpoint.
+type Spec struct {
+	Sort    []Sort
+	Filters []Filter
+}
+
+// Columns allow-lists the fields of one list endpoint and maps them to SQL.
+// Filter expressions take their placeholder through %s, like `s.duration >= %s`.
+type Columns struct {
+	Sorts   map[string]string
+	Filters map[string]string
+}
+
+// Parse reads the `sort` param and the allow-listed filter params out of the query string.
+// Other params are ignored. Unknown sort fields and invalid filter values come back as a
+// BadRequestError with the message keyed by param.
+func Parse(params map[string]string, cols Columns) (spec Spec, err error) {
+	errorsMap := make(map[string]string)
+
+	if raw := params["sort"]; raw != "" {
+		sorts, message := parseSort(raw, cols)
+		if message != "" {
+			errorsMap["sort"] = message
+		}
+		spec.Sort = sorts
+	}
+
+	for _, field := range sortedKeys(cols.Filters) {
+		raw, ok := params[field]
+		if !ok || raw == "" {
+			continue
+		}
+
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			errorsMap[field] = "Must be a non-negative integer"
+			continue
+		}
+
+		spec.Filters = append(spec.Filters, Filter{Field: field, Value: value})
+	}
+
+	if len(errorsMap) > 0 {
+		return Spec{}, errs.NewBadRequestError("validation failed", errorsMap)
+	}
+
+	return spec, nil
+}
+
+// Build turns the spec into SQL. where joins the filter conditions with AND, without the WHERE
+// keyword, using numbered placeholders from startIndex. orderBy lists the sort columns followed
+// by tieBreaker, which keeps pages stable and is the whole order when no sort was asked for.
+// Fields missing from cols are skipped, so only allow-listed SQL ever reaches the query.
+func (spec Spec) Build(cols Columns, startIndex int, tieBreaker string) (where, orderBy string, args []any) {
+	conditions := make([]string, 0, len(spec.Filters))
+	for _, filter := range spec.Filters {
+		expr, ok := cols.Filters[filter.Field]
+		if !ok {
+			continue
+		}
+
+		args = append(args, filter.Value)
+		conditions = append(conditions, fmt.Sprintf(expr, fmt.Sprintf("$%d", startIndex+len(args)-1)))
+	}
+
+	orders := make([]string, 0, len(spec.Sort)+1)
+	for _, sort := range spec.Sort {
+		column, ok := cols.Sorts[sort.Field]
+		if !ok {
+			continue
+		}
+
+		direction := "ASC"
+		if sort.Desc {
+			direction = "DESC"
+		}
+		orders = append(orders, column+" "+direction)
+	}
+	orders = append(orders, tieBreaker)
+
+	return strings.Join(conditions, " AND "), strings.Join(orders, ", "), args
+}
+
+// parseSort reads a comma separated list like `-created_at,title`, repeated fields keep the first direction.
+func parseSort(raw string, cols Columns) (sorts []Sort, message string) {
+	fields := strings.Split(raw, ",")
+	if len(fields) > MaxSortFields {
+		return nil, fmt.Sprintf("At most %d sort fields are allowed", MaxSortFields)
+	}
+
+	seen := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		sort := Sort{Field: strings.TrimSpace(field)}
+		if strings.HasPrefix(sort.Field, "-") {
+			sort.Field = sort.Field[1:]
+			sort.Desc = true
+		}
+
+		if _, ok := cols.Sorts[sort.Field]; !ok {
+			return nil, fmt.Sprintf("Unknown sort field %q, allowed: %s", sort.Field, allowedKeys(cols.Sorts))
+		}
+		if _, ok := seen[sort.Field]; ok {
+			continue
+		}
+		seen[sort.Field] = struct{}{}
+
+		sorts = append(sorts, sort)
+	}
+
+	return sorts, ""
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func allowedKeys(m map[string]string) string {
+	return strings.Join(sortedKeys(m), ", ")
+}
@@ -0,0 +1,104 @@
+package query
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+)
+
+var testColumns = Columns{
+	Sorts: map[string]string{
+		"title":      "s.title",
+		"created_at": "s.created_at",
+	},
+	Filters: map[string]string{
+		"album_id":     "s.album_id = %s",
+		"duration_min": "s.duration >= %s",
+	},
+}
+
+type QueryTestSuite struct {
+	suite.Suite
+}
+
+func (s *QueryTestSuite) TestParse() {
+	testCases := []struct {
+		name         string
+		params       map[string]string
+		expectSpec   Spec
+		expectErrors map[string]string
+	}{
+		{
+			name:   "sort and filters",
+			params: map[string]string{"sort": "-created_at, title,-created_at", "album_id": "2", "duration_min": "0", "page": "3"},
+			expectSpec: Spec{
+				Sort:    []Sort{{Field: "created_at", Desc: true}, {Field: "title"}},
+				Filters: []Filter{{Field: "album_id", Value: 2}, {Field: "duration_min", Value: 0}},
+			},
+		},
+		{
+			name:       "empty params",
+			params:     map[string]string{"album_id": ""},
+			expectSpec: Spec{},
+		},
+		{
+			name:         "unknown sort field",
+			params:       map[string]string{"sort": "password"},
+			expectErrors: map[string]string{"sort": `Unknown sort field "password", allowed: created_at, title`},
+		},
+		{
+			name:         "too many sort fields",
+			params:       map[string]string{"sort": "title,created_at,title,created_at"},
+			expectErrors: map[string]string{"sort": "At most 3 sort fields are allowed"},
+		},
+		{
+			name:         "invalid filter values",
+			params:       map[string]string{"album_id": "abc", "duration_min": "-1"},
+			expectErrors: map[string]string{"album_id": "Must be a non-negative integer", "duration_min": "Must be a non-negative integer"},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			spec, err := Parse(tc.params, testColumns)
+
+			if tc.expectErrors != nil {
+				var badRequest *errs.BadRequestError
+				s.Require().True(errors.As(err, &badRequest))
+				s.Equal(tc.expectErrors, badRequest.Errors)
+				s.Equal(Spec{}, spec)
+				return
+			}
+
+			s.NoError(err)
+			s.Equal(tc.expectSpec, spec)
+		})
+	}
+}
+
+func (s *QueryTestSuite) TestBuild() {
+	spec := Spec{
+		Sort:    []Sort{{Field: "title"}, {Field: "created_at", Desc: true}, {Field: "unknown"}},
+		Filters: []Filter{{Field: "album_id", Value: 2}, {Field: "unknown", Value: 1}, {Field: "duration_min", Value: 60}},
+	}
+
+	where, orderBy, args := spec.Build(testColumns, 3, "s.id DESC")
+
+	s.Equal("s.album_id = $3 AND s.duration >= $4", where)
+	s.Equal("s.title ASC, s.created_at DESC, s.id DESC", orderBy)
+	s.Equal([]any{2, 60}, args)
+}
+
+func (s *QueryTestSuite) TestBuildEmpty() {
+	where, orderBy, args := Spec{}.Build(testColumns, 1, "s.id DESC")
+
+	s.Empty(where)
+	s.Equal("s.id DESC", orderBy)
+	s.Empty(args)
+}
+
+func TestQueryTestSuite(t *testing.T) {
+	suite.Run(t, new(QueryTestSuite))
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memEntry struct {
+	counts
+	start   time.Time
+	expires time.Time
+}
+
+// memStore keeps the counters of this process only, they are lost on restart.
+type memStore struct {
+	mu      sync.Mutex
+	entries map[string]memEntry
+}
+
+func newMemStore() *memStore {
+	return &memStore{entries: make(map[string]memEntry)}
+}
+
+func (s *memStore) incr(_ context.Context, key string, start time.Time, window time.Duration) (c counts, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := memEntry{counts: s.slide(key, start, window), start: start, expires: start.Add(2 * window)}
+	entry.current++
+	s.entries[key] = entry
+
+	return entry.counts, nil
+}
+
+func (s *memStore) get(_ context.Context, key string, start time.Time, window time.Duration) (c counts, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.slide(key, start, window), nil
+}
+
+func (s *memStore) reset(_ context.Context, key string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *memStore) prune(_ context.Context, now time.Time) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.entries {
+		if entry.expires.Before(now) {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
+
+// slide returns the counts of key as seen from the window starting at start.
+func (s *memStore) slide(key string, start time.Time, window time.Duration) counts {
+	entry, ok := s.entries[key]
+	switch {
+	case !ok:
+		return counts{}
+	case entry.start.Equal(start):
+		return entry.counts
+	case entry.start.Add(window).Equal(start):
+		return counts{previous: entry.current}
+	default:
+		return counts{}
+	}
+}
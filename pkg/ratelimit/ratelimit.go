@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/app/database"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+// pruneInterval spaces the removal of expired counters, it runs on the hits themselves.
+const pruneInterval = time.Minute
+
+// Rule caps the hits of a key to Limit per sliding Window.
+type Rule struct {
+	// Name namespaces the keys of the rule, such as "login:ip"
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// Result is the state of a key after a hit, RetryAfter is only set when the hit is denied.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	// Allow counts a hit against key. Denied hits count too, hammering keeps the key blocked.
+	Allow(ctx context.Context, rule Rule, key string) (res Result, err error)
+	// Peek reports whether a hit would be allowed without counting it.
+	Peek(ctx context.Context, rule Rule, key string) (res Result, err error)
+	// Reset forgets the hits of key, such as the failed logins of an account after a successful one.
+	Reset(ctx context.Context, rule Rule, key string) (err error)
+}
+
+// NewLimiter keeps the counters in Postgres by default, the memory driver only limits a
+// single process.
+func NewLimiter(db *database.DB, cfg *config.Config, log *logrus.Logger) (Limiter, error) {
+	switch cfg.RateLimitDriver {
+	case "", "postgres":
+		return newLimiter(&pgStore{db: db.DB}, time.Now, log), nil
+	case "memory":
+		return newLimiter(newMemStore(), time.Now, log), nil
+	default:
+		return nil, fmt.Errorf("ratelimit: unknown driver %q", cfg.RateLimitDriver)
+	}
+}
+
+// limiter approximates a sliding window from two fixed ones, the hits of the previous window
+// are weighted by how much of it the sliding window still covers.
+type limiter struct {
+	store     store
+	now       func() time.Time
+	mu        sync.Mutex
+	lastPrune time.Time
+	log       *logrus.Logger
+}
+
+func newLimiter(store store, now func() time.Time, log *logrus.Logger) *limiter {
+	return &limiter{store: store, now: now, lastPrune: now(), log: log}
+}
+
+func (l *limiter) Allow(ctx context.Context, rule Rule, key string) (res Result, err error) {
+	now := l.now()
+	start, elapsed := windowOf(rule, now)
+
+	c, err := l.store.incr(ctx, counterKey(rule, key), start, rule.Window)
+	if err != nil {
+		utils.LogError(l.log, ctx, "ratelimit", "Allow", err)
+		return Result{}, err
+	}
+	l.prune(ctx, now)
+
+	res = judge(rule, c, elapsed)
+	if !res.Allowed {
+		// A retry is a hit of its own on top of the denied one
+		res.RetryAfter = retryAfter(rule, counts{current: c.current + 1, previous: c.previous}, elapsed)
+	}
+	return res, nil
+}
+
+func (l *limiter) Peek(ctx context.Context, rule Rule, key string) (res Result, err error) {
+	start, elapsed := windowOf(rule, l.now())
+
+	c, err := l.store.get(ctx, counterKey(rule, key), start, rule.Window)
+	if err != nil {
+		utils.LogError(l.log, ctx, "ratelimit", "Peek", err)
+		return Result{}, err
+	}
+
+	// Judge the hit Allow would count
+	c.current++
+	res = judge(rule, c, elapsed)
+	if !res.Allowed {
+		res.RetryAfter = retryAfter(rule, c, elapsed)
+	}
+	return res, nil
+}
+
+func (l *limiter) Reset(ctx context.Context, rule Rule, key string) (err error) {
+	if err := l.store.reset(ctx, counterKey(rule, key)); err != nil {
+		utils.LogError(l.log, ctx, "ratelimit", "Reset", err)
+		return err
+	}
+	return nil
+}
+
+// counterKey names the counter of key under rule. It is hashed to a fixed length, keys like
+// emails are longer than the column, and the rate limits table holds no emails or addresses.
+func counterKey(rule Rule, key string) string {
+	return utils.HashToken(rule.Name + ":" + key)
+}
+
+// prune removes expired counters at most once per pruneInterval, a failure only delays it.
+func (l *limiter) prune(ctx context.Context, now time.Time) {
+	l.mu.Lock()
+	if now.Sub(l.lastPrune) < pruneInterval {
+		l.mu.Unlock()
+		return
+	}
+	l.lastPrune = now
+	l.mu.Unlock()
+
+	if err := l.store.prune(ctx, now); err != nil {
+		utils.LogError(l.log, ctx, "ratelimit", "prune", err)
+	}
+}
+
+// windowOf returns the start of the fixed window now falls in and how far into it now is.
+func windowOf(rule Rule, now time.Time) (start time.Time, elapsed time.Duration) {
+	start = now.Truncate(rule.Window).UTC()
+	return start, now.Sub(start)
+}
+
+// estimate is the number of hits in the sliding window ending elapsed into the current window.
+func estimate(rule Rule, c counts, elapsed time.Duration) float64 {
+	overlap := 1 - float64(elapsed)/float64(rule.Window)
+	return float64(c.previous)*overlap + float64(c.current)
+}
+
+func judge(rule Rule, c counts, elapsed time.Duration) Result {
+	hits := estimate(rule, c, elapsed)
+	return Result{
+		Allowed:   hits <= float64(rule.Limit),
+		Remaining: max(int(math.Floor(float64(rule.Limit)-hits)), 0),
+	}
+}
+
+// retryAfter is how long until the counts c, the retried hit included, fit the rule again.
+func retryAfter(rule Rule, c counts, elapsed time.Duration) time.Duration {
+	limit, window := float64(rule.Limit), float64(rule.Window)
+
+	if c.current <= rule.Limit {
+		// The current window fits once enough of the previous one slid out:
+		// previous * (1 - x/window) + current <= limit
+		x := window * (1 - (limit-float64(c.current))/float64(c.previous))
+		return max(time.Duration(math.Ceil(x))-elapsed, 0)
+	}
+
+	// Only the next window fits, it starts with the retried hit and the current hits as previous
+	x := window
+	if prev := float64(c.current - 1); prev > 0 && limit > 1 {
+		x = window * max(1-(limit-1)/prev, 0)
+	}
+	return rule.Window - elapsed + time.Duration(math.Ceil(x))
+}
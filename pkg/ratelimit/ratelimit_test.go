@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimitTestSuite struct {
+	suite.Suite
+	store   *memStore
+	limiter *limiter
+	now     time.Time
+	rule    Rule
+}
+
+func (s *RateLimitTestSuite) SetupTest() {
+	s.store = newMemStore()
+	s.now = time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	s.limiter = newLimiter(s.store, func() time.Time { return s.now }, nil)
+	s.rule = Rule{Name: "test", Limit: 3, Window: time.Minute}
+}
+
+func (s *RateLimitTestSuite) allow(key string) Result {
+	res, err := s.limiter.Allow(context.Background(), s.rule, key)
+	s.Require().NoError(err)
+	return res
+}
+
+func (s *RateLimitTestSuite) peek(key string) Result {
+	res, err := s.limiter.Peek(context.Background(), s.rule, key)
+	s.Require().NoError(err)
+	return res
+}
+
+func (s *RateLimitTestSuite) TestAllowsUpToTheLimit() {
+	for remaining := 2; remaining >= 0; remaining-- {
+		res := s.allow("1.2.3.4")
+		s.True(res.Allowed)
+		s.Equal(remaining, res.Remaining)
+		s.Zero(res.RetryAfter)
+	}
+
+	res := s.allow("1.2.3.4")
+	s.False(res.Allowed)
+	s.Positive(res.RetryAfter)
+
+	// Keys and rules are counted apart
+	s.True(s.allow("5.6.7.8").Allowed)
+	s.rule.Name = "other"
+	s.True(s.allow("1.2.3.4").Allowed)
+}
+
+func (s *RateLimitTestSuite) TestSlidingWindow() {
+	for range 3 {
+		s.allow("key")
+	}
+
+	// Half into the next window half of the previous hits still count
+	s.now = s.now.Add(90 * time.Second)
+	s.True(s.allow("key").Allowed)
+	s.False(s.allow("key").Allowed)
+
+	// Two windows later nothing counts
+	s.now = s.now.Add(2 * time.Minute)
+	s.Equal(2, s.allow("key").Remaining)
+}
+
+func (s *RateLimitTestSuite) TestRetryAfter() {
+	for range 5 {
+		s.allow("key")
+	}
+
+	// Denied hits count, the key is free once the previous window slid out far enough
+	s.now = s.now.Add(10 * time.Second)
+	res := s.peek("key")
+	s.Require().False(res.Allowed)
+
+	s.now = s.now.Add(res.RetryAfter - time.Second)
+	s.False(s.peek("key").Allowed)
+	s.now = s.now.Add(time.Second)
+	s.True(s.peek("key").Allowed)
+}
+
+func (s *RateLimitTestSuite) TestPeekAndReset() {
+	for range 3 {
+		s.True(s.peek("key").Allowed)
+	}
+	s.Equal(2, s.allow("key").Remaining)
+
+	s.allow("key")
+	s.allow("key")
+	s.False(s.peek("key").Allowed)
+
+	s.Require().NoError(s.limiter.Reset(context.Background(), s.rule, "key"))
+	s.True(s.peek("key").Allowed)
+	s.Equal(2, s.allow("key").Remaining)
+}
+
+func (s *RateLimitTestSuite) TestPrunesExpiredCounters() {
+	s.allow("old")
+	s.now = s.now.Add(2*time.Minute + pruneInterval)
+	s.allow("new")
+
+	s.NotContains(s.store.entries, counterKey(s.rule, "old"))
+	s.Contains(s.store.entries, counterKey(s.rule, "new"))
+}
+
+func (s *RateLimitTestSuite) TestLongKeysFitTheColumn() {
+	email := strings.Repeat("a", 240) + "@example.com"
+	s.allow(email)
+
+	s.Len(counterKey(s.rule, email), 64)
+	s.Contains(s.store.entries, counterKey(s.rule, email))
+	s.NotEqual(counterKey(s.rule, "a"), counterKey(Rule{Name: "other"}, "a"))
+}
+
+func TestRateLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitTestSuite))
+}
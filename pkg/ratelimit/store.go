@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/wahyusahajaa/mulo-api-go/app/database"
+)
+
+// counts are the hits of a key in the current and in the previous fixed window.
+type counts struct {
+	current  int
+	previous int
+}
+
+type store interface {
+	// incr counts a hit in the window starting at start and returns the counts including it.
+	incr(ctx context.Context, key string, start time.Time, window time.Duration) (c counts, err error)
+	get(ctx context.Context, key string, start time.Time, window time.Duration) (c counts, err error)
+	reset(ctx context.Context, key string) (err error)
+	// prune removes the counters too old to weigh in any window.
+	prune(ctx context.Context, now time.Time) (err error)
+}
+
+type pgStore struct {
+	db *sql.DB
+}
+
+func (s *pgStore) incr(ctx context.Context, key string, start time.Time, window time.Duration) (c counts, err error) {
+	// A counter outlives its window by one window, it weighs in as the previous one
+	query := `
+		WITH hit AS (
+			INSERT INTO rate_limits (key, window_start, count, expires_at)
+			VALUES ($1, $2, 1, $3)
+			ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limits.count + 1
+			RETURNING count
+		)
+		SELECT
+			(SELECT count FROM hit),
+			COALESCE((SELECT count FROM rate_limits WHERE key = $1 AND window_start = $4), 0)`
+
+	if err := database.Conn(ctx, s.db).QueryRowContext(ctx, query, key, start, start.Add(2*window), start.Add(-window)).Scan(
+		&c.current,
+		&c.previous,
+	); err != nil {
+		return counts{}, err
+	}
+
+	return c, nil
+}
+
+func (s *pgStore) get(ctx context.Context, key string, start time.Time, window time.Duration) (c counts, err error) {
+	query := `
+		SELECT
+			COALESCE(SUM(count) FILTER (WHERE window_start = $2), 0),
+			COALESCE(SUM(count) FILTER (WHERE window_start = $3), 0)
+		FROM rate_limits
+		WHERE key = $1 AND window_start IN ($2, $3)`
+
+	if err := database.Conn(ctx, s.db).QueryRowContext(ctx, query, key, start, start.Add(-window)).Scan(
+		&c.current,
+		&c.previous,
+	); err != nil {
+		return counts{}, err
+	}
+
+	return c, nil
+}
+
+func (s *pgStore) reset(ctx context.Context, key string) (err error) {
+	_, err = database.Conn(ctx, s.db).ExecContext(ctx, `DELETE FROM rate_limits WHERE key = $1`, key)
+	return err
+}
+
+func (s *pgStore) prune(ctx context.Context, now time.Time) (err error) {
+	_, err = database.Conn(ctx, s.db).ExecContext(ctx, `DELETE FROM rate_limits WHERE expires_at < $1`, now)
+	return err
+}
@@ -1,5 +0,0 @@
-package resend
-
-type ResendService interface {
-	SendEmailVerificationCode(sendTo, code string)
-}
@@ -0,0 +1,34 @@
+package signedurl
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type SignedURLService interface {
+	// Sign grants userID access to resource until the configured TTL runs out.
+	Sign(resource string, userID int) (params Params)
+	// Verify checks the signature first and then the expiry, both fail with a Forbidden error.
+	Verify(resource string, params Params) (err error)
+}
+
+// Params are the query params a signed URL carries.
+type Params struct {
+	UserId    int    `query:"uid"`
+	Expires   int64  `query:"exp"`
+	Signature string `query:"sig"`
+}
+
+// Query encodes the params for the query string of the URL.
+func (p Params) Query() string {
+	values := url.Values{}
+	values.Set("uid", strconv.Itoa(p.UserId))
+	values.Set("exp", strconv.FormatInt(p.Expires, 10))
+	values.Set("sig", p.Signature)
+	return values.Encode()
+}
+
+func (p Params) ExpiresAt() time.Time {
+	return time.Unix(p.Expires, 0)
+}
@@ -0,0 +1,65 @@
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+)
+
+// minSecretLength is the shortest STREAM_URL_SECRET accepted, 32 bytes is the HMAC-SHA256 key size.
+const minSecretLength = 32
+
+type signedURLService struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewSignedURLService(cfg *config.Config) (SignedURLService, error) {
+	// Anyone could sign stream URLs with an empty or guessable secret
+	if len(cfg.StreamURLSecret) < minSecretLength {
+		return nil, errors.New("signedurl: STREAM_URL_SECRET must be at least 32 characters")
+	}
+
+	return &signedURLService{
+		secret: []byte(cfg.StreamURLSecret),
+		ttl:    cfg.StreamURLTTL,
+		now:    time.Now,
+	}, nil
+}
+
+func (s *signedURLService) Sign(resource string, userID int) (params Params) {
+	params = Params{
+		UserId:  userID,
+		Expires: s.now().Add(s.ttl).Unix(),
+	}
+	params.Signature = s.signature(resource, params.UserId, params.Expires)
+
+	return params
+}
+
+func (s *signedURLService) Verify(resource string, params Params) (err error) {
+	expected := s.signature(resource, params.UserId, params.Expires)
+	if !hmac.Equal([]byte(expected), []byte(params.Signature)) {
+		return errs.NewForbiddenError("Signed URL signature is invalid.")
+	}
+
+	if !s.now().Before(params.ExpiresAt()) {
+		return errs.NewForbiddenError("Signed URL is expired.")
+	}
+
+	return nil
+}
+
+// signature binds the resource, user and expiry together, changing any of them breaks the URL.
+func (s *signedURLService) signature(resource string, userID int, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%d\n%d", resource, userID, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
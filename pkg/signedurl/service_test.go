@@ -0,0 +1,125 @@
+package signedurl
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+	"github.com/wahyusahajaa/mulo-api-go/pkg/errs"
+)
+
+type SignedURLTestSuite struct {
+	suite.Suite
+	now time.Time
+	svc *signedURLService
+}
+
+func (s *SignedURLTestSuite) SetupTest() {
+	s.now = time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.svc = &signedURLService{
+		secret: []byte("secret"),
+		ttl:    10 * time.Minute,
+		now:    func() time.Time { return s.now },
+	}
+}
+
+func (s *SignedURLTestSuite) TestNewSignedURLService() {
+	_, err := NewSignedURLService(&config.Config{})
+	s.Error(err)
+
+	_, err = NewSignedURLService(&config.Config{StreamURLSecret: "short-secret"})
+	s.Error(err)
+
+	svc, err := NewSignedURLService(&config.Config{StreamURLSecret: "0123456789abcdef0123456789abcdef", StreamURLTTL: time.Minute})
+	s.Require().NoError(err)
+	s.NoError(svc.Verify("song:1:stream", svc.Sign("song:1:stream", 7)))
+}
+
+func (s *SignedURLTestSuite) TestSignAndVerify() {
+	params := s.svc.Sign("song:1:stream", 7)
+
+	s.Equal(7, params.UserId)
+	s.Equal(s.now.Add(10*time.Minute), params.ExpiresAt().UTC())
+	s.NoError(s.svc.Verify("song:1:stream", params))
+}
+
+func (s *SignedURLTestSuite) TestVerifyRejects() {
+	params := s.svc.Sign("song:1:stream", 7)
+
+	testCases := []struct {
+		name      string
+		resource  string
+		params    func() Params
+		advance   time.Duration
+		expectMsg string
+	}{
+		{
+			name:      "other resource",
+			resource:  "song:2:stream",
+			params:    func() Params { return params },
+			expectMsg: "Signed URL signature is invalid.",
+		},
+		{
+			name:     "other user",
+			resource: "song:1:stream",
+			params: func() Params {
+				p := params
+				p.UserId = 8
+				return p
+			},
+			expectMsg: "Signed URL signature is invalid.",
+		},
+		{
+			name:     "extended expiry",
+			resource: "song:1:stream",
+			params: func() Params {
+				p := params
+				p.Expires += 3600
+				return p
+			},
+			expectMsg: "Signed URL signature is invalid.",
+		},
+		{
+			name:     "missing signature",
+			resource: "song:1:stream",
+			params: func() Params {
+				p := params
+				p.Signature = ""
+				return p
+			},
+			expectMsg: "Signed URL signature is invalid.",
+		},
+		{
+			name:      "expired",
+			resource:  "song:1:stream",
+			params:    func() Params { return params },
+			advance:   10 * time.Minute,
+			expectMsg: "Signed URL is expired.",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.now = s.now.Add(tc.advance)
+			defer func() { s.now = s.now.Add(-tc.advance) }()
+
+			err := s.svc.Verify(tc.resource, tc.params())
+
+			var forbidden *errs.Fobidden
+			s.Require().True(errors.As(err, &forbidden))
+			s.Equal(tc.expectMsg, forbidden.Message)
+		})
+	}
+}
+
+func (s *SignedURLTestSuite) TestQuery() {
+	params := Params{UserId: 7, Expires: 1740823200, Signature: "abc-_"}
+
+	s.Equal("exp=1740823200&sig=abc-_&uid=7", params.Query())
+}
+
+func TestSignedURLTestSuite(t *testing.T) {
+	suite.Run(t, new(SignedURLTestSuite))
+}
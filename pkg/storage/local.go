@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/wahyusahajaa/mulo-api-go/pkg/utils"
+)
+
+type localStorage struct {
+	root string
+}
+
+// NewLocalStorage stores objects as files under root, creating it when missing.
+// The local filesystem keeps no metadata, content type comes back from the key extension.
+func NewLocalStorage(root string) (Storage, error) {
+	if root == "" {
+		return nil, errors.New("storage: local dir is empty")
+	}
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("storage: create local dir: %w", err)
+	}
+
+	return &localStorage{root: root}, nil
+}
+
+func (s *localStorage) Put(ctx context.Context, key string, r io.Reader, contentType string) (err error) {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	// Write next to the target and rename, readers never see a half written file
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = copyWithContext(ctx, tmp, r); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *localStorage) Open(ctx context.Context, key string) (rc io.ReadSeekCloser, obj Object, err error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, obj, err
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, obj, ErrNotFound
+		}
+		return nil, obj, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, obj, err
+	}
+
+	obj = Object{
+		Key:         key,
+		Size:        info.Size(),
+		ContentType: utils.ContentTypeByExtension(path.Ext(key)),
+		ModTime:     info.ModTime(),
+	}
+
+	return file, obj, nil
+}
+
+func (s *localStorage) Delete(ctx context.Context, key string) (err error) {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *localStorage) DeletePrefix(ctx context.Context, prefix string) (err error) {
+	name, err := s.path(prefix)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(name)
+}
+
+func (s *localStorage) path(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+// copyWithContext stops a long upload copy once the request is gone.
+func copyWithContext(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	return io.Copy(dst, readerFunc(func(p []byte) (int, error) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return src.Read(p)
+	}))
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LocalStorageTestSuite struct {
+	suite.Suite
+	root  string
+	store Storage
+}
+
+func (s *LocalStorageTestSuite) SetupTest() {
+	s.root = s.T().TempDir()
+
+	store, err := NewLocalStorage(s.root)
+	s.Require().NoError(err)
+	s.store = store
+}
+
+func (s *LocalStorageTestSuite) TestPutOpenDelete() {
+	ctx := context.Background()
+
+	s.Require().NoError(s.store.Put(ctx, "songs/1/a.mp3", strings.NewReader("first"), "audio/mpeg"))
+	s.Require().NoError(s.store.Put(ctx, "songs/1/a.mp3", strings.NewReader("second"), "audio/mpeg"))
+
+	rc, obj, err := s.store.Open(ctx, "songs/1/a.mp3")
+	s.Require().NoError(err)
+	body, err := io.ReadAll(rc)
+	s.Require().NoError(err)
+	s.NoError(rc.Close())
+
+	s.Equal("second", string(body))
+	s.Equal(int64(6), obj.Size)
+	s.Equal("audio/mpeg", obj.ContentType)
+
+	s.NoError(s.store.Delete(ctx, "songs/1/a.mp3"))
+	s.NoError(s.store.Delete(ctx, "songs/1/a.mp3"), "deleting a missing key is not an error")
+
+	_, _, err = s.store.Open(ctx, "songs/1/a.mp3")
+	s.ErrorIs(err, ErrNotFound)
+
+	// No temp files left behind by the writes
+	entries, err := os.ReadDir(filepath.Join(s.root, "songs", "1"))
+	s.Require().NoError(err)
+	s.Empty(entries)
+}
+
+func (s *LocalStorageTestSuite) TestDeletePrefix() {
+	ctx := context.Background()
+
+	s.Require().NoError(s.store.Put(ctx, "songs/1/hls/a/master.m3u8", strings.NewReader("#EXTM3U"), ""))
+	s.Require().NoError(s.store.Put(ctx, "songs/1/hls/a/64k/segment_000.ts", strings.NewReader("ts"), ""))
+	s.Require().NoError(s.store.Put(ctx, "songs/1/a.mp3", strings.NewReader("audio"), ""))
+
+	s.NoError(s.store.DeletePrefix(ctx, "songs/1/hls/a"))
+	s.NoError(s.store.DeletePrefix(ctx, "songs/1/hls/a"), "deleting a missing prefix is not an error")
+
+	_, _, err := s.store.Open(ctx, "songs/1/hls/a/64k/segment_000.ts")
+	s.ErrorIs(err, ErrNotFound)
+	rc, _, err := s.store.Open(ctx, "songs/1/a.mp3")
+	s.Require().NoError(err)
+	s.NoError(rc.Close())
+
+	s.ErrorIs(s.store.DeletePrefix(ctx, "../songs"), ErrInvalidKey)
+}
+
+func (s *LocalStorageTestSuite) TestInvalidKeys() {
+	ctx := context.Background()
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "songs/../../outside", "songs//a.mp3", `songs\a.mp3`, "."} {
+		s.ErrorIs(s.store.Put(ctx, key, strings.NewReader("x"), ""), ErrInvalidKey, key)
+		_, _, err := s.store.Open(ctx, key)
+		s.ErrorIs(err, ErrInvalidKey, key)
+		s.ErrorIs(s.store.Delete(ctx, key), ErrInvalidKey, key)
+	}
+}
+
+func (s *LocalStorageTestSuite) TestIsKey() {
+	s.True(IsKey("songs/1/a.mp3"))
+	s.False(IsKey(""))
+	s.False(IsKey("https://cdn.example.com/a.mp3"))
+}
+
+func TestLocalStorageTestSuite(t *testing.T) {
+	suite.Run(t, new(LocalStorageTestSuite))
+}
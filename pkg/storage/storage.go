@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/wahyusahajaa/mulo-api-go/app/config"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// Object describes a stored object.
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage keeps uploaded files under slash separated keys like `songs/12/ab34.mp3`.
+type Storage interface {
+	// Put writes the object, replacing any object stored under the same key.
+	Put(ctx context.Context, key string, r io.Reader, contentType string) (err error)
+	// Open returns a reader over the object, ErrNotFound when the key does not exist.
+	Open(ctx context.Context, key string) (rc io.ReadSeekCloser, obj Object, err error)
+	// Delete removes the object, deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) (err error)
+	// DeletePrefix removes every object under the prefix, like a packaged HLS directory.
+	DeletePrefix(ctx context.Context, prefix string) (err error)
+}
+
+// NewStorage builds the backend picked by STORAGE_DRIVER.
+func NewStorage(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageDriver {
+	case "", "local":
+		return NewLocalStorage(cfg.StorageLocalDir)
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", cfg.StorageDriver)
+	}
+}
+
+// IsKey reports whether audio or image references a stored object rather than an external URL
+// saved before uploads existed.
+func IsKey(ref string) bool {
+	return ref != "" && !strings.Contains(ref, "://")
+}
+
+// cleanKey rejects keys that are absolute or climb out of the storage root.
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return "", ErrInvalidKey
+	}
+
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+
+	return cleaned, nil
+}
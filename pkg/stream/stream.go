@@ -0,0 +1,121 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnsatisfiable the requested range starts past the end of the content.
+var ErrUnsatisfiable = errors.New("stream: range not satisfiable")
+
+// Range is a single byte range of the content.
+type Range struct {
+	Start  int64
+	Length int64
+}
+
+// Contains reports whether the byte at offset is part of the range.
+func (r Range) Contains(offset int64) bool {
+	return offset >= r.Start && offset < r.Start+r.Length
+}
+
+// ContentRange formats the Content-Range header of a 206 response.
+func (r Range) ContentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.Start, r.Start+r.Length-1, size)
+}
+
+// ParseRange reads a Range header like `bytes=0-`, `bytes=100-199` or `bytes=-500`.
+// ok is false when the header is empty, malformed or asks for several ranges, the caller then
+// serves the whole content as RFC 9110 allows. A range starting past the end is ErrUnsatisfiable.
+func ParseRange(header string, size int64) (r Range, ok bool, err error) {
+	spec, found := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !found || spec == "" || strings.Contains(spec, ",") {
+		return Range{}, false, nil
+	}
+
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return Range{}, false, nil
+	}
+
+	// Suffix range, the last n bytes
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return Range{}, false, nil
+		}
+		if n == 0 || size == 0 {
+			return Range{}, false, ErrUnsatisfiable
+		}
+		n = min(n, size)
+		return Range{Start: size - n, Length: n}, true, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return Range{}, false, nil
+	}
+	if start >= size {
+		return Range{}, false, ErrUnsatisfiable
+	}
+
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return Range{}, false, nil
+		}
+		end = min(end, size-1)
+	}
+
+	return Range{Start: start, Length: end - start + 1}, true, nil
+}
+
+// ETag builds a strong validator from the size and modification time of the content.
+func ETag(size int64, modTime time.Time) string {
+	return fmt.Sprintf(`"%x-%x"`, modTime.UnixNano(), size)
+}
+
+// NotModified evaluates If-None-Match, falling back to If-Modified-Since when it is absent.
+func NotModified(ifNoneMatch, ifModifiedSince, etag string, modTime time.Time) bool {
+	if ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince == "" {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	// HTTP dates have second precision
+	return !modTime.Truncate(time.Second).After(since)
+}
+
+// RangeApplies evaluates If-Range, the Range header only counts when the client still holds the
+// current content. An entity tag must match strongly, a date must not be older than modTime.
+func RangeApplies(ifRange, etag string, modTime time.Time) bool {
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) {
+		return ifRange == etag
+	}
+
+	since, err := http.ParseTime(ifRange)
+	if err != nil {
+		return false
+	}
+	return !modTime.Truncate(time.Second).After(since)
+}
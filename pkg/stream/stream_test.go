@@ -0,0 +1,81 @@
+package stream
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type StreamTestSuite struct {
+	suite.Suite
+}
+
+func (s *StreamTestSuite) TestParseRange() {
+	testCases := []struct {
+		name      string
+		header    string
+		size      int64
+		expectOk  bool
+		expect    Range
+		expectErr error
+	}{
+		{name: "no header", header: "", size: 100},
+		{name: "open ended", header: "bytes=10-", size: 100, expectOk: true, expect: Range{Start: 10, Length: 90}},
+		{name: "closed", header: "bytes=0-9", size: 100, expectOk: true, expect: Range{Start: 0, Length: 10}},
+		{name: "end past size is clamped", header: "bytes=90-500", size: 100, expectOk: true, expect: Range{Start: 90, Length: 10}},
+		{name: "suffix", header: "bytes=-20", size: 100, expectOk: true, expect: Range{Start: 80, Length: 20}},
+		{name: "suffix longer than size", header: "bytes=-500", size: 100, expectOk: true, expect: Range{Start: 0, Length: 100}},
+		{name: "start past end", header: "bytes=100-", size: 100, expectErr: ErrUnsatisfiable},
+		{name: "empty suffix", header: "bytes=-0", size: 100, expectErr: ErrUnsatisfiable},
+		{name: "multiple ranges served whole", header: "bytes=0-1,5-6", size: 100},
+		{name: "end before start ignored", header: "bytes=9-1", size: 100},
+		{name: "other unit ignored", header: "items=0-1", size: 100},
+		{name: "garbage ignored", header: "bytes=a-b", size: 100},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			r, ok, err := ParseRange(tc.header, tc.size)
+
+			s.ErrorIs(err, tc.expectErr)
+			s.Equal(tc.expectOk, ok)
+			s.Equal(tc.expect, r)
+		})
+	}
+}
+
+func (s *StreamTestSuite) TestContentRange() {
+	r := Range{Start: 10, Length: 5}
+
+	s.Equal("bytes 10-14/100", r.ContentRange(100))
+	s.True(r.Contains(10))
+	s.True(r.Contains(14))
+	s.False(r.Contains(15))
+}
+
+func (s *StreamTestSuite) TestConditionals() {
+	modTime := time.Date(2025, 3, 1, 10, 0, 0, 500, time.UTC)
+	etag := ETag(100, modTime)
+	lastModified := modTime.Format(http.TimeFormat)
+	earlier := modTime.Add(-time.Hour).Format(http.TimeFormat)
+
+	s.True(NotModified(etag, "", etag, modTime))
+	s.True(NotModified(`"other", W/`+etag, "", etag, modTime))
+	s.True(NotModified("*", "", etag, modTime))
+	s.False(NotModified(`"other"`, lastModified, etag, modTime), "If-None-Match wins over If-Modified-Since")
+	s.True(NotModified("", lastModified, etag, modTime))
+	s.False(NotModified("", earlier, etag, modTime))
+	s.False(NotModified("", "", etag, modTime))
+
+	s.True(RangeApplies("", etag, modTime))
+	s.True(RangeApplies(etag, etag, modTime))
+	s.False(RangeApplies(`"other"`, etag, modTime))
+	s.True(RangeApplies(lastModified, etag, modTime))
+	s.False(RangeApplies(earlier, etag, modTime))
+}
+
+func TestStreamTestSuite(t *testing.T) {
+	suite.Run(t, new(StreamTestSuite))
+}
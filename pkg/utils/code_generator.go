@@ -2,6 +2,7 @@ package utils
 
 import (
 	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"math/big"
 )
@@ -14,3 +15,13 @@ func GenerateUniqueCode() (string, error) {
 	}
 	return fmt.Sprintf("%05d", n.Int64()), nil
 }
+
+// GenerateToken returns 32 random bytes as unpadded base64url, for tokens sent by email or
+// stored in cookies.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at the last row of a page, keyset queries continue strictly after it.
+// The zero value starts from the first page.
+type Cursor struct {
+	Id        int        `json:"id"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// IsZero reports whether the cursor asks for the first page.
+func (c Cursor) IsZero() bool {
+	return c.Id == 0 && c.CreatedAt == nil
+}
+
+// EncodeCursor serializes the cursor into the opaque token handed to clients.
+func EncodeCursor(cursor Cursor) string {
+	payload, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// DecodeCursor parses a token made by EncodeCursor, an empty token is the first page.
+func DecodeCursor(token string) (cursor Cursor, err error) {
+	if token == "" {
+		return cursor, nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &cursor); err != nil || cursor.Id <= 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// GetCursorParam reports whether the request opted into keyset pagination by sending the cursor
+// query param, an empty cursor asks for the first page. withTotal asks for the total count as well.
+func GetCursorParam(c *fiber.Ctx) (cursor string, pageSize int, withTotal bool, ok bool) {
+	if !c.Context().QueryArgs().Has("cursor") {
+		return "", 0, false, false
+	}
+
+	pageSize, _ = strconv.Atoi(c.Query("pageSize", "10"))
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	return c.Query("cursor"), pageSize, c.QueryBool("withTotal"), true
+}
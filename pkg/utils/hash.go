@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -14,3 +17,10 @@ func HashPassword(password string) string {
 	bytes, _ := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
 	return string(bytes)
 }
+
+// HashToken hashes a random token for storage, unlike passwords the token has enough entropy
+// that a fast hash is safe and lookups by hash stay possible.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -28,3 +28,14 @@ func LogWarn(log *logrus.Logger, ctx context.Context, layer, operation string, e
 		}).Warn("operation completed with warnings")
 	}
 }
+
+// LogSecurity records an auth event worth auditing, such as a lockout or a rate limited client.
+func LogSecurity(log *logrus.Logger, ctx context.Context, event string, fields logrus.Fields) {
+	if log != nil {
+		log.WithFields(fields).WithFields(logrus.Fields{
+			"event":     event,
+			"ip":        GetClientIP(ctx),
+			"requestId": GetRequestId(ctx),
+		}).Warn("security event")
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+)
+
+// AudioSniffLen is how many leading bytes DetectAudioType needs to look at.
+const AudioSniffLen = 12
+
+// audioExtensions maps the audio content types accepted on upload to their file extension.
+var audioExtensions = map[string]string{
+	"audio/mpeg": ".mp3",
+	"audio/flac": ".flac",
+	"audio/ogg":  ".ogg",
+	"audio/wav":  ".wav",
+	"audio/mp4":  ".m4a",
+}
+
+// streamingTypes are the HLS files, the standard mime table maps .ts to a translation file on some systems.
+var streamingTypes = map[string]string{
+	".m3u8": "application/vnd.apple.mpegurl",
+	".ts":   "video/mp2t",
+}
+
+// DetectAudioType sniffs the content type of an audio file from its magic bytes, the client
+// supplied Content-Type is not trusted. Returns empty strings when the format is not supported.
+func DetectAudioType(head []byte) (contentType, ext string) {
+	switch {
+	case bytes.HasPrefix(head, []byte("ID3")), isMPEGFrameSync(head):
+		contentType = "audio/mpeg"
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		contentType = "audio/flac"
+	case bytes.HasPrefix(head, []byte("OggS")):
+		contentType = "audio/ogg"
+	case len(head) >= 12 && bytes.Equal(head[0:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WAVE")):
+		contentType = "audio/wav"
+	case len(head) >= 12 && bytes.Equal(head[4:8], []byte("ftyp")) && (bytes.Equal(head[8:12], []byte("M4A ")) || bytes.Equal(head[8:12], []byte("M4B "))):
+		contentType = "audio/mp4"
+	default:
+		return "", ""
+	}
+
+	return contentType, audioExtensions[contentType]
+}
+
+// ContentTypeByExtension returns the content type of a stored file from its extension,
+// covering the audio and HLS formats the standard mime table does not always know.
+func ContentTypeByExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	if contentType, ok := streamingTypes[ext]; ok {
+		return contentType
+	}
+	for contentType, audioExt := range audioExtensions {
+		if audioExt == ext {
+			return contentType
+		}
+	}
+
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// isMPEGFrameSync matches an MP3 file without ID3 tag, 11 sync bits followed by a non reserved
+// layer. ADTS AAC shares the sync word but always has layer 00.
+func isMPEGFrameSync(head []byte) bool {
+	return len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0 && (head[1]>>1)&0x03 != 0
+}
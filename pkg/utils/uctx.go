@@ -22,6 +22,24 @@ func GetUserId(ctx context.Context) (id int) {
 	return
 }
 
+// Get client ip from context
+func GetClientIP(ctx context.Context) string {
+	v := ctx.Value("ip")
+	if ip, ok := v.(string); ok {
+		return ip
+	}
+	return ""
+}
+
+// Get client user agent from context
+func GetUserAgent(ctx context.Context) string {
+	v := ctx.Value("user_agent")
+	if userAgent, ok := v.(string); ok {
+		return userAgent
+	}
+	return ""
+}
+
 // Get role from context
 func GetRole(ctx context.Context) string {
 	return ctx.Value("role").(string)
@@ -0,0 +1,54 @@
+package utils
+
+import "strings"
+
+// Checked in order, Edge and Opera also claim to be Chrome and every browser claims to be Safari
+var browsers = []struct{ token, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"CriOS/", "Chrome"},
+	{"Safari/", "Safari"},
+}
+
+// Android and ChromeOS user agents also mention Linux
+var systems = []struct{ token, name string }{
+	{"Windows", "Windows"},
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Mac OS X", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// DeviceName describes a user agent for a list of sessions, such as "Firefox on Windows".
+func DeviceName(userAgent string) string {
+	browser := match(userAgent, browsers)
+	system := match(userAgent, systems)
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+
+	// API clients such as "okhttp/4.12.0" name themselves first
+	if name, _, ok := strings.Cut(userAgent, "/"); ok && name != "" && len(name) <= 50 && !strings.Contains(name, " ") {
+		return name
+	}
+	return "Unknown device"
+}
+
+func match(userAgent string, candidates []struct{ token, name string }) string {
+	for _, candidate := range candidates {
+		if strings.Contains(userAgent, candidate.token) {
+			return candidate.name
+		}
+	}
+	return ""
+}
@@ -3,8 +3,12 @@ package utils
 import (
 	"fmt"
 	"strings"
+	"unicode"
 )
 
+// maxTsQueryTerms caps how many words of a search input end up in the tsquery.
+const maxTsQueryTerms = 8
+
 // BuildInClause builds a safe SQL "IN" clause with numbered placeholders
 // and returns the clause string (like `($1, $2, $3)`) and the args slice.
 func BuildInClause(startIndex int, items []any) (string, []any) {
@@ -22,3 +26,22 @@ func BuildInClause(startIndex int, items []any) (string, []any) {
 
 	return fmt.Sprintf("(%s)", strings.Join(placeholders, ", ")), args
 }
+
+// BuildPrefixTsQuery turns free text into a tsquery string matching every word as a prefix,
+// like `aku:* & pul:*`. Anything but letters and digits is dropped, so the result is always
+// safe for to_tsquery. Returns an empty string when no word is left.
+func BuildPrefixTsQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxTsQueryTerms {
+		words = words[:maxTsQueryTerms]
+	}
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, word+":*")
+	}
+
+	return strings.Join(terms, " & ")
+}
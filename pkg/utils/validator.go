@@ -51,6 +51,9 @@ func getErrorMessage(fe validator.FieldError) string {
 		"email":    "Must be a valid email",
 		"len":      fmt.Sprintf("Length must be %s characters", fe.Param()),
 		"gt":       "Field must be Greater than 0",
+		"gte":      fmt.Sprintf("Minimum value is %s", fe.Param()),
+		"datetime": fmt.Sprintf("Must be a valid datetime in format %s", fe.Param()),
+		"oneof":    fmt.Sprintf("Must be one of: %s", strings.ReplaceAll(fe.Param(), " ", ", ")),
 	}
 
 	if result, ok := customErrorMessage[fe.Tag()]; ok {